@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/marcus/nightshift/internal/blackout"
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// blackoutsConfigured reports whether schedule.blackout lists any period or
+// calendar.
+func blackoutsConfigured(cfg *config.Config) bool {
+	b := cfg.Schedule.Blackout
+	return len(b.Periods) > 0 || len(b.Calendars) > 0
+}
+
+// loadBlackouts reads schedule.blackout. Returns nil, nil when none are
+// configured.
+func loadBlackouts(cfg *config.Config) (*blackout.Calendar, error) {
+	if !blackoutsConfigured(cfg) {
+		return nil, nil
+	}
+	return blackout.Load(cfg.Schedule.Blackout)
+}
+
+// applyBlackout checks schedule.blackout for a run starting at now. It
+// returns ok false when the run should be skipped. When the run is
+// downgraded it returns filter narrowed to analysis-only tasks and noPR
+// true, for orchestrator.SetNoPR. A calendar that cannot be read downgrades
+// the run rather than risk opening PRs during a freeze.
+func applyBlackout(cfg *config.Config, now time.Time, filter tasks.Filter, log *logging.Logger) (_ tasks.Filter, noPR, ok bool) {
+	cal, err := loadBlackouts(cfg)
+	if err != nil {
+		log.Warnf("blackout: %v; running analysis-only tasks", err)
+		return filter.AnalysisOnly(), true, true
+	}
+	active := cal.Active(now)
+	if active == nil {
+		return filter, false, true
+	}
+	if active.Mode == config.BlackoutSkip {
+		log.Infof("blackout %s; skipping run", active)
+		return filter, false, false
+	}
+	log.Infof("blackout %s; running analysis-only tasks", active)
+	return filter.AnalysisOnly(), true, true
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+func TestApplyBlackout(t *testing.T) {
+	log := logging.Component("test")
+	cfg := &config.Config{}
+	cfg.Schedule.Blackout = config.BlackoutConfig{
+		Periods: []config.BlackoutPeriod{
+			{Name: "freeze", Start: "2026-12-14", End: "2026-12-15", Mode: config.BlackoutSkip},
+			{Name: "holidays", Start: "2026-12-24", End: "2026-12-26"},
+		},
+	}
+	pr := tasks.TaskDefinition{Category: tasks.CategoryPR}
+	analysis := tasks.TaskDefinition{Category: tasks.CategoryAnalysis}
+
+	if _, _, ok := applyBlackout(cfg, time.Date(2026, 12, 15, 22, 0, 0, 0, time.Local), tasks.Filter{}, log); ok {
+		t.Error("expected run to be skipped during a skip blackout")
+	}
+
+	filter, noPR, ok := applyBlackout(cfg, time.Date(2026, 12, 25, 22, 0, 0, 0, time.Local), tasks.Filter{}, log)
+	if !ok || !noPR || filter.Matches(pr) || !filter.Matches(analysis) {
+		t.Errorf("expected analysis-only no-PR run during a downgrade blackout, got ok=%v noPR=%v filter=%+v", ok, noPR, filter)
+	}
+
+	filter, noPR, ok = applyBlackout(cfg, time.Date(2026, 12, 20, 22, 0, 0, 0, time.Local), tasks.Filter{}, log)
+	if !ok || noPR || !filter.IsZero() {
+		t.Errorf("expected unrestricted run outside blackouts, got ok=%v noPR=%v filter=%+v", ok, noPR, filter)
+	}
+
+	// An unreadable calendar downgrades instead of failing open
+	cfg.Schedule.Blackout.Calendars = []config.BlackoutCalendar{{Path: filepath.Join(t.TempDir(), "missing.ics")}}
+	filter, noPR, ok = applyBlackout(cfg, time.Date(2026, 12, 20, 22, 0, 0, 0, time.Local), tasks.Filter{}, log)
+	if !ok || !noPR || filter.Matches(pr) {
+		t.Errorf("expected analysis-only no-PR run when the calendar is unreadable, got ok=%v noPR=%v filter=%+v", ok, noPR, filter)
+	}
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,6 +13,7 @@ import (
 	"github.com/marcus/nightshift/internal/db"
 	"github.com/marcus/nightshift/internal/providers"
 	"github.com/marcus/nightshift/internal/snapshots"
+	"github.com/marcus/nightshift/internal/state"
 	"github.com/marcus/nightshift/internal/trends"
 )
 
@@ -46,41 +48,23 @@ func runBudget(filterProvider string) error {
 	defer func() { _ = database.Close() }()
 
 	// Initialize providers
-	var claude *providers.Claude
-	var codex *providers.Codex
-	var gemini *providers.Gemini
-
-	if cfg.Providers.Claude.Enabled {
-		dataPath := cfg.ExpandedProviderPath("claude")
-		if dataPath != "" {
-			claude = providers.NewClaudeWithPath(dataPath)
-		} else {
-			claude = providers.NewClaude()
-		}
-	}
-
-	if cfg.Providers.Codex.Enabled {
-		dataPath := cfg.ExpandedProviderPath("codex")
-		if dataPath != "" {
-			codex = providers.NewCodexWithPath(dataPath)
-		} else {
-			codex = providers.NewCodex()
-		}
-	}
-
-	if cfg.Providers.Gemini.Enabled {
-		dataPath := cfg.ExpandedProviderPath("gemini")
-		if dataPath != "" {
-			gemini = providers.NewGeminiWithPath(dataPath)
-		} else {
-			gemini = providers.NewGemini()
-		}
-	}
+	providerSet := enabledProviders(cfg)
+	useLedger(providerSet, database)
+	codex, _ := providers.Find(providerSet, "codex").(*providers.Codex)
 
 	// Create budget manager
 	cal := calibrator.New(database, cfg)
 	trend := trends.NewAnalyzer(database, cfg.Budget.SnapshotRetentionDays)
-	mgr := budget.NewManagerFromProviders(cfg, claude, codex, gemini, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
+	snapCollector := snapshots.NewCollector(database, nil, nil, weekStartDayFromConfig(cfg))
+	opts := []budget.Option{
+		budget.WithBudgetSource(cal),
+		budget.WithTrendAnalyzer(trend),
+		budget.WithSessionResetSource(snapCollector),
+	}
+	if st, err := state.New(database); err == nil {
+		opts = append(opts, budget.WithSpendSource(st))
+	}
+	mgr := budget.NewManagerFromProviders(cfg, providerSet, opts...)
 
 	providerList, err := resolveProviderList(cfg, filterProvider)
 	if err != nil {
@@ -101,8 +85,13 @@ func runBudget(filterProvider string) error {
 	fmt.Println("================================")
 	fmt.Println()
 
+	if spend, err := mgr.SpendStatus(); err != nil {
+		fmt.Printf("Spend: error: %v\n\n", err)
+	} else if spend != nil {
+		fmt.Printf("Spend: %s\n\n", spend)
+	}
+
 	// Print status for each provider
-	snapCollector := snapshots.NewCollector(database, nil, nil, nil, nil, weekStartDayFromConfig(cfg))
 	for _, provName := range providerList {
 		if err := printProviderBudget(mgr, cfg, provName, cal, snapCollector, codex); err != nil {
 			fmt.Printf("%s: error: %v\n\n", provName, err)
@@ -273,6 +262,28 @@ func printProviderBudget(mgr *budget.Manager, cfg *config.Config, provName strin
 		}
 	}
 
+	// Per-model split of local usage
+	if usage, err := mgr.ModelUsage(provName); err == nil && len(usage) > 0 {
+		fmt.Printf("  Models:       %s\n", formatModelUsage(usage))
+		if result.WeightFactor != 1 && result.WeightFactor > 0 {
+			fmt.Printf("  Weighted:     %.2fx raw tokens (budget.model_weights)\n", result.WeightFactor)
+		}
+	}
+
+	// Short rolling window (e.g. 5h session limit)
+	if session, err := mgr.SessionStatus(provName); err == nil && session != nil {
+		fmt.Printf("  Session:      %s\n", session)
+	}
+
+	// Projected leftover at the weekly reset (budget.burst)
+	if plan, err := mgr.BurstPlan(provName); err == nil && plan != nil {
+		status := "not due"
+		if plan.Active {
+			status = "due"
+		}
+		fmt.Printf("  Burst:        %s, %s\n", status, plan)
+	}
+
 	// Show reset times from latest snapshot
 	if snapCollector != nil {
 		if latest, err := snapCollector.GetLatest(provName, 1); err == nil && len(latest) > 0 {
@@ -296,6 +307,47 @@ func printProviderBudget(mgr *budget.Manager, cfg *config.Config, provName strin
 	return nil
 }
 
+// formatModelUsage renders a per-model split, e.g.
+// "claude-opus-4-5 1.2M (75%, ×5) · claude-haiku-4-5 400.0K (25%)".
+func formatModelUsage(usage []budget.ModelUsage) string {
+	var total int64
+	for _, u := range usage {
+		total += u.Tokens
+	}
+	parts := make([]string, 0, len(usage))
+	for _, u := range usage {
+		part := fmt.Sprintf("%s %s", u.Model, formatTokens64(u.Tokens))
+		meta := []string{}
+		if total > 0 {
+			meta = append(meta, fmt.Sprintf("%.0f%%", float64(u.Tokens)/float64(total)*100))
+		}
+		if u.Weight != 1 {
+			meta = append(meta, fmt.Sprintf("×%g", u.Weight))
+		}
+		if len(meta) > 0 {
+			part += " (" + strings.Join(meta, ", ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// modelUsageFromTokens converts a per-model token map (e.g. from a snapshot)
+// into weighted ModelUsage entries, largest first.
+func modelUsageFromTokens(cfg *config.Config, provider string, byModel map[string]int64) []budget.ModelUsage {
+	usage := make([]budget.ModelUsage, 0, len(byModel))
+	for model, tokens := range byModel {
+		usage = append(usage, budget.ModelUsage{Model: model, Tokens: tokens, Weight: cfg.ModelWeight(provider, model)})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Tokens != usage[j].Tokens {
+			return usage[i].Tokens > usage[j].Tokens
+		}
+		return usage[i].Model < usage[j].Model
+	})
+	return usage
+}
+
 // printTokenAccountingNote adds a brief note about how tokens are counted.
 func printTokenAccountingNote(provider string, estimate budget.BudgetEstimate) {
 	if estimate.Source != "calibrated" && estimate.Source != "scraped" {
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/marcus/nightshift/internal/budget"
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/providers"
+	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// burstPlanner schedules extra daemon runs before the weekly reset when the
+// projected leftover budget (see budget.BurstPlan) would otherwise go unused.
+type burstPlanner struct {
+	cfg      *config.Config
+	database *db.DB
+	sched    *scheduler.Scheduler
+	log      *logging.Logger
+}
+
+// burstRun is one extra run planned by burstPlanner.
+type burstRun struct {
+	provider string
+	runsLeft int   // planned burst runs left, including this one
+	regular  int64 // regular allowance; tasks above it are what bursts are for
+}
+
+// plan checks each enabled provider, in preference order, and schedules
+// burst runs for the first one due. Runs already planned are left alone.
+func (b *burstPlanner) plan(ctx context.Context) {
+	burst := b.cfg.Budget.Burst
+	if !burst.Enabled || len(b.sched.PendingOnce()) > 0 {
+		return
+	}
+	st, err := state.New(b.database)
+	if err != nil {
+		b.log.Warnf("burst: init state: %v", err)
+		return
+	}
+	providerSet := providers.FromConfig(b.cfg)
+	providers.UseLedger(providerSet, st)
+	mgr := daemonBudgetManager(b.cfg, b.database, st, providerSet)
+
+	for _, name := range providerPreference(b.cfg) {
+		if providers.Find(providerSet, name) == nil {
+			continue
+		}
+		plan, err := mgr.BurstPlan(name)
+		if err != nil {
+			b.log.Warnf("burst %s: %v", name, err)
+			continue
+		}
+		if plan == nil || !plan.Active {
+			continue
+		}
+
+		spacing := durationOr(burst.Spacing, config.DefaultBurstSpacing)
+		maxRuns := burst.MaxRuns
+		if maxRuns <= 0 {
+			maxRuns = config.DefaultBurstMaxRuns
+		}
+		slots := b.sched.SlotsBetween(time.Now().Add(spacing), plan.ResetsAt, spacing, maxRuns)
+		if len(slots) == 0 {
+			b.log.Infof("burst %s: %s, but no run fits the window before the reset", name, plan)
+			return
+		}
+		for _, at := range slots {
+			b.sched.ScheduleOnce(ctx, at, func(runCtx context.Context) error {
+				run := &burstRun{provider: name, runsLeft: len(b.sched.PendingOnce()) + 1}
+				return runScheduledTasks(runCtx, b.cfg, b.database, b.log, daemonRun{burst: run})
+			}, nil)
+		}
+		b.log.InfoCtx("burst scheduled", map[string]any{
+			"provider": name,
+			"runs":     len(slots),
+			"first":    slots[0].Format(time.RFC3339),
+			"leftover": plan.Leftover,
+			"resets":   plan.ResetsAt.Format(time.RFC3339),
+		})
+		return
+	}
+}
+
+// choice returns the burst's provider with its allowance set to an even
+// share of the projected leftover across the remaining burst runs.
+func (r *burstRun) choice(cfg *config.Config, mgr *budget.Manager, projects []string) (*providerChoice, error) {
+	p := providerFromConfig(cfg, r.provider)
+	if p == nil {
+		return nil, fmt.Errorf("burst provider %s not configured", r.provider)
+	}
+	if _, err := exec.LookPath(p.Binary()); err != nil {
+		return nil, fmt.Errorf("burst provider %s: CLI not in PATH", r.provider)
+	}
+	plan, err := mgr.BurstPlan(r.provider)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil || plan.Leftover <= 0 {
+		return nil, fmt.Errorf("burst %s: no leftover budget", r.provider)
+	}
+	regular, err := mgr.CalculateAllowance(r.provider)
+	if err != nil {
+		return nil, err
+	}
+	r.regular = regular.Allowance
+
+	allowance := *regular
+	allowance.Allowance = plan.Leftover / int64(max(r.runsLeft, 1))
+	return &providerChoice{
+		agent:     newAgentFromConfig(cfg, p, projects),
+		name:      r.provider,
+		allowance: &allowance,
+	}, nil
+}
+
+// burstTaskSkipReason is taskBudgetSkipReason for burst runs: tasks are
+// checked against the projected leftover instead of the regular allowance.
+func burstTaskSkipReason(mgr *budget.Manager, provider string, estimatedTokens int) (string, error) {
+	plan, err := mgr.BurstPlan(provider)
+	if err != nil || plan == nil {
+		return "", err
+	}
+	if plan.Leftover < int64(estimatedTokens) {
+		return fmt.Sprintf("burst budget exhausted (%s tokens left)", formatTokens64(plan.Leftover)), nil
+	}
+	if ok, err := mgr.CanSpend(provider, int64(estimatedTokens)); err == nil && !ok {
+		if status, err := mgr.SpendStatus(); err == nil && status != nil {
+			return fmt.Sprintf("dollar cap reached (%s)", status), nil
+		}
+	}
+	return "", nil
+}
+
+func durationOr(value, fallback string) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	d, _ := time.ParseDuration(fallback)
+	return d
+}
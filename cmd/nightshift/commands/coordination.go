@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/coord"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// setupCoordination shares st's task claims and history with other
+// machines through the configured coordination backend, and returns a
+// func closing the backend. Without a backend, or when it cannot be
+// opened, st stays local-only.
+func setupCoordination(cfg *config.Config, st *state.State, log *logging.Logger) func() {
+	backend, err := openCoordination(cfg.Coordination)
+	if err != nil {
+		log.Warnf("coordination: %v; claims and history stay local", err)
+		return func() {}
+	}
+	if backend == nil {
+		return func() {}
+	}
+	lease, err := time.ParseDuration(cfg.Coordination.Lease)
+	if err != nil || lease <= 0 {
+		lease, _ = time.ParseDuration(config.DefaultCoordLease)
+	}
+	st.SetCoordinator(backend, lease)
+	return func() { _ = backend.Close() }
+}
+
+// openCoordination opens the configured backend, or returns nil when
+// coordination is off.
+func openCoordination(c config.CoordinationConfig) (coord.Backend, error) {
+	machine := c.Machine
+	if machine == "" {
+		machine = coord.DefaultMachine()
+	}
+	switch c.Backend {
+	case "":
+		return nil, nil
+	case config.CoordinationSQLite:
+		return coord.OpenSQLite(expandPath(c.Path), machine)
+	case config.CoordinationGit:
+		remote := c.Remote
+		if remote == "" {
+			remote = config.DefaultCoordRemote
+		}
+		return coord.NewGit(remote, machine), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", c.Backend)
+	}
+}
+
+// claimTaskSkipReason marks a task assigned and claims it for this
+// machine. It returns a skip reason when another machine holds the task.
+// Coordination failures are logged and do not block the task.
+func claimTaskSkipReason(st *state.State, taskID, project, taskType string, log *logging.Logger) string {
+	holder, err := st.ClaimTask(taskID, project, taskType)
+	if err != nil {
+		log.Warnf("claim %s: %v", taskType, err)
+	}
+	if holder != "" {
+		return "claimed by " + holder
+	}
+	return ""
+}
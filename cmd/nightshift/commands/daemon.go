@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/marcus/nightshift/internal/tasks"
 	"github.com/marcus/nightshift/internal/tmux"
 	"github.com/marcus/nightshift/internal/trends"
+	"github.com/marcus/nightshift/internal/triggers"
 	"github.com/spf13/cobra"
 )
 
@@ -140,7 +142,7 @@ func runDaemonStart(cmd *cobra.Command, args []string) error {
 	}
 
 	// Validate schedule is configured
-	if cfg.Schedule.Cron == "" && cfg.Schedule.Interval == "" {
+	if len(cfg.Schedule.Entries()) == 0 {
 		return fmt.Errorf("no schedule configured (set cron or interval in config)")
 	}
 
@@ -209,52 +211,119 @@ func runDaemonLoop(cfg *config.Config) error {
 		cancel()
 	}()
 
-	// Initialize scheduler from config
-	sched, err := scheduler.NewFromConfig(&cfg.Schedule)
+	// Initialize one scheduler per configured schedule. They share a run
+	// lock so runs never overlap.
+	scheds, err := buildSchedulers(cfg)
 	if err != nil {
 		return fmt.Errorf("init scheduler: %w", err)
 	}
+	runLock := &sync.Mutex{}
 
-	// Add the main run job
-	sched.AddJob(func(jobCtx context.Context) error {
-		return runScheduledTasks(jobCtx, cfg, database, log)
-	})
+	// Detect runs missed while the machine slept or the daemon was down
+	fireLog, err := state.New(database)
+	if err != nil {
+		return fmt.Errorf("init state: %w", err)
+	}
+	grace, _ := time.ParseDuration(cfg.Schedule.CatchUp.Grace)
+
+	// Bursts are extra runs of the first schedule, within its window
+	bursts := &burstPlanner{cfg: cfg, database: database, sched: scheds[0].sched, log: log}
+	for _, ns := range scheds {
+		schedule := ns.schedule
+		ns.sched.SetRunLock(runLock)
+		ns.sched.SetCatchUp(scheduler.CatchUp{
+			Name:    schedule.Name,
+			Log:     fireLog,
+			Enabled: cfg.Schedule.CatchUp.Enabled,
+			Grace:   grace,
+		})
+		ns.sched.AddJob(func(jobCtx context.Context) error {
+			err := runScheduledTasks(jobCtx, cfg, database, log, daemonRun{schedule: &schedule})
+			bursts.plan(jobCtx)
+			return err
+		})
+	}
+
+	if _, err := startCommitTriggers(ctx, cfg, database, log, runLock); err != nil {
+		log.Errorf("commit triggers: %v", err)
+	}
 
 	startSnapshotLoop(ctx, cfg, database, log)
 	startSnapshotPruneLoop(ctx, cfg, database, log)
+	startPROutcomeLoop(ctx, cfg, database, log)
 
-	// Start scheduler
-	if err := sched.Start(ctx); err != nil {
-		return fmt.Errorf("start scheduler: %w", err)
+	// Start schedulers
+	nextRuns := make(map[string]any, len(scheds))
+	for _, ns := range scheds {
+		if err := ns.sched.Start(ctx); err != nil {
+			return fmt.Errorf("start scheduler %s: %w", ns.schedule.Name, err)
+		}
+		nextRuns[ns.schedule.Name] = ns.sched.NextRun().Format(time.RFC3339)
 	}
 
 	log.InfoCtx("daemon running", map[string]any{
-		"next_run": sched.NextRun().Format(time.RFC3339),
+		"next_run": nextRuns,
 	})
 
 	// Wait for context cancellation
 	<-ctx.Done()
 
-	// Stop scheduler gracefully
-	if err := sched.Stop(); err != nil && err != scheduler.ErrNotRunning {
-		log.Errorf("stopping scheduler: %v", err)
+	// Stop schedulers gracefully
+	for _, ns := range scheds {
+		if err := ns.sched.Stop(); err != nil && err != scheduler.ErrNotRunning {
+			log.Errorf("stopping scheduler %s: %v", ns.schedule.Name, err)
+		}
 	}
 
 	log.Info("daemon stopped")
 	return nil
 }
 
+// daemonRun describes one daemon run. The zero value runs every project
+// and task on the regular schedule.
+type daemonRun struct {
+	schedule *config.NamedSchedule // narrows projects, tasks and budget
+	burst    *burstRun             // set for end-of-week burst runs
+	change   *triggers.Change      // set for runs triggered by new commits
+}
+
 // runScheduledTasks executes the scheduled nightshift tasks.
-func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB, log *logging.Logger) error {
-	log.Info("scheduled run starting")
+func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB, log *logging.Logger, run daemonRun) error {
+	schedule, burst, change := run.schedule, run.burst, run.change
+	scheduleName := config.DefaultScheduleName
+	var taskFilter tasks.Filter
+	if schedule != nil {
+		scheduleName = schedule.Name
+		cfg = schedule.Apply(cfg)
+		f, err := scheduleTaskFilter(*schedule)
+		if err != nil {
+			log.Errorf("%v", err)
+			return err
+		}
+		taskFilter = f
+	}
+	if burst != nil {
+		log.Infof("burst run starting (%s, %d of planned runs left)", burst.provider, burst.runsLeft)
+	} else if change != nil {
+		log.Infof("commit-triggered run starting (%s %s)", filepath.Base(change.Project), change.Range())
+	} else {
+		log.Infof("scheduled run starting (schedule %s)", scheduleName)
+	}
 	start := time.Now()
 
+	// Skip or downgrade runs during blackouts (schedule.blackout)
+	taskFilter, noPR, ok := applyBlackout(cfg, start, taskFilter, log)
+	if !ok {
+		return nil
+	}
+
 	// Initialize state manager
 	st, err := state.New(database)
 	if err != nil {
 		log.Errorf("init state: %v", err)
 		return err
 	}
+	defer setupCoordination(cfg, st, log)()
 
 	// Clear stale assignments older than 2 hours
 	cleared := st.ClearStaleAssignments(2 * time.Hour)
@@ -263,14 +332,12 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 	}
 
 	// Initialize providers
-	claudeProvider := providers.NewClaudeWithPath(cfg.ExpandedProviderPath("claude"))
-	codexProvider := providers.NewCodexWithPath(cfg.ExpandedProviderPath("codex"))
-	geminiProvider := providers.NewGeminiWithPath(cfg.ExpandedProviderPath("gemini"))
+	providerSet := providers.FromConfig(cfg)
+	providers.UseLedger(providerSet, st)
 
 	// Initialize budget manager
-	cal := calibrator.New(database, cfg)
-	trend := trends.NewAnalyzer(database, cfg.Budget.SnapshotRetentionDays)
-	budgetMgr := budget.NewManagerFromProviders(cfg, claudeProvider, codexProvider, geminiProvider, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
+	budgetMgr := daemonBudgetManager(cfg, database, st, providerSet)
+	prices := providers.NewPriceTable(cfg, providerSet)
 
 	report := newRunReport(time.Now(), calculateRunBudgetStart(cfg, budgetMgr, log))
 
@@ -281,18 +348,44 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 		return err
 	}
 
+	if schedule != nil {
+		projects = scheduleProjects(*schedule, projects)
+	}
+
 	if len(projects) == 0 {
 		log.Info("no projects configured")
 		return nil
 	}
+	// Reload custom tasks so edited task files apply to this run
+	if err := registerRunTasks(cfg, projects, log); err != nil {
+		log.Errorf("%v", err)
+		return err
+	}
+
+	// Wait for the user to stop working (schedule.idle)
+	if ok, err := awaitIdle(ctx, cfg, st, projects, schedule, log); !ok {
+		return err
+	}
 
 	// Create task selector
 	selector := tasks.NewSelector(cfg, st)
+	selector.SetFilter(taskFilter)
+	sandboxPreCommands(cfg, selector, projects)
+
+	// Don't start tasks predicted to overrun the window
+	if deadline := windowDeadline(cfg, schedule, time.Now()); !deadline.IsZero() {
+		selector.SetDeadline(deadline, predictedDurations(st, log))
+	}
+
+	ledger := newRunLedger(database, st, providerSet, weekStartDayFromConfig(cfg))
+	ledger.begin(ctx, log)
 
 	var tasksRun, tasksCompleted, tasksFailed int
 
-	// Process each project
-	for _, projectPath := range projects {
+	// Process each project, highest priority first, within its share of the
+	// provider's allowance
+	budgets := newProjectBudgets(cfg, projects)
+	for _, projectPath := range budgets.ordered() {
 		select {
 		case <-ctx.Done():
 			log.Info("run cancelled")
@@ -300,14 +393,21 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 		default:
 		}
 
-		// Skip if already processed today
-		if st.WasProcessedToday(projectPath) {
+		// Skip if already processed today. Bursts and named schedules revisit
+		// projects on purpose; task cooldowns still apply.
+		if burst == nil && scheduleName == config.DefaultScheduleName && st.WasProcessedToday(projectPath) {
 			log.Debugf("skip %s (processed today)", projectPath)
+			budgets.done(projectPath)
 			continue
 		}
 
 		// Select the best available provider with remaining budget
-		choice, err := selectProvider(cfg, budgetMgr, log, false)
+		var choice *providerChoice
+		if burst != nil {
+			choice, err = burst.choice(cfg, budgetMgr, projects)
+		} else {
+			choice, err = selectProvider(cfg, budgetMgr, log, false, projects)
+		}
 		if err != nil {
 			log.Infof("no provider available: %v", err)
 			break
@@ -327,9 +427,22 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 			}),
 			orchestrator.WithLogger(logging.Component("orchestrator")),
 		)
+		orch.SetNoPR(noPR)
+
+		// Tasks whose previous PR is still open are skipped or update it
+		openPRs := findOpenPRs(ctx, cfg, selector, projectPath, log)
 
 		// Select tasks
-		selectedTasks := selector.SelectTopN(allowance.Allowance, projectPath, 5)
+		alloc := budgets.allocator(choice)
+		allocation := alloc.Open(projectPath)
+		var selectedTasks []tasks.ScoredTask
+		if burst != nil {
+			selectedTasks = selector.SelectBurst(allocation, burst.regular, projectPath, 5)
+		} else if change != nil {
+			selectedTasks = selector.SelectChanged(allocation, projectPath, 5)
+		} else {
+			selectedTasks = selector.SelectTopN(allocation, projectPath, 5)
+		}
 		if len(selectedTasks) == 0 {
 			if report != nil {
 				report.addTask(reporting.TaskResult{
@@ -340,14 +453,16 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 					SkipReason: "no tasks available within budget",
 				})
 			}
+			budgets.done(projectPath)
 			continue
 		}
 
 		log.InfoCtx("processing project", map[string]any{
-			"project":  projectPath,
-			"tasks":    len(selectedTasks),
-			"budget":   allowance.Allowance,
-			"provider": choice.name,
+			"project":    projectPath,
+			"tasks":      len(selectedTasks),
+			"budget":     allowance.Allowance,
+			"allocation": allocation,
+			"provider":   choice.name,
 		})
 
 		// Execute each selected task
@@ -363,20 +478,72 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 			default:
 			}
 
+			// Re-check budget: spend recorded by earlier tasks counts against the caps
+			_, maxTok := scoredTask.Definition.EstimatedTokens()
+			var reason string
+			if burst != nil {
+				reason, err = burstTaskSkipReason(budgetMgr, choice.name, maxTok)
+			} else {
+				reason, err = taskBudgetSkipReason(budgetMgr, choice.name, maxTok)
+			}
+			if err != nil {
+				log.Warnf("budget check: %v", err)
+			}
+			if reason == "" {
+				reason = projectAllocationSkipReason(alloc, projectPath, maxTok)
+			}
+			if reason == "" {
+				reason = selector.DeadlineSkipReason(scoredTask.Definition.Type)
+			}
+			if reason == "" {
+				reason = selector.ChainSkipReason(scoredTask.Definition, projectPath)
+			}
+			if reason == "" {
+				reason, err = awaitSessionWindow(ctx, budgetMgr, choice.name, maxTok, selector.LatestStart(scoredTask.Definition.Type), log, nil)
+				if err != nil {
+					return err
+				}
+			}
+			// Claim last, so tasks skipped for other reasons stay free
+			taskID := fmt.Sprintf("%s:%s", scoredTask.Definition.Type, projectPath)
+			if reason == "" {
+				reason = claimTaskSkipReason(st, taskID, projectPath, string(scoredTask.Definition.Type), log)
+			}
+			var evidence string
+			if reason == "" {
+				evidence, reason = runPreAnalysis(ctx, st, selector, scoredTask.Definition, taskID, projectPath, log)
+			}
+			if reason != "" {
+				log.Infof("skip %s: %s", scoredTask.Definition.Type, reason)
+				report.addTask(reporting.TaskResult{
+					Project:    projectPath,
+					TaskType:   string(scoredTask.Definition.Type),
+					Title:      scoredTask.Definition.Name,
+					Status:     "skipped",
+					SkipReason: reason,
+				})
+				continue
+			}
+
 			tasksRun++
 			projectTaskTypes = append(projectTaskTypes, string(scoredTask.Definition.Type))
 
 			// Create task instance
 			taskInstance := &tasks.Task{
-				ID:          fmt.Sprintf("%s:%s", scoredTask.Definition.Type, projectPath),
+				ID:          taskID,
 				Title:       scoredTask.Definition.Name,
-				Description: scoredTask.Definition.Description,
+				Description: tasks.ChainPrompt(scoredTask.Definition.Description, selector.UpstreamOutputs(scoredTask.Definition, projectPath)),
 				Priority:    int(scoredTask.Score),
 				Type:        scoredTask.Definition.Type,
+				Evidence:    evidence,
+			}
+			if change != nil {
+				taskInstance.Description += fmt.Sprintf("\n\nTriggered by new commits on %s: %s. Focus on this range (git log %s).",
+					change.Branch, change.Range(), change.Range())
 			}
 
-			// Mark as assigned
-			st.MarkAssigned(taskInstance.ID, projectPath, string(scoredTask.Definition.Type))
+			orch.SetExistingPR(existingPRFor(cfg, openPRs, scoredTask.Definition.Type))
+			orch.SetScope(pathScopesFor(cfg, projectPath, scoredTask.Definition.Type), cfg.ScopeViolationPolicy())
 
 			// Execute via orchestrator
 			result, err := orch.RunTask(ctx, taskInstance, projectPath)
@@ -384,6 +551,16 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 			// Clear assignment
 			st.ClearAssigned(taskInstance.ID)
 
+			// Price and persist what the agent actually consumed
+			var costUSD float64
+			var modelTokens map[string]int64
+			if result != nil {
+				modelTokens = tokensByModel(result.Usage)
+				costUSD = recordLedger(st, prices, choice.name, projectPath, taskInstance.ID, string(scoredTask.Definition.Type), result.Invocations, maxTok, log)
+				recordTaskRun(st, choice.name, projectPath, string(scoredTask.Definition.Type), result, err, log)
+				alloc.Spend(projectPath, taskTokens(result.Usage, maxTok))
+			}
+
 			if err != nil {
 				tasksFailed++
 				projectFailed++
@@ -395,6 +572,8 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 						Title:      scoredTask.Definition.Name,
 						Status:     "failed",
 						TokensUsed: 0,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -412,7 +591,6 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 					"iterations": result.Iterations,
 					"duration":   result.Duration.String(),
 				})
-				_, maxTok := scoredTask.Definition.EstimatedTokens()
 				projectTokensUsed += maxTok
 				if report != nil {
 					report.addTask(reporting.TaskResult{
@@ -423,6 +601,8 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 						OutputType: result.OutputType,
 						OutputRef:  result.OutputRef,
 						TokensUsed: maxTok,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -437,6 +617,8 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 						Title:      scoredTask.Definition.Name,
 						Status:     "failed",
 						SkipReason: result.Error,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -451,6 +633,8 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 						Title:      scoredTask.Definition.Name,
 						Status:     "failed",
 						SkipReason: result.Error,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -475,9 +659,11 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 			TokensUsed: projectTokensUsed,
 			Status:     projectStatus,
 		})
+		budgets.done(projectPath)
 	}
 
 	// Summary
+	ledger.reconcile(ctx, log)
 	duration := time.Since(start)
 	log.InfoCtx("scheduled run complete", map[string]any{
 		"duration":  duration.String(),
@@ -485,6 +671,7 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 		"completed": tasksCompleted,
 		"failed":    tasksFailed,
 		"projects":  len(projects),
+		"schedule":  scheduleName,
 	})
 
 	if report != nil {
@@ -494,6 +681,16 @@ func runScheduledTasks(ctx context.Context, cfg *config.Config, database *db.DB,
 	return nil
 }
 
+// daemonBudgetManager builds the budget manager used by daemon runs.
+func daemonBudgetManager(cfg *config.Config, database *db.DB, st *state.State, providerSet []providers.Provider) *budget.Manager {
+	return budget.NewManagerFromProviders(cfg, providerSet,
+		budget.WithBudgetSource(calibrator.New(database, cfg)),
+		budget.WithTrendAnalyzer(trends.NewAnalyzer(database, cfg.Budget.SnapshotRetentionDays)),
+		budget.WithSpendSource(st),
+		budget.WithSessionResetSource(snapshots.NewCollector(database, nil, nil, weekStartDayFromConfig(cfg))),
+	)
+}
+
 type tmuxScraper struct{}
 
 // ScrapeClaudeUsage delegates to tmux.ScrapeClaudeUsage.
@@ -551,49 +748,26 @@ func takeSnapshot(ctx context.Context, cfg *config.Config, database *db.DB, log
 		scraper = tmuxScraper{}
 	}
 
-	collector := snapshots.NewCollector(
-		database,
-		providers.NewClaudeWithPath(cfg.ExpandedProviderPath("claude")),
-		providers.NewCodexWithPath(cfg.ExpandedProviderPath("codex")),
-		providers.NewGeminiWithPath(cfg.ExpandedProviderPath("gemini")),
-		scraper,
-		weekStartDayFromConfig(cfg),
-	)
-
-	if cfg.Providers.Claude.Enabled {
-		snapshot, err := collector.TakeSnapshot(ctx, "claude")
-		if err != nil {
-			log.Warnf("snapshot claude: %v", err)
-		} else if snapshot.ScrapedPct != nil {
-			log.Infof("snapshot claude: %.1f%%", *snapshot.ScrapedPct)
-		} else {
-			log.Info("snapshot claude: local-only")
-		}
-	}
-
-	if cfg.Providers.Codex.Enabled {
-		snapshot, err := collector.TakeSnapshot(ctx, "codex")
-		if err != nil {
-			log.Warnf("snapshot codex: %v", err)
-		} else if snapshot.ScrapedPct != nil {
-			log.Infof("snapshot codex: %.1f%%", *snapshot.ScrapedPct)
-		} else {
-			log.Info("snapshot codex: local-only")
-		}
-	}
+	providerSet := enabledProviders(cfg)
+	useLedger(providerSet, database)
+	collector := snapshots.NewCollector(database, usageSources(providerSet), scraper, weekStartDayFromConfig(cfg))
 
-	if cfg.Providers.Gemini.Enabled {
-		snapshot, err := collector.TakeSnapshot(ctx, "gemini")
-		if err != nil {
-			log.Warnf("snapshot gemini: %v", err)
-		} else {
-			log.Infof("snapshot gemini: %d tokens", snapshot.LocalTokens)
+	for _, p := range providerSet {
+		name := p.Name()
+		snapshot, err := collector.TakeSnapshot(ctx, name)
+		switch {
+		case err != nil:
+			log.Warnf("snapshot %s: %v", name, err)
+		case snapshot.ScrapedPct != nil:
+			log.Infof("snapshot %s: %.1f%%", name, *snapshot.ScrapedPct)
+		default:
+			log.Infof("snapshot %s: local-only (%d tokens)", name, snapshot.LocalTokens)
 		}
 	}
 }
 
 func pruneSnapshots(ctx context.Context, cfg *config.Config, database *db.DB, log *logging.Logger) {
-	collector := snapshots.NewCollector(database, nil, nil, nil, nil, weekStartDayFromConfig(cfg))
+	collector := snapshots.NewCollector(database, nil, nil, weekStartDayFromConfig(cfg))
 	deleted, err := collector.Prune(cfg.Budget.SnapshotRetentionDays)
 	if err != nil {
 		log.Warnf("snapshot prune: %v", err)
@@ -664,6 +838,26 @@ func runDaemonStop(cmd *cobra.Command, args []string) error {
 	}
 }
 
+// printScheduleFires shows a schedule's last run and its recent missed or
+// caught-up runs.
+func printScheduleFires(st *state.State, schedule string) {
+	const layout = "Mon 01-02 15:04"
+	if last, ok, err := st.LastFire(schedule); err == nil && ok {
+		fmt.Printf("             last %s (%s)\n", last.ScheduledAt.Format(layout), strings.ReplaceAll(string(last.Status), "_", " "))
+	}
+	fires, err := st.RecentFires(schedule, 3, scheduler.FireMissed, scheduler.FireCaughtUp)
+	if err != nil {
+		return
+	}
+	for _, f := range fires {
+		if f.Status == scheduler.FireCaughtUp {
+			fmt.Printf("             caught up %s run at %s\n", f.ScheduledAt.Format(layout), f.FiredAt.Format("15:04"))
+		} else {
+			fmt.Printf("             missed %s run (noticed %s)\n", f.ScheduledAt.Format(layout), f.FiredAt.Format(layout))
+		}
+	}
+}
+
 func runDaemonStatus(cmd *cobra.Command, args []string) error {
 	running, pid := isDaemonRunning()
 
@@ -675,26 +869,26 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Status: running\n")
 	fmt.Printf("PID: %d\n", pid)
 
-	// Try to load config and show next run time
+	// Try to load config and show each schedule with its next run
 	cfg, err := config.Load()
-	if err == nil && (cfg.Schedule.Cron != "" || cfg.Schedule.Interval != "") {
-		sched, err := scheduler.NewFromConfig(&cfg.Schedule)
-		if err == nil {
-			// We need to start the scheduler briefly to calculate next run
-			// Instead, just show the schedule config
-			if cfg.Schedule.Cron != "" {
-				fmt.Printf("Schedule: cron %s\n", cfg.Schedule.Cron)
-			} else if cfg.Schedule.Interval != "" {
-				fmt.Printf("Schedule: every %s\n", cfg.Schedule.Interval)
+	if err == nil {
+		if scheds, err := buildSchedulers(cfg); err == nil {
+			var st *state.State
+			if database, err := db.Open(cfg.ExpandedDBPath()); err == nil {
+				defer func() { _ = database.Close() }()
+				st, _ = state.New(database)
 			}
-			if cfg.Schedule.Window != nil {
-				fmt.Printf("Window: %s - %s", cfg.Schedule.Window.Start, cfg.Schedule.Window.End)
-				if cfg.Schedule.Window.Timezone != "" {
-					fmt.Printf(" (%s)", cfg.Schedule.Window.Timezone)
+			fmt.Println("Schedules:")
+			for _, ns := range scheds {
+				next := "unknown"
+				if runs, err := ns.sched.NextRuns(1); err == nil && len(runs) > 0 {
+					next = runs[0].Format("Mon 2006-01-02 15:04")
+				}
+				fmt.Printf("  %-10s next %s (%s)\n", ns.schedule.Name, next, describeSchedule(ns.schedule))
+				if st != nil {
+					printScheduleFires(st, ns.schedule.Name)
 				}
-				fmt.Println()
 			}
-			_ = sched // satisfy compiler
 		}
 	}
 
@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Back up, export and import the nightshift database",
+	Long: `Manage the SQLite database holding nightshift's state: task history,
+snapshots, spend, PR outcomes and analysis results.
+
+The database is backed up automatically before schema migrations.`,
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup [path]",
+	Short: "Write a consistent copy of the database",
+	Long: `Write a consistent copy of the database with VACUUM INTO. Safe while the
+daemon is running. Without a path, the backup goes in the backups directory
+next to the database.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dest := ""
+		if len(args) == 1 {
+			dest = args[0]
+		}
+		return runDBBackup(dest)
+	},
+}
+
+var dbExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export tables as JSON or CSV",
+	Long: `Export database tables. JSON writes every selected table to one document,
+which db import reads. CSV writes one file per table: to stdout for a
+single --table, otherwise to <table>.csv files in the --out directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		tables, _ := cmd.Flags().GetStringSlice("table")
+		out, _ := cmd.Flags().GetString("out")
+		return runDBExport(format, tables, out)
+	},
+}
+
+var dbImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a JSON export or restore a database file",
+	Long: `Import data, e.g. when moving to a new machine. The file is either a JSON
+document from db export, whose rows are loaded into the current database,
+or a SQLite database such as a backup, which replaces the current database.
+
+The current database is backed up first. JSON imports refuse tables that
+already hold rows unless --replace is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		replace, _ := cmd.Flags().GetBool("replace")
+		return runDBImport(args[0], replace)
+	},
+}
+
+var dbStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show table sizes and row counts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		return runDBStats(jsonOutput)
+	},
+}
+
+func init() {
+	dbExportCmd.Flags().String("format", "json", "Export format: json or csv")
+	dbExportCmd.Flags().StringSliceP("table", "t", nil, "Tables to export (default: all)")
+	dbExportCmd.Flags().StringP("out", "o", "", "Output file (json, single-table csv) or directory (csv)")
+	dbImportCmd.Flags().Bool("replace", false, "Delete existing rows in imported tables first (JSON only)")
+	dbStatsCmd.Flags().Bool("json", false, "Output as JSON")
+
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbExportCmd)
+	dbCmd.AddCommand(dbImportCmd)
+	dbCmd.AddCommand(dbStatsCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+func openConfiguredDB() (*db.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	database, err := db.Open(cfg.ExpandedDBPath())
+	if err != nil {
+		return nil, fmt.Errorf("opening db: %w", err)
+	}
+	return database, nil
+}
+
+func runDBBackup(dest string) error {
+	database, err := openConfiguredDB()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = database.Close() }()
+
+	if dest == "" {
+		dest = db.BackupPath(database.Path(), "manual", time.Now())
+	}
+	if err := database.Backup(expandPath(dest)); err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %s to %s\n", database.Path(), expandPath(dest))
+	return nil
+}
+
+func runDBExport(format string, tables []string, out string) error {
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("unknown format %q (valid: json, csv)", format)
+	}
+	database, err := openConfiguredDB()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = database.Close() }()
+
+	if format == "json" || len(tables) == 1 {
+		w := os.Stdout
+		if out != "" {
+			f, err := os.OpenFile(expandPath(out), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return fmt.Errorf("create %s: %w", out, err)
+			}
+			defer func() { _ = f.Close() }()
+			w = f
+		}
+		if format == "json" {
+			return database.ExportJSON(w, tables)
+		}
+		return database.ExportCSV(w, tables[0])
+	}
+
+	// CSV of several tables: one file each
+	if out == "" {
+		return fmt.Errorf("--out directory required to export several tables as csv")
+	}
+	if len(tables) == 0 {
+		if tables, err = database.Tables(); err != nil {
+			return err
+		}
+	}
+	dir := expandPath(out)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create %s: %w", dir, err)
+	}
+	for _, table := range tables {
+		if err := exportCSVFile(database, table, filepath.Join(dir, table+".csv")); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Exported %d table(s) to %s\n", len(tables), dir)
+	return nil
+}
+
+func exportCSVFile(database *db.DB, table, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if err := database.ExportCSV(f, table); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runDBImport(path string, replace bool) error {
+	path = expandPath(path)
+	isSQLite, err := db.IsSQLiteFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	var data []byte
+	if isSQLite {
+		if running, pid := isDaemonRunning(); running {
+			return fmt.Errorf("daemon is running (PID %d); stop it before restoring a database", pid)
+		}
+	} else {
+		if data, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("%s is neither a JSON export nor a SQLite database", path)
+		}
+	}
+
+	database, err := openConfiguredDB()
+	if err != nil {
+		return err
+	}
+	dbPath := database.Path()
+	backup := db.BackupPath(dbPath, "pre-import", time.Now())
+	if err := database.Backup(backup); err != nil {
+		_ = database.Close()
+		return err
+	}
+	fmt.Printf("Backed up current database to %s\n", backup)
+
+	if isSQLite {
+		_ = database.Close()
+		if err := db.Restore(path, dbPath); err != nil {
+			return err
+		}
+		// Opening migrates databases from older versions
+		restored, err := db.Open(dbPath)
+		if err != nil {
+			return fmt.Errorf("opening restored db: %w", err)
+		}
+		_ = restored.Close()
+		fmt.Printf("Restored %s from %s\n", dbPath, path)
+		return nil
+	}
+	defer func() { _ = database.Close() }()
+
+	imported, err := database.ImportJSON(bytes.NewReader(data), replace)
+	if err != nil {
+		return err
+	}
+	total := 0
+	for _, n := range imported {
+		total += n
+	}
+	fmt.Printf("Imported %d row(s) into %d table(s)\n", total, len(imported))
+	return nil
+}
+
+func runDBStats(jsonOutput bool) error {
+	database, err := openConfiguredDB()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = database.Close() }()
+
+	stats, err := database.Stats()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	fmt.Printf("Database: %s\n", stats.Path)
+	fmt.Printf("Size:     %s\n", formatBytes(stats.FileBytes))
+	fmt.Printf("Schema:   version %d\n\n", stats.SchemaVersion)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "Table\tRows\tSize")
+	for _, t := range stats.Tables {
+		_, _ = fmt.Fprintf(writer, "%s\t%d\t%s\n", t.Name, t.Rows, formatBytes(t.Bytes))
+	}
+	return writer.Flush()
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
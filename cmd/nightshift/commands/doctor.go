@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"github.com/marcus/nightshift/internal/db"
 	"github.com/marcus/nightshift/internal/providers"
 	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/security"
 	"github.com/marcus/nightshift/internal/snapshots"
 	"github.com/marcus/nightshift/internal/state"
 	"github.com/marcus/nightshift/internal/trends"
@@ -81,19 +83,23 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	defer func() { _ = database.Close() }()
 	add("db", statusOK, cfg.ExpandedDBPath())
 
-	if _, err := state.New(database); err != nil {
+	st, err := state.New(database)
+	if err != nil {
 		add("state", statusFail, err.Error())
 	} else {
 		add("state", statusOK, "ready")
 	}
 
 	checkSchedule(cfg, add)
+	checkIdle(cfg, st, add)
+	checkBlackouts(cfg, add)
 	checkService(add)
 	checkDaemon(add)
 
 	checkCLIs(cfg, add)
-	claudeProvider, codexProvider, geminiProvider := checkProviders(cfg, add)
-	checkBudget(cfg, database, claudeProvider, codexProvider, geminiProvider, add)
+	checkSandbox(cfg, add)
+	providerSet := checkProviders(cfg, database, add)
+	checkBudget(cfg, database, providerSet, add)
 	checkSnapshots(cfg, database, add)
 	checkTmux(cfg, add)
 
@@ -106,7 +112,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 }
 
 func checkSchedule(cfg *config.Config, add func(string, checkStatus, string)) {
-	sched, err := scheduler.NewFromConfig(&cfg.Schedule)
+	scheds, err := buildSchedulers(cfg)
 	if err != nil {
 		if errors.Is(err, scheduler.ErrNoSchedule) {
 			add("schedule", statusWarn, "no schedule configured (cron or interval)")
@@ -115,12 +121,56 @@ func checkSchedule(cfg *config.Config, add func(string, checkStatus, string)) {
 		add("schedule", statusFail, err.Error())
 		return
 	}
-	nextRuns, err := sched.NextRuns(1)
+	nextRuns, err := nextScheduledRuns(scheds, 1)
 	if err != nil || len(nextRuns) == 0 {
 		add("schedule", statusWarn, "unable to compute next run")
 		return
 	}
-	add("schedule", statusOK, fmt.Sprintf("next run %s", nextRuns[0].Format("2006-01-02 15:04")))
+	msg := fmt.Sprintf("next run %s", nextRuns[0].at.Format("2006-01-02 15:04"))
+	if len(scheds) > 1 {
+		msg = fmt.Sprintf("%d schedules, next run %s (%s)", len(scheds), nextRuns[0].at.Format("2006-01-02 15:04"), nextRuns[0].schedule.Name)
+	}
+	add("schedule", statusOK, msg)
+}
+
+func checkIdle(cfg *config.Config, st *state.State, add func(string, checkStatus, string)) {
+	if !cfg.Schedule.Idle.Enabled {
+		return
+	}
+	projects, _ := resolveProjects(cfg, "")
+	gate := newIdleGate(cfg, st, projects)
+	status, err := gate.Check(context.Background())
+	if err != nil {
+		add("idle", statusWarn, err.Error())
+		return
+	}
+	if status.Idle {
+		add("idle", statusOK, fmt.Sprintf("user idle (%s)", status))
+		return
+	}
+	add("idle", statusOK, fmt.Sprintf("user active (%s); runs wait %s", status, status.Wait.Round(time.Minute)))
+}
+
+func checkBlackouts(cfg *config.Config, add func(string, checkStatus, string)) {
+	cal, err := loadBlackouts(cfg)
+	if err != nil {
+		add("blackout", statusWarn, fmt.Sprintf("%v (runs are downgraded to analysis-only)", err))
+		return
+	}
+	if cal == nil {
+		return
+	}
+	now := time.Now()
+	if active := cal.Active(now); active != nil {
+		add("blackout", statusOK, fmt.Sprintf("active: %s", active))
+		return
+	}
+	upcoming := cal.Upcoming(now, now.Add(previewBlackoutHorizon))
+	if len(upcoming) == 0 {
+		add("blackout", statusOK, fmt.Sprintf("%d period(s), none in the next 30 days", len(cal.Periods)))
+		return
+	}
+	add("blackout", statusOK, fmt.Sprintf("next: %s", upcoming[0]))
 }
 
 func checkService(add func(string, checkStatus, string)) {
@@ -179,131 +229,107 @@ func checkDaemon(add func(string, checkStatus, string)) {
 }
 
 func checkCLIs(cfg *config.Config, add func(string, checkStatus, string)) {
-	if cfg.Providers.Claude.Enabled {
-		if path, err := exec.LookPath("claude"); err != nil {
-			add("claude.cli", statusFail, "claude not found in PATH")
+	for _, p := range enabledProviders(cfg) {
+		if path, err := exec.LookPath(p.Binary()); err != nil {
+			add(p.Name()+".cli", statusFail, p.Binary()+" not found in PATH")
 		} else {
-			add("claude.cli", statusOK, path)
+			add(p.Name()+".cli", statusOK, path)
 		}
 	}
-	if cfg.Providers.Codex.Enabled {
-		if path, err := exec.LookPath("codex"); err != nil {
-			add("codex.cli", statusFail, "codex not found in PATH")
-		} else {
-			add("codex.cli", statusOK, path)
-		}
+}
+
+func checkSandbox(cfg *config.Config, add func(string, checkStatus, string)) {
+	if !cfg.Sandbox.Enabled {
+		add("sandbox", statusOK, "disabled (agents inherit your environment)")
+		return
 	}
-	if cfg.Providers.Gemini.Enabled {
-		if path, err := exec.LookPath("gemini"); err != nil {
-			add("gemini.cli", statusFail, "gemini not found in PATH")
+
+	sc := sandboxConfigFromConfig(cfg, nil)
+	add("sandbox", statusOK, fmt.Sprintf("enabled (%d allow-listed env vars)", len(sc.EnvAllowlist)))
+
+	if sc.HomeOverlay {
+		sandbox, err := security.NewSandbox(sc)
+		if err != nil {
+			add("sandbox.home", statusFail, err.Error())
 		} else {
-			add("gemini.cli", statusOK, path)
+			add("sandbox.home", statusOK, fmt.Sprintf("temporary HOME overlay (%d passthrough entries)", len(sc.HomePassthrough)))
+			_ = sandbox.Cleanup()
 		}
+	} else {
+		add("sandbox.home", statusWarn, "agents see your real HOME")
 	}
-}
 
-func checkProviders(cfg *config.Config, add func(string, checkStatus, string)) (*providers.Claude, *providers.Codex, *providers.Gemini) {
-	var claudeProvider *providers.Claude
-	var codexProvider *providers.Codex
-	var geminiProvider *providers.Gemini
+	// Only the directory an agent starts in is checked; writes are not confined
+	extra, denied := cfg.ExpandedSandboxPaths()
+	add("sandbox.paths", statusOK, fmt.Sprintf("agents start only in projects + %d extra path(s), never in %d denied path(s); writes are not confined",
+		len(extra), len(denied)))
 
+	if !cfg.Sandbox.IsolateNetwork {
+		add("sandbox.network", statusWarn, "not isolated (isolate_network: false)")
+		return
+	}
+	if err := security.CheckNetworkIsolation(); err != nil {
+		add("sandbox.network", statusFail, fmt.Sprintf("isolation not effective: %v", err))
+		return
+	}
+	add("sandbox.network", statusOK, "isolated (private network namespace)")
+}
+
+func checkProviders(cfg *config.Config, database *db.DB, add func(string, checkStatus, string)) []providers.Provider {
 	mode := cfg.Budget.Mode
 	if mode == "" {
 		mode = config.DefaultBudgetMode
 	}
 
-	if cfg.Providers.Claude.Enabled {
-		path := cfg.ExpandedProviderPath("claude")
-		if _, err := os.Stat(path); err != nil {
-			add("claude.data_path", statusFail, fmt.Sprintf("missing %s", path))
-		} else {
-			add("claude.data_path", statusOK, path)
-		}
-		claudeProvider = providers.NewClaudeWithPath(path)
-		if usage, err := claudeProvider.GetWeeklyUsage(); err == nil {
-			add("claude.weekly_tokens", statusOK, fmt.Sprintf("%d tokens", usage))
-		}
-		if pct, err := claudeProvider.GetUsedPercent(mode, int64(cfg.GetProviderBudget("claude"))); err != nil {
-			add("claude.usage", statusFail, err.Error())
-		} else {
-			add("claude.usage", statusOK, fmt.Sprintf("%.1f%% used (%s, vs config budget)", pct, mode))
-		}
-	}
-
-	if cfg.Providers.Codex.Enabled {
-		path := cfg.ExpandedProviderPath("codex")
+	providerSet := enabledProviders(cfg)
+	useLedger(providerSet, database)
+	for _, p := range providerSet {
+		name := p.Name()
+		path := cfg.ExpandedProviderPath(name)
 		if _, err := os.Stat(path); err != nil {
-			add("codex.data_path", statusFail, fmt.Sprintf("missing %s", path))
-		} else {
-			add("codex.data_path", statusOK, path)
-		}
-		codexProvider = providers.NewCodexWithPath(path)
-		if pct, err := codexProvider.GetUsedPercent(mode, int64(cfg.GetProviderBudget("codex"))); err != nil {
-			add("codex.usage", statusFail, err.Error())
+			if name == "gemini" {
+				add(name+".data_path", statusWarn, fmt.Sprintf("missing %s (ok if no sessions yet)", path))
+			} else {
+				add(name+".data_path", statusFail, fmt.Sprintf("missing %s", path))
+			}
 		} else {
-			add("codex.usage", statusOK, fmt.Sprintf("%.1f%% used (%s)", pct, mode))
+			add(name+".data_path", statusOK, path)
 		}
-	}
-
-	if cfg.Providers.Gemini.Enabled {
-		path := cfg.ExpandedProviderPath("gemini")
-		if _, err := os.Stat(path); err != nil {
-			add("gemini.data_path", statusWarn, fmt.Sprintf("missing %s (ok if no sessions yet)", path))
-		} else {
-			add("gemini.data_path", statusOK, path)
+		if name == "claude" {
+			if usage, err := p.GetWeeklyTokens(); err == nil {
+				add(name+".weekly_tokens", statusOK, fmt.Sprintf("%d tokens", usage))
+			}
 		}
-		geminiProvider = providers.NewGeminiWithPath(path)
-		if pct, err := geminiProvider.GetUsedPercent(mode, int64(cfg.GetProviderBudget("gemini"))); err != nil {
-			add("gemini.usage", statusFail, err.Error())
+		if pct, err := p.GetUsedPercent(mode, int64(cfg.GetProviderBudget(name))); err != nil {
+			add(name+".usage", statusFail, err.Error())
 		} else {
-			add("gemini.usage", statusOK, fmt.Sprintf("%.1f%% used (%s)", pct, mode))
+			add(name+".usage", statusOK, fmt.Sprintf("%.1f%% used (%s)", pct, mode))
 		}
 	}
 
-	return claudeProvider, codexProvider, geminiProvider
+	return providerSet
 }
 
-func checkBudget(cfg *config.Config, database *db.DB, claudeProvider *providers.Claude, codexProvider *providers.Codex, geminiProvider *providers.Gemini, add func(string, checkStatus, string)) {
+func checkBudget(cfg *config.Config, database *db.DB, providerSet []providers.Provider, add func(string, checkStatus, string)) {
 	cal := calibrator.New(database, cfg)
 	trend := trends.NewAnalyzer(database, cfg.Budget.SnapshotRetentionDays)
-	budgetMgr := budget.NewManagerFromProviders(cfg, claudeProvider, codexProvider, geminiProvider, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
-
-	if cfg.Providers.Claude.Enabled {
-		if allowance, err := budgetMgr.CalculateAllowance("claude"); err != nil {
-			add("budget.claude", statusFail, err.Error())
-		} else {
-			add("budget.claude", statusOK, fmt.Sprintf("%.1f%% used, %d tokens available", allowance.UsedPercent, allowance.Allowance))
-		}
-	}
+	budgetMgr := budget.NewManagerFromProviders(cfg, providerSet, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
 
-	if cfg.Providers.Codex.Enabled {
-		if allowance, err := budgetMgr.CalculateAllowance("codex"); err != nil {
-			add("budget.codex", statusFail, err.Error())
+	for _, p := range providerSet {
+		name := p.Name()
+		if allowance, err := budgetMgr.CalculateAllowance(name); err != nil {
+			add("budget."+name, statusFail, err.Error())
 		} else {
-			add("budget.codex", statusOK, fmt.Sprintf("%.1f%% used, %d tokens available", allowance.UsedPercent, allowance.Allowance))
-		}
-	}
-
-	if cfg.Providers.Gemini.Enabled {
-		if allowance, err := budgetMgr.CalculateAllowance("gemini"); err != nil {
-			add("budget.gemini", statusFail, err.Error())
-		} else {
-			add("budget.gemini", statusOK, fmt.Sprintf("%.1f%% used, %d tokens available", allowance.UsedPercent, allowance.Allowance))
+			add("budget."+name, statusOK, fmt.Sprintf("%.1f%% used, %d tokens available", allowance.UsedPercent, allowance.Allowance))
 		}
 	}
 }
 
 func checkSnapshots(cfg *config.Config, database *db.DB, add func(string, checkStatus, string)) {
-	collector := snapshots.NewCollector(database, nil, nil, nil, nil, weekStartDayFromConfig(cfg))
+	collector := snapshots.NewCollector(database, nil, nil, weekStartDayFromConfig(cfg))
 
-	for _, provider := range []string{"claude", "codex", "gemini"} {
-		if provider == "claude" && !cfg.Providers.Claude.Enabled {
-			continue
-		}
-		if provider == "codex" && !cfg.Providers.Codex.Enabled {
-			continue
-		}
-		if provider == "gemini" && !cfg.Providers.Gemini.Enabled {
+	for _, provider := range providers.Names() {
+		if !cfg.ProviderSettings(provider).Enabled {
 			continue
 		}
 		latest, err := collector.GetLatest(provider, 1)
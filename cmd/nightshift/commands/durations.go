@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/state"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// durationSamples is how many recent executions of a task type its
+// predicted duration is based on.
+const durationSamples = 20
+
+// predictedDurations returns each task type's p90 duration from history.
+func predictedDurations(st *state.State, log *logging.Logger) map[tasks.TaskType]time.Duration {
+	history, err := st.RecentTaskDurations(durationSamples)
+	if err != nil {
+		log.Warnf("task durations: %v", err)
+		return nil
+	}
+	return tasks.PredictDurations(history)
+}
+
+// windowDeadline returns when the schedule window open at t closes, or
+// zero when there is no window or t is outside it. A nil schedule uses the
+// top-level window.
+func windowDeadline(cfg *config.Config, schedule *config.NamedSchedule, t time.Time) time.Time {
+	windowCfg := cfg.Schedule.Window
+	if schedule != nil {
+		windowCfg = schedule.Window
+	}
+	if windowCfg == nil {
+		return time.Time{}
+	}
+	sched := scheduler.New()
+	if err := sched.SetWindow(windowCfg); err != nil {
+		return time.Time{}
+	}
+	return sched.WindowEnd(t)
+}
@@ -2,62 +2,104 @@ package commands
 
 import (
 	"fmt"
+	"os/exec"
 	"strings"
 
 	"github.com/marcus/nightshift/internal/agents"
 	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/providers"
+	"github.com/marcus/nightshift/internal/security"
+	"github.com/marcus/nightshift/internal/snapshots"
+	"github.com/marcus/nightshift/internal/state"
 )
 
-// agentByName creates an agent for the given provider name.
-// Returns an error if the provider is unknown or its CLI is not in PATH.
-func agentByName(cfg *config.Config, provider string) (agents.Agent, error) {
-	switch strings.ToLower(provider) {
-	case "claude":
-		a := newClaudeAgentFromConfig(cfg)
-		if !a.Available() {
-			return nil, fmt.Errorf("claude CLI not found in PATH")
-		}
-		return a, nil
-	case "codex":
-		a := newCodexAgentFromConfig(cfg)
-		if !a.Available() {
-			return nil, fmt.Errorf("codex CLI not found in PATH")
-		}
-		return a, nil
-	case "gemini":
-		a := newGeminiAgentFromConfig(cfg)
-		if !a.Available() {
-			return nil, fmt.Errorf("gemini CLI not found in PATH")
+// agentByName creates an agent for the given provider name, sandboxed to
+// projects when sandbox.enabled is set. Returns an error if the provider is
+// unknown or its CLI is not in PATH.
+func agentByName(cfg *config.Config, provider string, projects []string) (agents.Agent, error) {
+	name := strings.ToLower(provider)
+	if _, ok := providers.Lookup(name); !ok {
+		return nil, fmt.Errorf("unknown provider: %s (supported: %s)", provider, strings.Join(providers.Names(), ", "))
+	}
+	p := providerFromConfig(cfg, name)
+	if _, err := exec.LookPath(p.Binary()); err != nil {
+		return nil, fmt.Errorf("%s CLI not found in PATH", p.Name())
+	}
+	return newAgentFromConfig(cfg, p, projects), nil
+}
+
+// providerFromConfig constructs a registered provider using the data path
+// from cfg. The name must be registered.
+func providerFromConfig(cfg *config.Config, name string) providers.Provider {
+	dataPath := ""
+	if cfg != nil {
+		dataPath = cfg.ExpandedProviderPath(name)
+	}
+	p, _ := providers.New(name, dataPath)
+	return p
+}
+
+// enabledProviders returns the registered providers enabled in cfg.
+func enabledProviders(cfg *config.Config) []providers.Provider {
+	var ps []providers.Provider
+	for _, p := range providers.FromConfig(cfg) {
+		if cfg.ProviderSettings(p.Name()).Enabled {
+			ps = append(ps, p)
 		}
-		return a, nil
-	default:
-		return nil, fmt.Errorf("unknown provider: %s (supported: claude, codex, gemini)", provider)
 	}
+	return ps
 }
 
-func newClaudeAgentFromConfig(cfg *config.Config) *agents.ClaudeAgent {
-	if cfg == nil {
-		return agents.NewClaudeAgent()
+// useLedger lets ps count the runs nightshift recorded in database, for
+// CLIs that keep no local record of headless runs.
+func useLedger(ps []providers.Provider, database *db.DB) {
+	if st, err := state.New(database); err == nil {
+		providers.UseLedger(ps, st)
 	}
-	return agents.NewClaudeAgent(
-		agents.WithDangerouslySkipPermissions(cfg.Providers.Claude.DangerouslySkipPermissions),
-	)
 }
 
-func newCodexAgentFromConfig(cfg *config.Config) *agents.CodexAgent {
-	if cfg == nil {
-		return agents.NewCodexAgent()
+// newAgentFromConfig creates the provider's agent, routed through the
+// sandbox when sandbox.enabled is set.
+func newAgentFromConfig(cfg *config.Config, p providers.Provider, projects []string) agents.Agent {
+	var runner agents.CommandRunner
+	if r := sandboxRunnerFromConfig(cfg, projects); r != nil {
+		runner = r
 	}
-	return agents.NewCodexAgent(
-		agents.WithDangerouslyBypassApprovalsAndSandbox(cfg.Providers.Codex.DangerouslyBypassApprovalsAndSandbox),
-	)
+	return p.NewAgent(cfg, runner)
 }
 
-func newGeminiAgentFromConfig(cfg *config.Config) *agents.GeminiAgent {
-	if cfg == nil {
-		return agents.NewGeminiAgent()
+// usageSources maps provider names to their local usage data for snapshots.
+func usageSources(ps []providers.Provider) map[string]snapshots.UsageSource {
+	sources := make(map[string]snapshots.UsageSource, len(ps))
+	for _, p := range ps {
+		sources[p.Name()] = p
 	}
-	return agents.NewGeminiAgent(
-		agents.WithGeminiYolo(cfg.Providers.Gemini.Yolo),
-	)
+	return sources
+}
+
+// sandboxRunnerFromConfig returns a sandboxed command runner when
+// sandbox.enabled is set, or nil to use the default runner.
+func sandboxRunnerFromConfig(cfg *config.Config, projects []string) *security.SandboxRunner {
+	if cfg == nil || !cfg.Sandbox.Enabled {
+		return nil
+	}
+	return security.NewSandboxRunner(sandboxConfigFromConfig(cfg, projects))
+}
+
+// sandboxConfigFromConfig maps the user-facing sandbox settings onto a
+// security.SandboxConfig. The project worktrees are added to the allow-list
+// so commands may only run inside the projects nightshift is processing.
+// Timeouts are left to the agents themselves.
+func sandboxConfigFromConfig(cfg *config.Config, projects []string) security.SandboxConfig {
+	sc := security.DefaultSandboxConfig()
+	sc.MaxDuration = 0
+	sc.AllowNetwork = !cfg.Sandbox.IsolateNetwork
+	sc.IsolateNetwork = cfg.Sandbox.IsolateNetwork
+	sc.EnvAllowlist = cfg.Sandbox.EnvAllowlist
+	sc.HomeOverlay = cfg.Sandbox.IsolateHome
+	sc.HomePassthrough = cfg.Sandbox.HomePassthrough
+	sc.AllowedPaths, sc.DeniedPaths = cfg.ExpandedSandboxPaths()
+	sc.AllowedPaths = append(sc.AllowedPaths, projects...)
+	return sc
 }
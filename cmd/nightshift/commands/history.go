@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/orchestrator"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show per-task execution history",
+	Long: `Show every task nightshift executed, newest first, with its outcome:
+status, iterations, measured tokens, duration, and the PR or other output
+it produced.
+
+The summary line covers all matching executions, not just those shown.
+Use --sync to refresh the state of opened PRs with gh first, and --json
+for machine-readable output.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var opts historyOptions
+		opts.project, _ = cmd.Flags().GetString("project")
+		opts.taskType, _ = cmd.Flags().GetString("task")
+		opts.provider, _ = cmd.Flags().GetString("provider")
+		opts.status, _ = cmd.Flags().GetString("status")
+		opts.since, _ = cmd.Flags().GetString("since")
+		opts.until, _ = cmd.Flags().GetString("until")
+		opts.limit, _ = cmd.Flags().GetInt("n")
+		opts.json, _ = cmd.Flags().GetBool("json")
+		opts.sync, _ = cmd.Flags().GetBool("sync")
+		return runHistory(cmd.Context(), opts)
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringP("project", "p", "", "Only executions in this project path")
+	historyCmd.Flags().StringP("task", "t", "", "Only executions of this task type")
+	historyCmd.Flags().String("provider", "", "Only executions by this provider (claude, codex, gemini)")
+	historyCmd.Flags().String("status", "", "Only executions with this status (completed, failed, abandoned)")
+	historyCmd.Flags().String("since", "", "Start time (YYYY-MM-DD, YYYY-MM-DD HH:MM, or RFC3339)")
+	historyCmd.Flags().String("until", "", "End time (YYYY-MM-DD, YYYY-MM-DD HH:MM, or RFC3339)")
+	historyCmd.Flags().IntP("n", "n", 20, "Number of executions to show (0 for all)")
+	historyCmd.Flags().Bool("json", false, "Output as JSON")
+	historyCmd.Flags().Bool("sync", false, "Poll opened PRs for merged/closed outcomes first")
+	rootCmd.AddCommand(historyCmd)
+}
+
+type historyOptions struct {
+	project  string
+	taskType string
+	provider string
+	status   string
+	since    string
+	until    string
+	limit    int
+	json     bool
+	sync     bool
+}
+
+// historySummary counts executions by outcome.
+type historySummary struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	Abandoned int `json:"abandoned"`
+	PRs       int `json:"prs"`
+	Merged    int `json:"merged"` // PRs merged
+}
+
+func runHistory(ctx context.Context, opts historyOptions) error {
+	filter := state.TaskRunFilter{
+		TaskType: opts.taskType,
+		Provider: opts.provider,
+		Status:   opts.status,
+	}
+	if opts.project != "" {
+		abs, err := filepath.Abs(expandPath(opts.project))
+		if err != nil {
+			return fmt.Errorf("resolve project: %w", err)
+		}
+		filter.Project = abs
+	}
+	if opts.since != "" {
+		t, err := parseTimeInput(opts.since, time.Local)
+		if err != nil {
+			return err
+		}
+		filter.Since = t
+	}
+	if opts.until != "" {
+		t, err := parseTimeInput(opts.until, time.Local)
+		if err != nil {
+			return err
+		}
+		filter.Until = t
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	database, err := db.Open(cfg.ExpandedDBPath())
+	if err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	st, err := state.New(database)
+	if err != nil {
+		return fmt.Errorf("init state: %w", err)
+	}
+
+	if opts.sync {
+		syncPROutcomes(ctx, cfg, st, logging.Component("history"))
+	}
+
+	runs, err := st.TaskRuns(filter)
+	if err != nil {
+		return err
+	}
+	summary := summarizeTaskRuns(runs)
+	if opts.limit > 0 && len(runs) > opts.limit {
+		runs = runs[:opts.limit]
+	}
+
+	if opts.json {
+		if runs == nil {
+			runs = []state.TaskRunRecord{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Summary historySummary        `json:"summary"`
+			Runs    []state.TaskRunRecord `json:"runs"`
+		}{summary, runs})
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No task executions found.")
+		return nil
+	}
+	printTaskRunTable(runs)
+	fmt.Println()
+	fmt.Println(formatHistorySummary(summary))
+	return nil
+}
+
+func summarizeTaskRuns(runs []state.TaskRunRecord) historySummary {
+	s := historySummary{Total: len(runs)}
+	for _, r := range runs {
+		switch orchestrator.TaskStatus(r.Status) {
+		case orchestrator.StatusCompleted:
+			s.Completed++
+		case orchestrator.StatusAbandoned:
+			s.Abandoned++
+		default:
+			s.Failed++
+		}
+		if r.OutputType == "PR" {
+			s.PRs++
+			if r.PRState == state.PRMerged {
+				s.Merged++
+			}
+		}
+	}
+	return s
+}
+
+func formatHistorySummary(s historySummary) string {
+	line := fmt.Sprintf("%d execution(s): %d completed, %d failed, %d abandoned", s.Total, s.Completed, s.Failed, s.Abandoned)
+	if s.Total > 0 {
+		line += fmt.Sprintf(" (%.0f%% success)", float64(s.Completed)/float64(s.Total)*100)
+	}
+	if s.PRs > 0 {
+		line += fmt.Sprintf(", %d PR(s), %d merged", s.PRs, s.Merged)
+	}
+	return line
+}
+
+func printTaskRunTable(runs []state.TaskRunRecord) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "Started\tProject\tTask\tProvider\tStatus\tIter\tTokens\tDuration\tOutput")
+	for _, r := range runs {
+		tokens := "-"
+		if r.Tokens > 0 {
+			tokens = formatTokens64(r.Tokens)
+		}
+		output := r.OutputRef
+		if r.PRState != "" {
+			output += " (" + r.PRState + ")"
+		}
+		if output == "" && r.Error != "" {
+			output = truncateHistoryField(r.Error, 60)
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			r.StartedAt.Local().Format("Jan 02 15:04"), filepath.Base(r.Project), r.TaskType, r.Provider,
+			r.Status, r.Iterations, tokens, formatDuration(r.Duration), output)
+	}
+	_ = writer.Flush()
+}
+
+func truncateHistoryField(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+// recordTaskRun persists a task execution and its outcome. runErr is the
+// error RunTask returned, if any.
+func recordTaskRun(st *state.State, provider, project, taskType string, result *orchestrator.TaskResult, runErr error, log *logging.Logger) {
+	if st == nil || result == nil {
+		return
+	}
+	var tokens int64
+	for _, u := range result.Usage {
+		tokens += u.InputTokens + u.OutputTokens
+	}
+	status := string(result.Status)
+	errMsg := result.Error
+	if runErr != nil {
+		status = string(orchestrator.StatusFailed)
+		if errMsg == "" {
+			errMsg = runErr.Error()
+		}
+	}
+	_, err := st.AddTaskRun(state.TaskRunRecord{
+		Project:    project,
+		TaskType:   taskType,
+		Provider:   provider,
+		Status:     status,
+		Iterations: result.Iterations,
+		Tokens:     tokens,
+		Duration:   result.Duration,
+		OutputType: result.OutputType,
+		OutputRef:  result.OutputRef,
+		Output:     result.Output,
+		Error:      errMsg,
+	})
+	if err != nil && log != nil {
+		log.Warnf("record task run: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/orchestrator"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+func TestRecordTaskRun(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	st, err := state.New(database)
+	if err != nil {
+		t.Fatalf("state: %v", err)
+	}
+	log := logging.Component("test")
+
+	recordTaskRun(st, "claude", "/tmp/proj", "lint-fix", &orchestrator.TaskResult{
+		Status:     orchestrator.StatusCompleted,
+		Iterations: 2,
+		Duration:   5 * time.Minute,
+		OutputType: "PR",
+		OutputRef:  "https://github.com/o/r/pull/1",
+	}, nil, log)
+	recordTaskRun(st, "codex", "/tmp/proj", "lint-fix", &orchestrator.TaskResult{
+		Status:   orchestrator.StatusCompleted,
+		Duration: time.Minute,
+	}, errors.New("agent crashed"), log)
+	recordTaskRun(st, "codex", "/tmp/proj", "lint-fix", nil, nil, log)
+
+	runs, err := st.TaskRuns(state.TaskRunFilter{TaskType: "lint-fix"})
+	if err != nil {
+		t.Fatalf("TaskRuns: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+
+	summary := summarizeTaskRuns(runs)
+	want := historySummary{Total: 2, Completed: 1, Failed: 1, PRs: 1}
+	if summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+	for _, r := range runs {
+		if r.Provider == "codex" && (r.Status != "failed" || r.Error != "agent crashed") {
+			t.Errorf("run error not recorded as failure: %+v", r)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/idle"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/providers"
+	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// newIdleGate builds the gate configured by schedule.idle, or nil when idle
+// detection is disabled. Session files and commits written while nightshift
+// was running are not counted as user activity.
+func newIdleGate(cfg *config.Config, st *state.State, projects []string) *idle.Gate {
+	idleCfg := cfg.Schedule.Idle
+	if !idleCfg.Enabled {
+		return nil
+	}
+	required := durationOr(idleCfg.Duration, config.DefaultIdleDuration)
+
+	var spans []idle.Span
+	if st != nil {
+		for _, r := range st.RunsSince(time.Now().Add(-required - time.Hour)) {
+			spans = append(spans, idle.Span{Start: r.StartTime, End: r.EndTime})
+		}
+	}
+	ignore := idle.IgnoreSpans(spans)
+
+	sources := idleCfg.Sources
+	if len(sources) == 0 {
+		sources = config.IdleSources
+	}
+	var probes []idle.Probe
+	if slices.Contains(sources, "sessions") {
+		for _, p := range providers.FromConfig(cfg) {
+			if lister, ok := p.(providers.SessionFileLister); ok {
+				probes = append(probes, &idle.SessionProbe{Provider: p.Name(), List: lister.ListSessionFiles, Ignore: ignore})
+			}
+		}
+	}
+	if slices.Contains(sources, "git") && len(projects) > 0 {
+		probes = append(probes, &idle.GitProbe{Projects: projects, Ignore: ignore})
+	}
+	if idleCfg.Command != "" {
+		probes = append(probes, &idle.CommandProbe{Command: idleCfg.Command})
+	}
+	return idle.NewGate(required, probes...)
+}
+
+// awaitIdle defers a scheduled run until the user has been idle for
+// schedule.idle.duration, checking again every check_interval. It gives up
+// (returning false) when waiting would leave the schedule's window or reach
+// its next run (if it has a cadence). A nil schedule uses the top-level
+// window. The only error returned is ctx's, when cancelled while waiting.
+func awaitIdle(ctx context.Context, cfg *config.Config, st *state.State, projects []string, schedule *config.NamedSchedule, log *logging.Logger) (bool, error) {
+	if !cfg.Schedule.Idle.Enabled {
+		return true, nil
+	}
+	checkEvery := durationOr(cfg.Schedule.Idle.CheckInterval, config.DefaultIdleCheck)
+
+	window := scheduler.New()
+	windowCfg := cfg.Schedule.Window
+	var deadline time.Time
+	if schedule != nil {
+		windowCfg = schedule.Window
+		if sched, err := scheduler.NewFromConfig(schedule.ScheduleConfig()); err == nil {
+			if runs, err := sched.NextRuns(1); err == nil && len(runs) > 0 {
+				deadline = runs[0]
+			}
+		}
+	}
+	if windowCfg != nil {
+		if err := window.SetWindow(windowCfg); err != nil {
+			log.Warnf("idle: %v", err)
+		}
+	}
+
+	deferred := false
+	for {
+		status, err := newIdleGate(cfg, st, projects).Check(ctx)
+		if err != nil {
+			log.Warnf("idle: %v", err)
+		}
+		if status.Idle {
+			if deferred {
+				log.Infof("user idle (%s); starting deferred run", status)
+			}
+			return true, nil
+		}
+
+		wait := min(status.Wait+time.Second, checkEvery)
+		next := time.Now().Add(wait)
+		if !window.IsInWindow(next) || (!deadline.IsZero() && !next.Before(deadline)) {
+			log.Infof("user still active (%s); skipping run", status)
+			return false, nil
+		}
+		log.Infof("user active (%s); deferring run, checking again in %s", status, wait.Round(time.Second))
+		deferred = true
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+)
+
+func TestAwaitIdle_DisabledRunsImmediately(t *testing.T) {
+	cfg := &config.Config{}
+	if gate := newIdleGate(cfg, nil, nil); gate != nil {
+		t.Fatal("expected no gate when schedule.idle is disabled")
+	}
+	ok, err := awaitIdle(context.Background(), cfg, nil, nil, nil, logging.Component("test"))
+	if !ok || err != nil {
+		t.Errorf("awaitIdle() = %v, %v; want true, nil", ok, err)
+	}
+}
+
+func TestAwaitIdle_UsesCommandProbe(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := &config.Config{Schedule: config.ScheduleConfig{Idle: config.IdleConfig{
+		Enabled:  true,
+		Duration: "30m",
+		Sources:  []string{"sessions"},
+		Command:  "echo 3600000", // idle for an hour
+	}}}
+	ok, err := awaitIdle(context.Background(), cfg, nil, nil, nil, logging.Component("test"))
+	if !ok || err != nil {
+		t.Errorf("awaitIdle() = %v, %v; want true, nil", ok, err)
+	}
+
+	// Active a second ago and cancelled while deferring
+	cfg.Schedule.Idle.Command = "echo 1000"
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ok, err = awaitIdle(ctx, cfg, nil, nil, nil, logging.Component("test"))
+	if ok || err == nil {
+		t.Errorf("awaitIdle() = %v, %v; want false with context error", ok, err)
+	}
+}
@@ -0,0 +1,289 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/orchestrator"
+	"github.com/marcus/nightshift/internal/providers"
+	"github.com/marcus/nightshift/internal/snapshots"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+var budgetLedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Reconcile nightshift's usage with provider-wide usage",
+	Long: `Show the spend ledger: one row per agent invocation with the tokens
+the agent reported, tagged by phase, task and project.
+
+The ledger total for the window is compared with the change in each
+provider's local token count between the snapshots bracketing it. Usage
+the ledger doesn't explain (your own sessions, or agent calls that
+reported nothing) is flagged as untracked. The share line is nightshift's
+part of the last 7 days of usage.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider, _ := cmd.Flags().GetString("provider")
+		since, _ := cmd.Flags().GetDuration("since")
+		n, _ := cmd.Flags().GetInt("n")
+		return runBudgetLedger(provider, since, n)
+	},
+}
+
+func init() {
+	budgetLedgerCmd.Flags().StringP("provider", "p", "", "Provider to reconcile (claude, codex, gemini)")
+	budgetLedgerCmd.Flags().Duration("since", 24*time.Hour, "Reconciliation window ending now")
+	budgetLedgerCmd.Flags().IntP("n", "n", 20, "Number of ledger entries to show")
+	budgetCmd.AddCommand(budgetLedgerCmd)
+}
+
+func runBudgetLedger(filterProvider string, since time.Duration, n int) error {
+	if since <= 0 {
+		return fmt.Errorf("since must be positive")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	database, err := db.Open(cfg.ExpandedDBPath())
+	if err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	st, err := state.New(database)
+	if err != nil {
+		return fmt.Errorf("init state: %w", err)
+	}
+
+	providerList, err := resolveProviderList(cfg, filterProvider)
+	if err != nil {
+		return err
+	}
+	if len(providerList) == 0 {
+		fmt.Println("No providers enabled.")
+		return nil
+	}
+
+	now := time.Now()
+	collector := snapshots.NewCollector(database, usageSources(enabledProviders(cfg)), nil, weekStartDayFromConfig(cfg))
+	for _, provider := range providerList {
+		r, err := collector.Reconcile(st, provider, now.Add(-since), now)
+		if err != nil {
+			fmt.Printf("%s: error: %v\n\n", provider, err)
+			continue
+		}
+		fmt.Printf("[%s]\n", provider)
+		printReconciliation(r)
+		fmt.Println()
+	}
+
+	if filterProvider != "" {
+		filterProvider = providerList[0] // normalized by resolveProviderList
+	}
+	entries, err := st.LedgerSince(filterProvider, now.Add(-since), n)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No ledger entries in this window.")
+		return nil
+	}
+	printLedgerTable(entries)
+	return nil
+}
+
+func printReconciliation(r snapshots.Reconciliation) {
+	fmt.Printf("  Ledger:       %s tokens", formatTokens64(r.LedgerTokens))
+	if r.Unmeasured > 0 {
+		fmt.Printf(" (+%d calls without usage)", r.Unmeasured)
+	}
+	fmt.Println()
+	if !r.HasSnapshots {
+		fmt.Printf("  Snapshots:    none bracket this window\n")
+	} else {
+		fmt.Printf("  Snapshots:    %s tokens\n", formatTokens64(r.SnapshotDelta))
+		untracked := r.Untracked
+		if untracked < 0 {
+			untracked = 0
+		}
+		line := fmt.Sprintf("  Untracked:    %s tokens", formatTokens64(untracked))
+		if r.Flagged() {
+			line += "  [outside nightshift]"
+		}
+		fmt.Println(line)
+	}
+	if r.WeeklyTokens > 0 {
+		fmt.Printf("  Weekly share: %.1f%% (%s of %s tokens)\n",
+			r.Share()*100, formatTokens64(r.WeeklyLedger), formatTokens64(r.WeeklyTokens))
+	}
+}
+
+func printLedgerTable(entries []state.LedgerEntry) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "Time\tProvider\tModel\tPhase\tTask\tTokens")
+	for _, e := range entries {
+		model := e.Model
+		if model == "" {
+			model = "-"
+		}
+		phase := e.Phase
+		if e.Iteration > 1 {
+			phase = fmt.Sprintf("%s #%d", phase, e.Iteration)
+		}
+		tokens := formatTokens64(e.Tokens())
+		if countsCacheTokens(e.Provider) {
+			tokens = formatTokens64(e.TokensWithCache())
+		}
+		if !e.Measured {
+			tokens = "unmeasured"
+		}
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Format("Jan 02 15:04"), e.Provider, model, phase, e.TaskType, tokens)
+	}
+	_ = writer.Flush()
+}
+
+// countsCacheTokens reports whether the provider's local usage, and so its
+// reconciliation, includes cache tokens.
+func countsCacheTokens(provider string) bool {
+	p, err := providers.New(provider, "")
+	if err != nil {
+		return false
+	}
+	c, ok := p.(providers.CacheTokenCounter)
+	return ok && c.CountsCacheTokens()
+}
+
+// recordLedger prices the usage of each agent invocation in a task and
+// persists one ledger row per model. Invocations that reported no usage get
+// a single unmeasured row so they still show up in reconciliation. When no
+// invocation reported usage, the task's token estimate is priced instead
+// and the first row is marked estimated. Returns the cost in USD.
+func recordLedger(st *state.State, prices *providers.PriceTable, provider, project, taskID, taskType string, invocations []orchestrator.Invocation, estimatedTokens int, log *logging.Logger) float64 {
+	if st == nil || prices == nil || len(invocations) == 0 {
+		return 0
+	}
+
+	var entries []state.LedgerEntry
+	var total float64
+	measured := false
+	for _, inv := range invocations {
+		base := state.LedgerEntry{
+			Time:      inv.Start,
+			Provider:  provider,
+			Phase:     string(inv.Phase),
+			Iteration: inv.Iteration,
+			TaskID:    taskID,
+			TaskType:  taskType,
+			Project:   project,
+			SessionID: inv.SessionID,
+			Duration:  inv.Duration,
+		}
+		if len(inv.Usage) == 0 {
+			entries = append(entries, base)
+			continue
+		}
+		for _, u := range inv.Usage {
+			e := base
+			e.Model = u.Model
+			e.InputTokens = u.InputTokens
+			e.OutputTokens = u.OutputTokens
+			e.CacheReadTokens = u.CacheReadTokens
+			e.CacheWriteTokens = u.CacheWriteTokens
+			e.Measured = true
+			e.CostUSD = prices.Cost(provider, []agents.TokenUsage{u})
+			total += e.CostUSD
+			measured = true
+			entries = append(entries, e)
+		}
+	}
+	if !measured && estimatedTokens > 0 {
+		total = prices.Estimate(provider, int64(estimatedTokens))
+		entries[0].CostUSD = total
+		entries[0].Estimated = true
+	}
+
+	if err := st.RecordLedger(entries...); err != nil && log != nil {
+		log.Warnf("record ledger: %v", err)
+	}
+	return total
+}
+
+// runLedger brackets a run with local-only usage snapshots so the ledger can
+// be reconciled against provider-wide consumption afterwards.
+type runLedger struct {
+	collector *snapshots.Collector
+	st        *state.State
+	providers []string
+	start     time.Time
+}
+
+func newRunLedger(database *db.DB, st *state.State, providerSet []providers.Provider, weekStart time.Weekday) *runLedger {
+	names := make([]string, 0, len(providerSet))
+	for _, p := range providerSet {
+		names = append(names, p.Name())
+	}
+	return &runLedger{
+		collector: snapshots.NewCollector(database, usageSources(providerSet), nil, weekStart),
+		st:        st,
+		providers: names,
+	}
+}
+
+// begin records the run start and snapshots local usage.
+func (l *runLedger) begin(ctx context.Context, log *logging.Logger) {
+	if l == nil {
+		return
+	}
+	l.start = time.Now()
+	l.snapshot(ctx, log)
+}
+
+// reconcile snapshots local usage again and compares each provider's ledger
+// total for the run with the snapshot delta. Untracked consumption is logged
+// as a warning.
+func (l *runLedger) reconcile(ctx context.Context, log *logging.Logger) []snapshots.Reconciliation {
+	if l == nil || l.start.IsZero() {
+		return nil
+	}
+	l.snapshot(ctx, log)
+	end := time.Now()
+
+	var results []snapshots.Reconciliation
+	for _, name := range l.providers {
+		r, err := l.collector.Reconcile(l.st, name, l.start, end)
+		if err != nil {
+			log.Warnf("reconcile %s: %v", name, err)
+			continue
+		}
+		if r.LedgerTokens == 0 && r.SnapshotDelta == 0 && r.Unmeasured == 0 {
+			continue
+		}
+		if r.Flagged() {
+			log.Warnf("ledger: %s", r)
+		} else {
+			log.Infof("ledger: %s", r)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func (l *runLedger) snapshot(ctx context.Context, log *logging.Logger) {
+	for _, name := range l.providers {
+		if _, err := l.collector.TakeSnapshot(ctx, name); err != nil {
+			log.Warnf("ledger snapshot %s: %v", name, err)
+		}
+	}
+}
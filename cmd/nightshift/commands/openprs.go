@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/orchestrator"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// openPRLookupTimeout bounds the gh call listing a project's open PRs.
+const openPRLookupTimeout = 30 * time.Second
+
+// openPRChecksEnabled reports whether any task's open PR policy is not
+// allow, i.e. whether open PRs need to be looked up at all.
+func openPRChecksEnabled(cfg *config.Config) bool {
+	if cfg.OpenPRPolicy("") != config.OpenPRAllow {
+		return true
+	}
+	for _, policy := range cfg.Tasks.OpenPRs {
+		if policy != config.OpenPRAllow {
+			return true
+		}
+	}
+	return false
+}
+
+// findOpenPRs looks up projectPath's open nightshift PRs by task type and
+// tells selector about them, so tasks with the skip policy are not picked.
+// Lookup failures are logged and treated as no open PRs.
+func findOpenPRs(ctx context.Context, cfg *config.Config, selector *tasks.Selector, projectPath string, log *logging.Logger) map[string]orchestrator.OpenPR {
+	if !openPRChecksEnabled(cfg) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, openPRLookupTimeout)
+	defer cancel()
+
+	open, err := orchestrator.FindOpenPRs(ctx, projectPath)
+	if err != nil {
+		log.Debugf("open PRs %s: %v", projectPath, err)
+		return nil
+	}
+	types := make([]tasks.TaskType, 0, len(open))
+	for taskType, pr := range open {
+		types = append(types, tasks.TaskType(taskType))
+		log.Infof("open PR for %s in %s: %s", taskType, projectPath, pr.URL)
+	}
+	selector.SetOpenPRs(projectPath, types)
+	return open
+}
+
+// existingPRFor returns the open PR taskType should update instead of
+// opening a new one, or nil.
+func existingPRFor(cfg *config.Config, open map[string]orchestrator.OpenPR, taskType tasks.TaskType) *orchestrator.OpenPR {
+	pr, ok := open[string(taskType)]
+	if !ok || cfg.OpenPRPolicy(string(taskType)) != config.OpenPRUpdate {
+		return nil
+	}
+	return &pr
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/outcomes"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// syncPROutcomes polls the PRs nightshift opened and records whether they
+// were merged, closed or left stale.
+func syncPROutcomes(ctx context.Context, cfg *config.Config, st *state.State, log *logging.Logger) {
+	staleAfter, _ := time.ParseDuration(cfg.Integrations.PROutcomes.StaleAfter)
+	syncer := &outcomes.Syncer{State: st, StaleAfter: staleAfter}
+	res, err := syncer.Sync(ctx)
+	switch {
+	case errors.Is(err, outcomes.ErrNoGH):
+		log.Debugf("pr outcomes: %v", err)
+	case err != nil:
+		log.Warnf("pr outcomes: %v", err)
+	case res.Checked > 0 || res.Errors > 0:
+		log.Infof("pr outcomes: checked %d PRs, %d newly resolved, %d unreachable", res.Checked, res.Resolved, res.Errors)
+	}
+}
+
+// startPROutcomeLoop syncs PR outcomes at startup and every sync interval.
+func startPROutcomeLoop(ctx context.Context, cfg *config.Config, database *db.DB, log *logging.Logger) {
+	pr := cfg.Integrations.PROutcomes
+	if !pr.Enabled {
+		return
+	}
+	interval, err := time.ParseDuration(pr.SyncInterval)
+	if err != nil || interval <= 0 {
+		if err != nil {
+			log.Warnf("invalid pr outcome sync interval %q: %v", pr.SyncInterval, err)
+		}
+		return
+	}
+	st, err := state.New(database)
+	if err != nil {
+		log.Warnf("pr outcomes: %v", err)
+		return
+	}
+
+	go func() {
+		syncPROutcomes(ctx, cfg, st, log)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				syncPROutcomes(ctx, cfg, st, log)
+			}
+		}
+	}()
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/state"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// sandboxPreCommands makes selector run pre-commands in the sandbox agents
+// run in when sandbox.enabled is set.
+func sandboxPreCommands(cfg *config.Config, selector *tasks.Selector, projects []string) {
+	if r := sandboxRunnerFromConfig(cfg, projects); r != nil {
+		selector.SetPreCommandRunner(r)
+	}
+}
+
+// runPreAnalysis runs a claimed task's pre-commands in project. It returns
+// their output as evidence for the plan prompt, or a skip reason when they
+// report nothing to do. A skipped task is released and recorded as run, so
+// it waits out its interval like a completed one.
+func runPreAnalysis(ctx context.Context, st *state.State, selector *tasks.Selector, def tasks.TaskDefinition, taskID, project string, log *logging.Logger) (evidence, skipReason string) {
+	if len(def.PreCommands) == 0 {
+		return "", ""
+	}
+	analysis := selector.RunPreCommands(ctx, def, project)
+	var ran []string
+	for _, r := range analysis.Results {
+		switch {
+		case r.Unavailable():
+			log.Debugf("pre-command %s for %s: not available", r.Command.Label(), def.Type)
+			continue
+		case r.Err != nil:
+			log.Warnf("pre-command %s for %s: %v", r.Command.Label(), def.Type, r.Err)
+		default:
+			log.Debugf("pre-command %s for %s: exit %d in %s", r.Command.Label(), def.Type, r.ExitCode, r.Duration.Round(time.Millisecond))
+		}
+		ran = append(ran, r.Command.Label())
+	}
+	if analysis.NothingToDo() {
+		st.ClearAssigned(taskID)
+		st.RecordTaskRun(project, string(def.Type))
+		return "", "nothing to do per " + strings.Join(ran, ", ")
+	}
+	return analysis.Evidence(), ""
+}
@@ -9,13 +9,14 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/marcus/nightshift/internal/blackout"
 	"github.com/marcus/nightshift/internal/budget"
 	"github.com/marcus/nightshift/internal/calibrator"
 	"github.com/marcus/nightshift/internal/config"
 	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/logging"
 	"github.com/marcus/nightshift/internal/orchestrator"
 	"github.com/marcus/nightshift/internal/providers"
-	"github.com/marcus/nightshift/internal/scheduler"
 	"github.com/marcus/nightshift/internal/state"
 	"github.com/marcus/nightshift/internal/tasks"
 	"github.com/marcus/nightshift/internal/trends"
@@ -23,6 +24,9 @@ import (
 
 const defaultPromptPreviewChars = 400
 
+// previewBlackoutHorizon is how far ahead preview lists blackouts.
+const previewBlackoutHorizon = 30 * 24 * time.Hour
+
 var previewCmd = &cobra.Command{
 	Use:   "preview",
 	Short: "Preview the next scheduled runs",
@@ -68,12 +72,6 @@ func runPreview(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("load config: %w", err)
 	}
 
-	// Register custom tasks from config
-	tasks.ClearCustom()
-	if err := tasks.RegisterCustomTasksFromConfig(cfg.Tasks.Custom); err != nil {
-		return fmt.Errorf("register custom tasks: %w", err)
-	}
-
 	database, err := db.Open(cfg.ExpandedDBPath())
 	if err != nil {
 		return fmt.Errorf("open db: %w", err)
@@ -85,6 +83,11 @@ func runPreview(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resolve projects: %w", err)
 	}
 
+	// Register custom tasks from config and task files
+	if err := registerCustomTasks(cfg, projects); err != nil {
+		return err
+	}
+
 	result, err := buildPreviewResult(cfg, database, projects, taskFilter, runs, writeDir, sources, explain || jsonOutput)
 	if err != nil {
 		return err
@@ -148,6 +151,8 @@ type previewResult struct {
 	ReservePercent int
 	EnabledTasks   []string
 	ProjectCount   int
+	Schedules      []config.NamedSchedule
+	Blackouts      []blackout.Period // upcoming, within previewBlackoutHorizon
 	Runs           []previewRun
 	Providers      []providerBudgetSummary
 	ConfigSources  *previewConfigSources
@@ -157,6 +162,8 @@ type previewResult struct {
 type previewRun struct {
 	Index    int
 	RunAt    time.Time
+	Schedule string
+	Blackout *blackout.Period // active at RunAt; skip runs have no projects
 	Projects []previewProject
 }
 
@@ -175,6 +182,8 @@ type previewProject struct {
 	Status      previewProjectStatus
 	Detail      string
 	Budget      *budget.AllowanceResult
+	Priority    int
+	Allocation  int64 // this project's share of the allowance; 0 with a task filter
 	Tasks       []previewTask
 	Diagnostics *previewDiagnostics
 }
@@ -188,32 +197,36 @@ type previewTask struct {
 	CostTier        string
 	MinTokens       int
 	MaxTokens       int
+	P90Duration     time.Duration // predicted from history; 0 when unknown
 	Prompt          string
 	PromptFile      string
 	PromptFileError string
 }
 
 type previewDiagnostics struct {
-	FilteredTask *previewFilteredTaskDiagnostic `json:"filtered_task,omitempty"`
-	Aggregate    *previewAggregateDiagnostic    `json:"aggregate,omitempty"`
-	Cooldowns    []previewCooldownEntry         `json:"cooldowns,omitempty"`
+	FilteredTask  *previewFilteredTaskDiagnostic `json:"filtered_task,omitempty"`
+	Aggregate     *previewAggregateDiagnostic    `json:"aggregate,omitempty"`
+	Cooldowns     []previewCooldownEntry         `json:"cooldowns,omitempty"`
+	NotApplicable []previewNotApplicableEntry    `json:"not_applicable,omitempty"`
 }
 
 type previewFilteredTaskDiagnostic struct {
-	Type         string `json:"type"`
-	Name         string `json:"name,omitempty"`
-	CostTier     string `json:"cost_tier,omitempty"`
-	MinTokens    int    `json:"min_tokens,omitempty"`
-	MaxTokens    int    `json:"max_tokens,omitempty"`
-	Budget       int64  `json:"budget,omitempty"`
-	BudgetTooLow bool   `json:"budget_too_low,omitempty"`
-	Disabled     bool   `json:"disabled,omitempty"`
-	Error        string `json:"error,omitempty"`
+	Type          string `json:"type"`
+	Name          string `json:"name,omitempty"`
+	CostTier      string `json:"cost_tier,omitempty"`
+	MinTokens     int    `json:"min_tokens,omitempty"`
+	MaxTokens     int    `json:"max_tokens,omitempty"`
+	Budget        int64  `json:"budget,omitempty"`
+	BudgetTooLow  bool   `json:"budget_too_low,omitempty"`
+	Disabled      bool   `json:"disabled,omitempty"`
+	NotApplicable string `json:"not_applicable,omitempty"`
+	Error         string `json:"error,omitempty"`
 }
 
 type previewAggregateDiagnostic struct {
 	Enabled        int      `json:"enabled"`
 	Disabled       int      `json:"disabled"`
+	NotApplicable  int      `json:"not_applicable"`
 	OverBudget     int      `json:"over_budget"`
 	Assigned       int      `json:"assigned"`
 	OnCooldown     int      `json:"on_cooldown"`
@@ -231,6 +244,12 @@ type previewCooldownEntry struct {
 	Simulated     bool   `json:"simulated,omitempty"`
 }
 
+type previewNotApplicableEntry struct {
+	TaskType string `json:"task_type"`
+	TaskName string `json:"task_name"`
+	Reason   string `json:"reason"`
+}
+
 func buildPreviewResult(cfg *config.Config, database *db.DB, projects []string, taskFilter string, runs int, writeDir string, sources *previewConfigSources, includeDiagnostics bool) (*previewResult, error) {
 	if runs <= 0 {
 		return nil, fmt.Errorf("runs must be positive")
@@ -244,24 +263,30 @@ func buildPreviewResult(cfg *config.Config, database *db.DB, projects []string,
 		return nil, fmt.Errorf("init state: %w", err)
 	}
 
-	sched, err := scheduler.NewFromConfig(&cfg.Schedule)
+	scheds, err := buildSchedulers(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("schedule config: %w", err)
 	}
 
-	nextRuns, err := sched.NextRuns(runs)
+	nextRuns, err := nextScheduledRuns(scheds, runs)
 	if err != nil {
 		return nil, fmt.Errorf("compute next runs: %w", err)
 	}
 
-	claudeProvider := providers.NewClaudeWithPath(cfg.ExpandedProviderPath("claude"))
-	codexProvider := providers.NewCodexWithPath(cfg.ExpandedProviderPath("codex"))
-	geminiProvider := providers.NewGeminiWithPath(cfg.ExpandedProviderPath("gemini"))
+	blackouts, err := loadBlackouts(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("blackout: %w", err)
+	}
+
+	providerSet := providers.FromConfig(cfg)
+	providers.UseLedger(providerSet, st)
 	cal := calibrator.New(database, cfg)
 	trend := trends.NewAnalyzer(database, cfg.Budget.SnapshotRetentionDays)
-	budgetMgr := budget.NewManagerFromProviders(cfg, claudeProvider, codexProvider, geminiProvider, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
+	budgetMgr := budget.NewManagerFromProviders(cfg, providerSet, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
+	scheduleBudgets := map[string]*budget.Manager{}
 
 	selector := tasks.NewSelector(cfg, st)
+	durations := predictedDurations(st, logging.Component("preview"))
 	orch := orchestrator.New()
 
 	if writeDir != "" {
@@ -297,24 +322,56 @@ func buildPreviewResult(cfg *config.Config, database *db.DB, projects []string,
 		ReservePercent: reservePercent,
 		EnabledTasks:   append([]string(nil), cfg.Tasks.Enabled...),
 		ProjectCount:   len(projects),
+		Schedules:      cfg.Schedule.Entries(),
 		Providers:      collectProviderBudgets(cfg, budgetMgr),
 		ConfigSources:  sources,
 		Note:           "Only the plan prompt is deterministic. Implement/review prompts are generated after plan output.",
 	}
+	horizon := result.GeneratedAt.Add(previewBlackoutHorizon)
+	if len(nextRuns) > 0 && nextRuns[len(nextRuns)-1].at.After(horizon) {
+		horizon = nextRuns[len(nextRuns)-1].at
+	}
+	result.Blackouts = blackouts.Upcoming(result.GeneratedAt, horizon)
+
+	for i, next := range nextRuns {
+		run := previewRun{Index: i + 1, RunAt: next.at, Schedule: next.schedule.Name}
+		runCfg := next.schedule.Apply(cfg)
+		filter, err := scheduleTaskFilter(next.schedule)
+		if err != nil {
+			return nil, err
+		}
+		if active := blackouts.Active(next.at); active != nil {
+			run.Blackout = active
+			if active.Mode == config.BlackoutSkip {
+				result.Runs = append(result.Runs, run)
+				continue
+			}
+			filter = filter.AnalysisOnly()
+		}
+		selector.SetFilter(filter)
+		runAt := next.at
+		selector.SetNowFunc(func() time.Time { return runAt })
+		selector.SetDeadline(windowDeadline(cfg, &next.schedule, next.at), durations)
+		runBudget, ok := scheduleBudgets[next.schedule.Name]
+		if !ok {
+			runBudget = budget.NewManagerFromProviders(runCfg, providerSet, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
+			scheduleBudgets[next.schedule.Name] = runBudget
+		}
 
-	for i, runAt := range nextRuns {
-		run := previewRun{Index: i + 1, RunAt: runAt}
-		for _, project := range projects {
-			projectResult := previewProject{Path: project}
+		budgets := newProjectBudgets(runCfg, scheduleProjects(next.schedule, projects))
+		for _, project := range budgets.ordered() {
+			projectResult := previewProject{Path: project, Priority: budgets.project(project).Priority}
 
-			if taskFilter == "" && st.WasProcessedToday(project) {
+			// Named schedules revisit projects the same day, like the daemon.
+			if taskFilter == "" && next.schedule.Name == config.DefaultScheduleName && st.WasProcessedToday(project) {
 				projectResult.Status = previewProjectSkipped
 				projectResult.Detail = "already processed today"
+				budgets.done(project)
 				run.Projects = append(run.Projects, projectResult)
 				continue
 			}
 
-			allowance, err := budgetMgr.CalculateAllowance(provider)
+			allowance, err := runBudget.CalculateAllowance(provider)
 			if err != nil {
 				projectResult.Status = previewProjectError
 				projectResult.Detail = fmt.Sprintf("budget error: %v", err)
@@ -323,22 +380,33 @@ func buildPreviewResult(cfg *config.Config, database *db.DB, projects []string,
 			}
 
 			projectResult.Budget = allowance
+			taskBudget := allowance.Allowance
+			if taskFilter == "" {
+				alloc := budgets.allocator(&providerChoice{name: provider, allowance: allowance})
+				taskBudget = alloc.Open(project)
+				projectResult.Allocation = taskBudget
+			}
 			if allowance.Allowance <= 0 {
 				projectResult.Status = previewProjectBudgetExhausted
 				projectResult.Detail = "budget exhausted"
 				if includeDiagnostics {
 					projectResult.Diagnostics = computePreviewDiagnostics(cfg, selector, project, taskFilter, allowance.Allowance)
 				}
+				budgets.done(project)
 				run.Projects = append(run.Projects, projectResult)
 				continue
 			}
 
-			selected, err := previewSelectTasks(selector, project, taskFilter, allowance.Allowance)
+			selected, err := previewSelectTasks(selector, project, taskFilter, taskBudget)
+			if a, ok := budgets.byProvider[provider]; ok && err == nil {
+				a.Spend(project, estimatedSpend(selected, taskBudget))
+			}
+			budgets.done(project)
 			if err != nil {
 				projectResult.Status = previewProjectError
 				projectResult.Detail = err.Error()
 				if includeDiagnostics {
-					projectResult.Diagnostics = computePreviewDiagnostics(cfg, selector, project, taskFilter, allowance.Allowance)
+					projectResult.Diagnostics = computePreviewDiagnostics(cfg, selector, project, taskFilter, taskBudget)
 				}
 				run.Projects = append(run.Projects, projectResult)
 				continue
@@ -346,8 +414,12 @@ func buildPreviewResult(cfg *config.Config, database *db.DB, projects []string,
 			if len(selected) == 0 {
 				projectResult.Status = previewProjectNoTasks
 				projectResult.Detail = "no tasks available within budget"
+				if taskFilter == "" && taskBudget < allowance.Allowance &&
+					len(selector.FilterByBudget(selector.FilterEnabled(tasks.ProjectDefinitions(project)), taskBudget)) == 0 {
+					projectResult.Detail = fmt.Sprintf("no tasks fit project allocation (%s tokens)", formatTokens64(taskBudget))
+				}
 				if includeDiagnostics {
-					projectResult.Diagnostics = computePreviewDiagnostics(cfg, selector, project, taskFilter, allowance.Allowance)
+					projectResult.Diagnostics = computePreviewDiagnostics(cfg, selector, project, taskFilter, taskBudget)
 				}
 				run.Projects = append(run.Projects, projectResult)
 				continue
@@ -355,7 +427,7 @@ func buildPreviewResult(cfg *config.Config, database *db.DB, projects []string,
 
 			projectResult.Status = previewProjectReady
 			if includeDiagnostics {
-				projectResult.Diagnostics = computePreviewDiagnostics(cfg, selector, project, taskFilter, allowance.Allowance)
+				projectResult.Diagnostics = computePreviewDiagnostics(cfg, selector, project, taskFilter, taskBudget)
 			}
 			projectResult.Tasks = make([]previewTask, 0, len(selected))
 			for idx, scored := range selected {
@@ -380,6 +452,7 @@ func buildPreviewResult(cfg *config.Config, database *db.DB, projects []string,
 					MaxTokens:   maxTokens,
 					Prompt:      prompt,
 				}
+				taskPreview.P90Duration, _ = selector.PredictedDuration(scored.Definition.Type)
 
 				if writeDir != "" {
 					filename := fmt.Sprintf("run-%02d-%s-%s-plan.txt", i+1, sanitizeFileName(filepath.Base(project)), scored.Definition.Type)
@@ -416,26 +489,13 @@ type providerBudgetSummary struct {
 
 func collectProviderBudgets(cfg *config.Config, budgetMgr *budget.Manager) []providerBudgetSummary {
 	var summaries []providerBudgetSummary
-	if cfg.Providers.Claude.Enabled {
-		allowance, err := budgetMgr.CalculateAllowance("claude")
-		summaries = append(summaries, providerBudgetSummary{
-			name:      "claude",
-			allowance: allowance,
-			err:       err,
-		})
-	}
-	if cfg.Providers.Codex.Enabled {
-		allowance, err := budgetMgr.CalculateAllowance("codex")
-		summaries = append(summaries, providerBudgetSummary{
-			name:      "codex",
-			allowance: allowance,
-			err:       err,
-		})
-	}
-	if cfg.Providers.Gemini.Enabled {
-		allowance, err := budgetMgr.CalculateAllowance("gemini")
+	for _, name := range providers.Names() {
+		if !cfg.ProviderSettings(name).Enabled {
+			continue
+		}
+		allowance, err := budgetMgr.CalculateAllowance(name)
 		summaries = append(summaries, providerBudgetSummary{
-			name:      "gemini",
+			name:      name,
 			allowance: allowance,
 			err:       err,
 		})
@@ -446,7 +506,7 @@ func collectProviderBudgets(cfg *config.Config, budgetMgr *budget.Manager) []pro
 func computePreviewDiagnostics(cfg *config.Config, selector *tasks.Selector, project, taskFilter string, allowance int64) *previewDiagnostics {
 	diagnostics := &previewDiagnostics{}
 	if taskFilter != "" {
-		def, err := tasks.GetDefinition(tasks.TaskType(taskFilter))
+		def, err := tasks.ProjectDefinition(project, tasks.TaskType(taskFilter))
 		if err != nil {
 			diagnostics.FilteredTask = &previewFilteredTaskDiagnostic{
 				Type:  taskFilter,
@@ -465,6 +525,9 @@ func computePreviewDiagnostics(cfg *config.Config, selector *tasks.Selector, pro
 			BudgetTooLow: int64(maxTok) > allowance,
 			Disabled:     !cfg.IsTaskEnabled(string(def.Type)),
 		}
+		if ok, reason := selector.Applicability(def, project); !ok {
+			diagnostics.FilteredTask.NotApplicable = reason
+		}
 		// Check cooldown for the filtered task
 		onCooldown, remaining, interval := selector.IsOnCooldown(tasks.TaskType(taskFilter), project)
 		simulated := selector.HasSimulatedCooldown(taskFilter, project)
@@ -485,7 +548,7 @@ func computePreviewDiagnostics(cfg *config.Config, selector *tasks.Selector, pro
 		return diagnostics
 	}
 
-	defs := tasks.AllDefinitions()
+	defs := tasks.ProjectDefinitions(project)
 	known := make(map[string]bool, len(defs))
 	for _, def := range defs {
 		known[string(def.Type)] = true
@@ -493,6 +556,7 @@ func computePreviewDiagnostics(cfg *config.Config, selector *tasks.Selector, pro
 
 	enabledCount := 0
 	disabledCount := 0
+	notApplicableCount := 0
 	overBudgetCount := 0
 	assignedCount := 0
 	cooldownCount := 0
@@ -503,6 +567,15 @@ func computePreviewDiagnostics(cfg *config.Config, selector *tasks.Selector, pro
 			continue
 		}
 		enabledCount++
+		if ok, reason := selector.Applicability(def, project); !ok {
+			notApplicableCount++
+			diagnostics.NotApplicable = append(diagnostics.NotApplicable, previewNotApplicableEntry{
+				TaskType: string(def.Type),
+				TaskName: def.Name,
+				Reason:   reason,
+			})
+			continue
+		}
 		_, maxTok := def.EstimatedTokens()
 		if int64(maxTok) > allowance {
 			overBudgetCount++
@@ -547,6 +620,7 @@ func computePreviewDiagnostics(cfg *config.Config, selector *tasks.Selector, pro
 	diagnostics.Aggregate = &previewAggregateDiagnostic{
 		Enabled:        enabledCount,
 		Disabled:       disabledCount,
+		NotApplicable:  notApplicableCount,
 		OverBudget:     overBudgetCount,
 		Assigned:       assignedCount,
 		OnCooldown:     cooldownCount,
@@ -560,21 +634,17 @@ func computePreviewDiagnostics(cfg *config.Config, selector *tasks.Selector, pro
 }
 
 func previewProvider(cfg *config.Config) (string, error) {
-	if cfg.Providers.Claude.Enabled {
-		return "claude", nil
-	}
-	if cfg.Providers.Codex.Enabled {
-		return "codex", nil
-	}
-	if cfg.Providers.Gemini.Enabled {
-		return "gemini", nil
+	for _, name := range providers.Names() {
+		if cfg.ProviderSettings(name).Enabled {
+			return name, nil
+		}
 	}
 	return "", fmt.Errorf("no providers enabled for preview")
 }
 
 func previewSelectTasks(selector *tasks.Selector, projectPath, taskFilter string, allowance int64) ([]tasks.ScoredTask, error) {
 	if taskFilter != "" {
-		def, err := tasks.GetDefinition(tasks.TaskType(taskFilter))
+		def, err := tasks.ProjectDefinition(projectPath, tasks.TaskType(taskFilter))
 		if err != nil {
 			return nil, fmt.Errorf("unknown task type: %s", taskFilter)
 		}
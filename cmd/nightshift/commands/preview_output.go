@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/marcus/nightshift/internal/blackout"
 	"github.com/marcus/nightshift/internal/budget"
+	"github.com/marcus/nightshift/internal/config"
 )
 
 type previewTextOptions struct {
@@ -90,14 +92,38 @@ func renderPreviewText(result *previewResult, opts previewTextOptions) string {
 	} else {
 		fmt.Fprintf(b, "  Task filter: enabled list (%d) [%s]\n", len(result.EnabledTasks), strings.Join(result.EnabledTasks, ", "))
 	}
-	if opts.Explain && result.ProjectCount > 1 {
-		b.WriteString("  Note: budget is not split per project during preview/run\n")
+	if len(result.Schedules) > 1 {
+		b.WriteString("  Schedules:\n")
+		for _, s := range result.Schedules {
+			fmt.Fprintf(b, "    - %s: %s\n", s.Name, describeSchedule(s))
+		}
+	}
+	if len(result.Blackouts) > 0 {
+		b.WriteString("  Blackouts:\n")
+		for _, p := range result.Blackouts {
+			fmt.Fprintf(b, "    - %s\n", p)
+		}
+	}
+	if opts.Explain && result.ProjectCount > 1 && result.TaskFilter == "" {
+		b.WriteString("  Project split: by priority; unspent allocation rolls over to lower-priority projects\n")
 	}
 
 	for _, run := range result.Runs {
 		b.WriteString("\n")
-		b.WriteString(styles.Section.Render(fmt.Sprintf("Run %d · %s", run.Index, run.RunAt.Format("2006-01-02 15:04"))))
+		header := fmt.Sprintf("Run %d · %s", run.Index, run.RunAt.Format("2006-01-02 15:04"))
+		if len(result.Schedules) > 1 {
+			header += " · " + run.Schedule
+		}
+		b.WriteString(styles.Section.Render(header))
 		b.WriteString("\n")
+		if run.Blackout != nil {
+			b.WriteString("  ")
+			b.WriteString(styles.Warn.Render(blackoutRunDetail(run.Blackout)))
+			b.WriteString("\n")
+		}
+		if opts.Explain && len(run.Projects) > 1 && result.TaskFilter == "" {
+			renderProjectSplitText(b, styles, run.Projects)
+		}
 
 		for _, project := range run.Projects {
 			b.WriteString(styles.Label.Render("  " + project.Path))
@@ -140,7 +166,11 @@ func renderPreviewText(result *previewResult, opts previewTextOptions) string {
 				b.WriteString(styles.Accent.Render(fmt.Sprintf("%d. %s", task.Index, task.Name)))
 				fmt.Fprintf(b, " (%s)\n", task.Type)
 				b.WriteString("       ")
-				b.WriteString(styles.Muted.Render(fmt.Sprintf("score=%.1f, cost=%s (%d-%d)\n", task.Score, task.CostTier, task.MinTokens, task.MaxTokens)))
+				meta := fmt.Sprintf("score=%.1f, cost=%s (%d-%d)", task.Score, task.CostTier, task.MinTokens, task.MaxTokens)
+				if task.P90Duration > 0 {
+					meta += fmt.Sprintf(", p90=%s", task.P90Duration.Round(time.Minute))
+				}
+				b.WriteString(styles.Muted.Render(meta + "\n"))
 				b.WriteString("       Prompt:\n")
 				preview := renderPromptPreview(task.Prompt, opts.LongPrompt)
 				b.WriteString(indentLines(preview, "       "))
@@ -179,6 +209,12 @@ func renderSetupPreviewText(result *previewResult) string {
 	b.WriteString(styles.Section.Render("Next run"))
 	b.WriteString("\n")
 	fmt.Fprintf(b, "  Scheduled: %s\n", run.RunAt.Format("Mon 2006-01-02 15:04"))
+	if len(result.Schedules) > 1 {
+		fmt.Fprintf(b, "  Schedule: %s\n", run.Schedule)
+	}
+	if run.Blackout != nil {
+		fmt.Fprintf(b, "  Blackout: %s\n", blackoutRunDetail(run.Blackout))
+	}
 	fmt.Fprintf(b, "  Provider: %s\n", result.Provider)
 
 	totalTasks := 0
@@ -250,6 +286,23 @@ func renderSetupPreviewText(result *previewResult) string {
 	return b.String()
 }
 
+// renderProjectSplitText lists each project's share of the allowance.
+func renderProjectSplitText(b *strings.Builder, styles previewStyles, projects []previewProject) {
+	b.WriteString("  Project split:\n")
+	for _, project := range projects {
+		if project.Budget == nil {
+			fmt.Fprintf(b, "    - %s: %s\n", project.Path, styles.Muted.Render(project.Detail))
+			continue
+		}
+		line := fmt.Sprintf("    - %s: %s tokens", project.Path, formatTokens64(project.Allocation))
+		if project.Budget.Allowance > 0 {
+			line += fmt.Sprintf(" (%.0f%%)", float64(project.Allocation)/float64(project.Budget.Allowance)*100)
+		}
+		line += fmt.Sprintf(", priority %d", project.Priority)
+		fmt.Fprintln(b, line)
+	}
+}
+
 func renderBudgetText(b *strings.Builder, allowance *budget.AllowanceResult, indent string) {
 	if allowance == nil {
 		return
@@ -307,6 +360,10 @@ func renderDiagnosticsText(b *strings.Builder, styles previewStyles, diagnostics
 			b.WriteString(indent)
 			b.WriteString("  - Task disabled by config\n")
 		}
+		if diagnostics.FilteredTask.NotApplicable != "" {
+			b.WriteString(indent)
+			fmt.Fprintf(b, "  - Not applicable to this repo: %s (runs anyway when requested by name)\n", diagnostics.FilteredTask.NotApplicable)
+		}
 		renderCooldownsText(b, styles, diagnostics.Cooldowns, indent)
 		return
 	}
@@ -318,6 +375,14 @@ func renderDiagnosticsText(b *strings.Builder, styles previewStyles, diagnostics
 	agg := diagnostics.Aggregate
 	b.WriteString(indent)
 	fmt.Fprintf(b, "  - Enabled tasks: %d (disabled: %d)\n", agg.Enabled, agg.Disabled)
+	if agg.NotApplicable > 0 {
+		b.WriteString(indent)
+		fmt.Fprintf(b, "  - Not applicable to this repo: %d\n", agg.NotApplicable)
+		for _, na := range diagnostics.NotApplicable {
+			b.WriteString(indent)
+			fmt.Fprintf(b, "      %s: %s\n", na.TaskType, na.Reason)
+		}
+	}
 	b.WriteString(indent)
 	fmt.Fprintf(b, "  - Over budget: %d (budget=%s)\n", agg.OverBudget, formatTokens64(agg.Budget))
 	if agg.Assigned > 0 {
@@ -433,6 +498,7 @@ type previewJSON struct {
 	Budget          previewJSONBudgetConfig     `json:"budget"`
 	Config          previewJSONConfigSources    `json:"config"`
 	ProviderBudgets []previewJSONProviderBudget `json:"provider_budgets,omitempty"`
+	Blackouts       []previewJSONBlackout       `json:"blackouts,omitempty"`
 	Runs            []previewJSONRun            `json:"runs"`
 	Notes           []string                    `json:"notes,omitempty"`
 }
@@ -470,6 +536,8 @@ type previewJSONProviderBudget struct {
 type previewJSONRun struct {
 	Index    int                  `json:"index"`
 	RunAt    string               `json:"run_at"`
+	Schedule string               `json:"schedule,omitempty"`
+	Blackout *previewJSONBlackout `json:"blackout,omitempty"`
 	Projects []previewJSONProject `json:"projects"`
 }
 
@@ -478,6 +546,8 @@ type previewJSONProject struct {
 	Status      string              `json:"status"`
 	Detail      string              `json:"detail,omitempty"`
 	Budget      *previewJSONBudget  `json:"budget,omitempty"`
+	Priority    int                 `json:"priority"`
+	Allocation  int64               `json:"allocation,omitempty"`
 	Tasks       []previewJSONTask   `json:"tasks,omitempty"`
 	Diagnostics *previewDiagnostics `json:"diagnostics,omitempty"`
 }
@@ -505,6 +575,7 @@ type previewJSONTask struct {
 	Score           float64 `json:"score"`
 	CostTier        string  `json:"cost_tier"`
 	MinTokens       int     `json:"min_tokens"`
+	P90Duration     string  `json:"p90_duration,omitempty"`
 	MaxTokens       int     `json:"max_tokens"`
 	Prompt          string  `json:"prompt"`
 	PromptFile      string  `json:"prompt_file,omitempty"`
@@ -578,6 +649,7 @@ func buildPreviewJSON(result *previewResult) previewJSON {
 					Score:           task.Score,
 					CostTier:        task.CostTier,
 					MinTokens:       task.MinTokens,
+					P90Duration:     formatP90(task.P90Duration),
 					MaxTokens:       task.MaxTokens,
 					Prompt:          task.Prompt,
 					PromptFile:      task.PromptFile,
@@ -590,6 +662,8 @@ func buildPreviewJSON(result *previewResult) previewJSON {
 				Status:      string(project.Status),
 				Detail:      project.Detail,
 				Budget:      budgetPayload,
+				Priority:    project.Priority,
+				Allocation:  project.Allocation,
 				Tasks:       tasksPayload,
 				Diagnostics: project.Diagnostics,
 			})
@@ -598,10 +672,17 @@ func buildPreviewJSON(result *previewResult) previewJSON {
 		runs = append(runs, previewJSONRun{
 			Index:    run.Index,
 			RunAt:    run.RunAt.Format(time.RFC3339),
+			Schedule: run.Schedule,
+			Blackout: buildPreviewJSONBlackout(run.Blackout),
 			Projects: projects,
 		})
 	}
 
+	var blackouts []previewJSONBlackout
+	for i := range result.Blackouts {
+		blackouts = append(blackouts, *buildPreviewJSONBlackout(&result.Blackouts[i]))
+	}
+
 	payload := previewJSON{
 		GeneratedAt:  result.GeneratedAt.Format(time.RFC3339),
 		Provider:     result.Provider,
@@ -614,6 +695,7 @@ func buildPreviewJSON(result *previewResult) previewJSON {
 		},
 		Config:          configSources,
 		ProviderBudgets: budgets,
+		Blackouts:       blackouts,
 		Runs:            runs,
 	}
 	if result.Note != "" {
@@ -622,3 +704,39 @@ func buildPreviewJSON(result *previewResult) previewJSON {
 
 	return payload
 }
+
+type previewJSONBlackout struct {
+	Name   string `json:"name"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Mode   string `json:"mode"`
+	Source string `json:"source"`
+}
+
+func buildPreviewJSONBlackout(p *blackout.Period) *previewJSONBlackout {
+	if p == nil {
+		return nil
+	}
+	return &previewJSONBlackout{
+		Name:   p.Name,
+		Start:  p.Start.Format(time.RFC3339),
+		End:    p.End.Format(time.RFC3339),
+		Mode:   p.Mode,
+		Source: p.Source,
+	}
+}
+
+// blackoutRunDetail describes what a blackout does to a run.
+func blackoutRunDetail(p *blackout.Period) string {
+	if p.Mode == config.BlackoutSkip {
+		return fmt.Sprintf("skipped: blackout %s", p.Name)
+	}
+	return fmt.Sprintf("analysis only: blackout %s", p.Name)
+}
+
+func formatP90(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/projects"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// projectBudgets splits each provider's allowance across the run's projects
+// by priority (see projects.Allocator). One allocator per provider, created
+// with that provider's allowance the first time it is chosen.
+type projectBudgets struct {
+	projects   []projects.Project
+	byProvider map[string]*projects.Allocator
+	finished   []string
+}
+
+func newProjectBudgets(cfg *config.Config, paths []string) *projectBudgets {
+	return &projectBudgets{
+		projects:   budgetProjects(cfg, paths),
+		byProvider: make(map[string]*projects.Allocator),
+	}
+}
+
+// done closes project in every allocator, so projects that were skipped or
+// ran on another provider don't hold on to a share of the pool.
+func (b *projectBudgets) done(project string) {
+	b.finished = append(b.finished, project)
+	for _, a := range b.byProvider {
+		a.Close(project)
+	}
+}
+
+// ordered returns the project paths highest priority first.
+func (b *projectBudgets) ordered() []string {
+	sorted := projects.SortByPriority(b.projects)
+	paths := make([]string, len(sorted))
+	for i, p := range sorted {
+		paths[i] = p.Path
+	}
+	return paths
+}
+
+// project returns the budget settings for path.
+func (b *projectBudgets) project(path string) projects.Project {
+	for _, p := range b.projects {
+		if p.Path == path {
+			return p
+		}
+	}
+	return projects.Project{Path: path}
+}
+
+// allocator returns the allocator for the chosen provider.
+func (b *projectBudgets) allocator(choice *providerChoice) *projects.Allocator {
+	if a, ok := b.byProvider[choice.name]; ok {
+		return a
+	}
+	var total int64
+	if choice.allowance != nil {
+		total = choice.allowance.Allowance
+	}
+	a := projects.NewAllocator(b.projects, total)
+	for _, project := range b.finished {
+		a.Close(project)
+	}
+	b.byProvider[choice.name] = a
+	return a
+}
+
+// budgetProjects maps resolved project paths to projects with the priority
+// and caps of the matching cfg.Projects entry (by path or glob pattern).
+func budgetProjects(cfg *config.Config, paths []string) []projects.Project {
+	out := make([]projects.Project, 0, len(paths))
+	for _, path := range paths {
+		proj := projects.Project{Path: path}
+		if pc, ok := projectConfigFor(cfg, path); ok {
+			proj.Priority = pc.Priority
+			proj.MaxTokens = int64(pc.MaxTokens)
+			proj.MaxPercent = pc.MaxPercent
+		}
+		out = append(out, proj)
+	}
+	return out
+}
+
+func projectConfigFor(cfg *config.Config, path string) (config.ProjectConfig, bool) {
+	if cfg == nil {
+		return config.ProjectConfig{}, false
+	}
+	for _, pc := range cfg.Projects {
+		if pc.Path != "" {
+			if abs, err := filepath.Abs(expandPath(pc.Path)); err == nil && abs == path {
+				return pc, true
+			}
+		}
+		if pc.Pattern != "" {
+			if ok, _ := filepath.Match(expandPath(pc.Pattern), path); ok {
+				return pc, true
+			}
+		}
+	}
+	return config.ProjectConfig{}, false
+}
+
+// estimatedSpend is the allocation a preflight selection is expected to use:
+// the tasks' max estimates, capped at the allocation.
+func estimatedSpend(selected []tasks.ScoredTask, allocation int64) int64 {
+	var total int64
+	for _, st := range selected {
+		_, maxTok := st.Definition.EstimatedTokens()
+		total += int64(maxTok)
+	}
+	return min(total, allocation)
+}
+
+// taskTokens returns the tokens a task used for allocation purposes: the
+// reported input+output tokens, or the estimate when nothing was reported.
+func taskTokens(usage []agents.TokenUsage, estimate int) int64 {
+	var total int64
+	for _, u := range usage {
+		total += u.InputTokens + u.OutputTokens
+	}
+	if total == 0 {
+		return int64(estimate)
+	}
+	return total
+}
+
+// projectAllocationSkipReason returns why a task doesn't fit the project's
+// remaining allocation, or "".
+func projectAllocationSkipReason(a *projects.Allocator, project string, estimatedTokens int) string {
+	if a == nil {
+		return ""
+	}
+	remaining := a.Remaining(project)
+	if int64(estimatedTokens) <= remaining {
+		return ""
+	}
+	return fmt.Sprintf("project allocation exhausted (%s of %s tokens left)",
+		formatTokens64(remaining), formatTokens64(a.Open(project)))
+}
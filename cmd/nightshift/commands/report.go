@@ -480,6 +480,7 @@ type runSummary struct {
 	Failed          int
 	Skipped         int
 	TokensUsed      int
+	CostUSD         float64
 	BudgetStart     int
 	BudgetRemaining int
 	Projects        map[string]int
@@ -494,6 +495,7 @@ func summarizeRun(results *reporting.RunResults) runSummary {
 		Start:           results.StartTime,
 		End:             results.EndTime,
 		TokensUsed:      results.UsedBudget,
+		CostUSD:         results.CostUSD,
 		BudgetStart:     results.StartBudget,
 		BudgetRemaining: results.RemainingBudget,
 		Projects:        make(map[string]int),
@@ -533,6 +535,11 @@ func summarizeRun(results *reporting.RunResults) runSummary {
 			summary.TokensUsed += task.TokensUsed
 		}
 	}
+	if summary.CostUSD == 0 {
+		for _, task := range results.Tasks {
+			summary.CostUSD += task.CostUSD
+		}
+	}
 
 	return summary
 }
@@ -578,6 +585,9 @@ func renderReportOverview(styles reportStyles, runs []reportRun, opts reportOpti
 			formatTokensCompact(agg.budgetStart),
 		))
 	}
+	if agg.costUSD > 0 {
+		summaryLines = append(summaryLines, fmt.Sprintf("%s %s", styles.Label.Render("Spend:"), formatUSD(agg.costUSD)))
+	}
 	if agg.prCount > 0 {
 		prLabel := "PR created"
 		if agg.prCount > 1 {
@@ -617,6 +627,9 @@ func renderReportOverview(styles reportStyles, runs []reportRun, opts reportOpti
 		} else if summary.TokensUsed > 0 {
 			runLines = append(runLines, fmt.Sprintf("%s %s", styles.Label.Render("Tokens:"), formatTokensCompact(summary.TokensUsed)))
 		}
+		if summary.CostUSD > 0 {
+			runLines = append(runLines, fmt.Sprintf("%s %s", styles.Label.Render("Spend:"), formatUSD(summary.CostUSD)))
+		}
 
 		if len(summary.Projects) > 0 {
 			runLines = append(runLines, fmt.Sprintf("%s %s", styles.Label.Render("Projects:"), formatProjectSummary(summary.Projects)))
@@ -672,6 +685,9 @@ func renderReportOverview(styles reportStyles, runs []reportRun, opts reportOpti
 			if task.TokensUsed > 0 {
 				line += fmt.Sprintf("  %s", styles.Muted.Render(formatTokensCompact(task.TokensUsed)+" tok"))
 			}
+			if task.CostUSD > 0 {
+				line += fmt.Sprintf("  %s", styles.Muted.Render(formatUSD(task.CostUSD)))
+			}
 			if task.OutputRef != "" {
 				line += fmt.Sprintf("  %s", formatOutputRef(styles, task))
 			}
@@ -802,6 +818,9 @@ func renderReportTasks(styles reportStyles, runs []reportRun) string {
 			if task.TokensUsed > 0 {
 				line += fmt.Sprintf(" · %s tokens", formatTokensCompact(task.TokensUsed))
 			}
+			if task.CostUSD > 0 {
+				line += fmt.Sprintf(" · %s", formatUSD(task.CostUSD))
+			}
 			if task.Duration > 0 {
 				line += fmt.Sprintf(" · %s", formatDuration(task.Duration))
 			}
@@ -907,6 +926,9 @@ func renderReportBudget(styles reportStyles, runs []reportRun) string {
 		} else {
 			b.WriteString("  No budget data recorded\n")
 		}
+		if summary.CostUSD > 0 {
+			b.WriteString(fmt.Sprintf("  %s %s\n", styles.Label.Render("Spend:"), formatUSD(summary.CostUSD)))
+		}
 
 		if i < len(runs)-1 {
 			b.WriteString("\n")
@@ -920,6 +942,7 @@ type aggregateSummary struct {
 	failed        int
 	skipped       int
 	tokensUsed    int
+	costUSD       float64
 	budgetStart   int
 	outputCounts  map[string]int
 	hasBudget     bool
@@ -943,6 +966,7 @@ func aggregateRuns(runs []reportRun) aggregateSummary {
 		agg.failed += summary.Failed
 		agg.skipped += summary.Skipped
 		agg.tokensUsed += summary.TokensUsed
+		agg.costUSD += summary.CostUSD
 		agg.totalDuration += summary.Duration
 		if summary.BudgetStart > 0 {
 			agg.budgetStart += summary.BudgetStart
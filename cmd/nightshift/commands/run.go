@@ -22,8 +22,10 @@ import (
 	"github.com/marcus/nightshift/internal/db"
 	"github.com/marcus/nightshift/internal/logging"
 	"github.com/marcus/nightshift/internal/orchestrator"
+	"github.com/marcus/nightshift/internal/projects"
 	"github.com/marcus/nightshift/internal/providers"
 	"github.com/marcus/nightshift/internal/reporting"
+	"github.com/marcus/nightshift/internal/snapshots"
 	"github.com/marcus/nightshift/internal/state"
 	"github.com/marcus/nightshift/internal/tasks"
 	"github.com/marcus/nightshift/internal/trends"
@@ -172,6 +174,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("init state: %w", err)
 	}
+	defer setupCoordination(cfg, st, log)()
 
 	// Clear stale assignments older than 2 hours
 	cleared := st.ClearStaleAssignments(2 * time.Hour)
@@ -180,14 +183,18 @@ func runRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize providers
-	claudeProvider := providers.NewClaudeWithPath(cfg.ExpandedProviderPath("claude"))
-	codexProvider := providers.NewCodexWithPath(cfg.ExpandedProviderPath("codex"))
-	geminiProvider := providers.NewGeminiWithPath(cfg.ExpandedProviderPath("gemini"))
+	providerSet := providers.FromConfig(cfg)
+	providers.UseLedger(providerSet, st)
 
 	// Initialize budget manager
 	cal := calibrator.New(database, cfg)
 	trend := trends.NewAnalyzer(database, cfg.Budget.SnapshotRetentionDays)
-	budgetMgr := budget.NewManagerFromProviders(cfg, claudeProvider, codexProvider, geminiProvider, budget.WithBudgetSource(cal), budget.WithTrendAnalyzer(trend))
+	budgetMgr := budget.NewManagerFromProviders(cfg, providerSet,
+		budget.WithBudgetSource(cal),
+		budget.WithTrendAnalyzer(trend),
+		budget.WithSpendSource(st),
+		budget.WithSessionResetSource(snapshots.NewCollector(database, nil, nil, weekStartDayFromConfig(cfg))),
+	)
 
 	// Determine projects to run
 	projects, err := resolveProjects(cfg, projectPath)
@@ -204,15 +211,14 @@ func runRun(cmd *cobra.Command, args []string) error {
 		fmt.Println("no projects configured")
 		return nil
 	}
-
-	// Register custom tasks from config
-	tasks.ClearCustom()
-	if err := tasks.RegisterCustomTasksFromConfig(cfg.Tasks.Custom); err != nil {
-		return fmt.Errorf("register custom tasks: %w", err)
+	// Register custom tasks from config and task files
+	if err := registerRunTasks(cfg, projects, log); err != nil {
+		return err
 	}
 
 	// Create task selector
 	selector := tasks.NewSelector(cfg, st)
+	sandboxPreCommands(cfg, selector, projects)
 
 	// Run execution
 	if ignoreBudget {
@@ -223,6 +229,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	params := executeRunParams{
 		cfg:          cfg,
 		budgetMgr:    budgetMgr,
+		prices:       providers.NewPriceTable(cfg, providerSet),
 		selector:     selector,
 		st:           st,
 		projects:     projects,
@@ -236,6 +243,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 	}
 	if !dryRun {
 		params.report = newRunReport(time.Now(), calculateRunBudgetStart(cfg, budgetMgr, log))
+		params.ledger = newRunLedger(database, st, providerSet, weekStartDayFromConfig(cfg))
 	}
 	return executeRun(ctx, params)
 }
@@ -243,6 +251,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 type executeRunParams struct {
 	cfg          *config.Config
 	budgetMgr    *budget.Manager
+	prices       *providers.PriceTable
 	selector     *tasks.Selector
 	st           *state.State
 	projects     []string
@@ -253,9 +262,27 @@ type executeRunParams struct {
 	dryRun       bool
 	yes          bool
 	report       *runReport
+	ledger       *runLedger
 	log          *logging.Logger
 }
 
+// reportSkippedTask prints, logs and reports a task skipped for reason.
+func reportSkippedTask(p executeRunParams, projectPath string, task tasks.ScoredTask, reason string) {
+	if !isInteractive() {
+		fmt.Printf("\n--- Skipping: %s (%s) ---\n", task.Definition.Name, reason)
+	}
+	p.log.Infof("skip %s: %s", task.Definition.Type, reason)
+	if p.report != nil {
+		p.report.addTask(reporting.TaskResult{
+			Project:    projectPath,
+			TaskType:   string(task.Definition.Type),
+			Title:      task.Definition.Name,
+			Status:     "skipped",
+			SkipReason: reason,
+		})
+	}
+}
+
 // providerChoice holds a selected provider's agent and name.
 type providerChoice struct {
 	agent     agents.Agent
@@ -266,40 +293,14 @@ type providerChoice struct {
 // selectProvider picks the best available provider with budget remaining.
 // Order is determined by providers.preference (default: claude, codex).
 // When ignoreBudget is true, budget-exhausted providers are still selected.
-func selectProvider(cfg *config.Config, budgetMgr *budget.Manager, log *logging.Logger, ignoreBudget bool) (*providerChoice, error) {
-	type candidate struct {
-		name      string
-		binary    string
-		makeAgent func() agents.Agent
-	}
-
-	var candidates []candidate
+func selectProvider(cfg *config.Config, budgetMgr *budget.Manager, log *logging.Logger, ignoreBudget bool, projects []string) (*providerChoice, error) {
+	var candidates []providers.Provider
 	for _, name := range providerPreference(cfg) {
-		switch name {
-		case "claude":
-			if cfg.Providers.Claude.Enabled {
-				candidates = append(candidates, candidate{
-					name:      "claude",
-					binary:    "claude",
-					makeAgent: func() agents.Agent { return newClaudeAgentFromConfig(cfg) },
-				})
-			}
-		case "codex":
-			if cfg.Providers.Codex.Enabled {
-				candidates = append(candidates, candidate{
-					name:      "codex",
-					binary:    "codex",
-					makeAgent: func() agents.Agent { return newCodexAgentFromConfig(cfg) },
-				})
-			}
-		case "gemini":
-			if cfg.Providers.Gemini.Enabled {
-				candidates = append(candidates, candidate{
-					name:      "gemini",
-					binary:    "gemini",
-					makeAgent: func() agents.Agent { return newGeminiAgentFromConfig(cfg) },
-				})
-			}
+		if !cfg.ProviderSettings(name).Enabled {
+			continue
+		}
+		if p := providerFromConfig(cfg, name); p != nil {
+			candidates = append(candidates, p)
 		}
 	}
 
@@ -309,32 +310,33 @@ func selectProvider(cfg *config.Config, budgetMgr *budget.Manager, log *logging.
 
 	var notInPath, budgetExhausted []string
 	for _, c := range candidates {
-		if _, err := exec.LookPath(c.binary); err != nil {
-			log.Infof("provider %s: CLI not in PATH, skipping", c.name)
-			notInPath = append(notInPath, c.name)
+		name := c.Name()
+		if _, err := exec.LookPath(c.Binary()); err != nil {
+			log.Infof("provider %s: CLI not in PATH, skipping", name)
+			notInPath = append(notInPath, name)
 			continue
 		}
-		allowance, err := budgetMgr.CalculateAllowance(c.name)
+		allowance, err := budgetMgr.CalculateAllowance(name)
 		if err != nil {
-			log.Warnf("provider %s: budget error: %v", c.name, err)
+			log.Warnf("provider %s: budget error: %v", name, err)
 			continue
 		}
 		if allowance.Allowance <= 0 {
-			log.Infof("provider %s: budget exhausted (%.1f%% used)", c.name, allowance.UsedPercent)
+			log.Infof("provider %s: budget exhausted (%.1f%% used)", name, allowance.UsedPercent)
 			if ignoreBudget {
-				log.Warnf("provider %s: ignoring exhausted budget per --ignore-budget", c.name)
+				log.Warnf("provider %s: ignoring exhausted budget per --ignore-budget", name)
 				return &providerChoice{
-					agent:     c.makeAgent(),
-					name:      c.name,
+					agent:     newAgentFromConfig(cfg, c, projects),
+					name:      name,
 					allowance: allowance,
 				}, nil
 			}
-			budgetExhausted = append(budgetExhausted, fmt.Sprintf("%s (%.0f%% used)", c.name, allowance.UsedPercent))
+			budgetExhausted = append(budgetExhausted, fmt.Sprintf("%s (%.0f%% used)", name, allowance.UsedPercent))
 			continue
 		}
 		return &providerChoice{
-			agent:     c.makeAgent(),
-			name:      c.name,
+			agent:     newAgentFromConfig(cfg, c, projects),
+			name:      name,
 			allowance: allowance,
 		}, nil
 	}
@@ -353,7 +355,7 @@ func selectProvider(cfg *config.Config, budgetMgr *budget.Manager, log *logging.
 }
 
 func providerPreference(cfg *config.Config) []string {
-	defaults := []string{"claude", "codex", "gemini"}
+	defaults := providers.Names()
 	if cfg == nil || len(cfg.Providers.Preference) == 0 {
 		return defaults
 	}
@@ -365,7 +367,7 @@ func providerPreference(cfg *config.Config) []string {
 		if name == "" || seen[name] {
 			continue
 		}
-		if name != "claude" && name != "codex" && name != "gemini" {
+		if _, ok := providers.Lookup(name); !ok {
 			continue
 		}
 		seen[name] = true
@@ -382,7 +384,9 @@ type preflightProject struct {
 	path       string
 	tasks      []tasks.ScoredTask
 	provider   *providerChoice
-	skipReason string // non-empty if project was skipped
+	allocation int64                          // project's share of the provider allowance; 0 when not split
+	skipReason string                         // non-empty if project was skipped
+	openPRs    map[string]orchestrator.OpenPR // open nightshift PRs by task type
 }
 
 // preflightPlan collects all planned work before execution.
@@ -399,9 +403,11 @@ func buildPreflight(p executeRunParams) (*preflightPlan, error) {
 		ignoreBudget: p.ignoreBudget,
 	}
 
-	for _, projectPath := range p.projects {
+	budgets := newProjectBudgets(p.cfg, p.projects)
+	for _, projectPath := range budgets.ordered() {
 		// Skip if already processed today (unless task filter specified)
 		if p.taskFilter == "" && p.st.WasProcessedToday(projectPath) {
+			budgets.done(projectPath)
 			p.log.Infof("skip %s (processed today)", projectPath)
 			reason := fmt.Sprintf("%s: already processed today", filepath.Base(projectPath))
 			plan.projects = append(plan.projects, preflightProject{
@@ -413,31 +419,43 @@ func buildPreflight(p executeRunParams) (*preflightPlan, error) {
 		}
 
 		// Select the best available provider with remaining budget
-		choice, err := selectProvider(p.cfg, p.budgetMgr, p.log, p.ignoreBudget)
+		choice, err := selectProvider(p.cfg, p.budgetMgr, p.log, p.ignoreBudget, p.projects)
 		if err != nil {
 			p.log.Infof("no provider available: %v", err)
 			plan.skipReasons = append(plan.skipReasons, fmt.Sprintf("no provider: %v", err))
 			break
 		}
 
+		// Each project selects within its own allocation of the allowance
+		taskBudget := choice.allowance.Allowance
+		var alloc *projects.Allocator
+		if p.ignoreBudget {
+			taskBudget = math.MaxInt64
+		} else if p.taskFilter == "" {
+			alloc = budgets.allocator(choice)
+			taskBudget = alloc.Open(projectPath)
+		}
+
+		// Tasks whose previous PR is still open are skipped or update it
+		openPRs := findOpenPRs(context.Background(), p.cfg, p.selector, projectPath, p.log)
+
 		// Select tasks
 		var selectedTasks []tasks.ScoredTask
 
 		if p.taskFilter != "" {
-			def, err := tasks.GetDefinition(tasks.TaskType(p.taskFilter))
-			if err != nil {
+			def, err := tasks.ProjectDefinition(projectPath, tasks.TaskType(p.taskFilter))
+			if err != nil && !taskDefinedIn(p.projects, tasks.TaskType(p.taskFilter)) {
 				return nil, fmt.Errorf("unknown task type: %s", p.taskFilter)
 			}
-			selectedTasks = []tasks.ScoredTask{{
-				Definition: def,
-				Score:      p.selector.ScoreTask(def.Type, projectPath),
-				Project:    projectPath,
-			}}
-		} else if p.randomTask {
-			taskBudget := choice.allowance.Allowance
-			if p.ignoreBudget {
-				taskBudget = math.MaxInt64
+			// A task from another project's task files is skipped here
+			if err == nil {
+				selectedTasks = []tasks.ScoredTask{{
+					Definition: def,
+					Score:      p.selector.ScoreTask(def.Type, projectPath),
+					Project:    projectPath,
+				}}
 			}
+		} else if p.randomTask {
 			if picked := p.selector.SelectRandom(taskBudget, projectPath); picked != nil {
 				selectedTasks = []tasks.ScoredTask{*picked}
 			}
@@ -446,10 +464,6 @@ func buildPreflight(p executeRunParams) (*preflightPlan, error) {
 			if n <= 0 {
 				n = 1
 			}
-			taskBudget := choice.allowance.Allowance
-			if p.ignoreBudget {
-				taskBudget = math.MaxInt64
-			}
 			selectedTasks = p.selector.SelectTopN(taskBudget, projectPath, n)
 		}
 
@@ -457,18 +471,36 @@ func buildPreflight(p executeRunParams) (*preflightPlan, error) {
 			path:     projectPath,
 			tasks:    selectedTasks,
 			provider: choice,
+			openPRs:  openPRs,
+		}
+		if alloc != nil {
+			// Whatever the selection leaves unspent rolls over to later projects
+			pp.allocation = taskBudget
+			alloc.Spend(projectPath, estimatedSpend(selectedTasks, taskBudget))
+			alloc.Close(projectPath)
 		}
+		budgets.done(projectPath)
 
 		if len(selectedTasks) == 0 {
 			skipReason := "no tasks available within budget"
-			allEnabled := p.selector.FilterEnabled(tasks.AllDefinitions())
-			inBudget := p.selector.FilterByBudget(allEnabled, choice.allowance.Allowance)
+			allEnabled := p.selector.FilterEnabled(tasks.ProjectDefinitions(projectPath))
+			inBudget := p.selector.FilterByBudget(allEnabled, taskBudget)
+			if alloc != nil && len(inBudget) == 0 && taskBudget < choice.allowance.Allowance {
+				skipReason = fmt.Sprintf("no tasks fit project allocation (%s tokens)", formatTokens64(taskBudget))
+			}
 			unassigned := p.selector.FilterUnassigned(inBudget, projectPath)
-			afterCooldown := p.selector.FilterByCooldown(unassigned, projectPath)
-			cooledDown := len(unassigned) - len(afterCooldown)
+			withoutOpenPR := p.selector.FilterOpenPRs(unassigned, projectPath)
+			afterCooldown := p.selector.FilterByCooldown(withoutOpenPR, projectPath)
+			cooledDown := len(withoutOpenPR) - len(afterCooldown)
+			if openPR := len(unassigned) - len(withoutOpenPR); openPR > 0 {
+				skipReason = fmt.Sprintf("%d task(s) with an open PR", openPR)
+			}
 			if cooledDown > 0 {
 				skipReason = fmt.Sprintf("%d task(s) on cooldown", cooledDown)
 			}
+			if p.taskFilter != "" {
+				skipReason = fmt.Sprintf("task %s not defined for this project", p.taskFilter)
+			}
 			pp.skipReason = skipReason
 			plan.skipReasons = append(plan.skipReasons, fmt.Sprintf("%s: %s", filepath.Base(projectPath), skipReason))
 		}
@@ -508,7 +540,11 @@ func displayPreflight(w io.Writer, plan *preflightPlan) {
 			continue
 		}
 		idx++
-		_, _ = fmt.Fprintf(w, "  %d. %s\n", idx, filepath.Base(pp.path))
+		if pp.allocation > 0 && len(plan.projects) > 1 {
+			_, _ = fmt.Fprintf(w, "  %d. %s (allocation: %s tokens)\n", idx, filepath.Base(pp.path), formatTokens64(pp.allocation))
+		} else {
+			_, _ = fmt.Fprintf(w, "  %d. %s\n", idx, filepath.Base(pp.path))
+		}
 		for _, st := range pp.tasks {
 			minTok, maxTok := st.Definition.EstimatedTokens()
 			_, _ = fmt.Fprintf(w, "     - %s (score=%.1f, cost=%s, ~%dk-%dk tokens)\n",
@@ -571,10 +607,13 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 		return nil
 	}
 
+	p.ledger.begin(ctx, p.log)
+
 	// Execute based on the plan
 	var tasksRun, tasksCompleted, tasksFailed int
 	var skipReasons []string
 	skipReasons = append(skipReasons, plan.skipReasons...)
+	budgets := newProjectBudgets(p.cfg, p.projects)
 
 	for _, pp := range plan.projects {
 		select {
@@ -584,6 +623,9 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 		default:
 		}
 
+		if pp.skipReason != "" || len(pp.tasks) == 0 {
+			budgets.done(pp.path)
+		}
 		if pp.skipReason != "" {
 			if pp.skipReason == "already processed today" {
 				fmt.Printf("Skipping %s: already processed today\n", filepath.Base(pp.path))
@@ -610,6 +652,14 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 		choice := pp.provider
 		projectPath := pp.path
 
+		// Enforce the project's share of the allowance; unspent tokens roll
+		// over to the projects after it.
+		var alloc *projects.Allocator
+		if !p.ignoreBudget && p.taskFilter == "" {
+			alloc = budgets.allocator(choice)
+			alloc.Open(projectPath)
+		}
+
 		if isInteractive() {
 			displayProjectHeaderColored(projectPath, choice.name, choice.allowance, len(pp.tasks), pp.tasks)
 		} else {
@@ -660,6 +710,53 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 			default:
 			}
 
+			// Re-check budget: spend recorded by earlier tasks counts against the caps
+			if !p.ignoreBudget {
+				_, maxTok := scoredTask.Definition.EstimatedTokens()
+				reason, err := taskBudgetSkipReason(p.budgetMgr, choice.name, maxTok)
+				if err != nil {
+					p.log.Warnf("budget check: %v", err)
+				}
+				if reason == "" {
+					reason = projectAllocationSkipReason(alloc, projectPath, maxTok)
+				}
+				if reason == "" {
+					reason, err = awaitSessionWindow(ctx, p.budgetMgr, choice.name, maxTok, p.selector.LatestStart(scoredTask.Definition.Type), p.log, func(msg string) {
+						if !isInteractive() {
+							fmt.Printf("\n--- Waiting: %s ---\n", msg)
+						}
+					})
+					if err != nil {
+						return err
+					}
+				}
+				if reason != "" {
+					reportSkippedTask(p, projectPath, scoredTask, reason)
+					continue
+				}
+			}
+
+			// Chained tasks wait for their upstream tasks, which may have
+			// failed earlier in this run; a task requested by name runs anyway
+			if p.taskFilter == "" {
+				if reason := p.selector.ChainSkipReason(scoredTask.Definition, projectPath); reason != "" {
+					reportSkippedTask(p, projectPath, scoredTask, reason)
+					continue
+				}
+			}
+
+			// Claim last, so tasks skipped for other reasons stay free
+			taskID := fmt.Sprintf("%s:%s", scoredTask.Definition.Type, projectPath)
+			if reason := claimTaskSkipReason(p.st, taskID, projectPath, string(scoredTask.Definition.Type), p.log); reason != "" {
+				reportSkippedTask(p, projectPath, scoredTask, reason)
+				continue
+			}
+			evidence, reason := runPreAnalysis(ctx, p.st, p.selector, scoredTask.Definition, taskID, projectPath, p.log)
+			if reason != "" {
+				reportSkippedTask(p, projectPath, scoredTask, reason)
+				continue
+			}
+
 			tasksRun++
 			if !isInteractive() {
 				fmt.Printf("\n--- Running: %s (via %s) ---\n", scoredTask.Definition.Name, choice.name)
@@ -668,16 +765,14 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 
 			// Create task instance
 			taskInstance := &tasks.Task{
-				ID:          fmt.Sprintf("%s:%s", scoredTask.Definition.Type, projectPath),
+				ID:          taskID,
 				Title:       scoredTask.Definition.Name,
-				Description: scoredTask.Definition.Description,
+				Description: tasks.ChainPrompt(scoredTask.Definition.Description, p.selector.UpstreamOutputs(scoredTask.Definition, projectPath)),
 				Priority:    int(scoredTask.Score),
 				Type:        scoredTask.Definition.Type,
+				Evidence:    evidence,
 			}
 
-			// Mark as assigned
-			p.st.MarkAssigned(taskInstance.ID, projectPath, string(scoredTask.Definition.Type))
-
 			// Inject run metadata for PR traceability
 			orch.SetRunMetadata(&orchestrator.RunMetadata{
 				Provider:  choice.name,
@@ -686,6 +781,8 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 				CostTier:  scoredTask.Definition.CostTier.String(),
 				RunStart:  projectStart,
 			})
+			orch.SetExistingPR(existingPRFor(p.cfg, pp.openPRs, scoredTask.Definition.Type))
+			orch.SetScope(pathScopesFor(p.cfg, projectPath, scoredTask.Definition.Type), p.cfg.ScopeViolationPolicy())
 
 			// Execute via orchestrator
 			result, err := orch.RunTask(ctx, taskInstance, projectPath)
@@ -693,6 +790,19 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 			// Clear assignment
 			p.st.ClearAssigned(taskInstance.ID)
 
+			// Price and persist what the agent actually consumed
+			var costUSD float64
+			var modelTokens map[string]int64
+			if result != nil {
+				modelTokens = tokensByModel(result.Usage)
+				_, maxTok := scoredTask.Definition.EstimatedTokens()
+				costUSD = recordLedger(p.st, p.prices, choice.name, projectPath, taskInstance.ID, string(scoredTask.Definition.Type), result.Invocations, maxTok, p.log)
+				recordTaskRun(p.st, choice.name, projectPath, string(scoredTask.Definition.Type), result, err, p.log)
+				if alloc != nil {
+					alloc.Spend(projectPath, taskTokens(result.Usage, maxTok))
+				}
+			}
+
 			if err != nil {
 				tasksFailed++
 				projectFailed++
@@ -707,6 +817,8 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 						Title:      scoredTask.Definition.Name,
 						Status:     "failed",
 						TokensUsed: 0,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -733,6 +845,8 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 						OutputType: result.OutputType,
 						OutputRef:  result.OutputRef,
 						TokensUsed: maxTok,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -749,6 +863,8 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 						Title:      scoredTask.Definition.Name,
 						Status:     "failed",
 						SkipReason: result.Error,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -765,6 +881,8 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 						Title:      scoredTask.Definition.Name,
 						Status:     "failed",
 						SkipReason: result.Error,
+						CostUSD:    costUSD,
+						Models:     modelTokens,
 						Duration:   result.Duration,
 					})
 				}
@@ -789,6 +907,10 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 			TokensUsed: projectTokensUsed,
 			Status:     projectStatus,
 		})
+		if alloc != nil {
+			alloc.Close(projectPath)
+		}
+		budgets.done(projectPath)
 	}
 
 	// Summary
@@ -808,6 +930,15 @@ func executeRun(ctx context.Context, p executeRunParams) error {
 		}
 	}
 
+	// Reconcile the ledger against provider-wide usage over the run
+	for _, r := range p.ledger.reconcile(ctx, p.log) {
+		flag := ""
+		if r.Flagged() {
+			flag = "  [untracked usage]"
+		}
+		fmt.Printf("Ledger: %s%s\n", r, flag)
+	}
+
 	p.log.InfoCtx("run complete", map[string]any{
 		"duration":  duration.String(),
 		"tasks_run": tasksRun,
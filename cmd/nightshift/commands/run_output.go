@@ -236,7 +236,11 @@ func displayPreflightColored(plan *preflightPlan) {
 			continue
 		}
 		idx++
-		fmt.Printf("  %s %s\n", s.Accent.Render(fmt.Sprintf("%d.", idx)), s.Value.Render(filepath.Base(pp.path)))
+		line := fmt.Sprintf("  %s %s", s.Accent.Render(fmt.Sprintf("%d.", idx)), s.Value.Render(filepath.Base(pp.path)))
+		if pp.allocation > 0 && len(plan.projects) > 1 {
+			line += " " + s.Muted.Render(fmt.Sprintf("(allocation: %s tokens)", formatTokens64(pp.allocation)))
+		}
+		fmt.Println(line)
 		for _, st := range pp.tasks {
 			minTok, maxTok := st.Definition.EstimatedTokens()
 			fmt.Printf("     %s %s %s\n",
@@ -8,6 +8,7 @@ import (
 	"github.com/marcus/nightshift/internal/budget"
 	"github.com/marcus/nightshift/internal/config"
 	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/providers"
 	"github.com/marcus/nightshift/internal/reporting"
 )
 
@@ -32,6 +33,7 @@ func newRunReport(start time.Time, startBudget int) *runReport {
 func (r *runReport) addTask(task reporting.TaskResult) {
 	r.results.Tasks = append(r.results.Tasks, task)
 	r.usedBudget += task.TokensUsed
+	r.results.CostUSD += task.CostUSD
 }
 
 func (r *runReport) finalize(cfg *config.Config, log *logging.Logger) {
@@ -87,18 +89,14 @@ func calculateRunBudgetStart(cfg *config.Config, budgetMgr *budget.Manager, log
 		return 0
 	}
 	total := 0
-	if cfg.Providers.Claude.Enabled {
-		if allowance, err := budgetMgr.CalculateAllowance("claude"); err == nil {
-			total += int(allowance.Allowance)
-		} else if log != nil {
-			log.Warnf("budget claude: %v", err)
+	for _, name := range providers.Names() {
+		if !cfg.ProviderSettings(name).Enabled {
+			continue
 		}
-	}
-	if cfg.Providers.Codex.Enabled {
-		if allowance, err := budgetMgr.CalculateAllowance("codex"); err == nil {
+		if allowance, err := budgetMgr.CalculateAllowance(name); err == nil {
 			total += int(allowance.Allowance)
 		} else if log != nil {
-			log.Warnf("budget codex: %v", err)
+			log.Warnf("budget %s: %v", name, err)
 		}
 	}
 	return total
@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/marcus/nightshift/internal/agents"
 	"github.com/marcus/nightshift/internal/budget"
 	"github.com/marcus/nightshift/internal/config"
 	"github.com/marcus/nightshift/internal/db"
 	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/orchestrator"
+	"github.com/marcus/nightshift/internal/providers"
 	"github.com/marcus/nightshift/internal/state"
 	"github.com/marcus/nightshift/internal/tasks"
 )
@@ -48,8 +52,10 @@ func TestSelectProvider_PreferenceOrder(t *testing.T) {
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
 			Preference: []string{"codex", "claude"},
-			Claude:     config.ProviderConfig{Enabled: true},
-			Codex:      config.ProviderConfig{Enabled: true},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: true},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -60,9 +66,9 @@ func TestSelectProvider_PreferenceOrder(t *testing.T) {
 
 	claude := &mockUsage{name: "claude", pct: 0}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false)
+	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false, nil)
 	if err != nil {
 		t.Fatalf("selectProvider error: %v", err)
 	}
@@ -82,8 +88,10 @@ func TestSelectProvider_FallbackOnBudget(t *testing.T) {
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
 			Preference: []string{"codex", "claude"},
-			Claude:     config.ProviderConfig{Enabled: true},
-			Codex:      config.ProviderConfig{Enabled: true},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: true},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -94,9 +102,9 @@ func TestSelectProvider_FallbackOnBudget(t *testing.T) {
 
 	claude := &mockUsage{name: "claude", pct: 0}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 100}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false)
+	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false, nil)
 	if err != nil {
 		t.Fatalf("selectProvider error: %v", err)
 	}
@@ -108,15 +116,17 @@ func TestSelectProvider_FallbackOnBudget(t *testing.T) {
 func TestSelectProvider_NoProvidersEnabled(t *testing.T) {
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
-			Claude: config.ProviderConfig{Enabled: false},
-			Codex:  config.ProviderConfig{Enabled: false},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: false},
+				"codex":  {Enabled: false},
+			},
 		},
 	}
 	claude := &mockUsage{name: "claude", pct: 0}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false)
+	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -133,8 +143,10 @@ func TestSelectProvider_AllBudgetExhausted(t *testing.T) {
 
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
-			Claude: config.ProviderConfig{Enabled: true},
-			Codex:  config.ProviderConfig{Enabled: true},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: true},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -144,9 +156,9 @@ func TestSelectProvider_AllBudgetExhausted(t *testing.T) {
 	}
 	claude := &mockUsage{name: "claude", pct: 100}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 100}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false)
+	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -161,8 +173,10 @@ func TestSelectProvider_CLINotInPath(t *testing.T) {
 
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
-			Claude: config.ProviderConfig{Enabled: true},
-			Codex:  config.ProviderConfig{Enabled: true},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: true},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -172,9 +186,9 @@ func TestSelectProvider_CLINotInPath(t *testing.T) {
 	}
 	claude := &mockUsage{name: "claude", pct: 0}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false)
+	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -213,8 +227,10 @@ func newTestRunState(t *testing.T) *state.State {
 func newTestRunConfig() *config.Config {
 	return &config.Config{
 		Providers: config.ProvidersConfig{
-			Claude: config.ProviderConfig{Enabled: true},
-			Codex:  config.ProviderConfig{Enabled: true},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: true},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -291,11 +307,10 @@ func TestMaxTasks_DefaultLimitsToOne(t *testing.T) {
 	st := newTestRunState(t)
 	cfg := newTestRunConfig()
 	selector := tasks.NewSelector(cfg, st)
-	budgetMgr := budget.NewManager(cfg,
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(
 		&mockUsage{name: "claude", pct: 0},
 		&mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}},
-		nil,
-	)
+	))
 	project := t.TempDir()
 
 	params := executeRunParams{
@@ -332,11 +347,10 @@ func TestMaxTasks_OverrideToN(t *testing.T) {
 	st := newTestRunState(t)
 	cfg := newTestRunConfig()
 	selector := tasks.NewSelector(cfg, st)
-	budgetMgr := budget.NewManager(cfg,
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(
 		&mockUsage{name: "claude", pct: 0},
 		&mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}},
-		nil,
-	)
+	))
 	project := t.TempDir()
 
 	params := executeRunParams{
@@ -375,11 +389,10 @@ func TestMaxTasks_IgnoredWhenTaskSet(t *testing.T) {
 	st := newTestRunState(t)
 	cfg := newTestRunConfig()
 	selector := tasks.NewSelector(cfg, st)
-	budgetMgr := budget.NewManager(cfg,
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(
 		&mockUsage{name: "claude", pct: 0},
 		&mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}},
-		nil,
-	)
+	))
 	project := t.TempDir()
 
 	// When taskFilter is set, maxTasks is ignored - only the specified task runs
@@ -411,8 +424,10 @@ func TestSelectProvider_IgnoreBudget_StillReturnsProvider(t *testing.T) {
 
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
-			Claude: config.ProviderConfig{Enabled: true},
-			Codex:  config.ProviderConfig{Enabled: true},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: true},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -423,9 +438,9 @@ func TestSelectProvider_IgnoreBudget_StillReturnsProvider(t *testing.T) {
 	// Both providers at 100% usage
 	claude := &mockUsage{name: "claude", pct: 100}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 100}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), true)
+	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), true, nil)
 	if err != nil {
 		t.Fatalf("selectProvider with ignoreBudget=true should succeed, got: %v", err)
 	}
@@ -445,8 +460,10 @@ func TestSelectProvider_IgnoreBudget_PopulatesAllowance(t *testing.T) {
 
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
-			Claude: config.ProviderConfig{Enabled: true},
-			Codex:  config.ProviderConfig{Enabled: false},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: false},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -456,9 +473,9 @@ func TestSelectProvider_IgnoreBudget_PopulatesAllowance(t *testing.T) {
 	}
 	claude := &mockUsage{name: "claude", pct: 100}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), true)
+	choice, err := selectProvider(cfg, budgetMgr, logging.Component("test"), true, nil)
 	if err != nil {
 		t.Fatalf("selectProvider error: %v", err)
 	}
@@ -478,8 +495,10 @@ func TestSelectProvider_IgnoreBudget_False_StillRejectsBudget(t *testing.T) {
 
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
-			Claude: config.ProviderConfig{Enabled: true},
-			Codex:  config.ProviderConfig{Enabled: true},
+			Settings: map[string]config.ProviderConfig{
+				"claude": {Enabled: true},
+				"codex":  {Enabled: true},
+			},
 		},
 		Budget: config.BudgetConfig{
 			Mode:         "daily",
@@ -489,9 +508,9 @@ func TestSelectProvider_IgnoreBudget_False_StillRejectsBudget(t *testing.T) {
 	}
 	claude := &mockUsage{name: "claude", pct: 100}
 	codex := &mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 100}}
-	budgetMgr := budget.NewManager(cfg, claude, codex, nil)
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(claude, codex))
 
-	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false)
+	_, err := selectProvider(cfg, budgetMgr, logging.Component("test"), false, nil)
 	if err == nil {
 		t.Fatal("expected error with ignoreBudget=false and exhausted budget")
 	}
@@ -513,11 +532,10 @@ func newPreflightParams(t *testing.T, projects []string) executeRunParams {
 	st := newTestRunState(t)
 	cfg := newTestRunConfig()
 	selector := tasks.NewSelector(cfg, st)
-	budgetMgr := budget.NewManager(cfg,
+	budgetMgr := budget.NewManager(cfg, budget.WithProviders(
 		&mockUsage{name: "claude", pct: 0},
 		&mockCodexUsage{mockUsage: mockUsage{name: "codex", pct: 0}},
-		nil,
-	)
+	))
 	return executeRunParams{
 		cfg:       cfg,
 		budgetMgr: budgetMgr,
@@ -585,6 +603,44 @@ func TestBuildPreflight_SkippedProject(t *testing.T) {
 	}
 }
 
+func TestBuildPreflight_ProjectAllocation(t *testing.T) {
+	low := t.TempDir()
+	high := t.TempDir()
+	params := newPreflightParams(t, []string{low, high})
+	params.cfg.Projects = []config.ProjectConfig{
+		{Path: low, Priority: 0},
+		{Path: high, Priority: 3},
+	}
+
+	plan, err := buildPreflight(params)
+	if err != nil {
+		t.Fatalf("buildPreflight: %v", err)
+	}
+	if len(plan.projects) != 2 {
+		t.Fatalf("projects = %d, want 2", len(plan.projects))
+	}
+	if plan.projects[0].path != high {
+		t.Fatalf("first project = %q, want higher priority %q", plan.projects[0].path, high)
+	}
+	first, second := plan.projects[0], plan.projects[1]
+	if first.allocation <= 0 || second.allocation <= 0 {
+		t.Fatalf("allocations = %d, %d, want both > 0", first.allocation, second.allocation)
+	}
+	if first.allocation >= first.provider.allowance.Allowance {
+		t.Fatalf("first allocation %d should be a share of allowance %d", first.allocation, first.provider.allowance.Allowance)
+	}
+	// The low priority project gets the remainder, including what the
+	// first project's selection left unspent.
+	var spent int64
+	for _, task := range first.tasks {
+		_, maxTok := task.Definition.EstimatedTokens()
+		spent += int64(maxTok)
+	}
+	if want := first.provider.allowance.Allowance - min(spent, first.allocation); second.allocation != want {
+		t.Fatalf("second allocation = %d, want %d", second.allocation, want)
+	}
+}
+
 func TestDisplayPreflight_OutputFormat(t *testing.T) {
 	plan := &preflightPlan{
 		projects: []preflightProject{
@@ -1153,3 +1209,63 @@ func TestDisplayPreflight_NoWarningsWhenBudgetRespected(t *testing.T) {
 		t.Errorf("output should not contain 'Warnings:' when ignoreBudget=false\nGot:\n%s", output)
 	}
 }
+
+func TestSandboxConfigFromConfig_AllowsProjects(t *testing.T) {
+	cfg := &config.Config{Sandbox: config.SandboxConfig{Enabled: true, AllowedPaths: []string{"/shared"}}}
+
+	// Repeated runs, as in the daemon, must not grow the configured list
+	for range 3 {
+		sc := sandboxConfigFromConfig(cfg, []string{"/work/a", "/work/b"})
+		if len(sc.AllowedPaths) != 3 || sc.AllowedPaths[1] != "/work/a" || sc.AllowedPaths[2] != "/work/b" {
+			t.Fatalf("AllowedPaths = %v, want /shared and both projects", sc.AllowedPaths)
+		}
+	}
+	if len(cfg.Sandbox.AllowedPaths) != 1 {
+		t.Errorf("cfg.Sandbox.AllowedPaths = %v, want it unchanged", cfg.Sandbox.AllowedPaths)
+	}
+}
+
+func TestRecordLedger_PricesInvocations(t *testing.T) {
+	st := newTestRunState(t)
+	cfg := newTestRunConfig()
+	prices := providers.NewPriceTable(cfg, providers.FromConfig(cfg))
+	start := time.Now().Add(-time.Minute)
+
+	cost := recordLedger(st, prices, "claude", "/p", "t1", "lint-fix", []orchestrator.Invocation{
+		{Phase: orchestrator.StatusPlanning, Start: start, Usage: []agents.TokenUsage{{Model: "claude-opus-4-5", InputTokens: 1_000_000}}},
+		{Phase: orchestrator.StatusExecuting, Start: start},
+	}, 50_000, nil)
+	if math.Abs(cost-5) > 1e-9 {
+		t.Errorf("measured cost = %f, want 5", cost)
+	}
+
+	// A task that reported no usage is priced from its estimate
+	estimated := recordLedger(st, prices, "claude", "/p", "t2", "lint-fix", []orchestrator.Invocation{
+		{Phase: orchestrator.StatusPlanning, Start: start},
+		{Phase: orchestrator.StatusExecuting, Start: start},
+	}, 50_000, nil)
+	if estimated <= 0 {
+		t.Errorf("estimated cost = %f, want > 0", estimated)
+	}
+
+	entries, err := st.LedgerSince("", start.Add(-time.Second), 0)
+	if err != nil {
+		t.Fatalf("LedgerSince: %v", err)
+	}
+	var estimatedRows int
+	for _, e := range entries {
+		if e.Estimated {
+			estimatedRows++
+		}
+	}
+	if len(entries) != 4 || estimatedRows != 1 {
+		t.Errorf("ledger has %d rows (%d estimated), want 4 (1 estimated)", len(entries), estimatedRows)
+	}
+	spent, err := st.SpendSince(start.Add(-time.Second))
+	if err != nil {
+		t.Fatalf("SpendSince: %v", err)
+	}
+	if math.Abs(spent-(cost+estimated)) > 1e-9 {
+		t.Errorf("SpendSince = %f, want %f", spent, cost+estimated)
+	}
+}
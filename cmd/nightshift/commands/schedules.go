@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// namedScheduler pairs a configured schedule with its scheduler.
+type namedScheduler struct {
+	schedule config.NamedSchedule
+	sched    *scheduler.Scheduler
+}
+
+// scheduledRun is one upcoming run of a schedule.
+type scheduledRun struct {
+	schedule config.NamedSchedule
+	at       time.Time
+}
+
+// buildSchedulers creates a scheduler for every entry of cfg.Schedule
+// (the top-level schedule first). Returns scheduler.ErrNoSchedule when
+// nothing is configured.
+func buildSchedulers(cfg *config.Config) ([]namedScheduler, error) {
+	entries := cfg.Schedule.Entries()
+	if len(entries) == 0 {
+		return nil, scheduler.ErrNoSchedule
+	}
+	out := make([]namedScheduler, 0, len(entries))
+	for _, entry := range entries {
+		sched, err := scheduler.NewFromConfig(entry.ScheduleConfig())
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", entry.Name, err)
+		}
+		out = append(out, namedScheduler{schedule: entry, sched: sched})
+	}
+	return out, nil
+}
+
+// nextScheduledRuns merges the next n runs of every schedule, earliest first.
+func nextScheduledRuns(scheds []namedScheduler, n int) ([]scheduledRun, error) {
+	var runs []scheduledRun
+	for _, ns := range scheds {
+		times, err := ns.sched.NextRuns(n)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", ns.schedule.Name, err)
+		}
+		for _, at := range times {
+			runs = append(runs, scheduledRun{schedule: ns.schedule, at: at})
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].at.Before(runs[j].at) })
+	if len(runs) > n {
+		runs = runs[:n]
+	}
+	return runs, nil
+}
+
+// scheduleTaskFilter converts a schedule's task filters for the selector.
+func scheduleTaskFilter(s config.NamedSchedule) (tasks.Filter, error) {
+	var f tasks.Filter
+	for _, name := range s.Categories {
+		cat, err := tasks.ParseCategory(name)
+		if err != nil {
+			return f, fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		f.Categories = append(f.Categories, cat)
+	}
+	for _, name := range s.CostTiers {
+		tier, err := tasks.ParseCostTier(name)
+		if err != nil {
+			return f, fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		f.CostTiers = append(f.CostTiers, tier)
+	}
+	for _, name := range s.Tasks {
+		f.Types = append(f.Types, tasks.TaskType(name))
+	}
+	return f, nil
+}
+
+// scheduleProjects returns the projects a schedule covers.
+func scheduleProjects(s config.NamedSchedule, projects []string) []string {
+	var out []string
+	for _, p := range projects {
+		if s.MatchesProject(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// describeSchedule summarizes a schedule's cadence and filters, e.g.
+// "cron 0 3 * * 6,0, window 22:00-06:00, categories safe, max 90%".
+func describeSchedule(s config.NamedSchedule) string {
+	desc := "every " + s.Interval
+	if s.Cron != "" {
+		desc = "cron " + s.Cron
+	}
+	if s.Window != nil {
+		desc += fmt.Sprintf(", window %s-%s", s.Window.Start, s.Window.End)
+		if s.Window.Timezone != "" {
+			desc += " " + s.Window.Timezone
+		}
+	}
+	for _, f := range []struct {
+		label  string
+		values []string
+	}{
+		{"categories", s.Categories},
+		{"tasks", s.Tasks},
+		{"cost", s.CostTiers},
+		{"projects", s.Projects},
+	} {
+		if len(f.values) > 0 {
+			desc += fmt.Sprintf(", %s %s", f.label, strings.Join(f.values, ","))
+		}
+	}
+	if s.MaxPercent > 0 {
+		desc += fmt.Sprintf(", max %d%%", s.MaxPercent)
+	}
+	return desc
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+func TestNextScheduledRuns_MergesSchedules(t *testing.T) {
+	cfg := &config.Config{Schedule: config.ScheduleConfig{
+		Interval: "1h",
+		Named: []config.NamedSchedule{
+			{Name: "slow", Interval: "90m"},
+		},
+	}}
+	scheds, err := buildSchedulers(cfg)
+	if err != nil {
+		t.Fatalf("buildSchedulers: %v", err)
+	}
+	if len(scheds) != 2 || scheds[0].schedule.Name != config.DefaultScheduleName {
+		t.Fatalf("expected default and slow schedules, got %d", len(scheds))
+	}
+
+	runs, err := nextScheduledRuns(scheds, 4)
+	if err != nil {
+		t.Fatalf("nextScheduledRuns: %v", err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("expected 4 runs, got %d", len(runs))
+	}
+	seen := map[string]bool{}
+	for i, run := range runs {
+		seen[run.schedule.Name] = true
+		if i > 0 && run.at.Before(runs[i-1].at) {
+			t.Errorf("runs out of order at %d", i)
+		}
+	}
+	if !seen["slow"] || !seen[config.DefaultScheduleName] {
+		t.Errorf("expected runs from both schedules, got %v", seen)
+	}
+}
+
+func TestBuildSchedulers_NoSchedule(t *testing.T) {
+	if _, err := buildSchedulers(&config.Config{}); !errors.Is(err, scheduler.ErrNoSchedule) {
+		t.Errorf("expected ErrNoSchedule, got %v", err)
+	}
+}
+
+func TestScheduleTaskFilter(t *testing.T) {
+	f, err := scheduleTaskFilter(config.NamedSchedule{
+		Name:       "weekend",
+		Categories: []string{"safe"},
+		CostTiers:  []string{"high", "veryhigh"},
+		Tasks:      []string{"perf-regression"},
+	})
+	if err != nil {
+		t.Fatalf("scheduleTaskFilter: %v", err)
+	}
+	if len(f.Categories) != 1 || f.Categories[0] != tasks.CategorySafe {
+		t.Errorf("categories = %v", f.Categories)
+	}
+	if len(f.CostTiers) != 2 || f.CostTiers[1] != tasks.CostVeryHigh {
+		t.Errorf("cost tiers = %v", f.CostTiers)
+	}
+	if len(f.Types) != 1 || f.Types[0] != "perf-regression" {
+		t.Errorf("types = %v", f.Types)
+	}
+
+	if _, err := scheduleTaskFilter(config.NamedSchedule{Name: "bad", Categories: []string{"risky"}}); err == nil {
+		t.Error("expected error for unknown category")
+	}
+}
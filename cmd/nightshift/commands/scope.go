@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// pathScopesFor returns the path scopes limiting taskType's changes in
+// project: the project's and the task's own.
+func pathScopesFor(cfg *config.Config, project string, taskType tasks.TaskType) []config.PathScope {
+	var scopes []config.PathScope
+	if pc, ok := projectConfigFor(cfg, project); ok && !pc.Scope.IsZero() {
+		scopes = append(scopes, pc.Scope)
+	}
+	if s, ok := cfg.Tasks.Scopes[string(taskType)]; ok && !s.IsZero() {
+		scopes = append(scopes, s)
+	}
+	return scopes
+}
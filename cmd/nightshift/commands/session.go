@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/marcus/nightshift/internal/budget"
+	"github.com/marcus/nightshift/internal/logging"
+)
+
+// awaitSessionWindow paces a task against the provider's short rolling
+// window. When the window is full but resets within budget.session.max_wait,
+// and before latestStart (when set), it sleeps until the reset (calling
+// notify first, if set) and checks again instead of giving up on the rest
+// of the night. Returns a skip reason when the task still doesn't fit, or
+// "" when it may run. The only error returned is ctx's, when cancelled
+// while waiting.
+func awaitSessionWindow(ctx context.Context, mgr *budget.Manager, provider string, estimatedTokens int, latestStart time.Time, log *logging.Logger, notify func(string)) (string, error) {
+	if mgr == nil {
+		return "", nil
+	}
+	waited := false
+	for {
+		decision, err := mgr.SessionCheck(provider, int64(estimatedTokens))
+		if err != nil {
+			if log != nil {
+				log.Warnf("session check: %v", err)
+			}
+			return "", nil
+		}
+		if decision.Allowed {
+			return "", nil
+		}
+		if decision.Wait <= 0 || waited {
+			return fmt.Sprintf("session window full (%s)", decision.Status), nil
+		}
+
+		// Allow for clock skew between the reported reset and the provider.
+		wait := decision.Wait + time.Minute
+		if !latestStart.IsZero() && time.Now().Add(wait).After(latestStart) {
+			return fmt.Sprintf("session window full (%s); resets too late to finish before the window ends", decision.Status), nil
+		}
+		msg := fmt.Sprintf("%s session window full (%s); waiting %s for reset", provider, decision.Status, wait.Round(time.Minute))
+		if log != nil {
+			log.Infof("%s", msg)
+		}
+		if notify != nil {
+			notify(msg)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+		waited = true
+	}
+}
@@ -193,9 +193,8 @@ func newSetupModel() (*setupModel, error) {
 		return nil, fmt.Errorf("load config: %w", err)
 	}
 	// Keep task registry aligned with current config so setup can display custom tasks.
-	tasks.ClearCustom()
-	if err := tasks.RegisterCustomTasksFromConfig(cfg.Tasks.Custom); err != nil {
-		return nil, fmt.Errorf("register custom tasks: %w", err)
+	if err := registerCustomTasks(cfg, nil); err != nil {
+		return nil, err
 	}
 	configPath := config.GlobalConfigPath()
 	_, err = os.Stat(configPath)
@@ -812,17 +811,12 @@ func (m *setupModel) handleProvidersInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.providerCursor--
 		}
 	case "down", "j":
-		if m.providerCursor < 2 {
+		if m.providerCursor < len(providers.Names())-1 {
 			m.providerCursor++
 		}
 	case " ":
-		switch m.providerCursor {
-		case 0:
-			m.cfg.Providers.Claude.Enabled = !m.cfg.Providers.Claude.Enabled
-		case 1:
-			m.cfg.Providers.Codex.Enabled = !m.cfg.Providers.Codex.Enabled
-		case 2:
-			m.cfg.Providers.Gemini.Enabled = !m.cfg.Providers.Gemini.Enabled
+		if names := providers.Names(); m.providerCursor < len(names) {
+			toggleProviderSetting(m.cfg, names[m.providerCursor], func(s *config.ProviderConfig) { s.Enabled = !s.Enabled })
 		}
 	case "enter":
 		return m, m.setStep(stepSafety)
@@ -843,11 +837,13 @@ func (m *setupModel) handleSafetyInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case " ":
 		switch m.safetyCursor {
 		case 0:
-			m.cfg.Providers.Claude.DangerouslySkipPermissions = !m.cfg.Providers.Claude.DangerouslySkipPermissions
+			toggleProviderSetting(m.cfg, "claude", func(s *config.ProviderConfig) { s.DangerouslySkipPermissions = !s.DangerouslySkipPermissions })
 		case 1:
-			m.cfg.Providers.Codex.DangerouslyBypassApprovalsAndSandbox = !m.cfg.Providers.Codex.DangerouslyBypassApprovalsAndSandbox
+			toggleProviderSetting(m.cfg, "codex", func(s *config.ProviderConfig) {
+				s.DangerouslyBypassApprovalsAndSandbox = !s.DangerouslyBypassApprovalsAndSandbox
+			})
 		case 2:
-			m.cfg.Providers.Gemini.Yolo = !m.cfg.Providers.Gemini.Yolo
+			toggleProviderSetting(m.cfg, "gemini", func(s *config.ProviderConfig) { s.Yolo = !s.Yolo })
 		}
 	case "enter":
 		return m, m.setStep(stepTaskPreset)
@@ -855,6 +851,21 @@ func (m *setupModel) handleSafetyInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// toggleProviderSetting applies flip to the named provider's settings.
+func toggleProviderSetting(cfg *config.Config, name string, flip func(*config.ProviderConfig)) {
+	settings := cfg.ProviderSettings(name)
+	flip(&settings)
+	cfg.SetProviderSettings(name, settings)
+}
+
+// providerLabel returns a provider name for display, e.g. "Claude".
+func providerLabel(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
 func (m *setupModel) handleTaskInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if len(m.taskItems) == 0 {
 		if msg.String() == "enter" {
@@ -1541,24 +1552,16 @@ func renderEnvChecks(cfg *config.Config) string {
 	} else {
 		b.WriteString(fmt.Sprintf("  %s %s\n", styleOk.Render("OK:"), "tmux available"))
 	}
-	providerChecks := []struct {
-		name    string
-		enabled bool
-		key     string
-	}{
-		{"Claude", cfg.Providers.Claude.Enabled, "claude"},
-		{"Codex", cfg.Providers.Codex.Enabled, "codex"},
-		{"Gemini", cfg.Providers.Gemini.Enabled, "gemini"},
-	}
-	for _, p := range providerChecks {
-		if !p.enabled {
-			b.WriteString(fmt.Sprintf("  %s %s (disabled)\n", styleDim.Render("--"), p.name))
+	for _, name := range providers.Names() {
+		label := providerLabel(name)
+		if !cfg.ProviderSettings(name).Enabled {
+			b.WriteString(fmt.Sprintf("  %s %s (disabled)\n", styleDim.Render("--"), label))
 			continue
 		}
-		if _, err := os.Stat(cfg.ExpandedProviderPath(p.key)); err != nil {
-			b.WriteString(fmt.Sprintf("  %s %s data path not found\n", styleWarn.Render("Note:"), p.name))
+		if _, err := os.Stat(cfg.ExpandedProviderPath(name)); err != nil {
+			b.WriteString(fmt.Sprintf("  %s %s data path not found\n", styleWarn.Render("Note:"), label))
 		} else {
-			b.WriteString(fmt.Sprintf("  %s %s data path found\n", styleOk.Render("OK:"), p.name))
+			b.WriteString(fmt.Sprintf("  %s %s data path found\n", styleOk.Render("OK:"), label))
 		}
 	}
 	return b.String()
@@ -1584,25 +1587,16 @@ func renderBudgetFields(b *strings.Builder, m *setupModel) {
 }
 
 func renderProviderFields(b *strings.Builder, m *setupModel) {
-	items := []struct {
-		label   string
-		enabled bool
-	}{
-		{label: "Claude", enabled: m.cfg.Providers.Claude.Enabled},
-		{label: "Codex", enabled: m.cfg.Providers.Codex.Enabled},
-		{label: "Gemini", enabled: m.cfg.Providers.Gemini.Enabled},
-	}
-
-	for i, item := range items {
+	for i, name := range providers.Names() {
 		cursor := " "
 		if i == m.providerCursor {
 			cursor = ">"
 		}
 		state := "OFF"
-		if item.enabled {
+		if m.cfg.ProviderSettings(name).Enabled {
 			state = "ON"
 		}
-		fmt.Fprintf(b, " %s [%s] %s\n", cursor, state, item.label)
+		fmt.Fprintf(b, " %s [%s] %s\n", cursor, state, providerLabel(name))
 	}
 }
 
@@ -1614,18 +1608,18 @@ func renderSafetyFields(b *strings.Builder, m *setupModel) {
 	}{
 		{
 			label:     "Claude: --dangerously-skip-permissions",
-			enabled:   m.cfg.Providers.Claude.DangerouslySkipPermissions,
-			available: m.cfg.Providers.Claude.Enabled,
+			enabled:   m.cfg.ProviderSettings("claude").DangerouslySkipPermissions,
+			available: m.cfg.ProviderSettings("claude").Enabled,
 		},
 		{
 			label:     "Codex:  --dangerously-bypass-approvals-and-sandbox",
-			enabled:   m.cfg.Providers.Codex.DangerouslyBypassApprovalsAndSandbox,
-			available: m.cfg.Providers.Codex.Enabled,
+			enabled:   m.cfg.ProviderSettings("codex").DangerouslyBypassApprovalsAndSandbox,
+			available: m.cfg.ProviderSettings("codex").Enabled,
 		},
 		{
 			label:     "Gemini: --yolo",
-			enabled:   m.cfg.Providers.Gemini.Yolo,
-			available: m.cfg.Providers.Gemini.Enabled,
+			enabled:   m.cfg.ProviderSettings("gemini").Yolo,
+			available: m.cfg.ProviderSettings("gemini").Enabled,
 		},
 	}
 
@@ -1695,8 +1689,7 @@ func scheduleFieldHelp(cursor int, mode string) string {
 
 func makeTaskItems(cfg *config.Config, projects []string, preset setup.Preset) []taskItem {
 	defs := tasks.AllDefinitionsSorted()
-	signals := setup.DetectRepoSignals(projects)
-	selected := setup.PresetTasks(preset, defs, signals)
+	selected := setup.PresetTasks(preset, defs, setup.DetectProfiles(projects))
 	for _, enabled := range cfg.Tasks.Enabled {
 		selected[tasks.TaskType(enabled)] = true
 	}
@@ -1733,37 +1726,24 @@ func runSnapshot(cfg *config.Config) (string, error) {
 		scraper = tmuxScraper{}
 	}
 
+	providerSet := providers.FromConfig(cfg)
+	useLedger(providerSet, database)
 	collector := snapshots.NewCollector(
 		database,
-		providers.NewClaudeWithPath(cfg.ExpandedProviderPath("claude")),
-		providers.NewCodexWithPath(cfg.ExpandedProviderPath("codex")),
-		providers.NewGeminiWithPath(cfg.ExpandedProviderPath("gemini")),
+		usageSources(providerSet),
 		scraper,
 		weekStartDayFromConfig(cfg),
 	)
 
 	var lines []string
 	ctx := context.Background()
-	if cfg.Providers.Claude.Enabled {
-		snapshot, err := collector.TakeSnapshot(ctx, "claude")
-		if err != nil {
-			lines = append(lines, fmt.Sprintf("claude: error: %v", err))
-		} else {
-			lines = append(lines, formatSnapshotLine(snapshot))
-		}
-	}
-	if cfg.Providers.Codex.Enabled {
-		snapshot, err := collector.TakeSnapshot(ctx, "codex")
-		if err != nil {
-			lines = append(lines, fmt.Sprintf("codex: error: %v", err))
-		} else {
-			lines = append(lines, formatSnapshotLine(snapshot))
+	for _, name := range providers.Names() {
+		if !cfg.ProviderSettings(name).Enabled {
+			continue
 		}
-	}
-	if cfg.Providers.Gemini.Enabled {
-		snapshot, err := collector.TakeSnapshot(ctx, "gemini")
+		snapshot, err := collector.TakeSnapshot(ctx, name)
 		if err != nil {
-			lines = append(lines, fmt.Sprintf("gemini: error: %v", err))
+			lines = append(lines, fmt.Sprintf("%s: error: %v", name, err))
 		} else {
 			lines = append(lines, formatSnapshotLine(snapshot))
 		}
@@ -2006,7 +1986,12 @@ func writeGlobalConfig(cfg *config.Config) error {
 	v.Set("budget.snapshot_retention_days", cfg.Budget.SnapshotRetentionDays)
 	v.Set("budget.week_start_day", cfg.Budget.WeekStartDay)
 
-	v.Set("providers", cfg.Providers)
+	// Provider blocks sit beside preference under providers
+	providerKeys := map[string]any{"preference": cfg.Providers.Preference}
+	for name, settings := range cfg.Providers.Settings {
+		providerKeys[name] = settings
+	}
+	v.Set("providers", providerKeys)
 	v.Set("projects", cfg.Projects)
 	v.Set("tasks.enabled", cfg.Tasks.Enabled)
 
@@ -180,9 +180,8 @@ func TestRenderEnvChecks_IncludesGemini(t *testing.T) {
 	dir := t.TempDir()
 	cfg := &config.Config{
 		Providers: config.ProvidersConfig{
-			Gemini: config.ProviderConfig{
-				Enabled:  true,
-				DataPath: dir,
+			Settings: map[string]config.ProviderConfig{
+				"gemini": {Enabled: true, DataPath: dir},
 			},
 		},
 	}
@@ -198,17 +197,17 @@ func TestHandleSafetyInput_TogglesGemini(t *testing.T) {
 		safetyCursor: 2,
 	}
 
-	if m.cfg.Providers.Gemini.Yolo {
+	if m.cfg.ProviderSettings("gemini").Yolo {
 		t.Fatal("expected Yolo to start as false")
 	}
 
 	m.handleSafetyInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}})
-	if !m.cfg.Providers.Gemini.Yolo {
+	if !m.cfg.ProviderSettings("gemini").Yolo {
 		t.Fatal("expected Yolo to be toggled to true")
 	}
 
 	m.handleSafetyInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{' '}})
-	if m.cfg.Providers.Gemini.Yolo {
+	if m.cfg.ProviderSettings("gemini").Yolo {
 		t.Fatal("expected Yolo to be toggled back to false")
 	}
 }
@@ -217,9 +216,11 @@ func TestHandleProvidersInput_TogglesEnabled(t *testing.T) {
 	m := &setupModel{
 		cfg: &config.Config{
 			Providers: config.ProvidersConfig{
-				Claude: config.ProviderConfig{Enabled: true},
-				Codex:  config.ProviderConfig{Enabled: true},
-				Gemini: config.ProviderConfig{Enabled: false},
+				Settings: map[string]config.ProviderConfig{
+					"claude": {Enabled: true},
+					"codex":  {Enabled: true},
+					"gemini": {Enabled: false},
+				},
 			},
 		},
 	}
@@ -229,25 +230,25 @@ func TestHandleProvidersInput_TogglesEnabled(t *testing.T) {
 	// cursor 0 = Claude
 	m.providerCursor = 0
 	m.handleProvidersInput(spaceMsg)
-	if m.cfg.Providers.Claude.Enabled {
+	if m.cfg.ProviderSettings("claude").Enabled {
 		t.Fatal("expected Claude.Enabled to be toggled to false")
 	}
 	m.handleProvidersInput(spaceMsg)
-	if !m.cfg.Providers.Claude.Enabled {
+	if !m.cfg.ProviderSettings("claude").Enabled {
 		t.Fatal("expected Claude.Enabled to be toggled back to true")
 	}
 
 	// cursor 1 = Codex
 	m.providerCursor = 1
 	m.handleProvidersInput(spaceMsg)
-	if m.cfg.Providers.Codex.Enabled {
+	if m.cfg.ProviderSettings("codex").Enabled {
 		t.Fatal("expected Codex.Enabled to be toggled to false")
 	}
 
 	// cursor 2 = Gemini
 	m.providerCursor = 2
 	m.handleProvidersInput(spaceMsg)
-	if !m.cfg.Providers.Gemini.Enabled {
+	if !m.cfg.ProviderSettings("gemini").Enabled {
 		t.Fatal("expected Gemini.Enabled to be toggled to true")
 	}
 }
@@ -256,9 +257,11 @@ func TestRenderProviderFields_ShowsAllProviders(t *testing.T) {
 	m := &setupModel{
 		cfg: &config.Config{
 			Providers: config.ProvidersConfig{
-				Claude: config.ProviderConfig{Enabled: true},
-				Codex:  config.ProviderConfig{Enabled: false},
-				Gemini: config.ProviderConfig{Enabled: true},
+				Settings: map[string]config.ProviderConfig{
+					"claude": {Enabled: true},
+					"codex":  {Enabled: false},
+					"gemini": {Enabled: true},
+				},
 			},
 		},
 	}
@@ -109,11 +109,11 @@ func runBudgetSnapshot(cmd *cobra.Command, filterProvider string, localOnly bool
 		scraper = tmuxScraper{}
 	}
 
+	providerSet := providers.FromConfig(cfg)
+	useLedger(providerSet, database)
 	collector := snapshots.NewCollector(
 		database,
-		providers.NewClaudeWithPath(cfg.ExpandedProviderPath("claude")),
-		providers.NewCodexWithPath(cfg.ExpandedProviderPath("codex")),
-		providers.NewGeminiWithPath(cfg.ExpandedProviderPath("gemini")),
+		usageSources(providerSet),
 		scraper,
 		weekStartDayFromConfig(cfg),
 	)
@@ -141,6 +141,9 @@ func runBudgetSnapshot(cmd *cobra.Command, filterProvider string, localOnly bool
 		}
 		fmt.Printf("  Local weekly: %s tokens\n", formatTokens64(snapshot.LocalTokens))
 		fmt.Printf("  Local daily:  %s tokens\n", formatTokens64(snapshot.LocalDaily))
+		if len(snapshot.ModelTokens) > 0 {
+			fmt.Printf("  By model:     %s\n", formatModelUsage(modelUsageFromTokens(cfg, provName, snapshot.ModelTokens)))
+		}
 		fmt.Printf("  Data source:  %s\n", dataSource)
 
 		// Scraping status
@@ -300,7 +303,7 @@ func runBudgetHistory(filterProvider string, n int) error {
 		return nil
 	}
 
-	collector := snapshots.NewCollector(database, nil, nil, nil, nil, weekStartDayFromConfig(cfg))
+	collector := snapshots.NewCollector(database, nil, nil, weekStartDayFromConfig(cfg))
 
 	for _, provider := range providerList {
 		history, err := collector.GetLatest(provider, n)
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/budget"
+)
+
+// tokensByModel collapses reported usage to input+output tokens per model for
+// run reports. Cache reads are left out so totals line up with budget usage.
+func tokensByModel(usage []agents.TokenUsage) map[string]int64 {
+	if len(usage) == 0 {
+		return nil
+	}
+	byModel := make(map[string]int64, len(usage))
+	for _, u := range usage {
+		model := u.Model
+		if model == "" {
+			model = "unknown"
+		}
+		byModel[model] += u.InputTokens + u.OutputTokens
+	}
+	return byModel
+}
+
+// taskBudgetSkipReason returns why the budget manager won't allow a task of
+// the given size, or "" when it may run.
+func taskBudgetSkipReason(mgr *budget.Manager, provider string, estimatedTokens int) (string, error) {
+	if mgr == nil {
+		return "", nil
+	}
+	ok, err := mgr.CanRun(provider, int64(estimatedTokens))
+	if err != nil || ok {
+		return "", err
+	}
+	status, err := mgr.SpendStatus()
+	if err != nil {
+		return "", err
+	}
+	if status != nil {
+		if canSpend, err := mgr.CanSpend(provider, int64(estimatedTokens)); err == nil && !canSpend {
+			return fmt.Sprintf("dollar cap reached (%s)", status), nil
+		}
+	}
+	return "insufficient budget", nil
+}
+
+// formatUSD formats a dollar amount for display.
+func formatUSD(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
@@ -152,6 +152,7 @@ func computeStatsFromRuns(runs []reportRun) *stats.StatsResult {
 		if r.UsedBudget > 0 {
 			result.TotalTokensUsed += r.UsedBudget
 		}
+		result.TotalCostUSD += r.CostUSD
 
 		// Track projects per run
 		runProjects := make(map[string]struct{})
@@ -187,6 +188,8 @@ func computeStatsFromRuns(runs []reportRun) *stats.StatsResult {
 			if r.UsedBudget == 0 && task.TokensUsed > 0 {
 				result.TotalTokensUsed += task.TokensUsed
 			}
+
+			result.AddModelTokens(task.Models)
 		}
 
 		for name := range runProjects {
@@ -234,6 +237,7 @@ func computeStatsFromRuns(runs []reportRun) *stats.StatsResult {
 		if result.TotalTokensUsed > 0 {
 			result.AvgTokensPerRun = result.TotalTokensUsed / result.TotalRuns
 		}
+		result.AvgCostPerRun = result.TotalCostUSD / float64(result.TotalRuns)
 	}
 
 	// Success rate
@@ -292,6 +296,10 @@ func renderStatsHuman(result *stats.StatsResult) error {
 	if result.TotalRuns > 0 && result.AvgTokensPerRun > 0 {
 		fmt.Printf("  Avg per run:  %s tokens\n", formatTokens64(int64(result.AvgTokensPerRun)))
 	}
+	if result.TotalCostUSD > 0 {
+		fmt.Printf("  Total spend:  %s\n", formatUSD(result.TotalCostUSD))
+		fmt.Printf("  Avg per run:  %s\n", formatUSD(result.AvgCostPerRun))
+	}
 	fmt.Println()
 
 	// Budget Projection section
@@ -354,6 +362,25 @@ func renderStatsHuman(result *stats.StatsResult) error {
 		fmt.Println()
 	}
 
+	// Models section
+	if len(result.ModelBreakdown) > 0 {
+		fmt.Println("Models")
+		var total int64
+		models := make([]string, 0, len(result.ModelBreakdown))
+		for model, tokens := range result.ModelBreakdown {
+			total += tokens
+			models = append(models, model)
+		}
+		sort.Slice(models, func(i, j int) bool {
+			return result.ModelBreakdown[models[i]] > result.ModelBreakdown[models[j]]
+		})
+		for _, model := range models {
+			tokens := result.ModelBreakdown[model]
+			fmt.Printf("  %-28s %s tokens (%.0f%%)\n", model, formatTokens64(tokens), float64(tokens)/float64(total)*100)
+		}
+		fmt.Println()
+	}
+
 	// Task Types section
 	if len(result.TaskTypeBreakdown) > 0 {
 		fmt.Println("Task Types")
@@ -6,13 +6,18 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"text/tabwriter"
 	"time"
 
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
 	"github.com/marcus/nightshift/internal/logging"
 	"github.com/marcus/nightshift/internal/orchestrator"
+	"github.com/marcus/nightshift/internal/providers"
+	"github.com/marcus/nightshift/internal/state"
 	"github.com/marcus/nightshift/internal/tasks"
 	"github.com/spf13/cobra"
 )
@@ -82,7 +87,11 @@ func runTaskList(cmd *cobra.Command, args []string) error {
 	costFilter, _ := cmd.Flags().GetString("cost")
 	asJSON, _ := cmd.Flags().GetBool("json")
 
-	defs := tasks.AllDefinitionsSorted()
+	project, err := loadTaskRegistry("")
+	if err != nil {
+		return err
+	}
+	defs := tasks.ProjectDefinitionsSorted(project)
 
 	if categoryFilter != "" {
 		cat, err := parseCategoryFilter(categoryFilter)
@@ -105,18 +114,18 @@ func runTaskList(cmd *cobra.Command, args []string) error {
 	}
 
 	if asJSON {
-		return printTaskListJSON(defs)
+		return printTaskListJSON(project, defs)
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	_, _ = fmt.Fprintln(w, "TYPE\tNAME\tCATEGORY\tCOST\tTOKENS\tRISK")
+	_, _ = fmt.Fprintln(w, "TYPE\tNAME\tCATEGORY\tCOST\tTOKENS\tRISK\tSOURCE")
 	for _, d := range defs {
 		min, max := d.EstimatedTokens()
 		typeStr := string(d.Type)
-		if tasks.IsCustom(d.Type) {
+		if isCustomTask(project, d.Type) {
 			typeStr += " [custom]"
 		}
-		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s-%s\t%s\n",
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s-%s\t%s\t%s\n",
 			typeStr,
 			d.Name,
 			categoryShort(d.Category),
@@ -124,6 +133,7 @@ func runTaskList(cmd *cobra.Command, args []string) error {
 			formatK(min),
 			formatK(max),
 			d.RiskLevel,
+			taskSource(project, d.Type),
 		)
 	}
 	_ = w.Flush()
@@ -137,7 +147,11 @@ func runTaskShow(cmd *cobra.Command, args []string) error {
 	asJSON, _ := cmd.Flags().GetBool("json")
 	projectPath, _ := cmd.Flags().GetString("project")
 
-	def, err := tasks.GetDefinition(taskType)
+	project, err := loadTaskRegistry(projectPath)
+	if err != nil {
+		return err
+	}
+	def, err := tasks.ProjectDefinition(project, taskType)
 	if err != nil {
 		return fmt.Errorf("unknown task: %s\nRun 'nightshift task list' to see available tasks", taskType)
 	}
@@ -153,7 +167,7 @@ func runTaskShow(cmd *cobra.Command, args []string) error {
 	}
 
 	if asJSON {
-		return printTaskShowJSON(def, prompt)
+		return printTaskShowJSON(project, def, prompt)
 	}
 
 	min, max := def.EstimatedTokens()
@@ -163,9 +177,30 @@ func runTaskShow(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Cost:        %s\n", def.CostTier)
 	fmt.Printf("Tokens:      %s - %s\n", formatK(min), formatK(max))
 	fmt.Printf("Risk:        %s\n", def.RiskLevel)
-	if tasks.IsCustom(def.Type) {
+	if isCustomTask(project, def.Type) {
 		fmt.Printf("Custom:      yes\n")
 	}
+	fmt.Printf("Source:      %s\n", taskSource(project, def.Type))
+	if len(def.After) > 0 {
+		fmt.Printf("After:       %s\n", joinTaskTypes(def.After))
+	}
+	if len(def.Consumes) > 0 {
+		fmt.Printf("Consumes:    %s\n", joinTaskTypes(def.Consumes))
+	}
+	for i, c := range def.PreCommands {
+		label := "Pre-command: "
+		if i > 0 {
+			label = "             "
+		}
+		line := c.Command
+		if c.SkipIf != "" {
+			line += fmt.Sprintf(" (skip if %s)", c.SkipIf)
+		}
+		if !c.Requires.IsZero() {
+			line += " [only in matching repos]"
+		}
+		fmt.Printf("%s%s\n", label, line)
+	}
 	fmt.Printf("Description: %s\n", def.Description)
 	fmt.Println()
 	fmt.Println("--- Planning Prompt ---")
@@ -181,7 +216,11 @@ func runTaskRun(cmd *cobra.Command, args []string) error {
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
 
-	def, err := tasks.GetDefinition(taskType)
+	project, err := loadTaskRegistry(projectPath)
+	if err != nil {
+		return err
+	}
+	def, err := tasks.ProjectDefinition(project, taskType)
 	if err != nil {
 		return fmt.Errorf("unknown task: %s\nRun 'nightshift task list' to see available tasks", taskType)
 	}
@@ -204,7 +243,12 @@ func runTaskRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("load config: %w", err)
 	}
 
-	agent, err := agentByName(cfg, provider)
+	var sandboxed []string
+	if abs, err := filepath.Abs(projectPath); err == nil {
+		sandboxed = []string{abs}
+	}
+
+	agent, err := agentByName(cfg, provider, sandboxed)
 	if err != nil {
 		return err
 	}
@@ -217,6 +261,9 @@ func runTaskRun(cmd *cobra.Command, args []string) error {
 		}),
 		orchestrator.WithLogger(logging.Component("task-run")),
 	)
+	if abs, err := filepath.Abs(projectPath); err == nil {
+		orch.SetScope(pathScopesFor(cfg, abs, def.Type), cfg.ScopeViolationPolicy())
+	}
 
 	prompt := orch.PlanPrompt(taskInstance)
 
@@ -250,11 +297,34 @@ func runTaskRun(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
+	// A task requested by name runs even when its pre-commands are clean
+	if len(def.PreCommands) > 0 {
+		selector := tasks.NewSelector(cfg, nil)
+		sandboxPreCommands(cfg, selector, sandboxed)
+		analysis := selector.RunPreCommands(ctx, def, projectPath)
+		if len(analysis.Results) > 0 && analysis.Results[0].Untrusted() {
+			fmt.Printf("Pre-commands not run: %v\n", tasks.ErrUntrustedPreCommand)
+		}
+		if analysis.NothingToDo() {
+			fmt.Println("Pre-commands report nothing to do; running anyway")
+		}
+		taskInstance.Evidence = analysis.Evidence()
+	}
+
 	result, err := orch.RunTask(ctx, taskInstance, projectPath)
 	if err != nil {
 		return fmt.Errorf("task failed: %w", err)
 	}
 
+	// Record usage like scheduled runs so budgets count it
+	if database, err := db.Open(cfg.ExpandedDBPath()); err == nil {
+		st, _ := state.New(database)
+		prices := providers.NewPriceTable(cfg, providers.FromConfig(cfg))
+		_, maxTok := def.EstimatedTokens()
+		recordLedger(st, prices, agent.Name(), projectPath, taskInstance.ID, string(def.Type), result.Invocations, maxTok, logging.Component("task-run"))
+		_ = database.Close()
+	}
+
 	fmt.Println()
 	switch result.Status {
 	case orchestrator.StatusCompleted:
@@ -275,6 +345,35 @@ func runTaskRun(cmd *cobra.Command, args []string) error {
 }
 
 // taskInstanceFromDef creates a tasks.Task from a TaskDefinition for prompt building.
+// loadTaskRegistry registers the custom tasks from config and task files,
+// including those of the project at projectPath (default: the current
+// directory), and returns the project's absolute path.
+func loadTaskRegistry(projectPath string) (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", fmt.Errorf("loading config: %w", err)
+	}
+	if projectPath == "" {
+		if projectPath, err = os.Getwd(); err != nil {
+			return "", fmt.Errorf("get working directory: %w", err)
+		}
+	}
+	abs, err := filepath.Abs(expandPath(projectPath))
+	if err != nil {
+		return "", fmt.Errorf("resolve project: %w", err)
+	}
+	return abs, registerCustomTasks(cfg, []string{abs})
+}
+
+// joinTaskTypes formats task types as a comma-separated list.
+func joinTaskTypes(types []tasks.TaskType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
 func taskInstanceFromDef(def tasks.TaskDefinition, projectPath string) *tasks.Task {
 	id := string(def.Type)
 	if projectPath != "" {
@@ -292,37 +391,11 @@ func taskInstanceFromDef(def tasks.TaskDefinition, projectPath string) *tasks.Ta
 // --- Filters ---
 
 func parseCategoryFilter(s string) (tasks.TaskCategory, error) {
-	switch strings.ToLower(s) {
-	case "pr":
-		return tasks.CategoryPR, nil
-	case "analysis":
-		return tasks.CategoryAnalysis, nil
-	case "options":
-		return tasks.CategoryOptions, nil
-	case "safe":
-		return tasks.CategorySafe, nil
-	case "map":
-		return tasks.CategoryMap, nil
-	case "emergency":
-		return tasks.CategoryEmergency, nil
-	default:
-		return 0, fmt.Errorf("unknown category: %s (valid: pr, analysis, options, safe, map, emergency)", s)
-	}
+	return tasks.ParseCategory(s)
 }
 
 func parseCostFilter(s string) (tasks.CostTier, error) {
-	switch strings.ToLower(s) {
-	case "low":
-		return tasks.CostLow, nil
-	case "medium":
-		return tasks.CostMedium, nil
-	case "high":
-		return tasks.CostHigh, nil
-	case "veryhigh":
-		return tasks.CostVeryHigh, nil
-	default:
-		return 0, fmt.Errorf("unknown cost tier: %s (valid: low, medium, high, veryhigh)", s)
-	}
+	return tasks.ParseCostTier(s)
 }
 
 func filterByCategory(defs []tasks.TaskDefinition, cat tasks.TaskCategory) []tasks.TaskDefinition {
@@ -403,9 +476,10 @@ type taskListEntry struct {
 	MaxTokens   int    `json:"max_tokens"`
 	Risk        string `json:"risk"`
 	Custom      bool   `json:"custom"`
+	Source      string `json:"source"`
 }
 
-func printTaskListJSON(defs []tasks.TaskDefinition) error {
+func printTaskListJSON(project string, defs []tasks.TaskDefinition) error {
 	entries := make([]taskListEntry, len(defs))
 	for i, d := range defs {
 		min, max := d.EstimatedTokens()
@@ -418,7 +492,8 @@ func printTaskListJSON(defs []tasks.TaskDefinition) error {
 			MinTokens:   min,
 			MaxTokens:   max,
 			Risk:        d.RiskLevel.String(),
-			Custom:      tasks.IsCustom(d.Type),
+			Custom:      isCustomTask(project, d.Type),
+			Source:      taskSource(project, d.Type),
 		}
 	}
 	enc := json.NewEncoder(os.Stdout)
@@ -436,10 +511,11 @@ type taskShowEntry struct {
 	MaxTokens   int    `json:"max_tokens"`
 	Risk        string `json:"risk"`
 	Custom      bool   `json:"custom"`
+	Source      string `json:"source"`
 	Prompt      string `json:"prompt"`
 }
 
-func printTaskShowJSON(def tasks.TaskDefinition, prompt string) error {
+func printTaskShowJSON(project string, def tasks.TaskDefinition, prompt string) error {
 	min, max := def.EstimatedTokens()
 	entry := taskShowEntry{
 		Type:        string(def.Type),
@@ -450,7 +526,8 @@ func printTaskShowJSON(def tasks.TaskDefinition, prompt string) error {
 		MinTokens:   min,
 		MaxTokens:   max,
 		Risk:        def.RiskLevel.String(),
-		Custom:      tasks.IsCustom(def.Type),
+		Custom:      isCustomTask(project, def.Type),
+		Source:      taskSource(project, def.Type),
 		Prompt:      prompt,
 	}
 	enc := json.NewEncoder(os.Stdout)
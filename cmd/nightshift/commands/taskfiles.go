@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// registerCustomTasks replaces the registered custom tasks with those in
+// config and the markdown task files: the global tasks directory and each
+// project's .nightshift/tasks. Files are re-read on every call, so edits
+// apply from the next run on. Any invalid file is an error, for the
+// commands that list and check tasks; runs use registerRunTasks.
+func registerCustomTasks(cfg *config.Config, projects []string) error {
+	tasks.ClearCustom()
+	if err := tasks.RegisterCustomTasksFromConfig(cfg.Tasks.Custom); err != nil {
+		return fmt.Errorf("register custom tasks: %w", err)
+	}
+	files, err := tasks.LoadTaskFiles(config.GlobalTasksDir(), projects)
+	if err != nil {
+		return fmt.Errorf("load task files:\n%w", err)
+	}
+	if err := tasks.RegisterTaskFiles(files); err != nil {
+		return fmt.Errorf("register task files: %w", err)
+	}
+	return tasks.ValidateChains()
+}
+
+// registerRunTasks registers custom tasks like registerCustomTasks, but
+// logs invalid task files and broken chains instead of failing, so one bad
+// file, perhaps in a project's own repo, does not stop the whole run. The
+// valid files are registered.
+func registerRunTasks(cfg *config.Config, projects []string, log *logging.Logger) error {
+	tasks.ClearCustom()
+	if err := tasks.RegisterCustomTasksFromConfig(cfg.Tasks.Custom); err != nil {
+		return fmt.Errorf("register custom tasks: %w", err)
+	}
+	files, err := tasks.LoadTaskFiles(config.GlobalTasksDir(), projects)
+	warnEach(err, "skipping task file", log)
+	for _, f := range files {
+		// One at a time, so a duplicate type skips only its own file
+		if err := tasks.RegisterTaskFiles([]tasks.TaskFile{f}); err != nil {
+			warnEach(err, "skipping task file", log)
+		}
+	}
+	warnEach(tasks.ValidateChains(), "task chains", log)
+	return nil
+}
+
+// warnEach logs each error joined into err with the given prefix.
+func warnEach(err error, prefix string, log *logging.Logger) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			warnEach(e, prefix, log)
+		}
+		return
+	}
+	if err != nil {
+		log.Warnf("%s: %v", prefix, err)
+	}
+}
+
+// isCustomTask reports whether taskType, as seen from project, comes from
+// config or a task file rather than being built in.
+func isCustomTask(project string, taskType tasks.TaskType) bool {
+	return tasks.IsCustom(taskType) || tasks.SourceFile(project, taskType) != ""
+}
+
+// taskDefinedIn reports whether taskType is defined for any of projects.
+func taskDefinedIn(projects []string, taskType tasks.TaskType) bool {
+	return slices.ContainsFunc(projects, func(project string) bool {
+		_, err := tasks.ProjectDefinition(project, taskType)
+		return err == nil
+	})
+}
+
+// taskSource describes where a task is defined for project: "built-in",
+// "config", or its markdown file with the home directory shortened to ~.
+func taskSource(project string, taskType tasks.TaskType) string {
+	if path := tasks.SourceFile(project, taskType); path != "" {
+		if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, home+string(os.PathSeparator)) {
+			return "~" + path[len(home):]
+		}
+		return path
+	}
+	if tasks.IsCustom(taskType) {
+		return "config"
+	}
+	return "built-in"
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+func TestRegisterRunTasks_SkipsInvalidFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	dir := filepath.Join(project, tasks.ProjectTasksDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"good-check.md": "---\nname: Good check\n---\nCheck things.\n",
+		"bad-check.md":  "---\ncategory: docs\n---\nBroken.\n",
+		"after-bad.md":  "---\nafter: [bad-check]\n---\nRuns after a broken task.\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(tasks.ClearCustom)
+
+	cfg := &config.Config{}
+	if err := registerCustomTasks(cfg, []string{project}); err == nil {
+		t.Error("registerCustomTasks accepted an invalid task file")
+	}
+	if err := registerRunTasks(cfg, []string{project}, logging.Component("test")); err != nil {
+		t.Fatalf("registerRunTasks: %v", err)
+	}
+	for _, taskType := range []tasks.TaskType{"good-check", "after-bad"} {
+		if tasks.SourceFile(project, taskType) == "" {
+			t.Errorf("valid task file %s not registered", taskType)
+		}
+	}
+	if tasks.SourceFile(project, "bad-check") != "" {
+		t.Error("invalid task file registered")
+	}
+}
+
+func TestRegisterRunTasks_SameTypeInTwoProjects(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	a, b := t.TempDir(), t.TempDir()
+	for _, project := range []string{a, b} {
+		dir := filepath.Join(project, tasks.ProjectTasksDir)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		content := "---\nname: Triage " + filepath.Base(project) + "\n---\nTriage.\n"
+		if err := os.WriteFile(filepath.Join(dir, "triage.md"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(tasks.ClearCustom)
+
+	if err := registerCustomTasks(&config.Config{}, []string{a, b}); err != nil {
+		t.Fatalf("registerCustomTasks: %v", err)
+	}
+	for _, project := range []string{a, b} {
+		def, err := tasks.ProjectDefinition(project, "triage")
+		if err != nil || def.Name != "Triage "+filepath.Base(project) {
+			t.Errorf("%s: triage = %q, %v", project, def.Name, err)
+		}
+		if got := taskSource(project, "triage"); got != filepath.Join(project, tasks.ProjectTasksDir, "triage.md") {
+			t.Errorf("%s: source = %q", project, got)
+		}
+	}
+}
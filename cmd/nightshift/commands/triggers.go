@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/triggers"
+)
+
+// triggerScheduleName labels commit-triggered runs in logs.
+const triggerScheduleName = "commits"
+
+// commitTriggers runs change-sensitive tasks (schedule.triggers.tasks) on a
+// project after new commits land on its main branch. Runs wait for the
+// schedule window and never overlap other daemon runs; commits arriving
+// before a queued run starts are folded into it.
+type commitTriggers struct {
+	cfg      *config.Config
+	database *db.DB
+	log      *logging.Logger
+	sched    *scheduler.Scheduler
+
+	mu      sync.Mutex
+	pending map[string]triggers.Change // project -> commits not run yet
+}
+
+// startCommitTriggers watches every configured project until ctx is done.
+// Returns nil when schedule.triggers is disabled.
+func startCommitTriggers(ctx context.Context, cfg *config.Config, database *db.DB, log *logging.Logger, runLock *sync.Mutex) (*commitTriggers, error) {
+	tc := cfg.Schedule.Triggers
+	if !tc.Enabled {
+		return nil, nil
+	}
+
+	sched := scheduler.New()
+	if cfg.Schedule.Window != nil {
+		if err := sched.SetWindow(cfg.Schedule.Window); err != nil {
+			return nil, fmt.Errorf("triggers window: %w", err)
+		}
+	}
+	sched.SetRunLock(runLock)
+	t := &commitTriggers{
+		cfg:      cfg,
+		database: database,
+		log:      log,
+		sched:    sched,
+		pending:  make(map[string]triggers.Change),
+	}
+
+	debounce := durationOr(tc.Debounce, config.DefaultTriggerDebounce)
+	watcher, err := triggers.New(debounce, tc.Branch, func(c triggers.Change) { t.queue(ctx, c) })
+	if err != nil {
+		return nil, err
+	}
+	projects, err := resolveProjects(cfg, "")
+	if err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("resolve projects: %w", err)
+	}
+	watched := 0
+	for _, project := range projects {
+		if err := watcher.Add(project); err != nil {
+			log.Warnf("triggers: %v", err)
+			continue
+		}
+		watched++
+	}
+	go watcher.Run(ctx)
+	log.Infof("watching %d project(s) for new commits", watched)
+	return t, nil
+}
+
+// queue schedules a run for the change, or merges it into the run already
+// queued for the project.
+func (t *commitTriggers) queue(ctx context.Context, c triggers.Change) {
+	t.mu.Lock()
+	if prev, ok := t.pending[c.Project]; ok {
+		t.pending[c.Project] = prev.Merge(c)
+		t.mu.Unlock()
+		return
+	}
+	t.pending[c.Project] = c
+	t.mu.Unlock()
+
+	at := t.sched.NextWindowStart(time.Now())
+	t.log.InfoCtx("commits queued", map[string]any{
+		"project": c.Project,
+		"range":   c.Range(),
+		"run_at":  at.Format(time.RFC3339),
+	})
+	t.sched.ScheduleOnce(ctx, at, func(runCtx context.Context) error {
+		return t.run(runCtx, c.Project)
+	}, func() { t.drop(c.Project) })
+}
+
+// drop forgets the project's pending commits when their run was skipped,
+// so the next commit queues a new run.
+func (t *commitTriggers) drop(project string) {
+	t.mu.Lock()
+	change, ok := t.pending[project]
+	delete(t.pending, project)
+	t.mu.Unlock()
+	if ok {
+		t.log.InfoCtx("queued commits dropped: run skipped outside the window", map[string]any{
+			"project": project,
+			"range":   change.Range(),
+		})
+	}
+}
+
+// run executes the trigger tasks for the project's pending commits.
+func (t *commitTriggers) run(ctx context.Context, project string) error {
+	t.mu.Lock()
+	change, ok := t.pending[project]
+	delete(t.pending, project)
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	tasks := t.cfg.Schedule.Triggers.Tasks
+	if len(tasks) == 0 {
+		tasks = config.DefaultTriggerTasks
+	}
+	schedule := config.NamedSchedule{
+		Name:     triggerScheduleName,
+		Window:   t.cfg.Schedule.Window,
+		Tasks:    tasks,
+		Projects: []string{project},
+	}
+	return runScheduledTasks(ctx, t.cfg, t.database, t.log, daemonRun{schedule: &schedule, change: &change})
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/logging"
+	"github.com/marcus/nightshift/internal/scheduler"
+	"github.com/marcus/nightshift/internal/triggers"
+)
+
+func TestCommitTriggers_MergesQueuedChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A window that never opens today keeps the run queued
+	sched := scheduler.New()
+	ct := &commitTriggers{
+		cfg:     &config.Config{},
+		log:     logging.Component("test"),
+		sched:   sched,
+		pending: make(map[string]triggers.Change),
+	}
+	if err := sched.SetWindow(&config.WindowConfig{Start: "00:00", End: "00:00"}); err != nil {
+		t.Fatalf("SetWindow: %v", err)
+	}
+
+	ct.queue(ctx, triggers.Change{Project: "/p", Branch: "main", From: "a", To: "b"})
+	ct.queue(ctx, triggers.Change{Project: "/p", Branch: "main", From: "b", To: "c"})
+
+	if got := len(sched.PendingOnce()); got != 1 {
+		t.Fatalf("expected one queued run, got %d", got)
+	}
+	if got := ct.pending["/p"].Range(); got != "a..c" {
+		t.Errorf("pending range = %q, want a..c", got)
+	}
+}
+
+func TestCommitTriggers_SkippedRunClearsPending(t *testing.T) {
+	// A run fired after ctx is done is skipped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ct := &commitTriggers{
+		cfg:     &config.Config{},
+		log:     logging.Component("test"),
+		sched:   scheduler.New(),
+		pending: make(map[string]triggers.Change),
+	}
+	ct.queue(ctx, triggers.Change{Project: "/p", Branch: "main", From: "a", To: "b"})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		ct.mu.Lock()
+		_, ok := ct.pending["/p"]
+		ct.mu.Unlock()
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("pending entry kept after the run was skipped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
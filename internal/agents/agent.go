@@ -34,6 +34,44 @@ type ExecuteResult struct {
 	ExitCode int           // Process exit code
 	Duration time.Duration // Execution duration
 	Error    string        // Error message if failed
+	Usage    []TokenUsage  // Per-model token usage reported by the CLI, if any
+
+	SessionID string // CLI session the call ran in, if reported
+}
+
+// TokenUsage is the token breakdown for one model in an agent call.
+type TokenUsage struct {
+	Model            string `json:"model"`
+	InputTokens      int64  `json:"input_tokens"`       // Uncached input
+	OutputTokens     int64  `json:"output_tokens"`      // Output, including reasoning
+	CacheReadTokens  int64  `json:"cache_read_tokens"`  // Input served from cache
+	CacheWriteTokens int64  `json:"cache_write_tokens"` // Input written to cache
+}
+
+// TotalTokens returns the sum of all token fields.
+func (u TokenUsage) TotalTokens() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// MergeUsage adds the usage in src to dst, combining entries by model.
+func MergeUsage(dst, src []TokenUsage) []TokenUsage {
+	for _, u := range src {
+		merged := false
+		for i := range dst {
+			if dst[i].Model == u.Model {
+				dst[i].InputTokens += u.InputTokens
+				dst[i].OutputTokens += u.OutputTokens
+				dst[i].CacheReadTokens += u.CacheReadTokens
+				dst[i].CacheWriteTokens += u.CacheWriteTokens
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst = append(dst, u)
+		}
+	}
+	return dst
 }
 
 // IsSuccess returns true if the execution succeeded.
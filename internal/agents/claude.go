@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -119,7 +120,7 @@ func (a *ClaudeAgent) Execute(ctx context.Context, opts ExecuteOptions) (*Execut
 	defer cancel()
 
 	// Build command args
-	args := []string{"--print"}
+	args := []string{"--print", "--output-format", "json"}
 	if a.skipPerms {
 		args = append(args, "--dangerously-skip-permissions")
 	}
@@ -145,10 +146,12 @@ func (a *ClaudeAgent) Execute(ctx context.Context, opts ExecuteOptions) (*Execut
 	// Run command
 	stdout, stderr, exitCode, err := a.runner.Run(ctx, a.binaryPath, args, opts.WorkDir, stdinContent)
 
+	output, usage := unwrapClaudeOutput(stdout)
 	result := &ExecuteResult{
-		Output:   stdout,
+		Output:   output,
 		ExitCode: exitCode,
 		Duration: time.Since(start),
+		Usage:    usage,
 	}
 
 	// Check for context timeout
@@ -170,11 +173,70 @@ func (a *ClaudeAgent) Execute(ctx context.Context, opts ExecuteOptions) (*Execut
 	}
 
 	// Try to parse JSON output
-	result.JSON = a.extractJSON([]byte(stdout))
+	result.JSON = a.extractJSON([]byte(output))
 
 	return result, nil
 }
 
+// claudeJSONOutput is the result object printed by
+// `claude --print --output-format json`.
+type claudeJSONOutput struct {
+	Type       string                     `json:"type"`
+	Result     string                     `json:"result"`
+	Usage      *claudeUsage               `json:"usage"`
+	ModelUsage map[string]claudeModelStat `json:"modelUsage"`
+}
+
+type claudeUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+}
+
+type claudeModelStat struct {
+	InputTokens              int64 `json:"inputTokens"`
+	OutputTokens             int64 `json:"outputTokens"`
+	CacheReadInputTokens     int64 `json:"cacheReadInputTokens"`
+	CacheCreationInputTokens int64 `json:"cacheCreationInputTokens"`
+}
+
+// unwrapClaudeOutput returns the result text and per-model usage from the
+// JSON result object. Other output is returned as is.
+func unwrapClaudeOutput(stdout string) (string, []TokenUsage) {
+	var out claudeJSONOutput
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stdout)), &out); err != nil || out.Type != "result" {
+		return stdout, nil
+	}
+
+	var usage []TokenUsage
+	if len(out.ModelUsage) > 0 {
+		models := make([]string, 0, len(out.ModelUsage))
+		for model := range out.ModelUsage {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		for _, model := range models {
+			m := out.ModelUsage[model]
+			usage = append(usage, TokenUsage{
+				Model:            model,
+				InputTokens:      m.InputTokens,
+				OutputTokens:     m.OutputTokens,
+				CacheReadTokens:  m.CacheReadInputTokens,
+				CacheWriteTokens: m.CacheCreationInputTokens,
+			})
+		}
+	} else if out.Usage != nil {
+		usage = append(usage, TokenUsage{
+			InputTokens:      out.Usage.InputTokens,
+			OutputTokens:     out.Usage.OutputTokens,
+			CacheReadTokens:  out.Usage.CacheReadInputTokens,
+			CacheWriteTokens: out.Usage.CacheCreationInputTokens,
+		})
+	}
+	return out.Result, usage
+}
+
 // ExecuteWithFiles runs claude with file context included.
 func (a *ClaudeAgent) ExecuteWithFiles(ctx context.Context, prompt string, files []string, workDir string) (*ExecuteResult, error) {
 	return a.Execute(ctx, ExecuteOptions{
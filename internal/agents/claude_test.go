@@ -111,14 +111,63 @@ func TestClaudeAgent_Execute_Success(t *testing.T) {
 	if mock.CapturedName != "claude" {
 		t.Errorf("binary = %q, want %q", mock.CapturedName, "claude")
 	}
-	if len(mock.CapturedArgs) != 3 || mock.CapturedArgs[0] != "--print" || mock.CapturedArgs[1] != "--dangerously-skip-permissions" || mock.CapturedArgs[2] != "fix the bug" {
-		t.Errorf("args = %v, want [--print --dangerously-skip-permissions fix the bug]", mock.CapturedArgs)
+	if len(mock.CapturedArgs) != 5 || mock.CapturedArgs[0] != "--print" || mock.CapturedArgs[1] != "--output-format" || mock.CapturedArgs[2] != "json" || mock.CapturedArgs[3] != "--dangerously-skip-permissions" || mock.CapturedArgs[4] != "fix the bug" {
+		t.Errorf("args = %v, want [--print --output-format json --dangerously-skip-permissions fix the bug]", mock.CapturedArgs)
 	}
 	if mock.CapturedDir != "/project" {
 		t.Errorf("dir = %q, want %q", mock.CapturedDir, "/project")
 	}
 }
 
+func TestClaudeAgent_Execute_ResultUsage(t *testing.T) {
+	mock := &MockRunner{
+		Stdout: `{"type":"result","subtype":"success","result":"{\"passed\":true}","usage":{"input_tokens":1,"output_tokens":2},` +
+			`"modelUsage":{"claude-sonnet-4-5":{"inputTokens":100,"outputTokens":50,"cacheReadInputTokens":1000,"cacheCreationInputTokens":200},` +
+			`"claude-haiku-4-5":{"inputTokens":10,"outputTokens":5}}}`,
+		ExitCode: 0,
+	}
+	agent := NewClaudeAgent(WithRunner(mock))
+
+	result, err := agent.Execute(context.Background(), ExecuteOptions{Prompt: "review"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Output != `{"passed":true}` {
+		t.Errorf("Output = %q", result.Output)
+	}
+	if string(result.JSON) != `{"passed":true}` {
+		t.Errorf("JSON = %s", result.JSON)
+	}
+	want := []TokenUsage{
+		{Model: "claude-haiku-4-5", InputTokens: 10, OutputTokens: 5},
+		{Model: "claude-sonnet-4-5", InputTokens: 100, OutputTokens: 50, CacheReadTokens: 1000, CacheWriteTokens: 200},
+	}
+	if len(result.Usage) != len(want) {
+		t.Fatalf("Usage = %+v, want %+v", result.Usage, want)
+	}
+	for i := range want {
+		if result.Usage[i] != want[i] {
+			t.Errorf("Usage[%d] = %+v, want %+v", i, result.Usage[i], want[i])
+		}
+	}
+}
+
+func TestMergeUsage(t *testing.T) {
+	got := MergeUsage(
+		[]TokenUsage{{Model: "a", InputTokens: 1, OutputTokens: 2}},
+		[]TokenUsage{{Model: "a", InputTokens: 3, CacheReadTokens: 4}, {Model: "b", OutputTokens: 5}},
+	)
+	if len(got) != 2 {
+		t.Fatalf("len = %d, want 2", len(got))
+	}
+	if got[0] != (TokenUsage{Model: "a", InputTokens: 4, OutputTokens: 2, CacheReadTokens: 4}) {
+		t.Errorf("merged a = %+v", got[0])
+	}
+	if got[1].TotalTokens() != 5 {
+		t.Errorf("b total = %d, want 5", got[1].TotalTokens())
+	}
+}
+
 func TestClaudeAgent_Execute_JSONOutput(t *testing.T) {
 	mock := &MockRunner{
 		Stdout:   `{"status":"success","files_changed":3}`,
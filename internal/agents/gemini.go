@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -20,6 +21,33 @@ type GeminiAgent struct {
 	yolo       bool          // Pass --yolo to bypass confirmations
 }
 
+// GeminiStats is the stats block reported by `gemini --output-format json`.
+type GeminiStats struct {
+	Models map[string]GeminiModelStats `json:"models"`
+}
+
+// GeminiModelStats holds per-model stats from the JSON output.
+type GeminiModelStats struct {
+	Tokens GeminiTokenStats `json:"tokens"`
+}
+
+// GeminiTokenStats holds per-model token counts from the JSON output.
+type GeminiTokenStats struct {
+	Prompt     int64 `json:"prompt"`
+	Candidates int64 `json:"candidates"`
+	Total      int64 `json:"total"`
+	Cached     int64 `json:"cached"`
+	Thoughts   int64 `json:"thoughts"`
+	Tool       int64 `json:"tool"`
+}
+
+// geminiJSONOutput is the envelope printed by `gemini --output-format json`.
+type geminiJSONOutput struct {
+	SessionID string       `json:"session_id"`
+	Response  string       `json:"response"`
+	Stats     *GeminiStats `json:"stats"`
+}
+
 // GeminiOption configures a GeminiAgent.
 type GeminiOption func(*GeminiAgent)
 
@@ -89,7 +117,7 @@ func (a *GeminiAgent) Execute(ctx context.Context, opts ExecuteOptions) (*Execut
 	if a.yolo {
 		args = append(args, "--yolo")
 	}
-	args = append(args, "--output-format", "text")
+	args = append(args, "--output-format", "json")
 
 	// Build stdin content from files if provided
 	var stdinContent string
@@ -107,10 +135,13 @@ func (a *GeminiAgent) Execute(ctx context.Context, opts ExecuteOptions) (*Execut
 	// Run command
 	stdout, stderr, exitCode, err := a.runner.Run(ctx, a.binaryPath, args, opts.WorkDir, stdinContent)
 
+	output, sessionID, usage := a.unwrapOutput(stdout)
 	result := &ExecuteResult{
-		Output:   stdout,
-		ExitCode: exitCode,
-		Duration: time.Since(start),
+		Output:    output,
+		ExitCode:  exitCode,
+		Duration:  time.Since(start),
+		Usage:     usage,
+		SessionID: sessionID,
 	}
 
 	// Check for context timeout
@@ -132,11 +163,47 @@ func (a *GeminiAgent) Execute(ctx context.Context, opts ExecuteOptions) (*Execut
 	}
 
 	// Try to parse JSON output
-	result.JSON = a.extractJSON([]byte(stdout))
+	result.JSON = a.extractJSON([]byte(result.Output))
 
 	return result, nil
 }
 
+// unwrapOutput returns the response text, session ID and usage from the
+// JSON envelope. Output that is not an envelope (older CLIs) is returned as
+// is.
+func (a *GeminiAgent) unwrapOutput(stdout string) (string, string, []TokenUsage) {
+	var out geminiJSONOutput
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stdout)), &out); err != nil || out.Stats == nil {
+		return stdout, "", nil
+	}
+	return out.Response, out.SessionID, out.Stats.Usage()
+}
+
+// Usage converts the stats to per-model token usage. Gemini counts cached
+// and tool-use tokens inside the prompt and bills thoughts as output.
+func (s *GeminiStats) Usage() []TokenUsage {
+	if s == nil {
+		return nil
+	}
+	models := make([]string, 0, len(s.Models))
+	for model := range s.Models {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	usage := make([]TokenUsage, 0, len(models))
+	for _, model := range models {
+		t := s.Models[model].Tokens
+		usage = append(usage, TokenUsage{
+			Model:           model,
+			InputTokens:     max(t.Prompt-t.Cached, 0) + t.Tool,
+			OutputTokens:    t.Candidates + t.Thoughts,
+			CacheReadTokens: t.Cached,
+		})
+	}
+	return usage
+}
+
 // buildFileContext reads files and formats them as context.
 func (a *GeminiAgent) buildFileContext(files []string) (string, error) {
 	var sb strings.Builder
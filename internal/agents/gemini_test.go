@@ -86,7 +86,7 @@ func TestGeminiAgent_Execute_Success(t *testing.T) {
 	if mock.CapturedName != "gemini" {
 		t.Errorf("binary = %q, want %q", mock.CapturedName, "gemini")
 	}
-	wantArgs := []string{"-p", "fix the bug", "--yolo", "--output-format", "text"}
+	wantArgs := []string{"-p", "fix the bug", "--yolo", "--output-format", "json"}
 	if len(mock.CapturedArgs) != len(wantArgs) {
 		t.Fatalf("args = %v, want %v", mock.CapturedArgs, wantArgs)
 	}
@@ -275,3 +275,26 @@ func TestGeminiAgent_Version(t *testing.T) {
 func TestGeminiAgent_ImplementsAgentInterface(t *testing.T) {
 	var _ Agent = (*GeminiAgent)(nil)
 }
+
+func TestGeminiAgent_Execute_JSONEnvelope(t *testing.T) {
+	mock := &MockRunner{
+		Stdout:   `{"session_id":"abc","response":"all done","stats":{"models":{"gemini-2.5-pro":{"tokens":{"prompt":12,"candidates":8,"total":20}}}}}`,
+		ExitCode: 0,
+	}
+	agent := NewGeminiAgent(WithGeminiRunner(mock))
+
+	result, err := agent.Execute(context.Background(), ExecuteOptions{Prompt: "task"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Output != "all done" {
+		t.Errorf("Output = %q, want %q", result.Output, "all done")
+	}
+	if result.SessionID != "abc" {
+		t.Errorf("SessionID = %q, want %q", result.SessionID, "abc")
+	}
+	want := TokenUsage{Model: "gemini-2.5-pro", InputTokens: 12, OutputTokens: 8}
+	if len(result.Usage) != 1 || result.Usage[0] != want {
+		t.Errorf("Usage = %+v, want [%+v]", result.Usage, want)
+	}
+}
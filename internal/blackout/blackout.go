@@ -0,0 +1,130 @@
+// Package blackout resolves periods (release freezes, holidays, on-call
+// weeks) during which scheduled runs are skipped or limited to analysis.
+package blackout
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+// Period is one blackout.
+type Period struct {
+	Name   string
+	Start  time.Time
+	End    time.Time // exclusive
+	Mode   string    // config.BlackoutSkip or config.BlackoutDowngrade
+	Source string    // "config" or the calendar file
+}
+
+// Contains reports whether t falls inside the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// String describes the period, e.g. "Release freeze (skip, Dec 15 - Jan 05)".
+func (p Period) String() string {
+	from, to := formatBound(p.Start), formatBound(p.End.Add(-time.Nanosecond))
+	if from == to {
+		return fmt.Sprintf("%s (%s, %s)", p.Name, p.Mode, from)
+	}
+	return fmt.Sprintf("%s (%s, %s - %s)", p.Name, p.Mode, from, to)
+}
+
+func formatBound(t time.Time) string {
+	t = t.Local()
+	if t.Hour() == 0 && t.Minute() == 0 || t.Hour() == 23 && t.Minute() == 59 {
+		return t.Format("Jan 02")
+	}
+	return t.Format("Jan 02 15:04")
+}
+
+// Calendar holds every configured blackout period.
+type Calendar struct {
+	Periods []Period
+}
+
+// Load reads blackout periods from config and its ICS calendars.
+func Load(cfg config.BlackoutConfig) (*Calendar, error) {
+	cal := &Calendar{}
+	for _, p := range cfg.Periods {
+		start, end, err := p.Bounds()
+		if err != nil {
+			return nil, err
+		}
+		name := p.Name
+		if name == "" {
+			name = "blackout"
+		}
+		cal.Periods = append(cal.Periods, Period{Name: name, Start: start, End: end, Mode: cfg.ModeFor(p.Mode), Source: "config"})
+	}
+	for _, c := range cfg.Calendars {
+		path := expandPath(c.Path)
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open blackout calendar: %w", err)
+		}
+		events, err := ParseICS(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, e := range events {
+			e.Mode = cfg.ModeFor(c.Mode)
+			e.Source = filepath.Base(path)
+			cal.Periods = append(cal.Periods, e)
+		}
+	}
+	sort.SliceStable(cal.Periods, func(i, j int) bool { return cal.Periods[i].Start.Before(cal.Periods[j].Start) })
+	return cal, nil
+}
+
+// Active returns the blackout covering t, or nil. When periods overlap,
+// skip wins over downgrade.
+func (c *Calendar) Active(t time.Time) *Period {
+	if c == nil {
+		return nil
+	}
+	var active *Period
+	for i := range c.Periods {
+		p := &c.Periods[i]
+		if !p.Contains(t) {
+			continue
+		}
+		if active == nil || (p.Mode == config.BlackoutSkip && active.Mode != config.BlackoutSkip) {
+			active = p
+		}
+	}
+	return active
+}
+
+// Upcoming returns periods that overlap [from, until), earliest first.
+func (c *Calendar) Upcoming(from, until time.Time) []Period {
+	if c == nil {
+		return nil
+	}
+	var out []Period
+	for _, p := range c.Periods {
+		if p.End.After(from) && p.Start.Before(until) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// expandPath expands ~ to home directory.
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
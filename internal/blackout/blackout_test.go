@@ -0,0 +1,112 @@
+package blackout
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Release\r\n" +
+	"  freeze\r\n" +
+	"DTSTART;VALUE=DATE:20261214\r\n" +
+	"DTEND;VALUE=DATE:20261216\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:On-call\\, primary\r\n" +
+	"DTSTART:20261220T080000Z\r\n" +
+	"DTEND:20261220T200000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20261225\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	periods, err := ParseICS(strings.NewReader(testICS))
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	if len(periods) != 3 {
+		t.Fatalf("expected 3 events, got %d: %+v", len(periods), periods)
+	}
+
+	freeze := periods[0]
+	if freeze.Name != "Release freeze" {
+		t.Errorf("folded summary not joined: %q", freeze.Name)
+	}
+	wantStart := time.Date(2026, 12, 14, 0, 0, 0, 0, time.Local)
+	wantEnd := time.Date(2026, 12, 16, 0, 0, 0, 0, time.Local)
+	if !freeze.Start.Equal(wantStart) || !freeze.End.Equal(wantEnd) {
+		t.Errorf("all-day bounds = %v - %v", freeze.Start, freeze.End)
+	}
+
+	oncall := periods[1]
+	if oncall.Name != "On-call, primary" {
+		t.Errorf("summary not unescaped: %q", oncall.Name)
+	}
+	if !oncall.Start.Equal(time.Date(2026, 12, 20, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("UTC start = %v", oncall.Start)
+	}
+
+	holiday := periods[2]
+	if holiday.End.Sub(holiday.Start) != 24*time.Hour {
+		t.Errorf("all-day event without DTEND should last a day, got %v", holiday.End.Sub(holiday.Start))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "freeze.ics")
+	if err := os.WriteFile(path, []byte(testICS), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cal, err := Load(config.BlackoutConfig{
+		Mode: config.BlackoutSkip,
+		Periods: []config.BlackoutPeriod{
+			{Name: "Winter break", Start: "2026-12-24", End: "2026-12-26", Mode: config.BlackoutDowngrade},
+		},
+		Calendars: []config.BlackoutCalendar{{Path: path}},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cal.Periods) != 4 {
+		t.Fatalf("expected 4 periods, got %d", len(cal.Periods))
+	}
+	if cal.Periods[0].Source != "freeze.ics" || cal.Periods[0].Mode != config.BlackoutSkip {
+		t.Errorf("calendar event should inherit the default mode: %+v", cal.Periods[0])
+	}
+
+	// The configured period and the calendar holiday overlap on the 25th
+	active := cal.Active(time.Date(2026, 12, 25, 12, 0, 0, 0, time.Local))
+	if active == nil || active.Name != "Holiday" || active.Mode != config.BlackoutSkip {
+		t.Errorf("skip should win over downgrade, got %+v", active)
+	}
+	active = cal.Active(time.Date(2026, 12, 26, 23, 0, 0, 0, time.Local))
+	if active == nil || active.Mode != config.BlackoutDowngrade {
+		t.Errorf("date-only end should cover the whole day, got %+v", active)
+	}
+	if cal.Active(time.Date(2026, 12, 27, 0, 0, 0, 0, time.Local)) != nil {
+		t.Error("expected no blackout after the last period")
+	}
+
+	upcoming := cal.Upcoming(time.Date(2026, 12, 15, 0, 0, 0, 0, time.Local), time.Date(2026, 12, 21, 0, 0, 0, 0, time.Local))
+	if len(upcoming) != 2 || upcoming[0].Name != "Release freeze" || upcoming[1].Name != "On-call, primary" {
+		t.Errorf("Upcoming = %+v", upcoming)
+	}
+}
+
+func TestLoad_MissingCalendar(t *testing.T) {
+	_, err := Load(config.BlackoutConfig{Calendars: []config.BlackoutCalendar{{Path: filepath.Join(t.TempDir(), "none.ics")}}})
+	if err == nil {
+		t.Error("expected error for a missing calendar file")
+	}
+}
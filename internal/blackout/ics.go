@@ -0,0 +1,131 @@
+package blackout
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ParseICS reads the events of an iCalendar file as blackout periods. Only
+// SUMMARY, DTSTART and DTEND are used; recurring events (RRULE) count for
+// their first occurrence only.
+func ParseICS(r io.Reader) ([]Period, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		periods []Period
+		inEvent bool
+		event   Period
+		allDay  bool
+		hasEnd  bool
+	)
+	for n, line := range lines {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent, event, allDay, hasEnd = true, Period{}, false, false
+		case name == "END" && value == "VEVENT":
+			if !inEvent {
+				continue
+			}
+			inEvent = false
+			if event.Start.IsZero() {
+				continue
+			}
+			if !hasEnd {
+				// RFC 5545: an all-day event without DTEND lasts one day
+				event.End = event.Start
+				if allDay {
+					event.End = event.Start.AddDate(0, 0, 1)
+				}
+			}
+			if !event.End.After(event.Start) {
+				continue
+			}
+			if event.Name == "" {
+				event.Name = "blackout"
+			}
+			periods = append(periods, event)
+		case !inEvent:
+		case name == "SUMMARY":
+			event.Name = unescapeICS(value)
+		case name == "DTSTART":
+			t, date, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: DTSTART: %w", n+1, err)
+			}
+			event.Start, allDay = t, date
+		case name == "DTEND":
+			t, _, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: DTEND: %w", n+1, err)
+			}
+			event.End, hasEnd = t, true
+		}
+	}
+	return periods, nil
+}
+
+// unfoldICS joins continuation lines (those starting with a space or tab).
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// splitICSLine splits "DTSTART;TZID=Europe/Berlin:20261224T090000" into
+// its name, parameters and value.
+func splitICSLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, strings.TrimSpace(value)
+}
+
+// parseICSTime parses a DATE or DATE-TIME value. Dates and floating times
+// are local; a trailing Z means UTC. Reports whether the value is a date.
+func parseICSTime(params map[string]string, value string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+var icsUnescaper = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeICS(s string) string {
+	return icsUnescaper.Replace(s)
+}
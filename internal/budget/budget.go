@@ -5,6 +5,8 @@ package budget
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/marcus/nightshift/internal/config"
@@ -12,29 +14,18 @@ import (
 )
 
 // UsageProvider is the interface for getting usage data from a provider.
+// Every providers.Provider satisfies it.
 type UsageProvider interface {
 	Name() string
-}
-
-// ClaudeUsageProvider extends UsageProvider for Claude-specific usage methods.
-type ClaudeUsageProvider interface {
-	UsageProvider
 	GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
 }
 
-// CodexUsageProvider extends UsageProvider for Codex-specific usage methods.
-type CodexUsageProvider interface {
-	UsageProvider
-	GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
+// ResetTimeProvider is implemented by providers that know when their usage
+// window resets. Providers without it are assumed to reset on Sunday.
+type ResetTimeProvider interface {
 	GetResetTime(mode string) (time.Time, error)
 }
 
-// GeminiUsageProvider extends UsageProvider for Gemini-specific usage methods.
-type GeminiUsageProvider interface {
-	UsageProvider
-	GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
-}
-
 // BudgetEstimate provides a resolved weekly budget with metadata.
 type BudgetEstimate struct {
 	WeeklyTokens int64
@@ -60,28 +51,38 @@ type TrendAnalyzer interface {
 	PredictDaytimeUsage(provider string, now time.Time, weeklyBudget int64) (int64, error)
 }
 
+// SpendSource reports dollars already spent across providers.
+type SpendSource interface {
+	SpendSince(since time.Time) (float64, error)
+}
+
+// CostEstimator prices an estimated token count in USD.
+type CostEstimator interface {
+	Estimate(provider string, tokens int64) float64
+}
+
 // Option configures a Manager.
 type Option func(*Manager)
 
 // Manager calculates and manages token budget allocation across providers.
 type Manager struct {
-	cfg          *config.Config
-	claude       ClaudeUsageProvider
-	codex        CodexUsageProvider
-	gemini       GeminiUsageProvider
-	budgetSource BudgetSource
-	trend        TrendAnalyzer
-	nowFunc      func() time.Time // for testing
+	cfg           *config.Config
+	providers     map[string]UsageProvider
+	budgetSource  BudgetSource
+	trend         TrendAnalyzer
+	spend         SpendSource
+	pricing       CostEstimator
+	sessionResets SessionResetSource
+	nowFunc       func() time.Time // for testing
 }
 
-// NewManager creates a budget manager with the given configuration and providers.
-func NewManager(cfg *config.Config, claude ClaudeUsageProvider, codex CodexUsageProvider, gemini GeminiUsageProvider, opts ...Option) *Manager {
+// NewManager creates a budget manager with the given configuration.
+// Usage providers are supplied with WithProviders.
+func NewManager(cfg *config.Config, opts ...Option) *Manager {
 	mgr := &Manager{
-		cfg:     cfg,
-		claude:  claude,
-		codex:   codex,
-		gemini:  gemini,
-		nowFunc: time.Now,
+		cfg:       cfg,
+		providers: make(map[string]UsageProvider),
+		nowFunc:   time.Now,
 	}
 	for _, opt := range opts {
 		opt(mgr)
@@ -89,6 +90,19 @@ func NewManager(cfg *config.Config, claude ClaudeUsageProvider, codex CodexUsage
 	return mgr
 }
 
+// WithProviders registers usage providers, keyed by their Name().
+// Nil entries are ignored.
+func WithProviders(ps ...UsageProvider) Option {
+	return func(m *Manager) {
+		for _, p := range ps {
+			if p == nil {
+				continue
+			}
+			m.providers[p.Name()] = p
+		}
+	}
+}
+
 // WithBudgetSource injects a BudgetSource for calibrated budgets.
 func WithBudgetSource(source BudgetSource) Option {
 	return func(m *Manager) {
@@ -103,6 +117,20 @@ func WithTrendAnalyzer(analyzer TrendAnalyzer) Option {
 	}
 }
 
+// WithSpendSource injects recorded spend for dollar cap enforcement.
+func WithSpendSource(source SpendSource) Option {
+	return func(m *Manager) {
+		m.spend = source
+	}
+}
+
+// WithCostEstimator injects pricing used to estimate a task's dollar cost.
+func WithCostEstimator(estimator CostEstimator) Option {
+	return func(m *Manager) {
+		m.pricing = estimator
+	}
+}
+
 // AllowanceResult contains the calculated budget allowance and metadata.
 type AllowanceResult struct {
 	Allowance          int64   // Final token allowance for this run
@@ -111,6 +139,7 @@ type AllowanceResult struct {
 	BudgetBase         int64   // Base budget (daily or remaining weekly)
 	UsedPercent        float64 // Current used percentage
 	UsedPercentSource  string  // Source of used percentage (e.g., stats-cache, jsonl-fallback)
+	WeightFactor       float64 // Weighted/raw token ratio applied to UsedPercent (1 = unweighted)
 	ReserveAmount      int64   // Tokens reserved
 	PredictedUsage     int64   // Predicted remaining usage today
 	Mode               string  // "daily" or "weekly"
@@ -129,7 +158,7 @@ func (m *Manager) CalculateAllowance(provider string) (*AllowanceResult, error)
 	}
 	weeklyBudget := estimate.WeeklyTokens
 
-	usedPercent, err := m.GetUsedPercent(provider)
+	usedPercent, factor, err := m.weightedUsedPercent(provider)
 	if err != nil {
 		return nil, fmt.Errorf("getting used percent for %s: %w", provider, err)
 	}
@@ -187,6 +216,7 @@ func (m *Manager) CalculateAllowance(provider string) (*AllowanceResult, error)
 	result.BudgetSampleCount = estimate.SampleCount
 	result.WeeklyBudget = weeklyBudget
 	result.UsedPercentSource = usedPercentSource
+	result.WeightFactor = factor
 
 	return result, nil
 }
@@ -278,9 +308,45 @@ func (m *Manager) resolveBudget(provider string) (BudgetEstimate, error) {
 	return estimate, nil
 }
 
-// GetUsedPercent retrieves the used percentage from the appropriate provider.
-// Uses the resolved (calibrated) budget so percentages match the displayed budget.
+// GetUsedPercent retrieves the used percentage from the appropriate provider,
+// scaled by any configured model weights (see ModelUsage).
 func (m *Manager) GetUsedPercent(provider string) (float64, error) {
+	pct, _, err := m.weightedUsedPercent(provider)
+	return pct, err
+}
+
+// weightedUsedPercent returns the provider's used percentage scaled by the
+// ratio of weighted to raw tokens, along with that ratio. Percentages the
+// provider reads from its own rate limits are already metered per model and
+// are returned unscaled.
+func (m *Manager) weightedUsedPercent(provider string) (float64, float64, error) {
+	pct, err := m.rawUsedPercent(provider)
+	if err != nil || pct == 0 || !m.cfg.HasModelWeights(provider) {
+		return pct, 1, err
+	}
+	if m.usedPercentSource(provider) == "rate-limit" {
+		return pct, 1, nil
+	}
+
+	usage, err := m.ModelUsage(provider)
+	if err != nil || len(usage) == 0 {
+		return pct, 1, nil
+	}
+	var raw, weighted float64
+	for _, u := range usage {
+		raw += float64(u.Tokens)
+		weighted += u.WeightedTokens()
+	}
+	if raw <= 0 {
+		return pct, 1, nil
+	}
+	factor := weighted / raw
+	return pct * factor, factor, nil
+}
+
+// rawUsedPercent retrieves the used percentage from the appropriate provider.
+// Uses the resolved (calibrated) budget so percentages match the displayed budget.
+func (m *Manager) rawUsedPercent(provider string) (float64, error) {
 	estimate, err := m.resolveBudget(provider)
 	if err != nil {
 		return 0, fmt.Errorf("resolving budget for %s: %w", provider, err)
@@ -292,94 +358,108 @@ func (m *Manager) GetUsedPercent(provider string) (float64, error) {
 		mode = config.DefaultBudgetMode
 	}
 
-	switch provider {
-	case "claude":
-		if m.claude == nil {
-			return 0, fmt.Errorf("claude provider not configured")
-		}
-		return m.claude.GetUsedPercent(mode, weeklyBudget)
+	p, ok := m.providers[provider]
+	if !ok {
+		return 0, fmt.Errorf("%s provider not configured", provider)
+	}
+	return p.GetUsedPercent(mode, weeklyBudget)
+}
 
-	case "codex":
-		if m.codex == nil {
-			return 0, fmt.Errorf("codex provider not configured")
-		}
-		return m.codex.GetUsedPercent(mode, weeklyBudget)
+// ModelUsage is one model's share of a provider's usage in the current
+// budget period.
+type ModelUsage struct {
+	Model  string
+	Tokens int64
+	Weight float64 // from budget.model_weights; 1 when unset
+}
 
-	case "gemini":
-		if m.gemini == nil {
-			return 0, fmt.Errorf("gemini provider not configured")
-		}
-		return m.gemini.GetUsedPercent(mode, weeklyBudget)
+// WeightedTokens returns Tokens scaled by Weight.
+func (u ModelUsage) WeightedTokens() float64 {
+	return float64(u.Tokens) * u.Weight
+}
 
-	default:
-		return 0, fmt.Errorf("unknown provider: %s", provider)
+// ModelUsage returns the provider's local usage split by model for the
+// budget mode's period (today in daily mode, the last 7 days in weekly mode),
+// largest first. Returns nil when the provider can't split usage by model.
+func (m *Manager) ModelUsage(provider string) ([]ModelUsage, error) {
+	reporter, ok := m.providers[provider].(providers.ModelUsageReporter)
+	if !ok {
+		return nil, nil
 	}
-}
 
-func (m *Manager) usedPercentSource(provider string) string {
-	switch provider {
-	case "claude":
-		if reporter, ok := m.claude.(UsedPercentSourceProvider); ok {
-			return reporter.LastUsedPercentSource()
-		}
-	case "codex":
-		if reporter, ok := m.codex.(UsedPercentSourceProvider); ok {
-			return reporter.LastUsedPercentSource()
+	var byModel map[string]int64
+	var err error
+	if m.cfg.Budget.Mode == "weekly" {
+		byModel, err = reporter.GetWeeklyTokensByModel()
+	} else {
+		byModel, err = reporter.GetTodayTokensByModel()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("model usage for %s: %w", provider, err)
+	}
+
+	usage := make([]ModelUsage, 0, len(byModel))
+	for model, tokens := range byModel {
+		if tokens <= 0 {
+			continue
 		}
-	case "gemini":
-		if reporter, ok := m.gemini.(UsedPercentSourceProvider); ok {
-			return reporter.LastUsedPercentSource()
+		usage = append(usage, ModelUsage{
+			Model:  model,
+			Tokens: tokens,
+			Weight: m.cfg.ModelWeight(provider, model),
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Tokens != usage[j].Tokens {
+			return usage[i].Tokens > usage[j].Tokens
 		}
+		return usage[i].Model < usage[j].Model
+	})
+	return usage, nil
+}
+
+// Provider returns the usage provider registered under name, or nil.
+func (m *Manager) Provider(name string) UsageProvider {
+	return m.providers[name]
+}
+
+func (m *Manager) usedPercentSource(provider string) string {
+	if reporter, ok := m.providers[provider].(UsedPercentSourceProvider); ok {
+		return reporter.LastUsedPercentSource()
 	}
 	return ""
 }
 
 // DaysUntilWeeklyReset calculates days remaining until the weekly budget resets.
-// For Claude: assumes weekly reset on Sunday (7 - current weekday, or 7 if Sunday).
-// For Codex: uses the secondary rate limit's resets_at timestamp.
+// Providers implementing ResetTimeProvider supply the reset timestamp (e.g.
+// Codex's secondary rate limit resets_at). Otherwise a Sunday reset is
+// assumed (7 - current weekday, or 7 if Sunday).
 func (m *Manager) DaysUntilWeeklyReset(provider string) (int, error) {
 	now := m.nowFunc()
 
-	switch provider {
-	case "claude":
-		// Claude resets weekly; assume Sunday reset
-		// Weekday: Sunday=0, Monday=1, ..., Saturday=6
-		weekday := int(now.Weekday())
-		if weekday == 0 {
-			return 7, nil // It's Sunday, next reset in 7 days
-		}
-		return 7 - weekday, nil
-
-	case "codex":
-		if m.codex == nil {
-			return 7, nil // Default fallback
-		}
-		resetTime, err := m.codex.GetResetTime("weekly")
-		if err != nil {
-			return 7, nil // Fallback on error
-		}
-		if resetTime.IsZero() {
-			return 7, nil // No reset time available
-		}
-
-		duration := resetTime.Sub(now)
-		days := int(math.Ceil(duration.Hours() / 24))
-		if days <= 0 {
-			return 1, nil // At least 1 day
-		}
-		return days, nil
+	if p, ok := m.providers[provider]; ok {
+		if reset, ok := p.(ResetTimeProvider); ok {
+			resetTime, err := reset.GetResetTime("weekly")
+			if err != nil || resetTime.IsZero() {
+				return 7, nil // Fallback when no reset time is available
+			}
 
-	case "gemini":
-		// Gemini resets weekly; assume Sunday reset (same as Claude)
-		weekday := int(now.Weekday())
-		if weekday == 0 {
-			return 7, nil
+			duration := resetTime.Sub(now)
+			days := int(math.Ceil(duration.Hours() / 24))
+			if days <= 0 {
+				return 1, nil // At least 1 day
+			}
+			return days, nil
 		}
-		return 7 - weekday, nil
+	}
 
-	default:
-		return 7, nil // Default for unknown providers
+	// Assume Sunday reset
+	// Weekday: Sunday=0, Monday=1, ..., Saturday=6
+	weekday := int(now.Weekday())
+	if weekday == 0 {
+		return 7, nil // It's Sunday, next reset in 7 days
 	}
+	return 7 - weekday, nil
 }
 
 // Summary returns a human-readable summary of the budget state for a provider.
@@ -410,12 +490,116 @@ func (m *Manager) Summary(provider string) (string, error) {
 }
 
 // CanRun checks if there's enough budget to run a task with the given estimated cost.
+// Both the token allowance and any dollar caps must have room for the task.
 func (m *Manager) CanRun(provider string, estimatedTokens int64) (bool, error) {
 	result, err := m.CalculateAllowance(provider)
 	if err != nil {
 		return false, err
 	}
-	return result.Allowance >= estimatedTokens, nil
+	if result.Allowance < estimatedTokens {
+		return false, nil
+	}
+	return m.CanSpend(provider, estimatedTokens)
+}
+
+// SpendStatus reports dollar spend against the configured caps.
+// A zero cap means no cap for that window.
+type SpendStatus struct {
+	DailyCap    float64
+	DailySpent  float64
+	WeeklyCap   float64
+	WeeklySpent float64
+}
+
+// Remaining returns the dollars left under the tightest cap, or +Inf when
+// no cap is set.
+func (s *SpendStatus) Remaining() float64 {
+	remaining := math.Inf(1)
+	if s.DailyCap > 0 {
+		remaining = math.Min(remaining, s.DailyCap-s.DailySpent)
+	}
+	if s.WeeklyCap > 0 {
+		remaining = math.Min(remaining, s.WeeklyCap-s.WeeklySpent)
+	}
+	return math.Max(remaining, 0)
+}
+
+// String describes the status, e.g. "$4.20 of $10.00 today".
+func (s *SpendStatus) String() string {
+	var parts []string
+	if s.DailyCap > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f of $%.2f today", s.DailySpent, s.DailyCap))
+	}
+	if s.WeeklyCap > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f of $%.2f this week", s.WeeklySpent, s.WeeklyCap))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SpendStatus returns spend against budget.daily_dollars and
+// budget.weekly_dollars. Returns nil when no cap is configured.
+func (m *Manager) SpendStatus() (*SpendStatus, error) {
+	if m.cfg == nil || (m.cfg.Budget.DailyDollars <= 0 && m.cfg.Budget.WeeklyDollars <= 0) {
+		return nil, nil
+	}
+	if m.spend == nil {
+		return nil, fmt.Errorf("dollar caps configured but no spend source")
+	}
+
+	now := m.nowFunc()
+	status := &SpendStatus{
+		DailyCap:  m.cfg.Budget.DailyDollars,
+		WeeklyCap: m.cfg.Budget.WeeklyDollars,
+	}
+	if status.DailyCap > 0 {
+		spent, err := m.spend.SpendSince(startOfDay(now))
+		if err != nil {
+			return nil, err
+		}
+		status.DailySpent = spent
+	}
+	if status.WeeklyCap > 0 {
+		spent, err := m.spend.SpendSince(startOfWeek(now, m.weekStartDay()))
+		if err != nil {
+			return nil, err
+		}
+		status.WeeklySpent = spent
+	}
+	return status, nil
+}
+
+// CanSpend reports whether the dollar caps leave room for a task of the
+// given estimated size. Always true when no cap is configured.
+func (m *Manager) CanSpend(provider string, estimatedTokens int64) (bool, error) {
+	status, err := m.SpendStatus()
+	if err != nil {
+		return false, err
+	}
+	if status == nil {
+		return true, nil
+	}
+	var estimate float64
+	if m.pricing != nil {
+		estimate = m.pricing.Estimate(provider, estimatedTokens)
+	}
+	remaining := status.Remaining()
+	return remaining > 0 && remaining >= estimate, nil
+}
+
+func (m *Manager) weekStartDay() time.Weekday {
+	if strings.EqualFold(m.cfg.Budget.WeekStartDay, "sunday") {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfWeek(t time.Time, weekStartDay time.Weekday) time.Time {
+	offset := (7 + int(t.Weekday()) - int(weekStartDay)) % 7
+	return startOfDay(t).AddDate(0, 0, -offset)
 }
 
 // Tracker provides backward compatibility for tracking actual spend.
@@ -448,21 +632,18 @@ func (t *Tracker) Remaining() int64 {
 	return t.limit - total
 }
 
-// NewManagerFromProviders is a convenience constructor that accepts the concrete provider types.
-func NewManagerFromProviders(cfg *config.Config, claude *providers.Claude, codex *providers.Codex, gemini *providers.Gemini, opts ...Option) *Manager {
-	var claudeProvider ClaudeUsageProvider
-	var codexProvider CodexUsageProvider
-	var geminiProvider GeminiUsageProvider
-
-	if claude != nil {
-		claudeProvider = claude
-	}
-	if codex != nil {
-		codexProvider = codex
+// NewManagerFromProviders is a convenience constructor that accepts registry providers.
+// The providers' prices (with config overrides) are used for cost estimates.
+func NewManagerFromProviders(cfg *config.Config, ps []providers.Provider, opts ...Option) *Manager {
+	usage := make([]UsageProvider, 0, len(ps))
+	for _, p := range ps {
+		if p != nil {
+			usage = append(usage, p)
+		}
 	}
-	if gemini != nil {
-		geminiProvider = gemini
+	base := []Option{
+		WithProviders(usage...),
+		WithCostEstimator(providers.NewPriceTable(cfg, ps)),
 	}
-
-	return NewManager(cfg, claudeProvider, codexProvider, geminiProvider, opts...)
+	return NewManager(cfg, append(base, opts...)...)
 }
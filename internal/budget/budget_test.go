@@ -132,7 +132,7 @@ func TestCalculateAllowance_DailyMode(t *testing.T) {
 			}
 
 			claude := &mockClaudeProvider{usedPercent: tt.usedPercent}
-			mgr := NewManager(cfg, claude, nil, nil)
+			mgr := NewManager(cfg, WithProviders(claude))
 
 			result, err := mgr.CalculateAllowance("claude")
 			if err != nil {
@@ -203,7 +203,7 @@ func TestCalculateAllowance_WeeklyMode(t *testing.T) {
 			}
 
 			claude := &mockClaudeProvider{usedPercent: tt.usedPercent}
-			mgr := NewManager(cfg, claude, nil, nil)
+			mgr := NewManager(cfg, WithProviders(claude))
 			mgr.nowFunc = func() time.Time { return fixedTime }
 
 			result, err := mgr.CalculateAllowance("claude")
@@ -273,7 +273,7 @@ func TestAggressiveEndOfWeek(t *testing.T) {
 			}
 
 			claude := &mockClaudeProvider{usedPercent: 0}
-			mgr := NewManager(cfg, claude, nil, nil)
+			mgr := NewManager(cfg, WithProviders(claude))
 			mgr.nowFunc = func() time.Time { return fixedTime }
 
 			result, err := mgr.CalculateAllowance("claude")
@@ -299,7 +299,7 @@ func TestCalculateAllowance_PredictedUsage(t *testing.T) {
 	}
 
 	claude := &mockClaudeProvider{usedPercent: 0}
-	mgr := NewManager(cfg, claude, nil, nil, WithTrendAnalyzer(&mockTrendAnalyzer{predicted: 2000}))
+	mgr := NewManager(cfg, WithProviders(claude), WithTrendAnalyzer(&mockTrendAnalyzer{predicted: 2000}))
 
 	result, err := mgr.CalculateAllowance("claude")
 	if err != nil {
@@ -344,7 +344,7 @@ func TestDaysUntilWeeklyReset_Claude(t *testing.T) {
 				},
 			}
 
-			mgr := NewManager(cfg, nil, nil, nil)
+			mgr := NewManager(cfg)
 			mgr.nowFunc = func() time.Time { return fixedTime }
 
 			days, err := mgr.DaysUntilWeeklyReset("claude")
@@ -399,7 +399,7 @@ func TestDaysUntilWeeklyReset_Codex(t *testing.T) {
 			}
 
 			codex := &mockCodexProvider{resetTime: tt.resetTime}
-			mgr := NewManager(cfg, nil, codex, nil)
+			mgr := NewManager(cfg, WithProviders(codex))
 			mgr.nowFunc = func() time.Time { return now }
 
 			days, err := mgr.DaysUntilWeeklyReset("codex")
@@ -427,7 +427,7 @@ func TestPerProviderBudget(t *testing.T) {
 	}
 
 	claude := &mockClaudeProvider{usedPercent: 0}
-	mgr := NewManager(cfg, claude, nil, nil)
+	mgr := NewManager(cfg, WithProviders(claude))
 
 	result, err := mgr.CalculateAllowance("claude")
 	if err != nil {
@@ -461,7 +461,7 @@ func TestBudgetSourceOverridesConfig(t *testing.T) {
 		SampleCount:  6,
 	}}
 
-	mgr := NewManager(cfg, claude, nil, nil, WithBudgetSource(source))
+	mgr := NewManager(cfg, WithProviders(claude), WithBudgetSource(source))
 	result, err := mgr.CalculateAllowance("claude")
 	if err != nil {
 		t.Fatalf("CalculateAllowance error: %v", err)
@@ -492,7 +492,7 @@ func TestBudgetSourceFallbacksToConfig(t *testing.T) {
 		WeeklyTokens: 0,
 	}}
 
-	mgr := NewManager(cfg, claude, nil, nil, WithBudgetSource(source))
+	mgr := NewManager(cfg, WithProviders(claude), WithBudgetSource(source))
 	result, err := mgr.CalculateAllowance("claude")
 	if err != nil {
 		t.Fatalf("CalculateAllowance error: %v", err)
@@ -514,7 +514,7 @@ func TestCanRun(t *testing.T) {
 	}
 
 	claude := &mockClaudeProvider{usedPercent: 0}
-	mgr := NewManager(cfg, claude, nil, nil)
+	mgr := NewManager(cfg, WithProviders(claude))
 
 	// Available: 10000 tokens (100000 * 10%)
 	tests := []struct {
@@ -538,6 +538,92 @@ func TestCanRun(t *testing.T) {
 	}
 }
 
+// mockSpendSource returns spend recorded at or after since.
+type mockSpendSource struct {
+	entries map[time.Time]float64
+}
+
+func (m *mockSpendSource) SpendSince(since time.Time) (float64, error) {
+	var total float64
+	for ts, usd := range m.entries {
+		if !ts.Before(since) {
+			total += usd
+		}
+	}
+	return total, nil
+}
+
+// flatEstimator prices every token at a fixed USD rate.
+type flatEstimator float64
+
+func (f flatEstimator) Estimate(_ string, tokens int64) float64 {
+	return float64(f) * float64(tokens)
+}
+
+func TestCanRunDollarCaps(t *testing.T) {
+	// Wednesday noon; the week starts Monday.
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.Local)
+	spend := &mockSpendSource{entries: map[time.Time]float64{
+		now.Add(-time.Hour):   3,  // today
+		now.AddDate(0, 0, -1): 4,  // this week
+		now.AddDate(0, 0, -5): 50, // last week
+	}}
+
+	tests := []struct {
+		name      string
+		daily     float64
+		weekly    float64
+		estimated int64
+		canRun    bool
+	}{
+		{"no caps", 0, 0, 1000, true},
+		{"daily room", 5, 0, 1000, true},                // $2 left, task $1
+		{"daily exceeded by task", 3.5, 0, 1000, false}, // $0.50 left, task $1
+		{"daily spent", 3, 0, 0, false},
+		{"weekly room", 0, 10, 2000, true},   // $3 left, task $2
+		{"weekly tight", 0, 10, 4000, false}, // $3 left, task $4
+		{"tightest cap wins", 100, 8, 2000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Budget: config.BudgetConfig{
+					Mode:          "daily",
+					WeeklyTokens:  700000,
+					MaxPercent:    100,
+					DailyDollars:  tt.daily,
+					WeeklyDollars: tt.weekly,
+					WeekStartDay:  "monday",
+				},
+			}
+			mgr := NewManager(cfg,
+				WithProviders(&mockClaudeProvider{}),
+				WithSpendSource(spend),
+				WithCostEstimator(flatEstimator(0.001)),
+			)
+			mgr.nowFunc = func() time.Time { return now }
+
+			canRun, err := mgr.CanRun("claude", tt.estimated)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if canRun != tt.canRun {
+				status, _ := mgr.SpendStatus()
+				t.Errorf("CanRun = %v, want %v (status: %v)", canRun, tt.canRun, status)
+			}
+		})
+	}
+}
+
+func TestSpendStatusRequiresSource(t *testing.T) {
+	cfg := &config.Config{Budget: config.BudgetConfig{DailyDollars: 5}}
+	mgr := NewManager(cfg)
+	if _, err := mgr.SpendStatus(); err == nil {
+		t.Fatal("expected error when caps are set without a spend source")
+	}
+}
+
 func TestSummary(t *testing.T) {
 	cfg := &config.Config{
 		Budget: config.BudgetConfig{
@@ -549,7 +635,7 @@ func TestSummary(t *testing.T) {
 	}
 
 	claude := &mockClaudeProvider{usedPercent: 25}
-	mgr := NewManager(cfg, claude, nil, nil)
+	mgr := NewManager(cfg, WithProviders(claude))
 
 	summary, err := mgr.Summary("claude")
 	if err != nil {
@@ -580,7 +666,7 @@ func TestCalculateAllowance_UsedPercentSource(t *testing.T) {
 	}
 
 	claude := &mockClaudeProvider{usedPercent: 25, source: "jsonl-fallback"}
-	mgr := NewManager(cfg, claude, nil, nil)
+	mgr := NewManager(cfg, WithProviders(claude))
 
 	result, err := mgr.CalculateAllowance("claude")
 	if err != nil {
@@ -603,7 +689,7 @@ func TestCalculateAllowance_Codex(t *testing.T) {
 	}
 
 	codex := &mockCodexProvider{usedPercent: 24} // 24% used (from scraped data)
-	mgr := NewManager(cfg, nil, codex, nil)
+	mgr := NewManager(cfg, WithProviders(codex))
 
 	result, err := mgr.CalculateAllowance("codex")
 	if err != nil {
@@ -633,7 +719,7 @@ func TestGetUsedPercent_Errors(t *testing.T) {
 	}
 
 	// Test missing claude provider
-	mgr := NewManager(cfg, nil, nil, nil)
+	mgr := NewManager(cfg)
 	_, err := mgr.GetUsedPercent("claude")
 	if err == nil {
 		t.Error("expected error for missing claude provider")
@@ -701,7 +787,7 @@ func TestReserveEnforcement(t *testing.T) {
 			}
 
 			claude := &mockClaudeProvider{usedPercent: tt.usedPercent}
-			mgr := NewManager(cfg, claude, nil, nil)
+			mgr := NewManager(cfg, WithProviders(claude))
 
 			result, err := mgr.CalculateAllowance("claude")
 			if err != nil {
@@ -728,3 +814,80 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+type mockModelProvider struct {
+	mockClaudeProvider
+	today  map[string]int64
+	weekly map[string]int64
+}
+
+func (m *mockModelProvider) GetTodayTokensByModel() (map[string]int64, error) {
+	return m.today, nil
+}
+func (m *mockModelProvider) GetWeeklyTokensByModel() (map[string]int64, error) {
+	return m.weekly, nil
+}
+
+func TestModelWeights(t *testing.T) {
+	provider := &mockModelProvider{
+		mockClaudeProvider: mockClaudeProvider{usedPercent: 40, source: "stats-cache"},
+		today:              map[string]int64{"claude-opus-4-5": 1000, "claude-haiku-4-5": 3000},
+		weekly:             map[string]int64{"claude-haiku-4-5": 4000},
+	}
+	cfg := &config.Config{
+		Budget: config.BudgetConfig{
+			Mode:         "daily",
+			MaxPercent:   100,
+			WeeklyTokens: 700000,
+			ModelWeights: []config.ModelWeight{
+				{Provider: "claude", Model: "claude-opus", Weight: 5},
+				{Provider: "claude", Model: "claude-haiku", Weight: 0.5},
+			},
+		},
+	}
+	mgr := NewManager(cfg, WithProviders(provider))
+
+	usage, err := mgr.ModelUsage("claude")
+	if err != nil {
+		t.Fatalf("ModelUsage: %v", err)
+	}
+	if len(usage) != 2 || usage[0].Model != "claude-haiku-4-5" || usage[1].Weight != 5 {
+		t.Fatalf("ModelUsage = %+v", usage)
+	}
+
+	// (1000*5 + 3000*0.5) / 4000 = 1.625
+	pct, err := mgr.GetUsedPercent("claude")
+	if err != nil {
+		t.Fatalf("GetUsedPercent: %v", err)
+	}
+	if pct != 65 {
+		t.Errorf("weighted used percent = %v, want 65", pct)
+	}
+	result, err := mgr.CalculateAllowance("claude")
+	if err != nil {
+		t.Fatalf("CalculateAllowance: %v", err)
+	}
+	if result.WeightFactor != 1.625 || result.UsedPercent != 65 {
+		t.Errorf("allowance factor/used = %v/%v, want 1.625/65", result.WeightFactor, result.UsedPercent)
+	}
+
+	// Weekly mode uses the 7-day split.
+	cfg.Budget.Mode = "weekly"
+	if pct, _ := mgr.GetUsedPercent("claude"); pct != 20 {
+		t.Errorf("weekly weighted used percent = %v, want 20", pct)
+	}
+
+	// Rate-limit percentages are already metered per model.
+	cfg.Budget.Mode = "daily"
+	provider.source = "rate-limit"
+	if pct, _ := mgr.GetUsedPercent("claude"); pct != 40 {
+		t.Errorf("rate-limit used percent = %v, want 40", pct)
+	}
+
+	// No weights configured: unscaled.
+	provider.source = "stats-cache"
+	cfg.Budget.ModelWeights = nil
+	if pct, _ := mgr.GetUsedPercent("claude"); pct != 40 {
+		t.Errorf("unweighted used percent = %v, want 40", pct)
+	}
+}
@@ -0,0 +1,137 @@
+package budget
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+// BurstPlan estimates how much of a provider's weekly budget will go unused
+// by the time it resets, after the usage expected from the user.
+type BurstPlan struct {
+	Provider  string
+	ResetsAt  time.Time
+	Weekly    int64 // weekly budget
+	Remaining int64 // weekly tokens not used yet
+	Predicted int64 // expected daytime usage before the reset
+	Reserve   int64 // reserve_percent of the weekly budget
+	Leftover  int64 // Remaining - Predicted - Reserve
+	Threshold int64 // leftover needed to burst (min_leftover_percent)
+	Active    bool  // reset within burst.within and Leftover >= Threshold
+}
+
+// String summarizes the plan for logs.
+func (p *BurstPlan) String() string {
+	return fmt.Sprintf("%d tokens projected unused before %s (remaining %d, expected daytime %d, reserve %d)",
+		p.Leftover, p.ResetsAt.Format("Mon 15:04"), p.Remaining, p.Predicted, p.Reserve)
+}
+
+// BurstPlan projects the provider's leftover budget at its weekly reset.
+// Returns nil when budget.burst is disabled.
+func (m *Manager) BurstPlan(provider string) (*BurstPlan, error) {
+	if m.cfg == nil || !m.cfg.Budget.Burst.Enabled {
+		return nil, nil
+	}
+	estimate, err := m.resolveBudget(provider)
+	if err != nil {
+		return nil, err
+	}
+	usedPercent, _, err := m.weightedUsedPercent(provider)
+	if err != nil {
+		return nil, fmt.Errorf("getting used percent for %s: %w", provider, err)
+	}
+	// Daily mode reports today's share; bursts are about the whole week.
+	if m.cfg.Budget.Mode != "weekly" {
+		if weekly, err := m.weeklyUsedPercent(provider, estimate.WeeklyTokens); err == nil {
+			usedPercent = weekly
+		}
+	}
+
+	now := m.nowFunc()
+	weekly := estimate.WeeklyTokens
+	plan := &BurstPlan{
+		Provider:  provider,
+		ResetsAt:  m.WeeklyResetTime(provider),
+		Weekly:    weekly,
+		Remaining: int64(math.Max(0, float64(weekly)*(1-usedPercent/100))),
+	}
+	reservePercent := m.cfg.Budget.ReservePercent
+	if reservePercent < 0 {
+		reservePercent = config.DefaultReservePercent
+	}
+	plan.Reserve = weekly * int64(reservePercent) / 100
+
+	if m.trend != nil {
+		plan.Predicted, err = m.predictUsageUntil(provider, now, plan.ResetsAt, weekly)
+		if err != nil {
+			return nil, fmt.Errorf("predict daytime usage: %w", err)
+		}
+	}
+	plan.Leftover = max(plan.Remaining-plan.Predicted-plan.Reserve, 0)
+
+	minLeftover := m.cfg.Budget.Burst.MinLeftoverPercent
+	if minLeftover <= 0 {
+		minLeftover = config.DefaultBurstMinLeftover
+	}
+	plan.Threshold = weekly * int64(minLeftover) / 100
+	plan.Active = plan.ResetsAt.Sub(now) <= m.burstWithin() && plan.Leftover >= plan.Threshold && plan.Leftover > 0
+	return plan, nil
+}
+
+// weeklyUsedPercent asks the provider for its weekly used percentage,
+// regardless of the configured budget mode.
+func (m *Manager) weeklyUsedPercent(provider string, weeklyBudget int64) (float64, error) {
+	p, ok := m.providers[provider]
+	if !ok {
+		return 0, fmt.Errorf("%s provider not configured", provider)
+	}
+	return p.GetUsedPercent("weekly", weeklyBudget)
+}
+
+// WeeklyResetTime returns when the provider's weekly budget resets: the
+// provider's reported reset time, else midnight at the start of the day
+// DaysUntilWeeklyReset reports.
+func (m *Manager) WeeklyResetTime(provider string) time.Time {
+	now := m.nowFunc()
+	if reset, ok := m.providers[provider].(ResetTimeProvider); ok {
+		if t, err := reset.GetResetTime("weekly"); err == nil && t.After(now) {
+			return t
+		}
+	}
+	days, _ := m.DaysUntilWeeklyReset(provider)
+	return time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, now.Location())
+}
+
+// predictUsageUntil sums the trend's expected usage from now until the given
+// time, one calendar day at a time. The trend predicts usage for the rest of
+// a day, so a partial final day is the difference of two predictions.
+func (m *Manager) predictUsageUntil(provider string, now, until time.Time, weeklyBudget int64) (int64, error) {
+	var total int64
+	for t := now; t.Before(until); {
+		dayEnd := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		rest, err := m.trend.PredictDaytimeUsage(provider, t, weeklyBudget)
+		if err != nil {
+			return 0, err
+		}
+		if until.Before(dayEnd) {
+			after, err := m.trend.PredictDaytimeUsage(provider, until, weeklyBudget)
+			if err != nil {
+				return 0, err
+			}
+			rest = max(rest-after, 0)
+		}
+		total += rest
+		t = dayEnd
+	}
+	return total, nil
+}
+
+func (m *Manager) burstWithin() time.Duration {
+	if d, err := time.ParseDuration(m.cfg.Budget.Burst.Within); err == nil && d > 0 {
+		return d
+	}
+	d, _ := time.ParseDuration(config.DefaultBurstWithin)
+	return d
+}
@@ -0,0 +1,112 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+// linearTrend predicts a fixed daily usage spread evenly over the day.
+type linearTrend struct {
+	daily int64
+}
+
+func (l *linearTrend) PredictDaytimeUsage(provider string, now time.Time, weeklyBudget int64) (int64, error) {
+	return l.daily * int64(24-now.Hour()) / 24, nil
+}
+
+func burstConfig() *config.Config {
+	return &config.Config{
+		Budget: config.BudgetConfig{
+			Mode:           "weekly",
+			WeeklyTokens:   1000000,
+			ReservePercent: 5,
+			Burst: config.BurstConfig{
+				Enabled:            true,
+				Within:             "48h",
+				MinLeftoverPercent: 15,
+				MaxRuns:            3,
+				Spacing:            "90m",
+			},
+		},
+	}
+}
+
+func TestBurstPlan(t *testing.T) {
+	now := time.Date(2026, 2, 13, 10, 0, 0, 0, time.UTC) // Friday
+
+	tests := []struct {
+		name         string
+		used         float64
+		reset        time.Time
+		wantActive   bool
+		wantLeftover int64
+	}{
+		{
+			name:         "reset close with budget left",
+			used:         40,
+			reset:        now.Add(30 * time.Hour), // Saturday 16:00
+			wantActive:   true,
+			wantLeftover: 600000 - 58333 - 66667 - 50000,
+		},
+		{
+			name:         "reset too far away",
+			used:         40,
+			reset:        now.Add(96 * time.Hour),
+			wantActive:   false,
+			wantLeftover: 600000 - 58333 - 3*100000 - 100000*10/24 - 50000,
+		},
+		{
+			name:         "little budget left",
+			used:         80,
+			reset:        now.Add(30 * time.Hour),
+			wantActive:   false,
+			wantLeftover: 200000 - 58333 - 66667 - 50000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mockCodexProvider{usedPercent: tt.used, resetTime: tt.reset}
+			mgr := NewManager(burstConfig(), WithProviders(p), WithTrendAnalyzer(&linearTrend{daily: 100000}))
+			mgr.nowFunc = func() time.Time { return now }
+
+			plan, err := mgr.BurstPlan("codex")
+			if err != nil {
+				t.Fatalf("BurstPlan: %v", err)
+			}
+			if plan.Active != tt.wantActive {
+				t.Errorf("Active = %v, want %v (%s)", plan.Active, tt.wantActive, plan)
+			}
+			if diff := plan.Leftover - tt.wantLeftover; diff < -2 || diff > 2 {
+				t.Errorf("Leftover = %d, want ~%d", plan.Leftover, tt.wantLeftover)
+			}
+			if !plan.ResetsAt.Equal(tt.reset) {
+				t.Errorf("ResetsAt = %v, want %v", plan.ResetsAt, tt.reset)
+			}
+		})
+	}
+}
+
+func TestBurstPlanDisabled(t *testing.T) {
+	cfg := burstConfig()
+	cfg.Budget.Burst.Enabled = false
+	mgr := NewManager(cfg, WithProviders(&mockCodexProvider{}))
+
+	plan, err := mgr.BurstPlan("codex")
+	if err != nil || plan != nil {
+		t.Errorf("expected nil plan when disabled, got %+v, %v", plan, err)
+	}
+}
+
+func TestWeeklyResetTimeFallsBackToSunday(t *testing.T) {
+	now := time.Date(2026, 2, 13, 10, 0, 0, 0, time.UTC) // Friday
+	mgr := NewManager(burstConfig(), WithProviders(&mockClaudeProvider{}))
+	mgr.nowFunc = func() time.Time { return now }
+
+	want := time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)
+	if got := mgr.WeeklyResetTime("claude"); !got.Equal(want) {
+		t.Errorf("WeeklyResetTime = %v, want %v", got, want)
+	}
+}
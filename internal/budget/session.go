@@ -0,0 +1,186 @@
+package budget
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/marcus/nightshift/internal/providers"
+)
+
+// defaultSessionMaxWait bounds a single wait for a session reset when
+// budget.session.max_wait is unset or invalid.
+const defaultSessionMaxWait = 2 * time.Hour
+
+// SessionResetSource supplies session reset times observed elsewhere (e.g.
+// scraped from the CLI's /usage screen). Preferred over provider estimates.
+type SessionResetSource interface {
+	SessionResetTime(provider string) (time.Time, bool)
+}
+
+// WithSessionResetSource injects scraped session reset times.
+func WithSessionResetSource(source SessionResetSource) Option {
+	return func(m *Manager) {
+		m.sessionResets = source
+	}
+}
+
+// SessionStatus is a provider's position in its short rolling usage window.
+type SessionStatus struct {
+	Provider     string
+	Length       time.Duration
+	UsedPercent  float64
+	Known        bool  // UsedPercent is reported or derived from WindowTokens
+	Tokens       int64 // local tokens in the active window
+	WindowTokens int64 // configured tokens per window; 0 when unknown
+	ResetsAt     time.Time
+	CapPercent   float64 // highest % nightshift may fill in this window
+}
+
+// String describes the status, e.g. "42.0% of 5h window used, cap 50%, resets 03:10".
+func (s *SessionStatus) String() string {
+	var parts []string
+	if s.Known {
+		parts = append(parts, fmt.Sprintf("%.1f%% of %s window used", s.UsedPercent, formatWindow(s.Length)))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d tokens in %s window", s.Tokens, formatWindow(s.Length)))
+	}
+	parts = append(parts, fmt.Sprintf("cap %.0f%%", s.CapPercent))
+	if !s.ResetsAt.IsZero() {
+		parts = append(parts, "resets "+s.ResetsAt.Format("15:04"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SessionDecision is the outcome of SessionCheck.
+type SessionDecision struct {
+	Allowed bool
+	Wait    time.Duration // >0 when the task fits after the window resets
+	Status  *SessionStatus
+}
+
+// SessionStatus returns the provider's short window usage, or nil when the
+// provider doesn't report one or session pacing is disabled
+// (budget.session.max_percent = 0).
+func (m *Manager) SessionStatus(provider string) (*SessionStatus, error) {
+	if m.cfg == nil || m.cfg.Budget.Session.MaxPercent <= 0 {
+		return nil, nil
+	}
+	reporter, ok := m.providers[provider].(providers.SessionWindowReporter)
+	if !ok {
+		return nil, nil
+	}
+	window, err := reporter.GetSessionWindow()
+	if err != nil {
+		return nil, err
+	}
+	if window.Length <= 0 {
+		return nil, nil
+	}
+
+	now := m.nowFunc()
+	status := &SessionStatus{
+		Provider:     provider,
+		Length:       window.Length,
+		Tokens:       window.Tokens,
+		WindowTokens: int64(m.cfg.Budget.Session.PerProvider[provider]),
+		ResetsAt:     window.ResetsAt,
+	}
+	if m.sessionResets != nil {
+		if reset, ok := m.sessionResets.SessionResetTime(provider); ok && reset.After(now) {
+			status.ResetsAt = reset
+		}
+	}
+	switch {
+	case window.UsedPercent != nil:
+		status.UsedPercent = *window.UsedPercent
+		status.Known = true
+	case status.WindowTokens > 0:
+		status.UsedPercent = float64(window.Tokens) / float64(status.WindowTokens) * 100
+		status.Known = true
+	}
+
+	windowEnd := status.ResetsAt
+	if windowEnd.IsZero() {
+		// No active window: the next task opens one.
+		windowEnd = now.Add(window.Length)
+	}
+	status.CapPercent = m.sessionCap(now, windowEnd)
+	return status, nil
+}
+
+// SessionCheck reports whether a task of estimatedTokens fits the provider's
+// short window. When it doesn't but the window resets within
+// budget.session.max_wait, Wait is how long to hold off instead of skipping.
+func (m *Manager) SessionCheck(provider string, estimatedTokens int64) (SessionDecision, error) {
+	status, err := m.SessionStatus(provider)
+	if err != nil || status == nil || !status.Known {
+		return SessionDecision{Allowed: true, Status: status}, err
+	}
+
+	projected := status.UsedPercent
+	if status.WindowTokens > 0 {
+		projected += float64(estimatedTokens) / float64(status.WindowTokens) * 100
+	}
+	if status.UsedPercent < status.CapPercent && projected <= status.CapPercent {
+		return SessionDecision{Allowed: true, Status: status}, nil
+	}
+
+	decision := SessionDecision{Status: status}
+	if m.cfg.Budget.Session.WaitForReset && !status.ResetsAt.IsZero() {
+		wait := status.ResetsAt.Sub(m.nowFunc())
+		if wait > 0 && wait <= m.sessionMaxWait() {
+			decision.Wait = wait
+		}
+	}
+	return decision, nil
+}
+
+// sessionCap returns the highest % of a window ending at windowEnd that a run
+// may fill. Windows still open when the user wakes keep
+// wake_reserve_percent free so the morning doesn't start rate limited.
+func (m *Manager) sessionCap(now, windowEnd time.Time) float64 {
+	session := m.cfg.Budget.Session
+	capPercent := float64(session.MaxPercent)
+	wake, ok := m.nextWakeTime(now)
+	if ok && windowEnd.After(wake) {
+		capPercent = math.Min(capPercent, float64(100-session.WakeReservePercent))
+	}
+	return capPercent
+}
+
+// nextWakeTime returns the next occurrence of the configured wake time,
+// interpreted in the schedule window's timezone when one is set.
+func (m *Manager) nextWakeTime(now time.Time) (time.Time, bool) {
+	clock, err := time.Parse("15:04", m.cfg.SessionWakeTime())
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc := now.Location()
+	if w := m.cfg.Schedule.Window; w != nil && w.Timezone != "" {
+		if l, err := time.LoadLocation(w.Timezone); err == nil {
+			loc = l
+		}
+	}
+	local := now.In(loc)
+	wake := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if !wake.After(local) {
+		wake = wake.AddDate(0, 0, 1)
+	}
+	return wake, true
+}
+
+func (m *Manager) sessionMaxWait() time.Duration {
+	if d, err := time.ParseDuration(m.cfg.Budget.Session.MaxWait); err == nil && d > 0 {
+		return d
+	}
+	return defaultSessionMaxWait
+}
+
+func formatWindow(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
@@ -0,0 +1,145 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/providers"
+)
+
+type mockSessionProvider struct {
+	mockClaudeProvider
+	window providers.SessionWindow
+}
+
+func (m *mockSessionProvider) GetSessionWindow() (providers.SessionWindow, error) {
+	return m.window, nil
+}
+
+type mockSessionResets struct {
+	reset time.Time
+}
+
+func (m *mockSessionResets) SessionResetTime(provider string) (time.Time, bool) {
+	return m.reset, !m.reset.IsZero()
+}
+
+func sessionConfig() *config.Config {
+	return &config.Config{
+		Budget: config.BudgetConfig{
+			Session: config.SessionConfig{
+				MaxPercent:         90,
+				WakeTime:           "07:00",
+				WakeReservePercent: 50,
+				WaitForReset:       true,
+				MaxWait:            "2h",
+				PerProvider:        map[string]int{"claude": 100000},
+			},
+		},
+	}
+}
+
+func TestSessionCheck(t *testing.T) {
+	now := time.Date(2026, 2, 10, 1, 0, 0, 0, time.UTC)
+	pct := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name        string
+		window      providers.SessionWindow
+		estimated   int64
+		wantAllowed bool
+		wantWait    time.Duration
+		wantCap     float64
+	}{
+		{
+			name:        "window resets before wake uses max_percent",
+			window:      providers.SessionWindow{Length: 5 * time.Hour, UsedPercent: pct(70), ResetsAt: now.Add(3 * time.Hour)},
+			wantAllowed: true,
+			wantCap:     90,
+		},
+		{
+			name:      "window open at wake keeps reserve",
+			window:    providers.SessionWindow{Length: 5 * time.Hour, UsedPercent: pct(60), ResetsAt: now.Add(7 * time.Hour)},
+			wantCap:   50,
+			wantWait:  0, // reset is beyond max_wait
+			estimated: 1000,
+		},
+		{
+			name:      "full window waits for reset",
+			window:    providers.SessionWindow{Length: 5 * time.Hour, UsedPercent: pct(95), ResetsAt: now.Add(90 * time.Minute)},
+			wantCap:   90,
+			wantWait:  90 * time.Minute,
+			estimated: 1000,
+		},
+		{
+			name:        "token window projects task size",
+			window:      providers.SessionWindow{Length: 5 * time.Hour, Tokens: 40000, ResetsAt: now.Add(6*time.Hour + 30*time.Minute)},
+			estimated:   20000,
+			wantAllowed: false,
+			wantCap:     50,
+		},
+		{
+			name:        "no active window opens a new one",
+			window:      providers.SessionWindow{Length: 5 * time.Hour},
+			estimated:   20000,
+			wantAllowed: true,
+			wantCap:     90,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mockSessionProvider{window: tt.window}
+			mgr := NewManager(sessionConfig(), WithProviders(p))
+			mgr.nowFunc = func() time.Time { return now }
+
+			decision, err := mgr.SessionCheck("claude", tt.estimated)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decision.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v (%s)", decision.Allowed, tt.wantAllowed, decision.Status)
+			}
+			if decision.Wait != tt.wantWait {
+				t.Errorf("Wait = %v, want %v", decision.Wait, tt.wantWait)
+			}
+			if decision.Status.CapPercent != tt.wantCap {
+				t.Errorf("CapPercent = %v, want %v", decision.Status.CapPercent, tt.wantCap)
+			}
+		})
+	}
+}
+
+func TestSessionCheckDisabled(t *testing.T) {
+	cfg := sessionConfig()
+	cfg.Budget.Session.MaxPercent = 0
+	used := 100.0
+	p := &mockSessionProvider{window: providers.SessionWindow{Length: 5 * time.Hour, UsedPercent: &used}}
+	mgr := NewManager(cfg, WithProviders(p))
+
+	decision, err := mgr.SessionCheck("claude", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decision.Allowed || decision.Status != nil {
+		t.Errorf("expected pacing disabled, got %+v", decision)
+	}
+}
+
+func TestSessionResetSourceOverridesEstimate(t *testing.T) {
+	now := time.Date(2026, 2, 10, 1, 0, 0, 0, time.UTC)
+	used := 95.0
+	p := &mockSessionProvider{window: providers.SessionWindow{Length: 5 * time.Hour, UsedPercent: &used, ResetsAt: now.Add(4 * time.Hour)}}
+	resets := &mockSessionResets{reset: now.Add(30 * time.Minute)}
+	mgr := NewManager(sessionConfig(), WithProviders(p), WithSessionResetSource(resets))
+	mgr.nowFunc = func() time.Time { return now }
+
+	decision, err := mgr.SessionCheck("claude", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decision.Wait != 30*time.Minute {
+		t.Errorf("Wait = %v, want 30m", decision.Wait)
+	}
+}
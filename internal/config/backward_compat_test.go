@@ -32,12 +32,12 @@ logging:
 	}
 
 	// v0.3.1 default: dangerous_skip_permissions must be false (security default)
-	if cfg.Providers.Claude.DangerouslySkipPermissions {
+	if cfg.ProviderSettings("claude").DangerouslySkipPermissions {
 		t.Error("Claude.DangerouslySkipPermissions should default to false, got true")
 	}
 
 	// v0.3.1 default: dangerous_bypass_approvals_and_sandbox must be false
-	if cfg.Providers.Codex.DangerouslyBypassApprovalsAndSandbox {
+	if cfg.ProviderSettings("codex").DangerouslyBypassApprovalsAndSandbox {
 		t.Error("Codex.DangerouslyBypassApprovalsAndSandbox should default to false, got true")
 	}
 }
@@ -68,7 +68,7 @@ providers:
 	}
 
 	// Explicitly set value should be preserved
-	if !cfg.Providers.Claude.DangerouslySkipPermissions {
+	if !cfg.ProviderSettings("claude").DangerouslySkipPermissions {
 		t.Error("Claude.DangerouslySkipPermissions should be true (explicitly set), got false")
 	}
 }
@@ -100,10 +100,10 @@ providers:
 	}
 
 	// Explicitly false values should be preserved
-	if cfg.Providers.Claude.DangerouslySkipPermissions {
+	if cfg.ProviderSettings("claude").DangerouslySkipPermissions {
 		t.Error("Claude.DangerouslySkipPermissions should be false, got true")
 	}
-	if cfg.Providers.Codex.DangerouslyBypassApprovalsAndSandbox {
+	if cfg.ProviderSettings("codex").DangerouslyBypassApprovalsAndSandbox {
 		t.Error("Codex.DangerouslyBypassApprovalsAndSandbox should be false, got true")
 	}
 }
@@ -133,12 +133,12 @@ providers:
 	}
 
 	// Explicitly set should be true
-	if !cfg.Providers.Claude.DangerouslySkipPermissions {
+	if !cfg.ProviderSettings("claude").DangerouslySkipPermissions {
 		t.Error("Claude.DangerouslySkipPermissions should be true, got false")
 	}
 
 	// Not set should default to false
-	if cfg.Providers.Codex.DangerouslyBypassApprovalsAndSandbox {
+	if cfg.ProviderSettings("codex").DangerouslyBypassApprovalsAndSandbox {
 		t.Error("Codex.DangerouslyBypassApprovalsAndSandbox should default to false, got true")
 	}
 }
@@ -283,8 +283,8 @@ providers:
 	if cfg.Budget.MaxPercent != 15 {
 		t.Errorf("Budget.MaxPercent = %d, want 15 (project override)", cfg.Budget.MaxPercent)
 	}
-	if !cfg.Providers.Claude.DangerouslySkipPermissions {
-		t.Errorf("Claude.DangerouslySkipPermissions = %v, want true (project override)", cfg.Providers.Claude.DangerouslySkipPermissions)
+	if !cfg.ProviderSettings("claude").DangerouslySkipPermissions {
+		t.Errorf("Claude.DangerouslySkipPermissions = %v, want true (project override)", cfg.ProviderSettings("claude").DangerouslySkipPermissions)
 	}
 
 	// Global value should still apply for non-overridden fields
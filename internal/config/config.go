@@ -6,12 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -23,15 +26,202 @@ type Config struct {
 	Projects     []ProjectConfig    `mapstructure:"projects"`
 	Tasks        TasksConfig        `mapstructure:"tasks"`
 	Integrations IntegrationsConfig `mapstructure:"integrations"`
+	Coordination CoordinationConfig `mapstructure:"coordination"`
 	Logging      LoggingConfig      `mapstructure:"logging"`
 	Reporting    ReportingConfig    `mapstructure:"reporting"`
+	Sandbox      SandboxConfig      `mapstructure:"sandbox"`
 }
 
 // ScheduleConfig defines when nightshift runs.
 type ScheduleConfig struct {
-	Cron     string        `mapstructure:"cron"`     // Cron expression (e.g., "0 2 * * *")
-	Interval string        `mapstructure:"interval"` // Alternative: duration (e.g., "1h")
-	Window   *WindowConfig `mapstructure:"window"`   // Optional time window constraint
+	Cron     string          `mapstructure:"cron"`     // Cron expression (e.g., "0 2 * * *")
+	Interval string          `mapstructure:"interval"` // Alternative: duration (e.g., "1h")
+	Window   *WindowConfig   `mapstructure:"window"`   // Optional time window constraint
+	Named    []NamedSchedule `mapstructure:"named"`    // Additional schedules with their own task sets
+	Idle     IdleConfig      `mapstructure:"idle"`     // Wait for the user to stop working before runs
+	Triggers TriggerConfig   `mapstructure:"triggers"` // Run change-sensitive tasks after new commits
+	CatchUp  CatchUpConfig   `mapstructure:"catch_up"` // Run missed schedules after sleep or downtime
+	Blackout BlackoutConfig  `mapstructure:"blackout"` // Release freezes, holidays, on-call weeks
+}
+
+// Blackout modes.
+const (
+	BlackoutSkip      = "skip"      // no runs at all
+	BlackoutDowngrade = "downgrade" // analysis-only runs, no PRs
+)
+
+// BlackoutConfig lists periods when scheduled runs are skipped or
+// downgraded, from config and from local ICS calendar files.
+type BlackoutConfig struct {
+	Mode      string             `mapstructure:"mode"` // Default for periods and calendars: skip or downgrade
+	Periods   []BlackoutPeriod   `mapstructure:"periods"`
+	Calendars []BlackoutCalendar `mapstructure:"calendars"`
+}
+
+// BlackoutPeriod is a configured blackout. Start and End are dates
+// ("2026-12-24", End inclusive), local times ("2026-12-24 18:00") or RFC3339.
+type BlackoutPeriod struct {
+	Name  string `mapstructure:"name"`
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+	Mode  string `mapstructure:"mode"` // Overrides blackout.mode
+}
+
+// BlackoutCalendar is an ICS file whose events are blackouts.
+type BlackoutCalendar struct {
+	Path string `mapstructure:"path"`
+	Mode string `mapstructure:"mode"` // Overrides blackout.mode
+}
+
+// Bounds parses the period into [start, end). A date-only End covers the
+// whole day.
+func (p BlackoutPeriod) Bounds() (time.Time, time.Time, error) {
+	start, _, err := parseBlackoutTime(p.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("blackout %q start: %w", p.Name, err)
+	}
+	end, dateOnly, err := parseBlackoutTime(p.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("blackout %q end: %w", p.Name, err)
+	}
+	if dateOnly {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("blackout %q ends before it starts", p.Name)
+	}
+	return start, end, nil
+}
+
+func parseBlackoutTime(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q (use YYYY-MM-DD, YYYY-MM-DD HH:MM or RFC3339)", value)
+}
+
+// ModeFor resolves a period's mode against the blackout default.
+func (b BlackoutConfig) ModeFor(mode string) string {
+	if mode != "" {
+		return mode
+	}
+	if b.Mode != "" {
+		return b.Mode
+	}
+	return BlackoutDowngrade
+}
+
+// CatchUpConfig controls runs missed while the machine slept or the daemon
+// was down. A missed run is caught up while its window is still open, or
+// within Grace of the missed time.
+type CatchUpConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Grace   string `mapstructure:"grace"` // e.g. "1h"; "0" catches up only inside the window
+}
+
+// TriggerConfig makes the daemon watch projects' git refs and run
+// change-sensitive tasks when new commits land on the main branch.
+type TriggerConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`
+	Tasks    []string `mapstructure:"tasks"`    // Task types to run (default DefaultTriggerTasks)
+	Branch   string   `mapstructure:"branch"`   // Branch to watch (default: origin's HEAD, else main or master)
+	Debounce string   `mapstructure:"debounce"` // Quiet time after the last ref change before queueing
+}
+
+// DefaultTriggerTasks are the tasks that only make sense after new commits.
+var DefaultTriggerTasks = []string{"semantic-diff", "changelog-synth", "doc-drift", "commit-normalize"}
+
+// IdleConfig defers scheduled runs while the user is working: recent
+// interactive provider sessions, commits in configured projects, or
+// desktop input reported by Command.
+type IdleConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	Duration      string   `mapstructure:"duration"`       // Idle time required before a run starts
+	CheckInterval string   `mapstructure:"check_interval"` // How often to check again while deferred
+	Sources       []string `mapstructure:"sources"`        // sessions, git (default both)
+	Command       string   `mapstructure:"command"`        // Prints desktop idle milliseconds (e.g. xprintidle)
+}
+
+// IdleSources lists the activity sources accepted in schedule.idle.sources.
+var IdleSources = []string{"sessions", "git"}
+
+// DefaultScheduleName names the top-level cron/interval schedule.
+const DefaultScheduleName = "default"
+
+// NamedSchedule is a schedule with its own cadence, task set and budget.
+// Empty filters match everything.
+type NamedSchedule struct {
+	Name       string        `mapstructure:"name"`
+	Cron       string        `mapstructure:"cron"`
+	Interval   string        `mapstructure:"interval"`
+	Window     *WindowConfig `mapstructure:"window"`      // Defaults to schedule.window
+	Categories []string      `mapstructure:"categories"`  // pr, analysis, options, safe, map, emergency
+	Tasks      []string      `mapstructure:"tasks"`       // Task types
+	CostTiers  []string      `mapstructure:"cost_tiers"`  // low, medium, high, veryhigh
+	Projects   []string      `mapstructure:"projects"`    // Project paths or glob patterns
+	MaxPercent int           `mapstructure:"max_percent"` // Overrides budget.max_percent (0 = inherit)
+}
+
+// Entries returns every configured schedule: the top-level cron/interval
+// as DefaultScheduleName (when set), then the named ones. Named schedules
+// without a window inherit schedule.window.
+func (s ScheduleConfig) Entries() []NamedSchedule {
+	var entries []NamedSchedule
+	if s.Cron != "" || s.Interval != "" {
+		entries = append(entries, NamedSchedule{
+			Name:     DefaultScheduleName,
+			Cron:     s.Cron,
+			Interval: s.Interval,
+			Window:   s.Window,
+		})
+	}
+	for _, n := range s.Named {
+		if n.Window == nil {
+			n.Window = s.Window
+		}
+		entries = append(entries, n)
+	}
+	return entries
+}
+
+// ScheduleConfig returns the cadence and window of the schedule.
+func (n NamedSchedule) ScheduleConfig() *ScheduleConfig {
+	return &ScheduleConfig{Cron: n.Cron, Interval: n.Interval, Window: n.Window}
+}
+
+// MatchesProject reports whether path passes the schedule's project filter.
+func (n NamedSchedule) MatchesProject(path string) bool {
+	if len(n.Projects) == 0 {
+		return true
+	}
+	for _, p := range n.Projects {
+		pattern := expandPath(p)
+		if abs, err := filepath.Abs(pattern); err == nil {
+			pattern = abs
+		}
+		if pattern == path {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns a copy of cfg with the schedule's budget override applied.
+func (n NamedSchedule) Apply(cfg *Config) *Config {
+	scoped := *cfg
+	if n.MaxPercent > 0 {
+		scoped.Budget.MaxPercent = n.MaxPercent
+	}
+	return &scoped
 }
 
 // WindowConfig defines a time window for execution.
@@ -55,15 +245,61 @@ type BudgetConfig struct {
 	SnapshotRetentionDays int            `mapstructure:"snapshot_retention_days"` // Snapshot retention in days
 	WeekStartDay          string         `mapstructure:"week_start_day"`          // monday | sunday
 	DBPath                string         `mapstructure:"db_path"`                 // Override DB path
+	DailyDollars          float64        `mapstructure:"daily_dollars"`           // Spend cap per day in USD (0 = none)
+	WeeklyDollars         float64        `mapstructure:"weekly_dollars"`          // Spend cap per week in USD (0 = none)
+	Pricing               []ModelPricing `mapstructure:"pricing"`                 // Overrides for built-in model prices
+	ModelWeights          []ModelWeight  `mapstructure:"model_weights"`           // Per-model budget weights
+	Session               SessionConfig  `mapstructure:"session"`                 // Short rolling window pacing
+	Burst                 BurstConfig    `mapstructure:"burst"`                   // Extra runs before the weekly reset
+}
+
+// BurstConfig adds daemon runs near the weekly reset when the budget left
+// over after expected daytime usage would otherwise go unused.
+type BurstConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	Within             string `mapstructure:"within"`               // How long before the reset bursts may start
+	MinLeftoverPercent int    `mapstructure:"min_leftover_percent"` // Projected leftover (% of weekly budget) that triggers a burst
+	MaxRuns            int    `mapstructure:"max_runs"`             // Max extra runs per burst
+	Spacing            string `mapstructure:"spacing"`              // Minimum time between burst runs
+}
+
+// SessionConfig paces runs against providers' short rolling usage windows
+// (e.g. the ~5 hour limits Claude and Codex enforce next to weekly limits).
+type SessionConfig struct {
+	MaxPercent         int            `mapstructure:"max_percent"`          // Max % of a window nightshift may fill
+	WakeTime           string         `mapstructure:"wake_time"`            // HH:MM the user is back (default: schedule.window.end)
+	WakeReservePercent int            `mapstructure:"wake_reserve_percent"` // % of the window left free when it is still open at wake time
+	WaitForReset       bool           `mapstructure:"wait_for_reset"`       // Wait for a window reset instead of skipping tasks
+	MaxWait            string         `mapstructure:"max_wait"`             // Longest wait for a single reset
+	PerProvider        map[string]int `mapstructure:"per_provider"`         // Tokens per window, for providers without a reported %
+}
+
+// ModelWeight scales how much a model's tokens count against the budget,
+// e.g. 5 for a model the provider meters at five times the base rate.
+// Model matches by prefix; the longest matching entry wins.
+type ModelWeight struct {
+	Provider string  `mapstructure:"provider"` // Optional; empty matches any provider
+	Model    string  `mapstructure:"model"`
+	Weight   float64 `mapstructure:"weight"`
+}
+
+// ModelPricing overrides the price of a model, in USD per million tokens.
+// Model matches by prefix; the longest matching entry wins.
+type ModelPricing struct {
+	Provider   string  `mapstructure:"provider"` // Optional; empty matches any provider
+	Model      string  `mapstructure:"model"`
+	Input      float64 `mapstructure:"input"`
+	Output     float64 `mapstructure:"output"`
+	CacheRead  float64 `mapstructure:"cache_read"`
+	CacheWrite float64 `mapstructure:"cache_write"`
 }
 
 // ProvidersConfig defines AI provider settings.
 type ProvidersConfig struct {
-	Claude ProviderConfig `mapstructure:"claude"`
-	Codex  ProviderConfig `mapstructure:"codex"`
-	Gemini ProviderConfig `mapstructure:"gemini"`
 	// Preference sets provider order (e.g., ["claude", "codex", "gemini"]).
 	Preference []string `mapstructure:"preference"`
+	// Settings holds each provider's block (providers.<name>), keyed by name.
+	Settings map[string]ProviderConfig `mapstructure:",remain"`
 }
 
 // ProviderConfig defines settings for a single AI provider.
@@ -80,32 +316,97 @@ type ProviderConfig struct {
 
 // ProjectConfig defines a project to manage.
 type ProjectConfig struct {
-	Path     string   `mapstructure:"path"`
-	Priority int      `mapstructure:"priority"`
-	Tasks    []string `mapstructure:"tasks"`   // Task overrides for this project
-	Config   string   `mapstructure:"config"`  // Per-project config file
-	Pattern  string   `mapstructure:"pattern"` // Glob pattern for discovery
-	Exclude  []string `mapstructure:"exclude"` // Paths to exclude
+	Path     string    `mapstructure:"path"`
+	Priority int       `mapstructure:"priority"`
+	Tasks    []string  `mapstructure:"tasks"`   // Task overrides for this project
+	Config   string    `mapstructure:"config"`  // Per-project config file
+	Pattern  string    `mapstructure:"pattern"` // Glob pattern for discovery
+	Exclude  []string  `mapstructure:"exclude"` // Paths to exclude
+	Scope    PathScope `mapstructure:"scope"`   // Paths tasks may change in the project
+
+	// Optional per-run budget caps; 0 means uncapped.
+	MaxTokens  int `mapstructure:"max_tokens"`  // Max tokens per run
+	MaxPercent int `mapstructure:"max_percent"` // Max % of the run's allowance
 }
 
 // TasksConfig defines task selection settings.
 type TasksConfig struct {
-	Enabled    []string           `mapstructure:"enabled"`    // Enabled task types
-	Priorities map[string]int     `mapstructure:"priorities"` // Priority per task type
-	Disabled   []string           `mapstructure:"disabled"`   // Explicitly disabled tasks
-	Intervals  map[string]string  `mapstructure:"intervals"`  // Per-task interval overrides (duration strings)
-	Custom     []CustomTaskConfig `mapstructure:"custom"`     // User-defined custom tasks
+	Enabled        []string             `mapstructure:"enabled"`         // Enabled task types
+	Priorities     map[string]int       `mapstructure:"priorities"`      // Priority per task type
+	Disabled       []string             `mapstructure:"disabled"`        // Explicitly disabled tasks
+	Intervals      map[string]string    `mapstructure:"intervals"`       // Per-task interval overrides (duration strings)
+	Custom         []CustomTaskConfig   `mapstructure:"custom"`          // User-defined custom tasks
+	OpenPR         string               `mapstructure:"open_pr"`         // What to do when a task's PR is still open: skip, update or allow
+	OpenPRs        map[string]string    `mapstructure:"open_prs"`        // Per-task open_pr overrides
+	Scopes         map[string]PathScope `mapstructure:"scopes"`          // Per-task path scopes
+	ScopeViolation string               `mapstructure:"scope_violation"` // Changes outside a task's path scope: revert or reject
+
+	// AllowProjectPreCommands lets pre-commands from a project's own task
+	// files run on the host when sandbox.enabled is off. Without it they
+	// only run in the sandbox, since cloning a repo must not be enough to
+	// run its commands.
+	AllowProjectPreCommands bool `mapstructure:"allow_project_pre_commands"`
+}
+
+// PathScope limits the files a task may change. Globs are repo-relative;
+// ** spans directories.
+type PathScope struct {
+	Include []string `mapstructure:"include"` // Only matching paths may change; empty allows all
+	Exclude []string `mapstructure:"exclude"` // Matching paths may never change
+}
+
+// IsZero reports whether the scope allows every path.
+func (s PathScope) IsZero() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0
 }
 
+// Scope violation policies: what happens to changes outside a task's path
+// scope before its PR is opened.
+const (
+	ScopeRevert = "revert" // revert the offending files and keep the rest
+	ScopeReject = "reject" // fail the attempt and ask the agent to fix it
+)
+
+// Open PR policies: what a task does when its previous PR is still open.
+const (
+	OpenPRSkip   = "skip"   // don't run the task in that project
+	OpenPRUpdate = "update" // push to the open PR's branch instead of opening a new PR
+	OpenPRAllow  = "allow"  // open another PR
+)
+
 // CustomTaskConfig defines a user-defined custom task.
 type CustomTaskConfig struct {
-	Type        string `mapstructure:"type"`        // Task type slug, e.g. "my-review"
-	Name        string `mapstructure:"name"`        // Human-readable name
-	Description string `mapstructure:"description"` // Agent prompt text
-	Category    string `mapstructure:"category"`    // One of: pr, analysis, options, safe, map, emergency
-	CostTier    string `mapstructure:"cost_tier"`   // One of: low, medium, high, very-high
-	RiskLevel   string `mapstructure:"risk_level"`  // One of: low, medium, high
-	Interval    string `mapstructure:"interval"`    // Duration string, e.g. "48h"
+	Type        string             `mapstructure:"type"`         // Task type slug, e.g. "my-review"
+	Name        string             `mapstructure:"name"`         // Human-readable name
+	Description string             `mapstructure:"description"`  // Agent prompt text
+	Category    string             `mapstructure:"category"`     // One of: pr, analysis, options, safe, map, emergency
+	CostTier    string             `mapstructure:"cost_tier"`    // One of: low, medium, high, very-high
+	RiskLevel   string             `mapstructure:"risk_level"`   // One of: low, medium, high
+	Interval    string             `mapstructure:"interval"`     // Duration string, e.g. "48h"
+	Requires    TaskRequirements   `mapstructure:"requires"`     // Only select the task in matching repos
+	After       []string           `mapstructure:"after"`        // Task types that must succeed before this one runs
+	Consumes    []string           `mapstructure:"consumes"`     // Like after, and their output is added to the prompt
+	PreCommands []PreCommandConfig `mapstructure:"pre_commands"` // Tools run before planning; output becomes evidence
+}
+
+// PreCommandConfig is a command run in the project before a custom task is
+// planned.
+type PreCommandConfig struct {
+	Name      string           `mapstructure:"name"`       // Shown in the prompt; defaults to the command
+	Command   string           `mapstructure:"command"`    // Run with sh -c in the project directory
+	Timeout   string           `mapstructure:"timeout"`    // Duration string; default 2m
+	MaxOutput int              `mapstructure:"max_output"` // Bytes of output kept; default 16384
+	SkipIf    string           `mapstructure:"skip_if"`    // clean (exit 0) or empty (no output) skips the task
+	Requires  TaskRequirements `mapstructure:"requires"`   // Only run in matching repos
+}
+
+// TaskRequirements limit a task to repositories it applies to. Each
+// non-empty list must have at least one entry present in the repo.
+type TaskRequirements struct {
+	Languages    []string `mapstructure:"languages"`    // e.g. go, typescript
+	Files        []string `mapstructure:"files"`        // Repo-relative globs; ** spans directories
+	Dirs         []string `mapstructure:"dirs"`         // Repo-relative directory globs
+	Dependencies []string `mapstructure:"dependencies"` // Substrings of module or package names
 }
 
 // IntegrationsConfig defines external integrations.
@@ -113,6 +414,33 @@ type IntegrationsConfig struct {
 	ClaudeMD    bool              `mapstructure:"claude_md"`    // Read claude.md
 	AgentsMD    bool              `mapstructure:"agents_md"`    // Read agents.md
 	TaskSources []TaskSourceEntry `mapstructure:"task_sources"` // Task sources
+	PROutcomes  PROutcomesConfig  `mapstructure:"pr_outcomes"`  // Learn from merged/closed PRs
+}
+
+// PROutcomesConfig makes nightshift poll the PRs it opened and favor task
+// types whose PRs get merged in a project over those whose PRs get closed.
+type PROutcomesConfig struct {
+	Enabled      bool    `mapstructure:"enabled"`
+	SyncInterval string  `mapstructure:"sync_interval"` // How often the daemon polls open PRs, e.g. "1h"
+	StaleAfter   string  `mapstructure:"stale_after"`   // Open PRs older than this count as not accepted, e.g. "336h"
+	Weight       float64 `mapstructure:"weight"`        // Score bonus at 100% merged, penalty at 0%
+}
+
+// Coordination backends.
+const (
+	CoordinationSQLite = "sqlite" // shared database file, e.g. on a network drive
+	CoordinationGit    = "git"    // lease file on a dedicated ref in each project's remote
+)
+
+// CoordinationConfig shares task claims and run history between machines
+// running nightshift against the same projects, so a task runs on one
+// machine at a time and cooldowns count runs made elsewhere.
+type CoordinationConfig struct {
+	Backend string `mapstructure:"backend"` // "" (off), sqlite or git
+	Path    string `mapstructure:"path"`    // sqlite: shared database file
+	Remote  string `mapstructure:"remote"`  // git: remote the lease ref is pushed to
+	Lease   string `mapstructure:"lease"`   // How long a claim lasts unless released, e.g. "2h"
+	Machine string `mapstructure:"machine"` // Name other machines see; defaults to the hostname
 }
 
 // TaskSourceEntry represents a task source configuration.
@@ -142,6 +470,21 @@ type ReportingConfig struct {
 	SlackWebhook   *string `mapstructure:"slack_webhook"` // Optional Slack webhook
 }
 
+// SandboxConfig controls isolation of agent invocations.
+type SandboxConfig struct {
+	Enabled         bool     `mapstructure:"enabled"`          // Route every agent invocation through the sandbox
+	EnvAllowlist    []string `mapstructure:"env_allowlist"`    // Extra env vars passed through (all others scrubbed)
+	IsolateHome     bool     `mapstructure:"isolate_home"`     // Run agents with a temporary HOME overlay
+	HomePassthrough []string `mapstructure:"home_passthrough"` // Entries under $HOME linked into the overlay
+	AllowedPaths    []string `mapstructure:"allowed_paths"`    // Extra directories agents may run in (projects are always allowed)
+	DeniedPaths     []string `mapstructure:"denied_paths"`     // Directories agents may never run in
+	IsolateNetwork  bool     `mapstructure:"isolate_network"`  // Linux only: private network namespace (blocks provider APIs too)
+}
+
+// DefaultHomePassthrough lists the home directory entries provider CLIs need
+// for authentication and session tracking when HOME is overlaid.
+var DefaultHomePassthrough = []string{".claude", ".claude.json", ".codex", ".gemini", ".gitconfig", ".config/gh"}
+
 // Default values for configuration.
 const (
 	DefaultBudgetMode        = "daily"
@@ -152,6 +495,22 @@ const (
 	DefaultSnapshotInterval  = "30m"
 	DefaultSnapshotRetention = 90
 	DefaultWeekStartDay      = "monday"
+	DefaultSessionMaxPercent = 90
+	DefaultWakeReserve       = 50
+	DefaultSessionMaxWait    = "2h"
+	DefaultBurstWithin       = "48h"
+	DefaultBurstMinLeftover  = 15
+	DefaultBurstMaxRuns      = 3
+	DefaultBurstSpacing      = "90m"
+	DefaultIdleDuration      = "30m"
+	DefaultIdleCheck         = "5m"
+	DefaultTriggerDebounce   = "2m"
+	DefaultCatchUpGrace      = "1h"
+	DefaultPRSyncInterval    = "1h"
+	DefaultPRStaleAfter      = "336h" // 14 days
+	DefaultPRWeight          = 3.0
+	DefaultCoordLease        = "2h"
+	DefaultCoordRemote       = "origin"
 	DefaultLogLevel          = "info"
 	DefaultLogFormat         = "json"
 	DefaultClaudeDataPath    = "~/.claude"
@@ -159,6 +518,38 @@ const (
 	DefaultGeminiDataPath    = "~/.gemini"
 )
 
+// registeredProvider is a provider's name and the settings it starts from.
+type registeredProvider struct {
+	name     string
+	defaults ProviderConfig
+}
+
+// registeredProviders lists providers in registration order. It is filled
+// by providers.Register, so it matches providers.Names().
+var registeredProviders []registeredProvider
+
+// RegisterProvider makes name a known provider with the given default
+// settings. It is called by providers.Register; registering the same name
+// twice replaces the earlier defaults.
+func RegisterProvider(name string, defaults ProviderConfig) {
+	for i, r := range registeredProviders {
+		if r.name == name {
+			registeredProviders[i].defaults = defaults
+			return
+		}
+	}
+	registeredProviders = append(registeredProviders, registeredProvider{name: name, defaults: defaults})
+}
+
+// ProviderNames returns the registered provider names in registration order.
+func ProviderNames() []string {
+	names := make([]string, 0, len(registeredProviders))
+	for _, r := range registeredProviders {
+		names = append(names, r.name)
+	}
+	return names
+}
+
 // DefaultLogPath returns the default log path.
 func DefaultLogPath() string {
 	home, _ := os.UserHomeDir()
@@ -177,6 +568,12 @@ func GlobalConfigPath() string {
 	return filepath.Join(home, ".config", "nightshift", "config.yaml")
 }
 
+// GlobalTasksDir returns the directory of markdown task files available
+// to every project.
+func GlobalTasksDir() string {
+	return filepath.Join(filepath.Dir(GlobalConfigPath()), "tasks")
+}
+
 // ProjectConfigName is the per-project config filename.
 const ProjectConfigName = "nightshift.yaml"
 
@@ -217,7 +614,7 @@ func LoadFromPaths(projectPath, globalPath string) (*Config, error) {
 
 	// Unmarshal into Config struct
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg, withTimeStrings); err != nil {
 		return nil, fmt.Errorf("unmarshaling config: %w", err)
 	}
 
@@ -245,20 +642,39 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("budget.snapshot_retention_days", DefaultSnapshotRetention)
 	v.SetDefault("budget.week_start_day", DefaultWeekStartDay)
 	v.SetDefault("budget.db_path", DefaultDBPath())
+	v.SetDefault("budget.session.max_percent", DefaultSessionMaxPercent)
+	v.SetDefault("budget.session.wake_reserve_percent", DefaultWakeReserve)
+	v.SetDefault("budget.session.wait_for_reset", true)
+	v.SetDefault("budget.session.max_wait", DefaultSessionMaxWait)
+	v.SetDefault("budget.burst.enabled", false)
+	v.SetDefault("budget.burst.within", DefaultBurstWithin)
+	v.SetDefault("budget.burst.min_leftover_percent", DefaultBurstMinLeftover)
+	v.SetDefault("budget.burst.max_runs", DefaultBurstMaxRuns)
+	v.SetDefault("budget.burst.spacing", DefaultBurstSpacing)
+
+	// Idle detection defaults
+	v.SetDefault("schedule.idle.enabled", false)
+	v.SetDefault("schedule.idle.duration", DefaultIdleDuration)
+	v.SetDefault("schedule.idle.check_interval", DefaultIdleCheck)
+	v.SetDefault("schedule.idle.sources", IdleSources)
+
+	// Commit trigger defaults
+	v.SetDefault("schedule.triggers.enabled", false)
+	v.SetDefault("schedule.triggers.tasks", DefaultTriggerTasks)
+	v.SetDefault("schedule.triggers.debounce", DefaultTriggerDebounce)
+
+	// Missed-run catch-up defaults
+	v.SetDefault("schedule.catch_up.enabled", true)
+	v.SetDefault("schedule.catch_up.grace", DefaultCatchUpGrace)
 
 	// Provider defaults
-	v.SetDefault("providers.preference", []string{"claude", "codex", "gemini"})
-	v.SetDefault("providers.claude.enabled", true)
-	v.SetDefault("providers.claude.data_path", DefaultClaudeDataPath)
-	// SECURITY: Default to false to require explicit opt-in for permission bypassing
-	v.SetDefault("providers.claude.dangerously_skip_permissions", false)
-	v.SetDefault("providers.codex.enabled", true)
-	v.SetDefault("providers.codex.data_path", DefaultCodexDataPath)
-	// SECURITY: Default to false to require explicit opt-in for bypassing approvals/sandbox
-	v.SetDefault("providers.codex.dangerously_bypass_approvals_and_sandbox", false)
-	v.SetDefault("providers.gemini.enabled", false)
-	v.SetDefault("providers.gemini.data_path", DefaultGeminiDataPath)
-	v.SetDefault("providers.gemini.yolo", false)
+	v.SetDefault("providers.preference", ProviderNames())
+	// SECURITY: Permission-bypass flags get no default, so they stay false
+	// until explicitly opted in
+	for _, r := range registeredProviders {
+		v.SetDefault("providers."+r.name+".enabled", r.defaults.Enabled)
+		v.SetDefault("providers."+r.name+".data_path", r.defaults.DataPath)
+	}
 
 	// Logging defaults
 	v.SetDefault("logging.level", DefaultLogLevel)
@@ -268,9 +684,27 @@ func setDefaults(v *viper.Viper) {
 	// Reporting defaults
 	v.SetDefault("reporting.morning_summary", true)
 
+	// Task defaults
+	v.SetDefault("tasks.open_pr", OpenPRSkip)
+	v.SetDefault("tasks.scope_violation", ScopeRevert)
+
 	// Integration defaults
 	v.SetDefault("integrations.claude_md", true)
 	v.SetDefault("integrations.agents_md", true)
+	v.SetDefault("integrations.pr_outcomes.enabled", true)
+	v.SetDefault("integrations.pr_outcomes.sync_interval", DefaultPRSyncInterval)
+	v.SetDefault("integrations.pr_outcomes.stale_after", DefaultPRStaleAfter)
+	v.SetDefault("integrations.pr_outcomes.weight", DefaultPRWeight)
+
+	// Coordination defaults
+	v.SetDefault("coordination.remote", DefaultCoordRemote)
+	v.SetDefault("coordination.lease", DefaultCoordLease)
+
+	// Sandbox defaults
+	v.SetDefault("sandbox.enabled", false)
+	v.SetDefault("sandbox.isolate_home", true)
+	v.SetDefault("sandbox.home_passthrough", DefaultHomePassthrough)
+	v.SetDefault("sandbox.isolate_network", false)
 }
 
 // loadConfigFile merges a YAML config file into viper.
@@ -301,6 +735,22 @@ func bindEnvVars(v *viper.Viper) {
 	_ = v.BindEnv("logging.path", "NIGHTSHIFT_LOG_PATH")
 }
 
+// withTimeStrings keeps YAML timestamps usable as strings: an unquoted
+// `start: 2026-12-24` is decoded by YAML as a time, not a string.
+func withTimeStrings(c *mapstructure.DecoderConfig) {
+	c.DecodeHook = mapstructure.ComposeDecodeHookFunc(c.DecodeHook,
+		func(from, to reflect.Type, data any) (any, error) {
+			t, ok := data.(time.Time)
+			if !ok || to.Kind() != reflect.String {
+				return data, nil
+			}
+			if t.Location() == time.UTC && t.Equal(t.Truncate(24*time.Hour)) {
+				return t.Format("2006-01-02"), nil
+			}
+			return t.Format(time.RFC3339), nil
+		})
+}
+
 // expandPath expands ~ to home directory.
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
@@ -322,9 +772,21 @@ var (
 	ErrInvalidMaxPercent        = errors.New("max_percent must be between 1 and 100")
 	ErrInvalidReservePercent    = errors.New("reserve_percent must be between 0 and 100")
 	ErrInvalidSnapshotRetention = errors.New("snapshot_retention_days must be >= 0")
+	ErrInvalidDollarCap         = errors.New("daily_dollars and weekly_dollars must be >= 0")
+	ErrInvalidPricing           = errors.New("pricing entries need a model and non-negative prices")
+	ErrInvalidModelWeight       = errors.New("model_weights entries need a model and a weight > 0")
+	ErrInvalidSessionPercent    = errors.New("session max_percent and wake_reserve_percent must be between 0 and 100")
+	ErrInvalidWakeTime          = errors.New("session wake_time must be HH:MM")
+	ErrInvalidBurst             = errors.New("burst min_leftover_percent must be between 0 and 100 and max_runs >= 0")
 	ErrInvalidLogLevel          = errors.New("log level must be debug, info, warn, or error")
 	ErrInvalidLogFormat         = errors.New("log format must be json or text")
 	ErrNoSchedule               = errors.New("either cron or interval must be specified")
+	ErrInvalidBlackoutMode      = errors.New("blackout mode must be skip or downgrade")
+	ErrInvalidOpenPRPolicy      = errors.New("open PR policy must be skip, update or allow")
+	ErrInvalidScopeViolation    = errors.New("tasks.scope_violation must be revert or reject")
+	ErrInvalidCoordination      = errors.New("coordination backend must be sqlite or git")
+	ErrInvalidIdleSource        = errors.New("idle sources must be sessions or git")
+	ErrInvalidNamedSchedule     = errors.New("named schedules need a unique name and exactly one of cron or interval")
 
 	ErrCustomTaskMissingType        = errors.New("custom task: type is required")
 	ErrCustomTaskMissingName        = errors.New("custom task: name is required")
@@ -344,6 +806,9 @@ func Validate(cfg *Config) error {
 	if cfg.Schedule.Cron != "" && cfg.Schedule.Interval != "" {
 		return ErrCronAndInterval
 	}
+	if err := validateNamedSchedules(cfg.Schedule.Named); err != nil {
+		return err
+	}
 
 	// Budget mode validation
 	if cfg.Budget.Mode != "" && cfg.Budget.Mode != "daily" && cfg.Budget.Mode != "weekly" {
@@ -380,6 +845,75 @@ func Validate(cfg *Config) error {
 		return ErrInvalidSnapshotRetention
 	}
 
+	if cfg.Budget.DailyDollars < 0 || cfg.Budget.WeeklyDollars < 0 {
+		return ErrInvalidDollarCap
+	}
+
+	for _, p := range cfg.Budget.Pricing {
+		if p.Model == "" || p.Input < 0 || p.Output < 0 || p.CacheRead < 0 || p.CacheWrite < 0 {
+			return ErrInvalidPricing
+		}
+	}
+
+	for _, w := range cfg.Budget.ModelWeights {
+		if w.Model == "" || w.Weight <= 0 {
+			return ErrInvalidModelWeight
+		}
+	}
+
+	session := cfg.Budget.Session
+	if session.MaxPercent < 0 || session.MaxPercent > 100 || session.WakeReservePercent < 0 || session.WakeReservePercent > 100 {
+		return ErrInvalidSessionPercent
+	}
+	if session.WakeTime != "" {
+		if _, err := time.Parse("15:04", session.WakeTime); err != nil {
+			return ErrInvalidWakeTime
+		}
+	}
+	if session.MaxWait != "" {
+		if _, err := time.ParseDuration(session.MaxWait); err != nil {
+			return fmt.Errorf("budget.session.max_wait: invalid duration %q: %w", session.MaxWait, err)
+		}
+	}
+
+	burst := cfg.Budget.Burst
+	if burst.MinLeftoverPercent < 0 || burst.MinLeftoverPercent > 100 || burst.MaxRuns < 0 {
+		return ErrInvalidBurst
+	}
+	if burst.Within != "" {
+		if d, err := time.ParseDuration(burst.Within); err != nil || d <= 0 {
+			return fmt.Errorf("budget.burst.within: invalid duration %q", burst.Within)
+		}
+	}
+	if burst.Spacing != "" {
+		if d, err := time.ParseDuration(burst.Spacing); err != nil || d <= 0 {
+			return fmt.Errorf("budget.burst.spacing: invalid duration %q", burst.Spacing)
+		}
+	}
+
+	if err := validateIdle(cfg.Schedule.Idle); err != nil {
+		return err
+	}
+	if err := validateBlackout(cfg.Schedule.Blackout); err != nil {
+		return err
+	}
+	if err := validatePROutcomes(cfg.Integrations.PROutcomes); err != nil {
+		return err
+	}
+	if err := validateCoordination(cfg.Coordination); err != nil {
+		return err
+	}
+	if g := cfg.Schedule.CatchUp.Grace; g != "" {
+		if parsed, err := time.ParseDuration(g); err != nil || parsed < 0 {
+			return fmt.Errorf("schedule.catch_up.grace: invalid duration %q", g)
+		}
+	}
+	if d := cfg.Schedule.Triggers.Debounce; d != "" {
+		if parsed, err := time.ParseDuration(d); err != nil || parsed <= 0 {
+			return fmt.Errorf("schedule.triggers.debounce: invalid duration %q", d)
+		}
+	}
+
 	// Log level validation
 	if cfg.Logging.Level != "" {
 		validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
@@ -396,6 +930,13 @@ func Validate(cfg *Config) error {
 	}
 
 	// Task intervals validation
+	if err := validateOpenPR(cfg.Tasks); err != nil {
+		return err
+	}
+	if err := validateScopes(cfg); err != nil {
+		return err
+	}
+
 	for taskType, dur := range cfg.Tasks.Intervals {
 		if _, err := time.ParseDuration(dur); err != nil {
 			return fmt.Errorf("tasks.intervals[%q]: invalid duration %q: %w", taskType, dur, err)
@@ -410,7 +951,7 @@ func Validate(cfg *Config) error {
 			if name == "" {
 				continue
 			}
-			if name != "claude" && name != "codex" && name != "gemini" {
+			if !slices.Contains(ProviderNames(), name) {
 				return fmt.Errorf("providers.preference contains unknown provider: %s", pref)
 			}
 			if seen[name] {
@@ -468,6 +1009,34 @@ func validateCustomTasks(tasks []CustomTaskConfig) error {
 				return fmt.Errorf("custom task %q: invalid interval %q: %w", task.Type, task.Interval, err)
 			}
 		}
+		for _, up := range slices.Concat(task.After, task.Consumes) {
+			if !customTaskTypeRe.MatchString(up) {
+				return fmt.Errorf("custom task %q: invalid upstream task type %q", task.Type, up)
+			}
+		}
+		for _, g := range slices.Concat(task.Requires.Files, task.Requires.Dirs) {
+			if _, err := path.Match(g, ""); err != nil {
+				return fmt.Errorf("custom task %q: invalid requires glob %q", task.Type, g)
+			}
+		}
+		for _, pc := range task.PreCommands {
+			if strings.TrimSpace(pc.Command) == "" {
+				return fmt.Errorf("custom task %q: pre-command has no command", task.Type)
+			}
+			if pc.Timeout != "" {
+				if d, err := time.ParseDuration(pc.Timeout); err != nil || d <= 0 {
+					return fmt.Errorf("custom task %q: invalid pre-command timeout %q", task.Type, pc.Timeout)
+				}
+			}
+			if pc.MaxOutput < 0 {
+				return fmt.Errorf("custom task %q: pre-command max_output must not be negative", task.Type)
+			}
+			switch strings.ToLower(strings.TrimSpace(pc.SkipIf)) {
+			case "", "clean", "empty":
+			default:
+				return fmt.Errorf("custom task %q: invalid pre-command skip_if %q (valid: clean, empty)", task.Type, pc.SkipIf)
+			}
+		}
 		if seenTypes[task.Type] {
 			return ErrCustomTaskDuplicateType
 		}
@@ -497,6 +1066,202 @@ func (c *Config) GetProviderBudget(provider string) int {
 	return c.Budget.WeeklyTokens
 }
 
+// ModelWeight returns the budget weight for a provider's model: the longest
+// matching model_weights prefix (case-insensitive), or 1 when none match.
+func (c *Config) ModelWeight(provider, model string) float64 {
+	model = strings.ToLower(model)
+	weight, matched := 1.0, -1
+	for _, w := range c.Budget.ModelWeights {
+		if w.Provider != "" && !strings.EqualFold(w.Provider, provider) {
+			continue
+		}
+		prefix := strings.ToLower(w.Model)
+		if strings.HasPrefix(model, prefix) && len(prefix) > matched {
+			weight, matched = w.Weight, len(prefix)
+		}
+	}
+	return weight
+}
+
+var (
+	scheduleCategories = map[string]bool{"pr": true, "analysis": true, "options": true, "safe": true, "map": true, "emergency": true}
+	scheduleCostTiers  = map[string]bool{"low": true, "medium": true, "high": true, "veryhigh": true}
+)
+
+func validateNamedSchedules(named []NamedSchedule) error {
+	seen := map[string]bool{DefaultScheduleName: true}
+	for _, n := range named {
+		if n.Name == "" || seen[n.Name] || (n.Cron == "") == (n.Interval == "") {
+			return fmt.Errorf("%w: %q", ErrInvalidNamedSchedule, n.Name)
+		}
+		seen[n.Name] = true
+		if n.MaxPercent < 0 || n.MaxPercent > 100 {
+			return fmt.Errorf("schedule %q: %w", n.Name, ErrInvalidMaxPercent)
+		}
+		for _, c := range n.Categories {
+			if !scheduleCategories[strings.ToLower(c)] {
+				return fmt.Errorf("schedule %q: unknown category %q (valid: pr, analysis, options, safe, map, emergency)", n.Name, c)
+			}
+		}
+		for _, c := range n.CostTiers {
+			if !scheduleCostTiers[strings.ToLower(c)] {
+				return fmt.Errorf("schedule %q: unknown cost tier %q (valid: low, medium, high, veryhigh)", n.Name, c)
+			}
+		}
+		if n.Interval != "" {
+			if d, err := time.ParseDuration(n.Interval); err != nil || d <= 0 {
+				return fmt.Errorf("schedule %q: invalid interval %q", n.Name, n.Interval)
+			}
+		}
+	}
+	return nil
+}
+
+func validateBlackout(b BlackoutConfig) error {
+	modes := []string{b.Mode}
+	for _, p := range b.Periods {
+		if _, _, err := p.Bounds(); err != nil {
+			return err
+		}
+		modes = append(modes, p.Mode)
+	}
+	for _, c := range b.Calendars {
+		if c.Path == "" {
+			return fmt.Errorf("blackout calendar needs a path")
+		}
+		modes = append(modes, c.Mode)
+	}
+	for _, m := range modes {
+		if m != "" && m != BlackoutSkip && m != BlackoutDowngrade {
+			return fmt.Errorf("%w: %q", ErrInvalidBlackoutMode, m)
+		}
+	}
+	return nil
+}
+
+func validateIdle(idle IdleConfig) error {
+	for _, src := range idle.Sources {
+		if !slices.Contains(IdleSources, strings.ToLower(src)) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdleSource, src)
+		}
+	}
+	if idle.Duration != "" {
+		if d, err := time.ParseDuration(idle.Duration); err != nil || d <= 0 {
+			return fmt.Errorf("schedule.idle.duration: invalid duration %q", idle.Duration)
+		}
+	}
+	if idle.CheckInterval != "" {
+		if d, err := time.ParseDuration(idle.CheckInterval); err != nil || d <= 0 {
+			return fmt.Errorf("schedule.idle.check_interval: invalid duration %q", idle.CheckInterval)
+		}
+	}
+	return nil
+}
+
+func validateOpenPR(t TasksConfig) error {
+	valid := []string{OpenPRSkip, OpenPRUpdate, OpenPRAllow}
+	if t.OpenPR != "" && !slices.Contains(valid, t.OpenPR) {
+		return fmt.Errorf("%w: tasks.open_pr %q", ErrInvalidOpenPRPolicy, t.OpenPR)
+	}
+	for taskType, policy := range t.OpenPRs {
+		if !slices.Contains(valid, policy) {
+			return fmt.Errorf("%w: tasks.open_prs[%q] %q", ErrInvalidOpenPRPolicy, taskType, policy)
+		}
+	}
+	return nil
+}
+
+func validateScopes(cfg *Config) error {
+	if v := cfg.Tasks.ScopeViolation; v != "" && v != ScopeRevert && v != ScopeReject {
+		return fmt.Errorf("%w: %q", ErrInvalidScopeViolation, v)
+	}
+	check := func(where string, s PathScope) error {
+		for _, g := range slices.Concat(s.Include, s.Exclude) {
+			if _, err := path.Match(g, ""); err != nil || g == "" {
+				return fmt.Errorf("%s: invalid scope glob %q", where, g)
+			}
+		}
+		return nil
+	}
+	for _, pc := range cfg.Projects {
+		if err := check("project "+pc.Path+pc.Pattern, pc.Scope); err != nil {
+			return err
+		}
+	}
+	for taskType, s := range cfg.Tasks.Scopes {
+		if err := check(fmt.Sprintf("tasks.scopes[%q]", taskType), s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScopeViolationPolicy returns what happens to changes outside a task's
+// path scope.
+func (c *Config) ScopeViolationPolicy() string {
+	if c.Tasks.ScopeViolation == ScopeReject {
+		return ScopeReject
+	}
+	return ScopeRevert
+}
+
+func validatePROutcomes(pr PROutcomesConfig) error {
+	if pr.SyncInterval != "" {
+		if d, err := time.ParseDuration(pr.SyncInterval); err != nil || d <= 0 {
+			return fmt.Errorf("integrations.pr_outcomes.sync_interval: invalid duration %q", pr.SyncInterval)
+		}
+	}
+	if pr.StaleAfter != "" {
+		if d, err := time.ParseDuration(pr.StaleAfter); err != nil || d <= 0 {
+			return fmt.Errorf("integrations.pr_outcomes.stale_after: invalid duration %q", pr.StaleAfter)
+		}
+	}
+	if pr.Weight < 0 {
+		return fmt.Errorf("integrations.pr_outcomes.weight: must not be negative, got %v", pr.Weight)
+	}
+	return nil
+}
+
+func validateCoordination(c CoordinationConfig) error {
+	switch c.Backend {
+	case "", CoordinationGit:
+	case CoordinationSQLite:
+		if c.Path == "" {
+			return fmt.Errorf("coordination.path: required for the sqlite backend")
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidCoordination, c.Backend)
+	}
+	if c.Lease != "" {
+		if d, err := time.ParseDuration(c.Lease); err != nil || d <= 0 {
+			return fmt.Errorf("coordination.lease: invalid duration %q", c.Lease)
+		}
+	}
+	return nil
+}
+
+// SessionWakeTime returns when the user is expected back ("HH:MM"):
+// budget.session.wake_time, else the end of the schedule window, else "".
+func (c *Config) SessionWakeTime() string {
+	if c.Budget.Session.WakeTime != "" {
+		return c.Budget.Session.WakeTime
+	}
+	if c.Schedule.Window != nil {
+		return c.Schedule.Window.End
+	}
+	return ""
+}
+
+// HasModelWeights reports whether any model_weights entry applies to provider.
+func (c *Config) HasModelWeights(provider string) bool {
+	for _, w := range c.Budget.ModelWeights {
+		if w.Provider == "" || strings.EqualFold(w.Provider, provider) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsTaskEnabled checks if a task type is enabled.
 func (c *Config) IsTaskEnabled(task string) bool {
 	// Check if explicitly disabled
@@ -516,6 +1281,18 @@ func (c *Config) IsTaskExplicitlyEnabled(task string) bool {
 	return slices.Contains(c.Tasks.Enabled, task)
 }
 
+// OpenPRPolicy returns what taskType does when its previous PR in a
+// project is still open: tasks.open_prs, else tasks.open_pr, else skip.
+func (c *Config) OpenPRPolicy(taskType string) string {
+	if policy, ok := c.Tasks.OpenPRs[taskType]; ok {
+		return policy
+	}
+	if c.Tasks.OpenPR != "" {
+		return c.Tasks.OpenPR
+	}
+	return OpenPRSkip
+}
+
 // GetTaskInterval returns the configured interval override for a task type.
 // Returns 0 if no override is set (caller should fall back to TaskDefinition.DefaultInterval).
 func (c *Config) GetTaskInterval(taskType string) time.Duration {
@@ -550,16 +1327,32 @@ func (c *Config) ExpandedDBPath() string {
 	return expandPath(c.Budget.DBPath)
 }
 
+// ExpandedSandboxPaths returns the sandbox allowed and denied paths with ~ expanded.
+func (c *Config) ExpandedSandboxPaths() (allowed, denied []string) {
+	for _, p := range c.Sandbox.AllowedPaths {
+		allowed = append(allowed, expandPath(p))
+	}
+	for _, p := range c.Sandbox.DeniedPaths {
+		denied = append(denied, expandPath(p))
+	}
+	return allowed, denied
+}
+
+// ProviderSettings returns the configuration block for a provider.
+// Unknown providers get a zero (disabled) config.
+func (c *Config) ProviderSettings(provider string) ProviderConfig {
+	return c.Providers.Settings[provider]
+}
+
+// SetProviderSettings replaces the configuration block for a provider.
+func (c *Config) SetProviderSettings(provider string, settings ProviderConfig) {
+	if c.Providers.Settings == nil {
+		c.Providers.Settings = make(map[string]ProviderConfig)
+	}
+	c.Providers.Settings[provider] = settings
+}
+
 // ExpandedProviderPath returns the provider data path with ~ expanded.
 func (c *Config) ExpandedProviderPath(provider string) string {
-	switch provider {
-	case "claude":
-		return expandPath(c.Providers.Claude.DataPath)
-	case "codex":
-		return expandPath(c.Providers.Codex.DataPath)
-	case "gemini":
-		return expandPath(c.Providers.Gemini.DataPath)
-	default:
-		return ""
-	}
+	return expandPath(c.ProviderSettings(provider).DataPath)
 }
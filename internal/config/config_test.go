@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -154,6 +155,53 @@ func TestGetProviderBudget(t *testing.T) {
 	}
 }
 
+func TestModelWeight(t *testing.T) {
+	cfg := &Config{
+		Budget: BudgetConfig{
+			ModelWeights: []ModelWeight{
+				{Provider: "claude", Model: "claude-opus", Weight: 5},
+				{Provider: "claude", Model: "claude-opus-4-5", Weight: 1.7},
+				{Model: "claude-3-5-haiku", Weight: 0.3},
+			},
+		},
+	}
+
+	tests := []struct {
+		provider, model string
+		want            float64
+	}{
+		{"claude", "claude-opus-4-1-20250805", 5},
+		{"claude", "Claude-Opus-4-5-20251101", 1.7},
+		{"claude", "claude-3-5-haiku-20241022", 0.3},
+		{"claude", "claude-sonnet-4-5", 1},
+		{"codex", "claude-opus-4", 1},
+	}
+	for _, tt := range tests {
+		if got := cfg.ModelWeight(tt.provider, tt.model); got != tt.want {
+			t.Errorf("ModelWeight(%s, %s) = %v, want %v", tt.provider, tt.model, got, tt.want)
+		}
+	}
+
+	if !cfg.HasModelWeights("codex") {
+		t.Error("HasModelWeights(codex) = false, want true (provider-less entry)")
+	}
+	cfg.Budget.ModelWeights = cfg.Budget.ModelWeights[:2]
+	if cfg.HasModelWeights("codex") {
+		t.Error("HasModelWeights(codex) = true, want false")
+	}
+}
+
+func TestValidate_InvalidModelWeight(t *testing.T) {
+	cfg := &Config{
+		Budget: BudgetConfig{
+			ModelWeights: []ModelWeight{{Model: "claude-opus", Weight: 0}},
+		},
+	}
+	if err := Validate(cfg); err != ErrInvalidModelWeight {
+		t.Errorf("expected ErrInvalidModelWeight, got %v", err)
+	}
+}
+
 func TestNormalizeBudgetConfig(t *testing.T) {
 	cfg := &Config{
 		Budget: BudgetConfig{
@@ -438,8 +486,14 @@ func TestLoadFromPaths_Defaults(t *testing.T) {
 	if cfg.Logging.Level != DefaultLogLevel {
 		t.Errorf("Logging.Level = %q, want %q", cfg.Logging.Level, DefaultLogLevel)
 	}
-	if cfg.Providers.Claude.DataPath != DefaultClaudeDataPath {
-		t.Errorf("Providers.Claude.DataPath = %q, want %q", cfg.Providers.Claude.DataPath, DefaultClaudeDataPath)
+	if cfg.Sandbox.Enabled {
+		t.Error("Sandbox.Enabled should default to false")
+	}
+	if !cfg.Sandbox.IsolateHome {
+		t.Error("Sandbox.IsolateHome should default to true")
+	}
+	if len(cfg.Sandbox.HomePassthrough) != len(DefaultHomePassthrough) {
+		t.Errorf("Sandbox.HomePassthrough = %v, want %v", cfg.Sandbox.HomePassthrough, DefaultHomePassthrough)
 	}
 }
 
@@ -589,6 +643,40 @@ func TestValidate_CustomTaskInvalidInterval(t *testing.T) {
 	}
 }
 
+func TestValidate_CustomTaskInvalidRequiresGlob(t *testing.T) {
+	cfg := &Config{
+		Tasks: TasksConfig{
+			Custom: []CustomTaskConfig{
+				{Type: "my-task", Name: "n", Description: "d", Requires: TaskRequirements{Files: []string{"db/[.sql"}}},
+			},
+		},
+	}
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), "db/[.sql") {
+		t.Errorf("expected invalid glob error, got: %v", err)
+	}
+}
+
+func TestValidate_CustomTaskInvalidPreCommand(t *testing.T) {
+	tests := map[string]PreCommandConfig{
+		"pre-command has no command": {Command: " "},
+		`timeout "soon"`:             {Command: "make lint", Timeout: "soon"},
+		`skip_if "never"`:            {Command: "make lint", SkipIf: "never"},
+	}
+	for want, pc := range tests {
+		cfg := &Config{
+			Tasks: TasksConfig{
+				Custom: []CustomTaskConfig{
+					{Type: "my-task", Name: "n", Description: "d", PreCommands: []PreCommandConfig{pc}},
+				},
+			},
+		}
+		if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%+v: expected error containing %q, got: %v", pc, want, err)
+		}
+	}
+}
+
 func TestValidate_CustomTaskDuplicateType(t *testing.T) {
 	cfg := &Config{
 		Tasks: TasksConfig{
@@ -602,3 +690,301 @@ func TestValidate_CustomTaskDuplicateType(t *testing.T) {
 		t.Errorf("expected ErrCustomTaskDuplicateType, got %v", err)
 	}
 }
+
+func TestValidate_Session(t *testing.T) {
+	cfg := &Config{Budget: BudgetConfig{Session: SessionConfig{MaxPercent: 120}}}
+	if err := Validate(cfg); err != ErrInvalidSessionPercent {
+		t.Errorf("expected ErrInvalidSessionPercent, got %v", err)
+	}
+
+	cfg = &Config{Budget: BudgetConfig{Session: SessionConfig{MaxPercent: 90, WakeTime: "7am"}}}
+	if err := Validate(cfg); err != ErrInvalidWakeTime {
+		t.Errorf("expected ErrInvalidWakeTime, got %v", err)
+	}
+}
+
+func TestSessionWakeTime(t *testing.T) {
+	cfg := &Config{Schedule: ScheduleConfig{Window: &WindowConfig{Start: "22:00", End: "06:00"}}}
+	if got := cfg.SessionWakeTime(); got != "06:00" {
+		t.Errorf("SessionWakeTime() = %q, want schedule window end", got)
+	}
+	cfg.Budget.Session.WakeTime = "07:30"
+	if got := cfg.SessionWakeTime(); got != "07:30" {
+		t.Errorf("SessionWakeTime() = %q, want 07:30", got)
+	}
+}
+
+func TestValidate_Burst(t *testing.T) {
+	cfg := &Config{Budget: BudgetConfig{Burst: BurstConfig{Enabled: true, MinLeftoverPercent: 150}}}
+	if err := Validate(cfg); err != ErrInvalidBurst {
+		t.Errorf("expected ErrInvalidBurst, got %v", err)
+	}
+
+	cfg = &Config{Budget: BudgetConfig{Burst: BurstConfig{Enabled: true, Spacing: "soon"}}}
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for invalid burst spacing")
+	}
+}
+
+func TestScheduleEntries(t *testing.T) {
+	window := &WindowConfig{Start: "22:00", End: "06:00"}
+	s := ScheduleConfig{
+		Cron:   "0 2 * * *",
+		Window: window,
+		Named: []NamedSchedule{
+			{Name: "weekend", Cron: "0 3 * * 6,0", Categories: []string{"safe"}},
+			{Name: "monthly", Cron: "0 4 1 * *", Window: &WindowConfig{Start: "00:00", End: "08:00"}},
+		},
+	}
+	entries := s.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Name != DefaultScheduleName || entries[0].Cron != "0 2 * * *" {
+		t.Errorf("expected default schedule first, got %+v", entries[0])
+	}
+	if entries[1].Window != window {
+		t.Error("named schedule should inherit schedule.window")
+	}
+	if entries[2].Window.Start != "00:00" {
+		t.Error("named schedule window should override schedule.window")
+	}
+
+	if got := (ScheduleConfig{Named: s.Named}).Entries(); len(got) != 2 || got[0].Name != "weekend" {
+		t.Errorf("expected only named entries without cron/interval, got %+v", got)
+	}
+}
+
+func TestValidate_NamedSchedules(t *testing.T) {
+	tests := []struct {
+		name  string
+		named []NamedSchedule
+		ok    bool
+	}{
+		{"valid", []NamedSchedule{{Name: "nightly", Interval: "24h", CostTiers: []string{"low"}, MaxPercent: 20}}, true},
+		{"missing name", []NamedSchedule{{Cron: "0 2 * * *"}}, false},
+		{"duplicate", []NamedSchedule{{Name: "a", Cron: "0 2 * * *"}, {Name: "a", Cron: "0 3 * * *"}}, false},
+		{"cron and interval", []NamedSchedule{{Name: "a", Cron: "0 2 * * *", Interval: "1h"}}, false},
+		{"no cadence", []NamedSchedule{{Name: "a"}}, false},
+		{"unknown category", []NamedSchedule{{Name: "a", Cron: "0 2 * * *", Categories: []string{"risky"}}}, false},
+		{"unknown cost", []NamedSchedule{{Name: "a", Cron: "0 2 * * *", CostTiers: []string{"cheap"}}}, false},
+		{"max percent", []NamedSchedule{{Name: "a", Cron: "0 2 * * *", MaxPercent: 120}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Schedule: ScheduleConfig{Named: tt.named}}
+			err := Validate(cfg)
+			if tt.ok && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestValidate_Idle(t *testing.T) {
+	cfg := &Config{Schedule: ScheduleConfig{Idle: IdleConfig{Enabled: true, Sources: []string{"sessions", "mouse"}}}}
+	if err := Validate(cfg); !errors.Is(err, ErrInvalidIdleSource) {
+		t.Errorf("expected ErrInvalidIdleSource, got %v", err)
+	}
+
+	cfg = &Config{Schedule: ScheduleConfig{Idle: IdleConfig{Enabled: true, Duration: "later"}}}
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for invalid idle duration")
+	}
+
+	cfg = &Config{Schedule: ScheduleConfig{Idle: IdleConfig{Enabled: true, Duration: "45m", Sources: []string{"git"}}}}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_CatchUpGrace(t *testing.T) {
+	cfg := &Config{Schedule: ScheduleConfig{CatchUp: CatchUpConfig{Enabled: true, Grace: "-1h"}}}
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for negative grace")
+	}
+	cfg.Schedule.CatchUp.Grace = "0"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_Blackout(t *testing.T) {
+	cfg := &Config{Schedule: ScheduleConfig{Blackout: BlackoutConfig{
+		Mode:    BlackoutSkip,
+		Periods: []BlackoutPeriod{{Name: "freeze", Start: "2026-12-14", End: "2026-12-14"}},
+	}}}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start, end, _ := cfg.Schedule.Blackout.Periods[0].Bounds()
+	if end.Sub(start) != 24*time.Hour {
+		t.Errorf("date-only end should cover the whole day, got %v", end.Sub(start))
+	}
+
+	cfg.Schedule.Blackout.Periods[0].Mode = "pause"
+	if err := Validate(cfg); !errors.Is(err, ErrInvalidBlackoutMode) {
+		t.Errorf("expected ErrInvalidBlackoutMode, got %v", err)
+	}
+
+	cfg.Schedule.Blackout.Periods[0] = BlackoutPeriod{Name: "backwards", Start: "2026-12-14 18:00", End: "2026-12-14 09:00"}
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for a period ending before it starts")
+	}
+}
+
+func TestValidate_PROutcomes(t *testing.T) {
+	cfg := &Config{Integrations: IntegrationsConfig{PROutcomes: PROutcomesConfig{
+		Enabled:      true,
+		SyncInterval: DefaultPRSyncInterval,
+		StaleAfter:   DefaultPRStaleAfter,
+		Weight:       DefaultPRWeight,
+	}}}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Integrations.PROutcomes.StaleAfter = "14d"
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for an unparseable stale_after")
+	}
+
+	cfg.Integrations.PROutcomes.StaleAfter = DefaultPRStaleAfter
+	cfg.Integrations.PROutcomes.Weight = -1
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for a negative weight")
+	}
+}
+
+func TestValidate_Coordination(t *testing.T) {
+	cfg := &Config{Coordination: CoordinationConfig{Backend: CoordinationGit, Lease: DefaultCoordLease}}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Coordination.Backend = CoordinationSQLite
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for the sqlite backend without a path")
+	}
+
+	cfg.Coordination.Path = "/mnt/shared/nightshift.db"
+	cfg.Coordination.Lease = "0s"
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for a zero lease")
+	}
+
+	cfg.Coordination.Lease = DefaultCoordLease
+	cfg.Coordination.Backend = "redis"
+	if err := Validate(cfg); !errors.Is(err, ErrInvalidCoordination) {
+		t.Errorf("expected ErrInvalidCoordination, got %v", err)
+	}
+}
+
+func TestOpenPRPolicy(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.OpenPRPolicy("lint-fix"); got != OpenPRSkip {
+		t.Errorf("default policy = %q, want skip", got)
+	}
+	cfg.Tasks.OpenPR = OpenPRAllow
+	cfg.Tasks.OpenPRs = map[string]string{"lint-fix": OpenPRUpdate}
+	if got := cfg.OpenPRPolicy("lint-fix"); got != OpenPRUpdate {
+		t.Errorf("per-task policy = %q, want update", got)
+	}
+	if got := cfg.OpenPRPolicy("bug-finder"); got != OpenPRAllow {
+		t.Errorf("tasks.open_pr policy = %q, want allow", got)
+	}
+
+	cfg.Tasks.OpenPRs["bug-finder"] = "replace"
+	if err := Validate(cfg); !errors.Is(err, ErrInvalidOpenPRPolicy) {
+		t.Errorf("expected ErrInvalidOpenPRPolicy, got %v", err)
+	}
+}
+
+func TestLoadFromPaths_BlackoutDates(t *testing.T) {
+	tmpDir := t.TempDir()
+	configContent := `
+schedule:
+  cron: "0 3 * * *"
+  blackout:
+    periods:
+      - name: freeze
+        start: 2026-12-14
+        end: 2026-12-18
+      - name: offsite
+        start: "2026-11-03 09:00"
+        end: 2026-11-05T17:00:00Z
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "nightshift.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromPaths(tmpDir, filepath.Join(tmpDir, "nonexistent.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFromPaths error: %v", err)
+	}
+	periods := cfg.Schedule.Blackout.Periods
+	if len(periods) != 2 || periods[0].Start != "2026-12-14" || periods[0].End != "2026-12-18" {
+		t.Errorf("unquoted dates should decode as dates, got %+v", periods)
+	}
+	if len(periods) == 2 && periods[1].End != "2026-11-05T17:00:00Z" {
+		t.Errorf("timestamp end = %q", periods[1].End)
+	}
+}
+
+func TestValidate_Scopes(t *testing.T) {
+	cfg := &Config{
+		Projects: []ProjectConfig{{Path: "~/code/app", Scope: PathScope{Exclude: []string{"vendor", "billing/**"}}}},
+		Tasks: TasksConfig{
+			Scopes:         map[string]PathScope{"auto-dry": {Include: []string{"src/**"}}},
+			ScopeViolation: ScopeReject,
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("valid scopes: %v", err)
+	}
+	if cfg.ScopeViolationPolicy() != ScopeReject {
+		t.Errorf("ScopeViolationPolicy() = %q, want reject", cfg.ScopeViolationPolicy())
+	}
+
+	cfg.Tasks.Scopes["auto-dry"] = PathScope{Include: []string{"src/[a"}}
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), `tasks.scopes["auto-dry"]`) {
+		t.Errorf("bad glob: err = %v", err)
+	}
+
+	cfg.Tasks.Scopes = nil
+	cfg.Tasks.ScopeViolation = "ignore"
+	if err := Validate(cfg); !errors.Is(err, ErrInvalidScopeViolation) {
+		t.Errorf("bad policy: err = %v", err)
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	saved := registeredProviders
+	defer func() { registeredProviders = saved }()
+	registeredProviders = nil
+	RegisterProvider("mycli", ProviderConfig{Enabled: true, DataPath: "~/.mycli"})
+	RegisterProvider("other", ProviderConfig{DataPath: "~/.other"})
+
+	tmpDir := t.TempDir()
+	cfg, err := LoadFromPaths(tmpDir, filepath.Join(tmpDir, "nonexistent.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFromPaths error: %v", err)
+	}
+	if got := cfg.ProviderSettings("mycli"); !got.Enabled || got.DataPath != "~/.mycli" {
+		t.Errorf("mycli settings = %+v", got)
+	}
+	if got := cfg.ProviderSettings("other"); got.Enabled {
+		t.Errorf("other should default to disabled: %+v", got)
+	}
+	if !slices.Equal(cfg.Providers.Preference, []string{"mycli", "other"}) {
+		t.Errorf("Preference = %v, want registration order", cfg.Providers.Preference)
+	}
+
+	cfg.Providers.Preference = []string{"other", "claude"}
+	if err := Validate(cfg); err == nil {
+		t.Error("expected error for unregistered provider in preference")
+	}
+}
@@ -0,0 +1,68 @@
+// Package coord implements the backends that let machines running
+// nightshift against the same projects share task claims and run history:
+// a SQLite database on a shared path, or a lease file committed to a
+// dedicated git ref in each project's remote.
+package coord
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// gitTimeout bounds each git command the backends run.
+const gitTimeout = 30 * time.Second
+
+// Backend is a state.Coordinator holding resources to release.
+type Backend interface {
+	state.Coordinator
+	io.Closer
+}
+
+// DefaultMachine returns the name this machine's claims carry when none
+// is configured: its hostname.
+func DefaultMachine() string {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// ProjectKey identifies the project at path across machines, where it may
+// be checked out at different paths: its origin remote without scheme,
+// user or .git suffix (e.g. "github.com/marcus/nightshift"), or path when
+// it has no origin.
+func ProjectKey(path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", path, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return path
+	}
+	if key := normalizeRemoteURL(string(out)); key != "" {
+		return key
+	}
+	return path
+}
+
+// normalizeRemoteURL reduces the URL and scp-like forms of a git remote to
+// host/path, so every clone of a repo yields the same key.
+func normalizeRemoteURL(url string) string {
+	url = strings.TrimSpace(url)
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	} else if host, path, ok := strings.Cut(url, ":"); ok && !strings.Contains(host, "/") {
+		// scp-like: git@github.com:owner/repo
+		url = host + "/" + path
+	}
+	if at := strings.Index(url, "@"); at >= 0 && at < strings.Index(url+"/", "/") {
+		url = url[at+1:]
+	}
+	return url
+}
@@ -0,0 +1,159 @@
+package coord
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRemoteURL(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:marcus/nightshift.git":             "github.com/marcus/nightshift",
+		"https://github.com/marcus/nightshift.git\n":       "github.com/marcus/nightshift",
+		"https://user@github.com/marcus/nightshift/":       "github.com/marcus/nightshift",
+		"ssh://git@github.com/marcus/nightshift":           "github.com/marcus/nightshift",
+		"/srv/git/nightshift.git":                          "/srv/git/nightshift",
+		"https://gitlab.example.com/group/sub/project.git": "gitlab.example.com/group/sub/project",
+	}
+	for url, want := range tests {
+		if got := normalizeRemoteURL(url); got != want {
+			t.Errorf("normalizeRemoteURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+// machines are two backends sharing a clock, standing in for two machines.
+type machines struct {
+	a, b  Backend
+	clock *time.Time
+}
+
+func TestSQLiteClaims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.db")
+	now := time.Now()
+	open := func(name string) *SQLite {
+		s, err := OpenSQLite(path, name)
+		if err != nil {
+			t.Fatalf("OpenSQLite: %v", err)
+		}
+		s.now = func() time.Time { return now }
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	}
+	testClaims(t, machines{a: open("a"), b: open("b"), clock: &now}, t.TempDir())
+}
+
+func TestGitClaims(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	origin := filepath.Join(dir, "origin.git")
+	runGit(t, dir, "init", "--quiet", "--bare", origin)
+	cloneA, cloneB := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	runGit(t, dir, "clone", "--quiet", origin, cloneA)
+	runGit(t, dir, "clone", "--quiet", origin, cloneB)
+
+	now := time.Now()
+	a, b := NewGit("origin", "a"), NewGit("origin", "b")
+	a.now = func() time.Time { return now }
+	b.now = func() time.Time { return now }
+	testClaims(t, machines{a: clonePath{a, cloneA}, b: clonePath{b, cloneB}, clock: &now}, "")
+
+	// The lease ref is not a branch
+	if out := runGit(t, cloneA, "branch", "--all"); out != "" {
+		t.Errorf("lease ref shows up as a branch: %q", out)
+	}
+}
+
+// clonePath points a backend at a fixed clone, standing in for a machine
+// with the project checked out at its own path.
+type clonePath struct {
+	*Git
+	path string
+}
+
+func (c clonePath) Claim(_, taskType string, ttl time.Duration) (string, error) {
+	return c.Git.Claim(c.path, taskType, ttl)
+}
+
+func (c clonePath) Release(_, taskType string) error {
+	return c.Git.Release(c.path, taskType)
+}
+
+func (c clonePath) Holder(_, taskType string) (string, error) {
+	c.Git.mu.Lock()
+	delete(c.Git.cache, c.path)
+	c.Git.mu.Unlock()
+	return c.Git.Holder(c.path, taskType)
+}
+
+func (c clonePath) RecordRun(_, taskType string, at time.Time) error {
+	return c.Git.RecordRun(c.path, taskType, at)
+}
+
+func (c clonePath) LastRun(_, taskType string) (time.Time, error) {
+	c.Git.mu.Lock()
+	delete(c.Git.cache, c.path)
+	c.Git.mu.Unlock()
+	return c.Git.LastRun(c.path, taskType)
+}
+
+func testClaims(t *testing.T, m machines, project string) {
+	t.Helper()
+	if holder, err := m.a.Claim(project, "lint-fix", time.Hour); err != nil || holder != "" {
+		t.Fatalf("a.Claim() = %q, %v; want claimed", holder, err)
+	}
+	if holder, err := m.b.Claim(project, "lint-fix", time.Hour); err != nil || holder != "a" {
+		t.Fatalf("b.Claim() = %q, %v; want held by a", holder, err)
+	}
+	if holder, err := m.b.Holder(project, "lint-fix"); err != nil || holder != "a" {
+		t.Errorf("b.Holder() = %q, %v; want a", holder, err)
+	}
+	if holder, err := m.a.Holder(project, "lint-fix"); err != nil || holder != "" {
+		t.Errorf("a.Holder() = %q, %v; own leases are not reported", holder, err)
+	}
+	if holder, err := m.a.Claim(project, "lint-fix", time.Hour); err != nil || holder != "" {
+		t.Errorf("a.Claim() renewal = %q, %v; want claimed", holder, err)
+	}
+	if holder, err := m.b.Claim(project, "docs-backfill", time.Hour); err != nil || holder != "" {
+		t.Errorf("b.Claim() of another task = %q, %v; want claimed", holder, err)
+	}
+
+	ran := m.clock.Truncate(time.Second)
+	if err := m.a.RecordRun(project, "lint-fix", ran); err != nil {
+		t.Fatalf("a.RecordRun: %v", err)
+	}
+	if err := m.a.Release(project, "lint-fix"); err != nil {
+		t.Fatalf("a.Release: %v", err)
+	}
+	if last, err := m.b.LastRun(project, "lint-fix"); err != nil || !last.Equal(ran) {
+		t.Errorf("b.LastRun() = %v, %v; want %v", last, err, ran)
+	}
+	if holder, err := m.b.Claim(project, "lint-fix", time.Hour); err != nil || holder != "" {
+		t.Errorf("b.Claim() after release = %q, %v; want claimed", holder, err)
+	}
+	// Releasing another machine's lease is a no-op
+	if err := m.a.Release(project, "lint-fix"); err != nil {
+		t.Fatalf("a.Release: %v", err)
+	}
+	if holder, err := m.a.Holder(project, "lint-fix"); err != nil || holder != "b" {
+		t.Errorf("a.Holder() = %q, %v; want b", holder, err)
+	}
+
+	// Expired leases can be taken over
+	*m.clock = m.clock.Add(2 * time.Hour)
+	if holder, err := m.a.Claim(project, "lint-fix", time.Hour); err != nil || holder != "" {
+		t.Errorf("a.Claim() of an expired lease = %q, %v; want claimed", holder, err)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
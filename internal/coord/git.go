@@ -0,0 +1,275 @@
+package coord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LeaseRef is the ref in each project's remote holding the lease file.
+// It lives outside refs/heads so it never shows up as a branch.
+const LeaseRef = "refs/nightshift/leases"
+
+const (
+	leaseFileName = "leases.json"
+	// gitCacheTTL is how long Holder and LastRun reuse a fetched lease file.
+	gitCacheTTL = time.Minute
+	// maxPushAttempts bounds retries when other machines move the ref
+	// between fetch and push.
+	maxPushAttempts = 3
+)
+
+// leaseFile is the JSON document committed to LeaseRef.
+type leaseFile struct {
+	Leases  map[string]lease    `json:"leases"`  // by task type
+	History map[string]runEntry `json:"history"` // by task type
+}
+
+type lease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type runEntry struct {
+	At      time.Time `json:"at"`
+	Machine string    `json:"machine"`
+}
+
+type cachedLeases struct {
+	file      *leaseFile
+	err       error
+	fetchedAt time.Time
+}
+
+// Git coordinates through a lease file committed to LeaseRef in each
+// project's remote, so machines share nothing but the remote they already
+// push to. Updates push without force: when another machine moved the ref
+// since the fetch, the push is rejected and the update is retried against
+// the new state.
+type Git struct {
+	remote  string
+	machine string
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedLeases // project path -> last fetched state
+}
+
+// NewGit returns a backend pushing lease files to remote. Claims made
+// through it carry the name machine.
+func NewGit(remote, machine string) *Git {
+	return &Git{remote: remote, machine: machine, now: time.Now, cache: make(map[string]cachedLeases)}
+}
+
+// Close implements Backend; the git backend holds no resources.
+func (g *Git) Close() error {
+	return nil
+}
+
+// Claim takes or renews this machine's lease on a task, unless another
+// machine holds a live lease, whose holder is returned.
+func (g *Git) Claim(project, taskType string, ttl time.Duration) (string, error) {
+	var holder string
+	err := g.update(project, "claim "+taskType, func(f *leaseFile, now time.Time) bool {
+		if l, ok := f.Leases[taskType]; ok && l.Holder != g.machine && l.ExpiresAt.After(now) {
+			holder = l.Holder
+			return false
+		}
+		holder = ""
+		f.Leases[taskType] = lease{Holder: g.machine, ExpiresAt: now.Add(ttl)}
+		return true
+	})
+	return holder, err
+}
+
+// Release drops this machine's lease on a task.
+func (g *Git) Release(project, taskType string) error {
+	return g.update(project, "release "+taskType, func(f *leaseFile, _ time.Time) bool {
+		if l, ok := f.Leases[taskType]; !ok || l.Holder != g.machine {
+			return false
+		}
+		delete(f.Leases, taskType)
+		return true
+	})
+}
+
+// Holder returns the other machine holding a live lease on a task, or "".
+func (g *Git) Holder(project, taskType string) (string, error) {
+	f, err := g.load(project)
+	if err != nil {
+		return "", err
+	}
+	if l, ok := f.Leases[taskType]; ok && l.Holder != g.machine && l.ExpiresAt.After(g.now()) {
+		return l.Holder, nil
+	}
+	return "", nil
+}
+
+// RecordRun records that this machine ran a task at the given time.
+func (g *Git) RecordRun(project, taskType string, at time.Time) error {
+	return g.update(project, "ran "+taskType, func(f *leaseFile, _ time.Time) bool {
+		if !at.After(f.History[taskType].At) {
+			return false
+		}
+		f.History[taskType] = runEntry{At: at, Machine: g.machine}
+		return true
+	})
+}
+
+// LastRun returns when a task last ran on any machine, or the zero time.
+func (g *Git) LastRun(project, taskType string) (time.Time, error) {
+	f, err := g.load(project)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return f.History[taskType].At, nil
+}
+
+// load returns the project's lease file, fetching it when the cached copy
+// is older than gitCacheTTL. Failures are cached too, so a project without
+// the remote is not retried on every lookup.
+func (g *Git) load(project string) (*leaseFile, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.cache[project]; ok && g.now().Sub(c.fetchedAt) < gitCacheTTL {
+		return c.file, c.err
+	}
+	f, _, err := g.fetch(project)
+	g.cache[project] = cachedLeases{file: f, err: err, fetchedAt: g.now()}
+	return f, err
+}
+
+// update fetches the lease file, applies change, and pushes the result
+// when change reports a modification, retrying when the push loses a race
+// with another machine.
+func (g *Git) update(project, action string, change func(f *leaseFile, now time.Time) bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.cache, project)
+
+	var lastErr error
+	for attempt := 0; attempt < maxPushAttempts; attempt++ {
+		f, parent, err := g.fetch(project)
+		if err != nil {
+			return err
+		}
+		now := g.now()
+		if !change(f, now) {
+			g.cache[project] = cachedLeases{file: f, fetchedAt: now}
+			return nil
+		}
+		pruneLeases(f, now)
+		commit, err := g.commit(project, f, parent, action)
+		if err != nil {
+			return err
+		}
+		if lastErr = g.push(project, commit); lastErr == nil {
+			g.cache[project] = cachedLeases{file: f, fetchedAt: now}
+			return nil
+		}
+		if !strings.Contains(lastErr.Error(), "rejected") {
+			return lastErr
+		}
+	}
+	return fmt.Errorf("%s: %w", action, lastErr)
+}
+
+// pruneLeases drops expired leases so the file does not grow.
+func pruneLeases(f *leaseFile, now time.Time) {
+	for taskType, l := range f.Leases {
+		if !l.ExpiresAt.After(now) {
+			delete(f.Leases, taskType)
+		}
+	}
+}
+
+// fetch updates the local LeaseRef from the remote and returns the lease
+// file it points at and its commit, or an empty file and "" when the ref
+// does not exist yet.
+func (g *Git) fetch(project string) (*leaseFile, string, error) {
+	// A glob refspec does not fail when the remote has no matching ref
+	if _, err := g.git(project, nil, "fetch", "--quiet", "--no-tags", g.remote, "+refs/nightshift/*:refs/nightshift/*"); err != nil {
+		return nil, "", err
+	}
+	f := &leaseFile{Leases: make(map[string]lease), History: make(map[string]runEntry)}
+	commit, err := g.git(project, nil, "rev-parse", "--verify", "--quiet", LeaseRef+"^{commit}")
+	if err != nil {
+		return f, "", nil
+	}
+	data, err := g.git(project, nil, "cat-file", "blob", commit+":"+leaseFileName)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := json.Unmarshal([]byte(data), f); err != nil {
+		return nil, "", fmt.Errorf("parse %s: %w", LeaseRef, err)
+	}
+	if f.Leases == nil {
+		f.Leases = make(map[string]lease)
+	}
+	if f.History == nil {
+		f.History = make(map[string]runEntry)
+	}
+	return f, commit, nil
+}
+
+// commit writes f as a commit on top of parent without touching the
+// working tree or index, and returns its hash.
+func (g *Git) commit(project string, f *leaseFile, parent, action string) (string, error) {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	blob, err := g.git(project, data, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	tree, err := g.git(project, []byte(fmt.Sprintf("100644 blob %s\t%s\n", blob, leaseFileName)), "mktree")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", tree, "-m", fmt.Sprintf("nightshift: %s (%s)", action, g.machine)}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	return g.git(project, nil, args...)
+}
+
+// push moves the remote LeaseRef to commit. Pushes are never forced, so
+// they fail when the remote ref is not an ancestor of commit.
+func (g *Git) push(project, commit string) error {
+	if _, err := g.git(project, nil, "push", "--quiet", g.remote, commit+":"+LeaseRef); err != nil {
+		return err
+	}
+	_, err := g.git(project, nil, "update-ref", LeaseRef, commit)
+	return err
+}
+
+// git runs a git command in project and returns its trimmed output.
+func (g *Git) git(project string, stdin []byte, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", project}, args...)...)
+	// Lease commits need an identity but must not depend on the user's
+	// git config; prompts would hang the daemon
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=nightshift", "GIT_AUTHOR_EMAIL=nightshift@"+g.machine,
+		"GIT_COMMITTER_NAME=nightshift", "GIT_COMMITTER_EMAIL=nightshift@"+g.machine,
+		"GIT_TERMINAL_PROMPT=0",
+	)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
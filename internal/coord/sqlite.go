@@ -0,0 +1,150 @@
+package coord
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema is created in the shared database on open. Times are unix
+// seconds so machines in different time zones compare them correctly.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS leases (
+    project    TEXT NOT NULL,
+    task_type  TEXT NOT NULL,
+    holder     TEXT NOT NULL,
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (project, task_type)
+);
+CREATE TABLE IF NOT EXISTS task_history (
+    project   TEXT NOT NULL,
+    task_type TEXT NOT NULL,
+    last_run  INTEGER NOT NULL,
+    machine   TEXT NOT NULL,
+    PRIMARY KEY (project, task_type)
+);`
+
+// SQLite coordinates through a database file every machine can open, e.g.
+// on a network drive. It uses rollback journaling rather than WAL, whose
+// shared memory index does not work across network filesystems, and
+// claims leases with a single conditional upsert.
+type SQLite struct {
+	db      *sql.DB
+	machine string
+	keys    sync.Map // project path -> ProjectKey
+	now     func() time.Time
+}
+
+// OpenSQLite opens or creates the shared database at path. Claims made
+// through it carry the name machine.
+func OpenSQLite(path, machine string) (*SQLite, error) {
+	sqlDB, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening coordination db: %w", err)
+	}
+	// Pragmas are per connection
+	sqlDB.SetMaxOpenConns(1)
+	for _, stmt := range []string{"PRAGMA journal_mode=DELETE;", "PRAGMA busy_timeout=10000;", sqliteSchema} {
+		if _, err := sqlDB.Exec(stmt); err != nil {
+			_ = sqlDB.Close()
+			return nil, fmt.Errorf("init coordination db %s: %w", path, err)
+		}
+	}
+	return &SQLite{db: sqlDB, machine: machine, now: time.Now}, nil
+}
+
+// Close closes the shared database.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLite) key(project string) string {
+	if key, ok := s.keys.Load(project); ok {
+		return key.(string)
+	}
+	key := ProjectKey(project)
+	s.keys.Store(project, key)
+	return key
+}
+
+// Claim takes or renews this machine's lease on a task, unless another
+// machine holds a live lease, whose holder is returned.
+func (s *SQLite) Claim(project, taskType string, ttl time.Duration) (string, error) {
+	now := s.now()
+	res, err := s.db.Exec(
+		`INSERT INTO leases (project, task_type, holder, expires_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(project, task_type) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		 WHERE leases.holder = excluded.holder OR leases.expires_at <= ?`,
+		s.key(project), taskType, s.machine, now.Add(ttl).Unix(), now.Unix(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("claim %s: %w", taskType, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return "", err
+	}
+	holder, err := s.Holder(project, taskType)
+	if err == nil && holder == "" {
+		// The lease expired between the upsert and the lookup
+		return s.Claim(project, taskType, ttl)
+	}
+	return holder, err
+}
+
+// Release drops this machine's lease on a task.
+func (s *SQLite) Release(project, taskType string) error {
+	_, err := s.db.Exec(`DELETE FROM leases WHERE project = ? AND task_type = ? AND holder = ?`,
+		s.key(project), taskType, s.machine)
+	if err != nil {
+		return fmt.Errorf("release %s: %w", taskType, err)
+	}
+	return nil
+}
+
+// Holder returns the other machine holding a live lease on a task, or "".
+func (s *SQLite) Holder(project, taskType string) (string, error) {
+	var holder string
+	err := s.db.QueryRow(
+		`SELECT holder FROM leases WHERE project = ? AND task_type = ? AND holder != ? AND expires_at > ?`,
+		s.key(project), taskType, s.machine, s.now().Unix(),
+	).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("lease holder %s: %w", taskType, err)
+	}
+	return holder, nil
+}
+
+// RecordRun records that this machine ran a task at the given time.
+func (s *SQLite) RecordRun(project, taskType string, at time.Time) error {
+	_, err := s.db.Exec(
+		`INSERT INTO task_history (project, task_type, last_run, machine) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(project, task_type) DO UPDATE SET last_run = excluded.last_run, machine = excluded.machine
+		 WHERE excluded.last_run > task_history.last_run`,
+		s.key(project), taskType, at.Unix(), s.machine,
+	)
+	if err != nil {
+		return fmt.Errorf("record run %s: %w", taskType, err)
+	}
+	return nil
+}
+
+// LastRun returns when a task last ran on any machine, or the zero time.
+func (s *SQLite) LastRun(project, taskType string) (time.Time, error) {
+	var unix int64
+	err := s.db.QueryRow(`SELECT last_run FROM task_history WHERE project = ? AND task_type = ?`,
+		s.key(project), taskType).Scan(&unix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("last run %s: %w", taskType, err)
+	}
+	return time.Unix(unix, 0), nil
+}
@@ -0,0 +1,228 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// keepMigrationBackups is how many automatic pre-migration backups are
+// kept; older ones are deleted.
+const keepMigrationBackups = 3
+
+// sqliteHeader starts every SQLite database file.
+const sqliteHeader = "SQLite format 3\x00"
+
+// Path returns the resolved database file path.
+func (d *DB) Path() string {
+	if d == nil {
+		return ""
+	}
+	return d.path
+}
+
+// LatestVersion returns the schema version this build migrates to.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// BackupDir returns the directory backups of the database at dbPath go in.
+func BackupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(expandPath(dbPath)), "backups")
+}
+
+// BackupPath returns a timestamped path in BackupDir for a backup labeled
+// label, e.g. "manual" or "pre-v11".
+func BackupPath(dbPath, label string, t time.Time) string {
+	name := fmt.Sprintf("nightshift-%s-%s.db", label, t.Format("20060102-150405.000"))
+	return filepath.Join(BackupDir(dbPath), name)
+}
+
+// Backup writes a consistent copy of the database to dest with VACUUM
+// INTO. It is safe while other connections are reading and writing.
+func (d *DB) Backup(dest string) error {
+	return backupTo(d.SQL(), dest)
+}
+
+func backupTo(db *sql.DB, dest string) error {
+	if db == nil {
+		return errors.New("db is nil")
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup %s: file already exists", dest)
+	}
+	// SECURITY: backups hold the same data as the database; keep them owner-only
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return fmt.Errorf("creating backup dir: %w", err)
+	}
+	if _, err := db.Exec(`VACUUM INTO ?`, dest); err != nil {
+		return fmt.Errorf("backup %s: %w", dest, err)
+	}
+	if err := os.Chmod(dest, 0600); err != nil {
+		return fmt.Errorf("backup %s: %w", dest, err)
+	}
+	return nil
+}
+
+// mainDBPath returns the file backing db's main schema, or "" for
+// in-memory databases.
+func mainDBPath(db *sql.DB) (string, error) {
+	rows, err := db.Query(`PRAGMA database_list`)
+	if err != nil {
+		return "", fmt.Errorf("database_list: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var seq int
+		var name, file string
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return "", fmt.Errorf("scan database_list: %w", err)
+		}
+		if name == "main" {
+			return file, nil
+		}
+	}
+	return "", rows.Err()
+}
+
+// backupBeforeMigrate backs the database up before migrating it from
+// version to a newer schema, then prunes old pre-migration backups.
+// In-memory databases are not backed up.
+func backupBeforeMigrate(db *sql.DB, version int) (string, error) {
+	path, err := mainDBPath(db)
+	if err != nil || path == "" {
+		return "", err
+	}
+	dest := BackupPath(path, fmt.Sprintf("pre-v%d", version+1), time.Now())
+	if err := backupTo(db, dest); err != nil {
+		return "", err
+	}
+	return dest, pruneBackups(BackupDir(path), "nightshift-pre-v", keepMigrationBackups)
+}
+
+// pruneBackups deletes all but the newest keep backups in dir whose names
+// start with prefix.
+func pruneBackups(dir, prefix string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read backup dir: %w", err)
+	}
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+	slices.SortFunc(backups, func(a, b backup) int { return b.modTime.Compare(a.modTime) })
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].path); err != nil {
+			return fmt.Errorf("prune backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// Restore replaces the database file at dbPath with the SQLite database at
+// src, e.g. a backup or a copy from another machine. The database at
+// dbPath must not be open. Databases from a newer nightshift, with a
+// schema version this build does not know, are refused; older ones are
+// migrated the next time the database is opened.
+func Restore(src, dbPath string) error {
+	dbPath = expandPath(dbPath)
+	if err := checkRestorable(src); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0700); err != nil {
+		return fmt.Errorf("creating db dir: %w", err)
+	}
+
+	tmp := dbPath + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		return err
+	}
+	// Stale WAL files would be replayed into the restored database
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			_ = os.Remove(tmp)
+			return fmt.Errorf("remove %s: %w", dbPath+suffix, err)
+		}
+	}
+	if err := os.Rename(tmp, dbPath); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("restore %s: %w", dbPath, err)
+	}
+	return nil
+}
+
+// IsSQLiteFile reports whether path is a SQLite database file.
+func IsSQLiteFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return string(header) == sqliteHeader, nil
+}
+
+func checkRestorable(src string) error {
+	ok, err := IsSQLiteFile(src)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", src, err)
+	}
+	if !ok {
+		return fmt.Errorf("%s is not a SQLite database", src)
+	}
+
+	sqlDB, err := sql.Open("sqlite", "file:"+src+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer func() { _ = sqlDB.Close() }()
+	version, err := CurrentVersion(sqlDB)
+	if err != nil {
+		return fmt.Errorf("%s is not a nightshift database: %w", src, err)
+	}
+	if version > LatestVersion() {
+		return fmt.Errorf("%s has schema version %d, newer than this nightshift supports (%d)", src, version, LatestVersion())
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dest, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("copy %s: %w", src, err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "nightshift.db")
+
+	database, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if _, err := database.SQL().Exec(`INSERT INTO projects (path, last_run, run_count) VALUES ('/p', ?, 4)`, time.Now()); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	backup := BackupPath(dbPath, "manual", time.Now())
+	if err := database.Backup(backup); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if err := database.Backup(backup); err == nil {
+		t.Error("expected Backup to refuse overwriting an existing file")
+	}
+	if _, err := database.SQL().Exec(`DELETE FROM projects`); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_ = database.Close()
+
+	if err := Restore(backup, dbPath); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	database, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	var runs int
+	if err := database.SQL().QueryRow(`SELECT run_count FROM projects WHERE path = '/p'`).Scan(&runs); err != nil || runs != 4 {
+		t.Errorf("restored run_count = %d (%v), want 4", runs, err)
+	}
+
+	notDB := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notDB, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(notDB, dbPath); err == nil {
+		t.Error("expected Restore to refuse a non-SQLite file")
+	}
+}
+
+func TestMigrateBacksUpBeforeUpgrading(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dbPath := filepath.Join(t.TempDir(), "nightshift.db")
+
+	// Fresh databases have nothing to back up
+	database, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if entries, _ := os.ReadDir(BackupDir(dbPath)); len(entries) != 0 {
+		t.Errorf("expected no backups for a fresh database, got %d", len(entries))
+	}
+
+	// Pretend the last migration has not been applied yet
+	latest := LatestVersion()
+	if _, err := database.SQL().Exec(`DELETE FROM schema_version WHERE version = ?`, latest); err != nil {
+		t.Fatalf("delete version: %v", err)
+	}
+	saved := migrations
+	migrations = append([]Migration(nil), saved...)
+	migrations[len(migrations)-1].SQL = `SELECT 1;`
+	defer func() { migrations = saved }()
+
+	if err := Migrate(database.SQL()); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	_ = database.Close()
+
+	entries, err := os.ReadDir(BackupDir(dbPath))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one pre-migration backup, got %v (%v)", entries, err)
+	}
+	want := fmt.Sprintf("nightshift-pre-v%d-", latest)
+	if name := entries[0].Name(); !strings.HasPrefix(name, want) {
+		t.Errorf("backup name = %q, want prefix %q", name, want)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	for i := range 5 {
+		path := filepath.Join(dir, fmt.Sprintf("nightshift-pre-v%d.db", i))
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		modTime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nightshift-manual.db"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneBackups(dir, "nightshift-pre-v", 3); err != nil {
+		t.Fatalf("pruneBackups: %v", err)
+	}
+	for i, want := range []bool{false, false, true, true, true} {
+		_, err := os.Stat(filepath.Join(dir, fmt.Sprintf("nightshift-pre-v%d.db", i)))
+		if (err == nil) != want {
+			t.Errorf("backup %d kept = %v, want %v", i, err == nil, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nightshift-manual.db")); err != nil {
+		t.Error("manual backups must not be pruned")
+	}
+}
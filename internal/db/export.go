@@ -0,0 +1,347 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNotEmpty is returned by ImportJSON when the tables being imported
+// already hold rows and replace was not requested.
+var ErrNotEmpty = errors.New("database already has data")
+
+// TableStats describes one table.
+type TableStats struct {
+	Name  string `json:"name"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"` // pages used by the table and its indexes
+}
+
+// Stats describes the database.
+type Stats struct {
+	Path          string       `json:"path"`
+	FileBytes     int64        `json:"file_bytes"`
+	SchemaVersion int          `json:"schema_version"`
+	Tables        []TableStats `json:"tables"`
+}
+
+// Export is the JSON export format read by ImportJSON: every table's rows
+// as column -> value objects.
+type Export struct {
+	SchemaVersion int                         `json:"schema_version"`
+	ExportedAt    time.Time                   `json:"exported_at"`
+	Tables        map[string][]map[string]any `json:"tables"`
+}
+
+// Tables returns the names of the nightshift data tables, sorted. SQLite
+// internals and schema_version are excluded.
+func (d *DB) Tables() ([]string, error) {
+	rows, err := d.SQL().Query(
+		`SELECT name FROM sqlite_master WHERE type = 'table'
+		 AND name NOT LIKE 'sqlite_%' AND name != 'schema_version' ORDER BY name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan table: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// Stats returns row counts and sizes per table.
+func (d *DB) Stats() (*Stats, error) {
+	version, err := CurrentVersion(d.SQL())
+	if err != nil {
+		return nil, err
+	}
+	stats := &Stats{Path: d.path, SchemaVersion: version}
+	if info, err := os.Stat(d.path); err == nil {
+		stats.FileBytes = info.Size()
+	}
+
+	tables, err := d.Tables()
+	if err != nil {
+		return nil, err
+	}
+	sizes, err := d.tableSizes()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tables {
+		var n int64
+		if err := d.SQL().QueryRow(`SELECT COUNT(*) FROM ` + quoteIdent(name)).Scan(&n); err != nil {
+			return nil, fmt.Errorf("count %s: %w", name, err)
+		}
+		stats.Tables = append(stats.Tables, TableStats{Name: name, Rows: n, Bytes: sizes[name]})
+	}
+	return stats, nil
+}
+
+// tableSizes returns the bytes used by each table and its indexes.
+func (d *DB) tableSizes() (map[string]int64, error) {
+	rows, err := d.SQL().Query(
+		`SELECT m.tbl_name, SUM(s.pgsize) FROM dbstat s
+		 JOIN sqlite_master m ON m.name = s.name GROUP BY m.tbl_name`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("table sizes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	sizes := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var size int64
+		if err := rows.Scan(&name, &size); err != nil {
+			return nil, fmt.Errorf("scan table size: %w", err)
+		}
+		sizes[name] = size
+	}
+	return sizes, rows.Err()
+}
+
+// ExportJSON writes tables (all when empty) to w in the Export format.
+func (d *DB) ExportJSON(w io.Writer, tables []string) error {
+	tables, err := d.resolveTables(tables)
+	if err != nil {
+		return err
+	}
+	version, err := CurrentVersion(d.SQL())
+	if err != nil {
+		return err
+	}
+
+	export := Export{
+		SchemaVersion: version,
+		ExportedAt:    time.Now(),
+		Tables:        make(map[string][]map[string]any, len(tables)),
+	}
+	for _, table := range tables {
+		columns, values, err := d.readTable(table)
+		if err != nil {
+			return err
+		}
+		rows := make([]map[string]any, 0, len(values))
+		for _, v := range values {
+			row := make(map[string]any, len(columns))
+			for i, col := range columns {
+				row[col] = v[i]
+			}
+			rows = append(rows, row)
+		}
+		export.Tables[table] = rows
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// ExportCSV writes table to w as CSV with a header row. NULLs are written
+// as empty fields.
+func (d *DB) ExportCSV(w io.Writer, table string) error {
+	if _, err := d.resolveTables([]string{table}); err != nil {
+		return err
+	}
+	columns, values, err := d.readTable(table)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, v := range values {
+		for i, val := range v {
+			record[i] = formatCSVValue(val)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportJSON loads an Export from r. Every table in the export must exist.
+// Tables that already hold rows are an ErrNotEmpty error unless replace is
+// set, in which case their rows are deleted first. The import is atomic.
+func (d *DB) ImportJSON(r io.Reader, replace bool) (map[string]int, error) {
+	var export Export
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&export); err != nil {
+		return nil, fmt.Errorf("parse export: %w", err)
+	}
+	if export.SchemaVersion > LatestVersion() {
+		return nil, fmt.Errorf("export has schema version %d, newer than this nightshift supports (%d)",
+			export.SchemaVersion, LatestVersion())
+	}
+
+	tables := make([]string, 0, len(export.Tables))
+	for table := range export.Tables {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+	if _, err := d.resolveTables(tables); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.SQL().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin import: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	imported := make(map[string]int, len(tables))
+	for _, table := range tables {
+		if err := prepareImportTable(tx, table, replace); err != nil {
+			return nil, err
+		}
+		for _, row := range export.Tables[table] {
+			if err := insertRow(tx, table, row); err != nil {
+				return nil, err
+			}
+		}
+		imported[table] = len(export.Tables[table])
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit import: %w", err)
+	}
+	return imported, nil
+}
+
+func prepareImportTable(tx *sql.Tx, table string, replace bool) error {
+	if replace {
+		if _, err := tx.Exec(`DELETE FROM ` + quoteIdent(table)); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+		return nil
+	}
+	var n int64
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM ` + quoteIdent(table)).Scan(&n); err != nil {
+		return fmt.Errorf("count %s: %w", table, err)
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: %s has %d rows", ErrNotEmpty, table, n)
+	}
+	return nil
+}
+
+func insertRow(tx *sql.Tx, table string, row map[string]any) error {
+	columns := make([]string, 0, len(row))
+	for col := range row {
+		columns = append(columns, col)
+	}
+	slices.Sort(columns)
+
+	quoted := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(col)
+		args[i] = importValue(row[col])
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, quoteIdent(table),
+		strings.Join(quoted, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("import %s: %w", table, err)
+	}
+	return nil
+}
+
+// importValue converts a decoded JSON value to a SQL argument.
+func importValue(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return n.String()
+}
+
+// resolveTables returns tables, or every data table when empty, and fails
+// on names that are not data tables.
+func (d *DB) resolveTables(tables []string) ([]string, error) {
+	all, err := d.Tables()
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return all, nil
+	}
+	for _, t := range tables {
+		if !slices.Contains(all, t) {
+			return nil, fmt.Errorf("unknown table %q (tables: %s)", t, strings.Join(all, ", "))
+		}
+	}
+	return tables, nil
+}
+
+// readTable returns table's column names and rows.
+func (d *DB) readTable(table string) ([]string, [][]any, error) {
+	rows, err := d.SQL().Query(`SELECT * FROM ` + quoteIdent(table) + ` ORDER BY rowid`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", table, err)
+	}
+	var values [][]any
+	for rows.Next() {
+		row := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, fmt.Errorf("scan %s: %w", table, err)
+		}
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		values = append(values, row)
+	}
+	return columns, values, rows.Err()
+}
+
+func formatCSVValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
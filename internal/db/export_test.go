@@ -0,0 +1,124 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	database, err := Open(filepath.Join(t.TempDir(), "nightshift.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database
+}
+
+func TestExportImportJSON(t *testing.T) {
+	src := openTestDB(t)
+	lastRun := time.Date(2026, 10, 1, 3, 4, 5, 123456789, time.UTC)
+	if _, err := src.SQL().Exec(`INSERT INTO projects (path, last_run, run_count) VALUES ('/p', ?, 4)`, lastRun); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := src.SQL().Exec(`INSERT INTO task_runs (project, task_type, status, tokens, duration_ms, started_at, ended_at)
+		VALUES ('/p', 'lint-fix', 'completed', 12345, 60000, ?, ?)`, lastRun, lastRun); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportJSON(&buf, nil); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+	exported := buf.String()
+
+	dest := openTestDB(t)
+	imported, err := dest.ImportJSON(strings.NewReader(exported), false)
+	if err != nil {
+		t.Fatalf("ImportJSON: %v", err)
+	}
+	if imported["projects"] != 1 || imported["task_runs"] != 1 {
+		t.Errorf("imported = %v", imported)
+	}
+
+	var gotRun time.Time
+	var runCount int
+	if err := dest.SQL().QueryRow(`SELECT last_run, run_count FROM projects WHERE path = '/p'`).Scan(&gotRun, &runCount); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if !gotRun.Equal(lastRun) || runCount != 4 {
+		t.Errorf("imported project = %v, %d; want %v, 4", gotRun, runCount, lastRun)
+	}
+	var tokens int64
+	if err := dest.SQL().QueryRow(`SELECT tokens FROM task_runs WHERE task_type = 'lint-fix'`).Scan(&tokens); err != nil || tokens != 12345 {
+		t.Errorf("imported tokens = %d (%v), want 12345", tokens, err)
+	}
+
+	// A second import collides unless replacing
+	if _, err := dest.ImportJSON(strings.NewReader(exported), false); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("expected ErrNotEmpty, got %v", err)
+	}
+	if _, err := dest.ImportJSON(strings.NewReader(exported), true); err != nil {
+		t.Errorf("ImportJSON with replace: %v", err)
+	}
+
+	if _, err := dest.ImportJSON(strings.NewReader(`{"schema_version": 1, "tables": {"nope": []}}`), true); err == nil {
+		t.Error("expected an error importing an unknown table")
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	database := openTestDB(t)
+	if _, err := database.SQL().Exec(`INSERT INTO task_history (project_path, task_type, last_run) VALUES ('/p', 'lint-fix', ?)`,
+		time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := database.ExportCSV(&buf, "task_history"); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	want := "project_path,task_type,last_run\n/p,lint-fix,2026-10-01T03:00:00Z\n"
+	if buf.String() != want {
+		t.Errorf("ExportCSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	if err := database.ExportCSV(&buf, "schema_version"); err == nil {
+		t.Error("expected an error exporting a non-data table")
+	}
+}
+
+func TestStats(t *testing.T) {
+	database := openTestDB(t)
+	if _, err := database.SQL().Exec(`INSERT INTO projects (path, run_count) VALUES ('/a', 1), ('/b', 2)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	stats, err := database.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.SchemaVersion != LatestVersion() {
+		t.Errorf("SchemaVersion = %d, want %d", stats.SchemaVersion, LatestVersion())
+	}
+	found := false
+	for _, table := range stats.Tables {
+		if table.Name == "schema_version" {
+			t.Error("schema_version should not be listed")
+		}
+		if table.Name == "projects" {
+			found = true
+			if table.Rows != 2 || table.Bytes <= 0 {
+				t.Errorf("projects stats = %+v", table)
+			}
+		}
+	}
+	if !found {
+		t.Error("projects table missing from stats")
+	}
+}
@@ -35,6 +35,46 @@ var migrations = []Migration{
 		Description: "add bus_factor_results table for code ownership analysis",
 		SQL:         migration004SQL,
 	},
+	{
+		Version:     5,
+		Description: "add spend table for per-task dollar cost",
+		SQL:         migration005SQL,
+	},
+	{
+		Version:     6,
+		Description: "add per-model token breakdown to snapshots",
+		SQL:         migration006SQL,
+	},
+	{
+		Version:     7,
+		Description: "extend spend into a per-invocation ledger",
+		SQL:         migration007SQL,
+	},
+	{
+		Version:     8,
+		Description: "add schedule_fires table for missed-run detection",
+		SQL:         migration008SQL,
+	},
+	{
+		Version:     9,
+		Description: "add task_runs table for per-execution task history and durations",
+		SQL:         migration009SQL,
+	},
+	{
+		Version:     10,
+		Description: "add PR outcome columns to task_runs",
+		SQL:         migration010SQL,
+	},
+	{
+		Version:     11,
+		Description: "add output column to task_runs for task chains",
+		SQL:         migration011SQL,
+	},
+	{
+		Version:     12,
+		Description: "add session_id column to spend for provider usage",
+		SQL:         migration012SQL,
+	},
 }
 
 const migration002SQL = `
@@ -60,6 +100,90 @@ CREATE TABLE IF NOT EXISTS bus_factor_results (
 CREATE INDEX IF NOT EXISTS idx_bus_factor_component_time ON bus_factor_results(component, timestamp DESC);
 `
 
+const migration005SQL = `
+CREATE TABLE IF NOT EXISTS spend (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp          DATETIME NOT NULL,
+    provider           TEXT NOT NULL,
+    model              TEXT NOT NULL DEFAULT '',
+    project            TEXT NOT NULL DEFAULT '',
+    task_type          TEXT NOT NULL DEFAULT '',
+    input_tokens       INTEGER NOT NULL DEFAULT 0,
+    output_tokens      INTEGER NOT NULL DEFAULT 0,
+    cache_read_tokens  INTEGER NOT NULL DEFAULT 0,
+    cache_write_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd           REAL NOT NULL DEFAULT 0,
+    estimated          INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_spend_time ON spend(timestamp DESC);
+`
+
+const migration006SQL = `
+ALTER TABLE snapshots ADD COLUMN model_tokens TEXT;
+`
+
+const migration007SQL = `
+ALTER TABLE spend ADD COLUMN phase TEXT NOT NULL DEFAULT '';
+ALTER TABLE spend ADD COLUMN iteration INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE spend ADD COLUMN task_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE spend ADD COLUMN duration_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE spend ADD COLUMN measured INTEGER NOT NULL DEFAULT 0;
+
+UPDATE spend SET measured = 1 WHERE estimated = 0;
+
+CREATE INDEX IF NOT EXISTS idx_spend_provider_time ON spend(provider, timestamp);
+`
+
+const migration008SQL = `
+CREATE TABLE IF NOT EXISTS schedule_fires (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule     TEXT NOT NULL,
+    scheduled_at DATETIME NOT NULL,
+    fired_at     DATETIME NOT NULL,
+    status       TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_fires_schedule ON schedule_fires(schedule, scheduled_at DESC);
+`
+
+const migration009SQL = `
+CREATE TABLE IF NOT EXISTS task_runs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    project     TEXT NOT NULL DEFAULT '',
+    task_type   TEXT NOT NULL,
+    provider    TEXT NOT NULL DEFAULT '',
+    status      TEXT NOT NULL DEFAULT '',
+    iterations  INTEGER NOT NULL DEFAULT 0,
+    tokens      INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    output_type TEXT NOT NULL DEFAULT '',
+    output_ref  TEXT NOT NULL DEFAULT '',
+    error       TEXT NOT NULL DEFAULT '',
+    started_at  DATETIME NOT NULL,
+    ended_at    DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_runs_started ON task_runs(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_task_runs_type_started ON task_runs(task_type, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_task_runs_project_type ON task_runs(project, task_type, started_at DESC);
+`
+
+const migration010SQL = `
+ALTER TABLE task_runs ADD COLUMN pr_state TEXT NOT NULL DEFAULT '';
+ALTER TABLE task_runs ADD COLUMN pr_reviews INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE task_runs ADD COLUMN pr_resolved_at DATETIME;
+ALTER TABLE task_runs ADD COLUMN pr_synced_at DATETIME;
+`
+
+const migration011SQL = `
+ALTER TABLE task_runs ADD COLUMN output TEXT NOT NULL DEFAULT '';
+`
+
+const migration012SQL = `
+ALTER TABLE spend ADD COLUMN session_id TEXT NOT NULL DEFAULT '';
+`
+
 const migration001SQL = `
 CREATE TABLE projects (
     path        TEXT PRIMARY KEY,
@@ -127,6 +251,17 @@ func Migrate(db *sql.DB) error {
 		return err
 	}
 
+	// Back up existing databases before changing their schema
+	if currentVersion > 0 && currentVersion < LatestVersion() {
+		backup, err := backupBeforeMigrate(db, currentVersion)
+		if err != nil {
+			return fmt.Errorf("pre-migration backup: %w", err)
+		}
+		if backup != "" {
+			log.Printf("db: backed up schema version %d to %s", currentVersion, backup)
+		}
+	}
+
 	for _, migration := range migrations {
 		if migration.Version <= currentVersion {
 			continue
@@ -0,0 +1,228 @@
+// Package idle detects whether the user is busy with interactive work, so
+// scheduled runs can wait instead of competing for the same provider limits.
+package idle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Probe reports the last time the user was seen working. A zero time means
+// no activity was found.
+type Probe interface {
+	Name() string
+	LastActivity(ctx context.Context) (time.Time, error)
+}
+
+// Ignore reports whether activity at t was caused by nightshift itself.
+type Ignore func(t time.Time) bool
+
+// Span is a period of nightshift's own activity.
+type Span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// spanSlack covers writes that land just after a run is recorded as done.
+const spanSlack = time.Minute
+
+// IgnoreSpans returns an Ignore that matches times within any span. Spans
+// with a zero End are treated as still running.
+func IgnoreSpans(spans []Span) Ignore {
+	return func(t time.Time) bool {
+		for _, s := range spans {
+			end := s.End
+			if end.IsZero() {
+				end = time.Now()
+			}
+			if !t.Before(s.Start) && !t.After(end.Add(spanSlack)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Status is the outcome of Gate.Check.
+type Status struct {
+	Idle         bool
+	LastActivity time.Time     // most recent activity; zero when none was found
+	Source       string        // probe that saw LastActivity
+	IdleFor      time.Duration // time since LastActivity
+	Wait         time.Duration // how long until the user counts as idle; 0 when Idle
+}
+
+// String describes the status, e.g. "active 4m ago (git)".
+func (s Status) String() string {
+	if s.LastActivity.IsZero() {
+		return "no recent activity"
+	}
+	return fmt.Sprintf("active %s ago (%s)", s.IdleFor.Round(time.Second), s.Source)
+}
+
+// Gate decides whether the user has been idle long enough.
+type Gate struct {
+	probes   []Probe
+	required time.Duration
+	nowFunc  func() time.Time
+}
+
+// NewGate creates a gate requiring the given idle duration across probes.
+func NewGate(required time.Duration, probes ...Probe) *Gate {
+	return &Gate{probes: probes, required: required, nowFunc: time.Now}
+}
+
+// SetNowFunc overrides the clock (for testing).
+func (g *Gate) SetNowFunc(fn func() time.Time) {
+	g.nowFunc = fn
+}
+
+// Check asks every probe for the user's last activity. Probe errors are
+// returned alongside the status of the probes that succeeded, so a broken
+// probe never blocks a run on its own.
+func (g *Gate) Check(ctx context.Context) (Status, error) {
+	now := g.nowFunc()
+	var status Status
+	var errs []string
+	for _, p := range g.probes {
+		t, err := p.LastActivity(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+			continue
+		}
+		if t.After(status.LastActivity) {
+			status.LastActivity = t
+			status.Source = p.Name()
+		}
+	}
+
+	status.Idle = true
+	if !status.LastActivity.IsZero() {
+		status.IdleFor = max(now.Sub(status.LastActivity), 0)
+		if status.IdleFor < g.required {
+			status.Idle = false
+			status.Wait = g.required - status.IdleFor
+		}
+	}
+	if len(errs) > 0 {
+		return status, fmt.Errorf("idle probes: %s", strings.Join(errs, "; "))
+	}
+	return status, nil
+}
+
+// SessionProbe reports the newest modification time of a provider's
+// session files, skipping files last written by nightshift.
+type SessionProbe struct {
+	Provider string
+	List     func() ([]string, error)
+	Ignore   Ignore
+}
+
+// Name returns the probe name, e.g. "claude sessions".
+func (p *SessionProbe) Name() string {
+	return p.Provider + " sessions"
+}
+
+// LastActivity returns the newest session modification time.
+func (p *SessionProbe) LastActivity(ctx context.Context) (time.Time, error) {
+	files, err := p.List()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var latest time.Time
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		mod := info.ModTime()
+		if mod.After(latest) && (p.Ignore == nil || !p.Ignore(mod)) {
+			latest = mod
+		}
+	}
+	return latest, nil
+}
+
+// gitLookback bounds how far back GitProbe reads commit history.
+const gitLookback = 7 * 24 * time.Hour
+
+// GitProbe reports the newest commit across the given repositories,
+// skipping commits made while nightshift was running.
+type GitProbe struct {
+	Projects []string
+	Ignore   Ignore
+}
+
+// Name returns "git".
+func (p *GitProbe) Name() string {
+	return "git"
+}
+
+// LastActivity returns the newest committer time on any branch. Paths that
+// aren't git repositories are skipped.
+func (p *GitProbe) LastActivity(ctx context.Context) (time.Time, error) {
+	since := time.Now().Add(-gitLookback).Unix()
+	var latest time.Time
+	for _, project := range p.Projects {
+		cmd := exec.CommandContext(ctx, "git", "-C", project, "log", "--all",
+			fmt.Sprintf("--since=%d", since), "--format=%ct")
+		out, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Fields(string(out)) {
+			secs, err := strconv.ParseInt(line, 10, 64)
+			if err != nil {
+				continue
+			}
+			t := time.Unix(secs, 0)
+			if p.Ignore != nil && p.Ignore(t) {
+				continue
+			}
+			if t.After(latest) {
+				latest = t
+			}
+			break // log is newest first
+		}
+	}
+	return latest, nil
+}
+
+// CommandProbe runs a command that prints the desktop idle time in
+// milliseconds, like xprintidle on X11 or a compositor-specific script on
+// Wayland.
+type CommandProbe struct {
+	Command string
+	nowFunc func() time.Time
+}
+
+// Name returns "desktop".
+func (p *CommandProbe) Name() string {
+	return "desktop"
+}
+
+// LastActivity runs the command and converts its idle time to a timestamp.
+func (p *CommandProbe) LastActivity(ctx context.Context) (time.Time, error) {
+	fields := strings.Fields(p.Command)
+	if len(fields) == 0 {
+		return time.Time{}, fmt.Errorf("empty idle command")
+	}
+	out, err := exec.CommandContext(ctx, fields[0], fields[1:]...).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", fields[0], err)
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil || ms < 0 {
+		return time.Time{}, fmt.Errorf("%s: expected idle milliseconds, got %q", fields[0], strings.TrimSpace(string(out)))
+	}
+	now := time.Now()
+	if p.nowFunc != nil {
+		now = p.nowFunc()
+	}
+	return now.Add(-time.Duration(ms) * time.Millisecond), nil
+}
@@ -0,0 +1,99 @@
+package idle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeProbe struct {
+	name string
+	t    time.Time
+	err  error
+}
+
+func (p fakeProbe) Name() string { return p.name }
+
+func (p fakeProbe) LastActivity(context.Context) (time.Time, error) { return p.t, p.err }
+
+func TestGateCheck(t *testing.T) {
+	now := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+
+	gate := NewGate(30*time.Minute,
+		fakeProbe{name: "claude sessions", t: now.Add(-2 * time.Hour)},
+		fakeProbe{name: "git", t: now.Add(-10 * time.Minute)},
+	)
+	gate.SetNowFunc(func() time.Time { return now })
+	status, err := gate.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if status.Idle || status.Source != "git" || status.Wait != 20*time.Minute {
+		t.Errorf("expected busy via git with 20m wait, got %+v", status)
+	}
+
+	gate = NewGate(30*time.Minute, fakeProbe{name: "git", t: now.Add(-45 * time.Minute)})
+	gate.SetNowFunc(func() time.Time { return now })
+	if status, _ := gate.Check(context.Background()); !status.Idle {
+		t.Errorf("expected idle after 45m, got %+v", status)
+	}
+}
+
+func TestGateCheck_ProbeErrorDoesNotBlock(t *testing.T) {
+	gate := NewGate(time.Hour, fakeProbe{name: "desktop", err: errors.New("no display")})
+	status, err := gate.Check(context.Background())
+	if err == nil {
+		t.Error("expected probe error to be reported")
+	}
+	if !status.Idle {
+		t.Error("a failing probe alone should not block the run")
+	}
+}
+
+func TestSessionProbe_IgnoresNightshiftSpans(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	user := filepath.Join(dir, "user.jsonl")
+	ours := filepath.Join(dir, "nightshift.jsonl")
+	for path, mod := range map[string]time.Time{user: now.Add(-3 * time.Hour), ours: now.Add(-time.Hour)} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	probe := &SessionProbe{
+		Provider: "claude",
+		List:     func() ([]string, error) { return []string{user, ours}, nil },
+		Ignore:   IgnoreSpans([]Span{{Start: now.Add(-90 * time.Minute), End: now.Add(-61 * time.Minute)}}),
+	}
+	got, err := probe.LastActivity(context.Background())
+	if err != nil {
+		t.Fatalf("LastActivity: %v", err)
+	}
+	if want := now.Add(-3 * time.Hour); got.Sub(want).Abs() > time.Second {
+		t.Errorf("expected user session time %v, got %v", want, got)
+	}
+}
+
+func TestCommandProbe(t *testing.T) {
+	now := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	probe := &CommandProbe{Command: "echo 90000", nowFunc: func() time.Time { return now }}
+	got, err := probe.LastActivity(context.Background())
+	if err != nil {
+		t.Fatalf("LastActivity: %v", err)
+	}
+	if want := now.Add(-90 * time.Second); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	probe = &CommandProbe{Command: "echo busy"}
+	if _, err := probe.LastActivity(context.Background()); err == nil {
+		t.Error("expected error for non-numeric output")
+	}
+}
@@ -0,0 +1,129 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// TaskTrailer is the git trailer agents add to commits to record the task
+// type that produced them.
+const TaskTrailer = "Nightshift-Task"
+
+// OpenPR is an open pull request nightshift opened for a task.
+type OpenPR struct {
+	Number   int    `json:"number"`
+	URL      string `json:"url"`
+	Branch   string `json:"branch"`
+	TaskType string `json:"task_type"`
+}
+
+// ghPR is the subset of `gh pr list --json` FindOpenPRs reads.
+type ghPR struct {
+	Number      int    `json:"number"`
+	URL         string `json:"url"`
+	HeadRefName string `json:"headRefName"`
+	Body        string `json:"body"`
+	Commits     []struct {
+		MessageBody string `json:"messageBody"`
+	} `json:"commits"`
+}
+
+// FindOpenPRs lists the open nightshift PRs of the GitHub repo at dir, keyed
+// by task type. PRs are recognized by the metadata block in their body or
+// the Nightshift-Task trailer on their commits. Returns nil when gh is not
+// installed.
+func FindOpenPRs(ctx context.Context, dir string) (map[string]OpenPR, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return nil, nil
+	}
+	cmd := exec.CommandContext(ctx, "gh", "pr", "list", "--state", "open", "--limit", "100",
+		"--json", "number,url,headRefName,body,commits")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("gh pr list: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("gh pr list: %w", err)
+	}
+	return parseOpenPRs(out)
+}
+
+func parseOpenPRs(data []byte) (map[string]OpenPR, error) {
+	var prs []ghPR
+	if err := json.Unmarshal(data, &prs); err != nil {
+		return nil, fmt.Errorf("parse gh pr list: %w", err)
+	}
+	open := make(map[string]OpenPR)
+	for _, pr := range prs {
+		taskType := prTaskType(pr)
+		if taskType == "" {
+			continue
+		}
+		// gh lists newest first; keep the newest PR per task type
+		if _, ok := open[taskType]; ok {
+			continue
+		}
+		open[taskType] = OpenPR{Number: pr.Number, URL: pr.URL, Branch: pr.HeadRefName, TaskType: taskType}
+	}
+	return open, nil
+}
+
+// prTaskType returns the task type that opened pr, or "" for PRs nightshift
+// did not open.
+func prTaskType(pr ghPR) string {
+	if meta := ParseMetadataBlock(pr.Body); meta["task-type"] != "" {
+		return meta["task-type"]
+	}
+	for _, c := range pr.Commits {
+		if t := ParseTaskTrailer(c.MessageBody); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// ParseTaskTrailer returns the value of the Nightshift-Task trailer in a
+// commit message, or "".
+func ParseTaskTrailer(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), TaskTrailer) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// SetExistingPR makes the next task update pr's branch instead of opening
+// a new PR. Nil restores the default.
+func (o *Orchestrator) SetExistingPR(pr *OpenPR) {
+	o.existingPR = pr
+}
+
+// prBranchHead returns the commit at the head of the existing PR's branch
+// on origin, or "" when there is none or it cannot be read.
+func (o *Orchestrator) prBranchHead(ctx context.Context, workDir string) string {
+	if o.existingPR == nil || o.existingPR.Branch == "" {
+		return ""
+	}
+	out, err := gitOutput(ctx, workDir, "ls-remote", "origin", "refs/heads/"+o.existingPR.Branch)
+	if err != nil {
+		return ""
+	}
+	sha, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	return sha
+}
+
+// existingPRUpdated reports whether the existing PR's branch moved since
+// the task started, meaning the agent pushed to it.
+func (o *Orchestrator) existingPRUpdated(ctx context.Context, workDir string) bool {
+	if o.existingHead == "" {
+		return false
+	}
+	head := o.prBranchHead(ctx, workDir)
+	return head != "" && head != o.existingHead
+}
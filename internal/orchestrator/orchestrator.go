@@ -16,6 +16,7 @@ import (
 
 	"github.com/marcus/nightshift/internal/agents"
 	"github.com/marcus/nightshift/internal/budget"
+	"github.com/marcus/nightshift/internal/config"
 	"github.com/marcus/nightshift/internal/logging"
 	"github.com/marcus/nightshift/internal/tasks"
 )
@@ -51,6 +52,20 @@ type TaskResult struct {
 	Error      string        `json:"error,omitempty"`
 	Duration   time.Duration `json:"duration"`
 	Logs       []LogEntry    `json:"logs"`
+	// Usage is the token usage reported by the agent across all phases.
+	Usage []agents.TokenUsage `json:"usage,omitempty"`
+	// Invocations lists each agent call with the usage it reported.
+	Invocations []Invocation `json:"invocations,omitempty"`
+}
+
+// Invocation is a single agent call made while orchestrating a task.
+type Invocation struct {
+	Phase     TaskStatus          `json:"phase"` // planning, executing or reviewing
+	Iteration int                 `json:"iteration,omitempty"`
+	Start     time.Time           `json:"start"`
+	Duration  time.Duration       `json:"duration"`
+	Usage     []agents.TokenUsage `json:"usage,omitempty"` // nil when the agent reported none
+	SessionID string              `json:"session_id,omitempty"`
 }
 
 // PlanOutput represents structured plan from the plan agent.
@@ -118,6 +133,14 @@ type Orchestrator struct {
 	logger       *logging.Logger
 	eventHandler EventHandler // optional callback for real-time events
 	runMeta      *RunMetadata
+	existingPR   *OpenPR            // set when the task should update an open PR
+	existingHead string             // its branch head when the task started
+	noPR         bool               // set when the run may not publish changes
+	scopes       []config.PathScope // set when the task's changes are limited to paths
+	scopePolicy  string
+	scopeBase    string   // commit a scoped task started from
+	scopeBranch  string   // branch a scoped task started on
+	scopeDirty   []string // paths already changed when it started
 }
 
 // Option configures an Orchestrator.
@@ -215,6 +238,12 @@ func (o *Orchestrator) RunTask(ctx context.Context, task *tasks.Task, workDir st
 	if workDir == "" && o.config.WorkDir != "" {
 		workDir = o.config.WorkDir
 	}
+	o.startScope(ctx, result, workDir)
+	o.existingHead = ""
+	if !o.noPR {
+		o.existingHead = o.prBranchHead(ctx, workDir)
+	}
+	defer o.endScope(ctx, result, workDir)
 
 	// Step 1: Plan
 	result.Status = StatusPlanning
@@ -223,7 +252,7 @@ func (o *Orchestrator) RunTask(ctx context.Context, task *tasks.Task, workDir st
 	o.emit(Event{Type: EventPhaseStart, Phase: StatusPlanning, TaskID: task.ID})
 	phaseStart := time.Now()
 
-	plan, err := o.plan(ctx, task, workDir)
+	plan, err := o.plan(ctx, result, task, workDir)
 	if err != nil {
 		result.Status = StatusFailed
 		result.Error = fmt.Sprintf("planning failed: %v", err)
@@ -249,7 +278,7 @@ func (o *Orchestrator) RunTask(ctx context.Context, task *tasks.Task, workDir st
 		o.emit(Event{Type: EventPhaseStart, Phase: StatusExecuting, TaskID: task.ID, Iteration: iteration})
 		phaseStart = time.Now()
 
-		impl, err := o.implement(ctx, task, plan, workDir, iteration)
+		impl, err := o.implement(ctx, result, task, plan, workDir, iteration)
 		if err != nil {
 			result.Status = StatusFailed
 			result.Error = fmt.Sprintf("implement failed (iteration %d): %v", iteration, err)
@@ -268,7 +297,7 @@ func (o *Orchestrator) RunTask(ctx context.Context, task *tasks.Task, workDir st
 		o.emit(Event{Type: EventPhaseStart, Phase: StatusReviewing, TaskID: task.ID, Iteration: iteration})
 		phaseStart = time.Now()
 
-		review, err := o.review(ctx, task, impl, workDir)
+		review, err := o.review(ctx, result, task, impl, workDir)
 		if err != nil {
 			result.Status = StatusFailed
 			result.Error = fmt.Sprintf("review failed (iteration %d): %v", iteration, err)
@@ -295,6 +324,16 @@ func (o *Orchestrator) RunTask(ctx context.Context, task *tasks.Task, workDir st
 			if url == "" {
 				url = ExtractPRURL(impl.Summary)
 			}
+			if url == "" {
+				// Scoped tasks open the PR in review
+				url = ExtractPRURL(review.Raw)
+			}
+			if url == "" && o.existingPRUpdated(ctx, workDir) {
+				url = o.existingPR.URL
+			}
+			if o.noPR {
+				url = ""
+			}
 			if url != "" {
 				result.OutputType = "PR"
 				result.OutputRef = url
@@ -335,6 +374,13 @@ func (o *Orchestrator) RunTask(ctx context.Context, task *tasks.Task, workDir st
 	return result, nil
 }
 
+// SetNoPR makes tasks report their results in their output only: the
+// prompts ask for no branches, commits or PRs, and PR URLs in the agents'
+// output are ignored. Runs downgraded by a blackout use it.
+func (o *Orchestrator) SetNoPR(noPR bool) {
+	o.noPR = noPR
+}
+
 // SetRunMetadata sets the metadata to inject into PRs for the next task.
 func (o *Orchestrator) SetRunMetadata(m *RunMetadata) {
 	o.runMeta = m
@@ -429,13 +475,13 @@ func (o *Orchestrator) annotatePR(ctx context.Context, prURL string, task *tasks
 }
 
 // plan spawns the plan agent to create an execution plan.
-func (o *Orchestrator) plan(ctx context.Context, task *tasks.Task, workDir string) (*PlanOutput, error) {
+func (o *Orchestrator) plan(ctx context.Context, result *TaskResult, task *tasks.Task, workDir string) (*PlanOutput, error) {
 	prompt := o.buildPlanPrompt(task)
 
 	ctx, cancel := context.WithTimeout(ctx, o.config.AgentTimeout)
 	defer cancel()
 
-	execResult, err := o.agent.Execute(ctx, agents.ExecuteOptions{
+	execResult, err := o.execute(ctx, result, agents.ExecuteOptions{
 		Prompt:  prompt,
 		WorkDir: workDir,
 		Timeout: o.config.AgentTimeout,
@@ -464,7 +510,7 @@ func (o *Orchestrator) plan(ctx context.Context, task *tasks.Task, workDir strin
 }
 
 // implement spawns the implement agent to execute the plan.
-func (o *Orchestrator) implement(ctx context.Context, task *tasks.Task, plan *PlanOutput, workDir string, iteration int) (*ImplementOutput, error) {
+func (o *Orchestrator) implement(ctx context.Context, result *TaskResult, task *tasks.Task, plan *PlanOutput, workDir string, iteration int) (*ImplementOutput, error) {
 	prompt := o.buildImplementPrompt(task, plan, iteration)
 
 	ctx, cancel := context.WithTimeout(ctx, o.config.AgentTimeout)
@@ -481,7 +527,7 @@ func (o *Orchestrator) implement(ctx context.Context, task *tasks.Task, plan *Pl
 		files = filtered
 	}
 
-	execResult, err := o.agent.Execute(ctx, agents.ExecuteOptions{
+	execResult, err := o.execute(ctx, result, agents.ExecuteOptions{
 		Prompt:  prompt,
 		WorkDir: workDir,
 		Files:   files,
@@ -509,6 +555,28 @@ func (o *Orchestrator) implement(ctx context.Context, task *tasks.Task, plan *Pl
 	return impl, nil
 }
 
+// execute runs the agent, accumulates its reported token usage into result
+// and records the call under the current phase.
+func (o *Orchestrator) execute(ctx context.Context, result *TaskResult, opts agents.ExecuteOptions) (*agents.ExecuteResult, error) {
+	start := time.Now()
+	execResult, err := o.agent.Execute(ctx, opts)
+	inv := Invocation{
+		Phase:     result.Status,
+		Iteration: result.Iterations,
+		Start:     start,
+		Duration:  time.Since(start),
+	}
+	if execResult != nil {
+		inv.SessionID = execResult.SessionID
+		if len(execResult.Usage) > 0 {
+			result.Usage = agents.MergeUsage(result.Usage, execResult.Usage)
+			inv.Usage = append([]agents.TokenUsage(nil), execResult.Usage...)
+		}
+	}
+	result.Invocations = append(result.Invocations, inv)
+	return execResult, err
+}
+
 func filterExistingFiles(files []string, workDir string) ([]string, []string) {
 	existing := make([]string, 0, len(files))
 	skipped := make([]string, 0)
@@ -562,7 +630,11 @@ func filterExistingFiles(files []string, workDir string) ([]string, []string) {
 }
 
 // review spawns the review agent to check the implementation.
-func (o *Orchestrator) review(ctx context.Context, task *tasks.Task, impl *ImplementOutput, workDir string) (*ReviewOutput, error) {
+func (o *Orchestrator) review(ctx context.Context, result *TaskResult, task *tasks.Task, impl *ImplementOutput, workDir string) (*ReviewOutput, error) {
+	// Changes outside the path scope are reverted or fail the attempt first
+	if rejected := o.checkScope(ctx, result, task, impl, workDir); rejected != nil {
+		return rejected, nil
+	}
 	prompt := o.buildReviewPrompt(task, impl)
 
 	ctx, cancel := context.WithTimeout(ctx, o.config.AgentTimeout)
@@ -579,7 +651,7 @@ func (o *Orchestrator) review(ctx context.Context, task *tasks.Task, impl *Imple
 		files = filtered
 	}
 
-	execResult, err := o.agent.Execute(ctx, agents.ExecuteOptions{
+	execResult, err := o.execute(ctx, result, agents.ExecuteOptions{
 		Prompt:  prompt,
 		WorkDir: workDir,
 		Files:   files,
@@ -662,6 +734,15 @@ func (o *Orchestrator) PlanPrompt(task *tasks.Task) string {
 }
 
 func (o *Orchestrator) buildPlanPrompt(task *tasks.Task) string {
+	steps := []string{"You are running autonomously. If the task is broad or ambiguous, choose a concrete, minimal scope that delivers value and state any assumptions in the description."}
+	steps = append(steps, o.planGitSteps(task.Type)...)
+	steps = append(steps,
+		"Analyze the task requirements",
+		"Identify files that need to be modified",
+		"Create step-by-step implementation plan",
+		"Output only valid JSON (no markdown, no extra text). The output is read by a machine. Use this schema:",
+	)
+
 	return fmt.Sprintf(`You are a planning agent. Create a detailed execution plan for this task.
 
 ## Task
@@ -669,24 +750,23 @@ ID: %s
 Title: %s
 Description: %s
 
-## Instructions
-0. You are running autonomously. If the task is broad or ambiguous, choose a concrete, minimal scope that delivers value and state any assumptions in the description.
-1. Work on a new branch and plan to submit a PR. Never work directly on the primary branch.
-2. Before creating your branch, record the current branch name and plan to switch back after the PR is opened.
-3. If you create commits, include a concise message with these git trailers:
-   Nightshift-Task: %s
-   Nightshift-Ref: https://github.com/marcus/nightshift
-4. Analyze the task requirements
-5. Identify files that need to be modified
-6. Create step-by-step implementation plan
-7. Output only valid JSON (no markdown, no extra text). The output is read by a machine. Use this schema:
-
+%s%s## Instructions
+%s
 {
   "steps": ["step1", "step2", ...],
   "files": ["file1.go", "file2.go", ...],
   "description": "overall approach"
 }
-`, task.ID, task.Title, task.Description, task.Type)
+`, task.ID, task.Title, task.Description, evidenceSection(task), o.scopeSection(), numberedSteps(0, steps...))
+}
+
+// evidenceSection returns the prompt section with the task's pre-command
+// output, or "".
+func evidenceSection(task *tasks.Task) string {
+	if task.Evidence == "" {
+		return ""
+	}
+	return "## Evidence\nOutput of tools run in the project before planning. Base the plan on it\ninstead of re-running them.\n\n" + task.Evidence + "\n\n"
 }
 
 func (o *Orchestrator) buildImplementPrompt(task *tasks.Task, plan *PlanOutput, iteration int) string {
@@ -694,6 +774,13 @@ func (o *Orchestrator) buildImplementPrompt(task *tasks.Task, plan *PlanOutput,
 	if iteration > 1 {
 		iterationNote = fmt.Sprintf("\n\n## Note\nThis is iteration %d. Previous attempts did not pass review. Pay attention to the feedback in the plan description.", iteration)
 	}
+	steps := o.implementGitSteps(task.Type)
+	if o.noPR {
+		steps = append(steps, "Carry out the plan step by step", "Report your findings in the summary")
+	} else {
+		steps = append(steps, "Implement the plan step by step", "Make all necessary code changes", "Ensure tests pass")
+	}
+	steps = append(steps, "Output a summary as JSON:")
 
 	return fmt.Sprintf(`You are an implementation agent. Execute the plan for this task.
 
@@ -708,25 +795,25 @@ Description: %s
 ## Steps
 %v
 %s
-## Instructions
-0. Before creating your branch, record the current branch name. Create and work on a new branch. Never modify or commit directly to the primary branch.
-   When finished, open a PR. After the PR is submitted, switch back to the original branch. If you cannot open a PR, leave the branch and explain next steps.
-1. If you create commits, include a concise message with these git trailers:
-   Nightshift-Task: %s
-   Nightshift-Ref: https://github.com/marcus/nightshift
-2. Implement the plan step by step
-3. Make all necessary code changes
-4. Ensure tests pass
-5. Output a summary as JSON:
-
+%s## Instructions
+%s
 {
   "files_modified": ["file1.go", ...],
   "summary": "what was done"
 }
-`, task.ID, task.Title, task.Description, plan.Description, plan.Steps, iterationNote, task.Type)
+`, task.ID, task.Title, task.Description, plan.Description, plan.Steps, iterationNote, o.scopeSection(), numberedSteps(0, steps...))
 }
 
 func (o *Orchestrator) buildReviewPrompt(task *tasks.Task, impl *ImplementOutput) string {
+	steps := []string{
+		o.reviewBranchStep(),
+		"Check if implementation meets task requirements",
+		"Verify code quality and correctness",
+		"Check for bugs or issues",
+	}
+	steps = append(steps, o.reviewPublishSteps()...)
+	steps = append(steps, "Output your review as JSON:")
+
 	return fmt.Sprintf(`You are a code review agent. Review this implementation.
 
 ## Task
@@ -740,13 +827,8 @@ Description: %s
 ## Files Modified
 %v
 
-## Instructions
-1. Confirm work was done on a branch (not primary) and is ready for a PR
-2. Check if implementation meets task requirements
-3. Verify code quality and correctness
-4. Check for bugs or issues
-5. Output your review as JSON:
-
+%s## Instructions
+%s
 {
   "passed": true/false,
   "feedback": "detailed feedback",
@@ -754,7 +836,96 @@ Description: %s
 }
 
 Set "passed" to true ONLY if the implementation is correct and complete.
-`, task.ID, task.Title, task.Description, impl.Summary, impl.FilesModified)
+`, task.ID, task.Title, task.Description, impl.Summary, impl.FilesModified, o.scopeSection(), numberedSteps(1, steps...))
+}
+
+// noPRStep is the instruction that replaces branch, commit and PR steps
+// in no-PR mode.
+const noPRStep = "Do not create branches or commits, push, or open a PR: this run may not publish changes. Leave the repository as you found it."
+
+// planGitSteps returns the planning instructions on where the work goes:
+// a new branch and PR, the existing PR's branch, or nowhere in no-PR mode.
+func (o *Orchestrator) planGitSteps(taskType tasks.TaskType) []string {
+	if o.noPR {
+		return []string{noPRStep + " Plan to report the results in the output."}
+	}
+	if pr := o.existingPR; pr != nil {
+		return []string{
+			fmt.Sprintf("An open nightshift PR for this task already exists: %s (branch %s). Plan to build on its changes on that branch. Do not plan a new branch or PR.", pr.URL, pr.Branch),
+			"Before checking out that branch, record the current branch name and plan to switch back afterwards.",
+			trailerStep(taskType),
+		}
+	}
+	return []string{
+		"Work on a new branch and plan to submit a PR. Never work directly on the primary branch.",
+		"Before creating your branch, record the current branch name and plan to switch back after the PR is opened.",
+		trailerStep(taskType),
+	}
+}
+
+// implementGitSteps returns the implementation instructions on where the
+// work goes and the trailers its commits carry.
+func (o *Orchestrator) implementGitSteps(taskType tasks.TaskType) []string {
+	if o.noPR {
+		return []string{noPRStep}
+	}
+	return []string{o.implementBranchStep(), trailerStep(taskType)}
+}
+
+// implementBranchStep returns the implementation instruction on where the
+// work goes and how it is delivered. Scoped work is only committed: its
+// paths are checked before review publishes it.
+func (o *Orchestrator) implementBranchStep() string {
+	start := "Before creating your branch, record the current branch name. Create and work on a new branch. Never modify or commit directly to the primary branch."
+	finish := "When finished, open a PR. After the PR is submitted, switch back to the original branch. If you cannot open a PR, leave the branch and explain next steps."
+	if pr := o.existingPR; pr != nil {
+		start = fmt.Sprintf("An open nightshift PR for this task already exists: %s (branch %s). Before checking out its branch, record the current branch name. Build on its changes. Do not create a new branch or PR, and never modify or commit directly to the primary branch.", pr.URL, pr.Branch)
+		finish = "When finished, push your commits to the PR's branch, then switch back to the original branch. If you cannot push, leave the branch and explain next steps."
+	}
+	if o.scoped() {
+		finish = "When finished, commit your work and stay on the branch. Do not push or open a PR: the changed paths are checked first, and the work is published after review."
+	}
+	return start + "\n   " + finish
+}
+
+// reviewBranchStep returns the review instruction checking where the work
+// was done.
+func (o *Orchestrator) reviewBranchStep() string {
+	if o.noPR {
+		return "Confirm no branch, commit or PR was created"
+	}
+	if pr := o.existingPR; pr != nil {
+		return fmt.Sprintf("Confirm work was done on the existing PR's branch %s (not primary)", pr.Branch)
+	}
+	return "Confirm work was done on a branch (not primary) and is ready for a PR"
+}
+
+// reviewPublishSteps returns the review instructions that publish a scoped
+// task's checked work. Unscoped work is published by the implementation.
+func (o *Orchestrator) reviewPublishSteps() []string {
+	if !o.scoped() || o.noPR {
+		return nil
+	}
+	if pr := o.existingPR; pr != nil {
+		return []string{fmt.Sprintf("If the review passes, push the branch to the existing PR %s, then switch back to the original branch", pr.URL)}
+	}
+	return []string{"If the review passes, push the branch and open the PR, include its URL in your feedback, then switch back to the original branch"}
+}
+
+// trailerStep returns the instruction on the git trailers commits carry.
+func trailerStep(taskType tasks.TaskType) string {
+	return fmt.Sprintf(`If you create commits, include a concise message with these git trailers:
+   %s: %s
+   Nightshift-Ref: https://github.com/marcus/nightshift`, TaskTrailer, taskType)
+}
+
+// numberedSteps formats steps as a numbered list starting at first.
+func numberedSteps(first int, steps ...string) string {
+	var b strings.Builder
+	for i, step := range steps {
+		fmt.Fprintf(&b, "%d. %s\n", first+i, step)
+	}
+	return b.String()
 }
 
 // prURLPattern matches standard GitHub pull request URLs.
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
 	"github.com/marcus/nightshift/internal/tasks"
 )
 
@@ -162,6 +163,64 @@ func TestRunTaskSuccessFirstIteration(t *testing.T) {
 	}
 }
 
+func TestRunTaskAccumulatesUsage(t *testing.T) {
+	planResp := jsonResponse(PlanOutput{Steps: []string{"step1"}, Description: "plan"})
+	planResp.Usage = []agents.TokenUsage{{Model: "m1", InputTokens: 100, OutputTokens: 10}}
+	implResp := jsonResponse(ImplementOutput{Summary: "done"})
+	implResp.Usage = []agents.TokenUsage{{Model: "m1", InputTokens: 200, CacheReadTokens: 50}}
+	reviewResp := jsonResponse(ReviewOutput{Passed: true})
+	reviewResp.Usage = []agents.TokenUsage{{Model: "m2", OutputTokens: 5}}
+
+	o := New(WithAgent(newMockAgent(planResp, implResp, reviewResp)))
+	result, err := o.RunTask(context.Background(), &tasks.Task{ID: "usage", Title: "Usage"}, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []agents.TokenUsage{
+		{Model: "m1", InputTokens: 300, OutputTokens: 10, CacheReadTokens: 50},
+		{Model: "m2", OutputTokens: 5},
+	}
+	if len(result.Usage) != len(want) {
+		t.Fatalf("usage = %+v, want %+v", result.Usage, want)
+	}
+	for i := range want {
+		if result.Usage[i] != want[i] {
+			t.Errorf("usage[%d] = %+v, want %+v", i, result.Usage[i], want[i])
+		}
+	}
+}
+
+func TestRunTaskRecordsInvocations(t *testing.T) {
+	planResp := jsonResponse(PlanOutput{Steps: []string{"step1"}, Description: "plan"})
+	planResp.Usage = []agents.TokenUsage{{Model: "m1", InputTokens: 100, OutputTokens: 10}}
+	implResp := jsonResponse(ImplementOutput{Summary: "done"})
+	reviewResp := jsonResponse(ReviewOutput{Passed: true})
+	reviewResp.Usage = []agents.TokenUsage{{Model: "m2", OutputTokens: 5}}
+
+	o := New(WithAgent(newMockAgent(planResp, implResp, reviewResp)))
+	result, err := o.RunTask(context.Background(), &tasks.Task{ID: "ledger", Title: "Ledger"}, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantPhases := []TaskStatus{StatusPlanning, StatusExecuting, StatusReviewing}
+	if len(result.Invocations) != len(wantPhases) {
+		t.Fatalf("invocations = %+v, want %d", result.Invocations, len(wantPhases))
+	}
+	for i, phase := range wantPhases {
+		if result.Invocations[i].Phase != phase {
+			t.Errorf("invocation %d phase = %s, want %s", i, result.Invocations[i].Phase, phase)
+		}
+	}
+	if result.Invocations[1].Iteration != 1 || result.Invocations[1].Usage != nil {
+		t.Errorf("implement invocation = %+v, want iteration 1 without usage", result.Invocations[1])
+	}
+	if got := result.Invocations[2].Usage; len(got) != 1 || got[0].Model != "m2" {
+		t.Errorf("review usage = %+v", got)
+	}
+}
+
 func TestRunTaskReviewFailsThenPasses(t *testing.T) {
 	// Setup: plan, implement, review (fail), implement, review (pass)
 	planResp := jsonResponse(PlanOutput{
@@ -759,3 +818,145 @@ func TestRunTaskNoPRURL(t *testing.T) {
 		t.Errorf("OutputRef = %q, want empty", result.OutputRef)
 	}
 }
+
+func TestParseOpenPRs(t *testing.T) {
+	data := `[
+	  {"number": 12, "url": "https://github.com/o/r/pull/12", "headRefName": "nightshift/lint-2",
+	   "body": "Fix lint\n<!-- nightshift:metadata\ntask-type: lint-fix\nnightshift:metadata -->", "commits": []},
+	  {"number": 9, "url": "https://github.com/o/r/pull/9", "headRefName": "nightshift/lint-1",
+	   "body": "<!-- nightshift:metadata\ntask-type: lint-fix\nnightshift:metadata -->", "commits": []},
+	  {"number": 8, "url": "https://github.com/o/r/pull/8", "headRefName": "docs",
+	   "body": "Docs", "commits": [{"messageBody": "Update docs\n\nNightshift-Task: docs-backfill\nNightshift-Ref: https://github.com/marcus/nightshift"}]},
+	  {"number": 7, "url": "https://github.com/o/r/pull/7", "headRefName": "feature",
+	   "body": "Human PR", "commits": [{"messageBody": "Add feature"}]}
+	]`
+	open, err := parseOpenPRs([]byte(data))
+	if err != nil {
+		t.Fatalf("parseOpenPRs: %v", err)
+	}
+	if len(open) != 2 {
+		t.Fatalf("got %d open PRs, want 2: %v", len(open), open)
+	}
+	if pr := open["lint-fix"]; pr.Number != 12 || pr.Branch != "nightshift/lint-2" {
+		t.Errorf("lint-fix = %+v, want the newest PR #12", pr)
+	}
+	if pr := open["docs-backfill"]; pr.Number != 8 {
+		t.Errorf("docs-backfill = %+v, want PR #8 found by commit trailer", pr)
+	}
+}
+
+func TestBuildPrompts_ExistingPR(t *testing.T) {
+	o := New()
+	task := &tasks.Task{ID: "lint-fix:/repo", Title: "Lint Fix", Type: "lint-fix"}
+	plan := &PlanOutput{Description: "fix lint"}
+
+	if strings.Contains(o.buildPlanPrompt(task), "already exists") {
+		t.Error("plan prompt mentions an existing PR when none is set")
+	}
+
+	o.SetExistingPR(&OpenPR{URL: "https://github.com/o/r/pull/12", Branch: "nightshift/lint"})
+	for name, prompt := range map[string]string{
+		"plan":      o.buildPlanPrompt(task),
+		"implement": o.buildImplementPrompt(task, plan, 1),
+	} {
+		if !strings.Contains(prompt, "https://github.com/o/r/pull/12 (branch nightshift/lint)") {
+			t.Errorf("%s prompt does not point at the existing PR:\n%s", name, prompt)
+		}
+		if strings.Contains(prompt, "on a new branch") || strings.Contains(prompt, "open a PR") {
+			t.Errorf("%s prompt still asks for a new branch or PR:\n%s", name, prompt)
+		}
+	}
+}
+
+func TestExistingPRUpdated(t *testing.T) {
+	dir := newScopeRepo(t)
+	ctx := context.Background()
+	origin := t.TempDir()
+	gitRun(t, origin, "init", "-q", "--bare")
+	gitRun(t, dir, "remote", "add", "origin", origin)
+	gitRun(t, dir, "push", "-q", "origin", "main:nightshift/lint")
+
+	o := New()
+	o.SetExistingPR(&OpenPR{URL: "https://github.com/o/r/pull/12", Branch: "nightshift/lint"})
+	o.existingHead = o.prBranchHead(ctx, dir)
+	if o.existingHead == "" {
+		t.Fatal("branch head not read from origin")
+	}
+	if o.existingPRUpdated(ctx, dir) {
+		t.Error("existing PR reported updated before any push")
+	}
+
+	writeFile(t, dir, "src/app.go", "package src\n\nfunc Fixed() {}\n")
+	gitRun(t, dir, "commit", "-q", "-am", "fix")
+	gitRun(t, dir, "push", "-q", "origin", "main:nightshift/lint")
+	if !o.existingPRUpdated(ctx, dir) {
+		t.Error("push to the PR's branch not detected")
+	}
+}
+
+func TestRunTask_ExistingPRNotUpdated(t *testing.T) {
+	agent := newMockAgent(
+		jsonResponse(PlanOutput{Steps: []string{"step1"}, Description: "test plan"}),
+		jsonResponse(ImplementOutput{Summary: "nothing to add"}),
+		jsonResponse(ReviewOutput{Passed: true, Feedback: "looks good"}),
+	)
+	o := New(WithAgent(agent))
+	o.SetExistingPR(&OpenPR{URL: "https://github.com/o/r/pull/12", Branch: "nightshift/lint"})
+
+	result, err := o.RunTask(context.Background(), &tasks.Task{ID: "lint-fix:/work", Type: "lint-fix"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.OutputRef != "" {
+		t.Errorf("OutputRef = %q, want empty when the PR's branch did not move", result.OutputRef)
+	}
+}
+
+func TestBuildPlanPrompt_Evidence(t *testing.T) {
+	o := New()
+	task := &tasks.Task{ID: "lint-fix:/repo", Title: "Lint Fix", Type: "lint-fix"}
+	if strings.Contains(o.buildPlanPrompt(task), "## Evidence") {
+		t.Error("plan prompt has an evidence section without evidence")
+	}
+
+	task.Evidence = "### golangci-lint\nmain.go:3:1: unused variable"
+	if prompt := o.buildPlanPrompt(task); !strings.Contains(prompt, "## Evidence") || !strings.Contains(prompt, "main.go:3:1: unused variable") {
+		t.Errorf("plan prompt does not include the evidence:\n%s", prompt)
+	}
+}
+
+func TestNoPR(t *testing.T) {
+	o := New()
+	o.SetNoPR(true)
+	o.SetExistingPR(&OpenPR{URL: "https://github.com/o/r/pull/12", Branch: "nightshift/lint"})
+	o.SetScope([]config.PathScope{{Exclude: []string{"vendor"}}}, config.ScopeRevert)
+	task := &tasks.Task{ID: "doc-drift:/repo", Title: "Doc Drift", Type: "doc-drift"}
+	plan := &PlanOutput{Description: "check docs"}
+	for name, prompt := range map[string]string{
+		"plan":      o.buildPlanPrompt(task),
+		"implement": o.buildImplementPrompt(task, plan, 1),
+		"review":    o.buildReviewPrompt(task, &ImplementOutput{Summary: "findings"}),
+	} {
+		for _, s := range []string{"new branch", "submit a PR", "When finished", "open the PR", "pull/12", "Nightshift-Task:", "PR is opened"} {
+			if strings.Contains(prompt, s) {
+				t.Errorf("%s prompt contains %q in no-PR mode:\n%s", name, s, prompt)
+			}
+		}
+	}
+
+	// A PR opened anyway is not reported
+	agent := newMockAgent(
+		jsonResponse(PlanOutput{Steps: []string{"step1"}, Description: "test plan"}),
+		jsonResponse(ImplementOutput{Summary: "Opened https://github.com/o/r/pull/13"}),
+		jsonResponse(ReviewOutput{Passed: true, Feedback: "looks good"}),
+	)
+	o = New(WithAgent(agent))
+	o.SetNoPR(true)
+	result, err := o.RunTask(context.Background(), task, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.OutputType != "" || result.OutputRef != "" {
+		t.Errorf("output = %q %q, want no PR in no-PR mode", result.OutputType, result.OutputRef)
+	}
+}
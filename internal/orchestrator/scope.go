@@ -0,0 +1,208 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+// Path scopes: when set, agents are told which files they may change, the
+// implementation leaves its commits on its branch, and the changes since
+// the task started are checked before review. Under the revert policy
+// files outside the scope are reverted; under reject the attempt fails
+// with feedback naming them. The review step opens the PR.
+
+// SetScope limits the next task's changes to paths every scope allows,
+// with policy (config.ScopeRevert or config.ScopeReject) deciding what
+// happens to the rest. Nil restores the default.
+func (o *Orchestrator) SetScope(scopes []config.PathScope, policy string) {
+	o.scopes = nil
+	for _, s := range scopes {
+		if !s.IsZero() {
+			o.scopes = append(o.scopes, s)
+		}
+	}
+	o.scopePolicy = policy
+}
+
+// scoped reports whether the current task has a path scope.
+func (o *Orchestrator) scoped() bool {
+	return len(o.scopes) > 0
+}
+
+// scopeSection returns the prompt section stating the path scope, or "".
+func (o *Orchestrator) scopeSection() string {
+	if !o.scoped() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Path scope\nOnly change files allowed by every line below. Globs are relative to the project;\n** spans directories and a directory covers everything below it.\n")
+	for _, s := range o.scopes {
+		if len(s.Include) > 0 {
+			fmt.Fprintf(&b, "- Only within: %s\n", strings.Join(s.Include, ", "))
+		}
+		if len(s.Exclude) > 0 {
+			fmt.Fprintf(&b, "- Never: %s\n", strings.Join(s.Exclude, ", "))
+		}
+	}
+	if o.scopePolicy == config.ScopeReject {
+		b.WriteString("Changes outside the scope fail the attempt.\n")
+	} else {
+		b.WriteString("Changes outside the scope are reverted before review.\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// startScope records the commit and branch a scoped task starts from, so
+// its changes can be checked and the branch restored afterwards. Paths
+// already changed at the start are not the agent's and are left alone.
+func (o *Orchestrator) startScope(ctx context.Context, result *TaskResult, workDir string) {
+	o.scopeBase, o.scopeBranch, o.scopeDirty = "", "", nil
+	if !o.scoped() {
+		return
+	}
+	base, err := gitOutput(ctx, workDir, "rev-parse", "HEAD")
+	if err != nil {
+		o.log(result, "warn", "path scope not enforced: no git HEAD", map[string]any{"error": err.Error()})
+		return
+	}
+	dirty, err := changedPaths(ctx, workDir, "HEAD")
+	if err != nil {
+		o.log(result, "warn", "path scope not enforced", map[string]any{"error": err.Error()})
+		return
+	}
+	o.scopeBase = strings.TrimSpace(string(base))
+	o.scopeDirty = dirty
+	if branch, err := gitOutput(ctx, workDir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		o.scopeBranch = strings.TrimSpace(string(branch))
+	}
+}
+
+// endScope switches back to the branch the task started on if the agent
+// left the work tree on its own branch with no uncommitted changes.
+func (o *Orchestrator) endScope(ctx context.Context, result *TaskResult, workDir string) {
+	if o.scopeBranch == "" || o.scopeBranch == "HEAD" {
+		return
+	}
+	branch, err := gitOutput(ctx, workDir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || strings.TrimSpace(string(branch)) == o.scopeBranch {
+		return
+	}
+	if status, err := gitOutput(ctx, workDir, "status", "--porcelain", "--untracked-files=no"); err != nil || len(status) > 0 {
+		return
+	}
+	if _, err := gitOutput(ctx, workDir, "checkout", "-q", o.scopeBranch); err != nil {
+		o.log(result, "warn", "switch back to original branch failed", map[string]any{"branch": o.scopeBranch, "error": err.Error()})
+	}
+}
+
+// checkScope checks the changes made since the task started against its
+// path scope. Under the revert policy files outside the scope are
+// reverted and noted in impl's summary; under reject, or when reverting
+// fails, it returns a failed review naming them. It returns nil when the
+// changes are in scope or cannot be checked.
+func (o *Orchestrator) checkScope(ctx context.Context, result *TaskResult, task *tasks.Task, impl *ImplementOutput, workDir string) *ReviewOutput {
+	if !o.scoped() || o.scopeBase == "" {
+		return nil
+	}
+	changed, err := changedPaths(ctx, workDir, o.scopeBase)
+	if err != nil {
+		o.log(result, "warn", "path scope check failed", map[string]any{"error": err.Error()})
+		return nil
+	}
+	changed = slices.DeleteFunc(changed, func(p string) bool { return slices.Contains(o.scopeDirty, p) })
+	outside := tasks.ScopeViolations(o.scopes, changed)
+	if len(outside) == 0 {
+		return nil
+	}
+	o.log(result, "warn", "changes outside path scope", map[string]any{"paths": outside, "policy": o.scopePolicy})
+
+	if o.scopePolicy != config.ScopeReject {
+		err := revertPaths(ctx, workDir, o.scopeBase, outside, task)
+		if err == nil {
+			impl.Summary += "\n\nReverted changes outside the path scope: " + strings.Join(outside, ", ")
+			impl.FilesModified = slices.DeleteFunc(impl.FilesModified, func(f string) bool { return slices.Contains(outside, f) })
+			return nil
+		}
+		o.log(result, "warn", "revert out-of-scope changes failed", map[string]any{"error": err.Error()})
+	}
+	return &ReviewOutput{
+		Passed:   false,
+		Feedback: fmt.Sprintf("Changed files outside the path scope: %s. Undo those changes and keep the work within the scope.", strings.Join(outside, ", ")),
+		Issues:   outside,
+	}
+}
+
+// changedPaths returns the paths under dir, relative to it, that differ
+// from base: committed, staged, unstaged and untracked changes. Renames
+// count as a deletion and an addition, so moving a file out of a path
+// changes that path.
+func changedPaths(ctx context.Context, dir, base string) ([]string, error) {
+	diff, err := gitOutput(ctx, dir, "diff", "--name-only", "--no-renames", "--relative", "-z", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(ctx, dir, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range bytes.Split(append(diff, untracked...), []byte{0}) {
+		if len(p) > 0 {
+			paths = append(paths, string(p))
+		}
+	}
+	return paths, nil
+}
+
+// revertPaths restores paths in dir to their content at base, deleting
+// those base does not have, and commits the result.
+func revertPaths(ctx context.Context, dir, base string, paths []string, task *tasks.Task) error {
+	for _, p := range paths {
+		if _, err := gitOutput(ctx, dir, "checkout", base, "--", p); err == nil {
+			continue
+		}
+		// Not in base: the change added the file
+		if _, err := gitOutput(ctx, dir, "rm", "-q", "--cached", "--ignore-unmatch", "--", p); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(p))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	staged, err := gitOutput(ctx, dir, append([]string{"diff", "--cached", "--name-only", "--no-renames", "--relative", "-z", "HEAD", "--"}, paths...)...)
+	if err != nil || len(staged) == 0 {
+		return err
+	}
+	args := []string{"commit", "-q", "-m", fmt.Sprintf("Revert changes outside the task's path scope\n\n%s: %s", TaskTrailer, task.Type), "--"}
+	for _, p := range bytes.Split(staged, []byte{0}) {
+		if len(p) > 0 {
+			args = append(args, string(p))
+		}
+	}
+	_, err = gitOutput(ctx, dir, args...)
+	return err
+}
+
+// gitOutput runs git in dir and returns its output.
+func gitOutput(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,187 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/tasks"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newScopeRepo returns a repo on main with src/app.go and billing/pay.go,
+// and an untracked notes.txt that predates the task.
+func newScopeRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for k, v := range map[string]string{
+		"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com",
+		"GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com",
+	} {
+		t.Setenv(k, v)
+	}
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, dir, "src/app.go", "package src\n")
+	writeFile(t, dir, "billing/pay.go", "package billing\n")
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", "initial")
+	writeFile(t, dir, "notes.txt", "local notes\n")
+	return dir
+}
+
+// simulateAgent makes the changes an implementation agent might: in and
+// out of scope, committed and not.
+func simulateAgent(t *testing.T, dir string) {
+	t.Helper()
+	gitRun(t, dir, "checkout", "-q", "-b", "nightshift/fix")
+	writeFile(t, dir, "src/app.go", "package src\n\nfunc Fixed() {}\n")
+	writeFile(t, dir, "billing/pay.go", "package billing\n\n// changed\n")
+	gitRun(t, dir, "commit", "-q", "-am", "fix")
+	writeFile(t, dir, "billing/new.go", "package billing\n")
+}
+
+func TestCheckScope_Revert(t *testing.T) {
+	dir := newScopeRepo(t)
+	ctx := context.Background()
+	o := New()
+	o.SetScope([]config.PathScope{{Exclude: []string{"billing"}}, {}}, config.ScopeRevert)
+	result := &TaskResult{}
+	o.startScope(ctx, result, dir)
+	simulateAgent(t, dir)
+
+	impl := &ImplementOutput{Summary: "fixed", FilesModified: []string{"src/app.go", "billing/pay.go"}}
+	task := &tasks.Task{ID: "lint-fix:" + dir, Type: "lint-fix"}
+	if review := o.checkScope(ctx, result, task, impl, dir); review != nil {
+		t.Fatalf("revert policy rejected the attempt: %+v", review)
+	}
+
+	if got := gitRun(t, dir, "diff", "--name-only", "main"); got != "src/app.go" {
+		t.Errorf("branch changes after revert = %q, want src/app.go", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "billing", "new.go")); !os.IsNotExist(err) {
+		t.Error("new out-of-scope file not removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("untracked file from before the task was removed")
+	}
+	if msg := gitRun(t, dir, "log", "-1", "--format=%B"); !strings.Contains(msg, "Nightshift-Task: lint-fix") {
+		t.Errorf("revert commit message = %q", msg)
+	}
+	if !strings.Contains(impl.Summary, "billing/pay.go") || len(impl.FilesModified) != 1 {
+		t.Errorf("impl not updated: %+v", impl)
+	}
+
+	// Back on main once the work tree is clean
+	o.endScope(ctx, result, dir)
+	if branch := gitRun(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("branch after endScope = %q, want main", branch)
+	}
+}
+
+func TestCheckScope_Reject(t *testing.T) {
+	dir := newScopeRepo(t)
+	ctx := context.Background()
+	o := New()
+	o.SetScope([]config.PathScope{{Include: []string{"src/**"}}}, config.ScopeReject)
+	result := &TaskResult{}
+	o.startScope(ctx, result, dir)
+	simulateAgent(t, dir)
+
+	review := o.checkScope(ctx, result, &tasks.Task{Type: "lint-fix"}, &ImplementOutput{}, dir)
+	if review == nil || review.Passed {
+		t.Fatalf("reject policy passed out-of-scope changes: %+v", review)
+	}
+	if !strings.Contains(review.Feedback, "billing/pay.go") || !strings.Contains(review.Feedback, "billing/new.go") || strings.Contains(review.Feedback, "notes.txt") {
+		t.Errorf("feedback = %q", review.Feedback)
+	}
+	if got := gitRun(t, dir, "diff", "--name-only", "main", "--", "billing"); got != "billing/pay.go" {
+		t.Errorf("reject modified the branch: %q", got)
+	}
+}
+
+func TestCheckScope_RenameOutOfExcluded(t *testing.T) {
+	dir := newScopeRepo(t)
+	ctx := context.Background()
+	o := New()
+	o.SetScope([]config.PathScope{{Exclude: []string{"billing"}}}, config.ScopeRevert)
+	result := &TaskResult{}
+	o.startScope(ctx, result, dir)
+
+	gitRun(t, dir, "checkout", "-q", "-b", "nightshift/move")
+	gitRun(t, dir, "mv", "billing/pay.go", "src/pay.go")
+	gitRun(t, dir, "commit", "-q", "-m", "move")
+
+	impl := &ImplementOutput{}
+	if review := o.checkScope(ctx, result, &tasks.Task{Type: "lint-fix"}, impl, dir); review != nil {
+		t.Fatalf("revert policy rejected the attempt: %+v", review)
+	}
+	if !strings.Contains(impl.Summary, "billing/pay.go") {
+		t.Errorf("move out of billing not flagged: %q", impl.Summary)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "billing", "pay.go")); err != nil {
+		t.Error("file moved out of the excluded path not restored")
+	}
+}
+
+func TestBuildPrompts_Scope(t *testing.T) {
+	o := New()
+	task := &tasks.Task{ID: "lint-fix:/repo", Title: "Lint Fix", Type: "lint-fix"}
+	plan := &PlanOutput{Description: "fix lint"}
+	impl := &ImplementOutput{Summary: "fixed"}
+
+	if strings.Contains(o.buildPlanPrompt(task)+o.buildImplementPrompt(task, plan, 1)+o.buildReviewPrompt(task, impl), "## Path scope") {
+		t.Error("prompts mention a path scope when none is set")
+	}
+
+	o.SetScope([]config.PathScope{{Include: []string{"src/**"}, Exclude: []string{"vendor"}}}, config.ScopeRevert)
+	for name, prompt := range map[string]string{
+		"plan":      o.buildPlanPrompt(task),
+		"implement": o.buildImplementPrompt(task, plan, 1),
+		"review":    o.buildReviewPrompt(task, impl),
+	} {
+		if !strings.Contains(prompt, "- Only within: src/**") || !strings.Contains(prompt, "- Never: vendor") {
+			t.Errorf("%s prompt does not state the scope:\n%s", name, prompt)
+		}
+	}
+	if prompt := o.buildImplementPrompt(task, plan, 1); !strings.Contains(prompt, "Do not push or open a PR") || strings.Contains(prompt, "open a PR.") {
+		t.Errorf("implement prompt does not hold back the PR:\n%s", prompt)
+	}
+	if !strings.Contains(o.buildReviewPrompt(task, impl), "open the PR") {
+		t.Error("review prompt does not open the PR")
+	}
+
+	// An existing PR is pushed to after review, not during implementation
+	o.SetExistingPR(&OpenPR{URL: "https://github.com/o/r/pull/12", Branch: "nightshift/lint"})
+	if prompt := o.buildImplementPrompt(task, plan, 1); strings.Contains(prompt, "push your commits") {
+		t.Errorf("scoped implement prompt pushes to the existing PR:\n%s", prompt)
+	}
+	if prompt := o.buildReviewPrompt(task, impl); !strings.Contains(prompt, "push the branch to the existing PR https://github.com/o/r/pull/12") {
+		t.Errorf("scoped review prompt does not push to the existing PR:\n%s", prompt)
+	}
+}
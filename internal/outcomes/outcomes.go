@@ -0,0 +1,129 @@
+// Package outcomes tracks what happened to the pull requests nightshift
+// opened: merged, closed without merging, or left open until stale.
+package outcomes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// ErrNoGH is returned by Sync when the gh CLI is not installed.
+var ErrNoGH = errors.New("gh CLI not found")
+
+// PRView is the subset of `gh pr view --json` the sync needs.
+type PRView struct {
+	State    string     `json:"state"` // OPEN, MERGED or CLOSED
+	MergedAt *time.Time `json:"mergedAt"`
+	ClosedAt *time.Time `json:"closedAt"`
+	Reviews  []struct {
+		State string `json:"state"`
+	} `json:"reviews"`
+}
+
+// ViewFunc fetches a PR by URL.
+type ViewFunc func(ctx context.Context, url string) (PRView, error)
+
+// GHView fetches a PR with the gh CLI.
+func GHView(ctx context.Context, url string) (PRView, error) {
+	cmd := exec.CommandContext(ctx, "gh", "pr", "view", url, "--json", "state,mergedAt,closedAt,reviews")
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return PRView{}, fmt.Errorf("gh pr view %s: %s", url, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return PRView{}, fmt.Errorf("gh pr view %s: %w", url, err)
+	}
+	var v PRView
+	if err := json.Unmarshal(out, &v); err != nil {
+		return PRView{}, fmt.Errorf("parse gh pr view %s: %w", url, err)
+	}
+	return v, nil
+}
+
+// Classify maps a PR's state to an outcome. A PR still open staleAfter
+// after it was opened is stale; zero staleAfter never marks PRs stale.
+func Classify(v PRView, openedAt, now time.Time, staleAfter time.Duration) state.PROutcome {
+	o := state.PROutcome{Reviews: len(v.Reviews)}
+	switch strings.ToUpper(v.State) {
+	case "MERGED":
+		o.State = state.PRMerged
+		if v.MergedAt != nil {
+			o.ResolvedAt = *v.MergedAt
+		}
+	case "CLOSED":
+		o.State = state.PRClosed
+		if v.ClosedAt != nil {
+			o.ResolvedAt = *v.ClosedAt
+		}
+	default:
+		o.State = state.PROpen
+		if staleAfter > 0 && now.Sub(openedAt) >= staleAfter {
+			o.State = state.PRStale
+		}
+	}
+	return o
+}
+
+// Syncer polls unresolved PRs and stores their outcomes.
+type Syncer struct {
+	State      *state.State
+	View       ViewFunc // defaults to GHView
+	StaleAfter time.Duration
+	Now        func() time.Time // defaults to time.Now
+}
+
+// Result summarizes a sync.
+type Result struct {
+	Checked  int // PRs polled
+	Resolved int // PRs newly merged or closed
+	Errors   int // PRs that could not be fetched
+}
+
+// Sync polls every PR not yet merged or closed and records its outcome.
+// A PR that cannot be fetched is skipped and retried on the next sync.
+func (s *Syncer) Sync(ctx context.Context) (Result, error) {
+	view := s.View
+	if view == nil {
+		if _, err := exec.LookPath("gh"); err != nil {
+			return Result{}, ErrNoGH
+		}
+		view = GHView
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	runs, err := s.State.UnresolvedPRRuns()
+	if err != nil {
+		return Result{}, err
+	}
+
+	var res Result
+	for _, r := range runs {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		v, err := view(ctx, r.OutputRef)
+		if err != nil {
+			res.Errors++
+			continue
+		}
+		o := Classify(v, r.EndedAt, now(), s.StaleAfter)
+		if err := s.State.SetPROutcome(r.ID, o); err != nil {
+			return res, err
+		}
+		res.Checked++
+		if o.State == state.PRMerged || o.State == state.PRClosed {
+			res.Resolved++
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,113 @@
+package outcomes
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+func TestClassify(t *testing.T) {
+	opened := time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+	merged := opened.Add(48 * time.Hour)
+	stale := 14 * 24 * time.Hour
+
+	tests := []struct {
+		name string
+		view PRView
+		now  time.Time
+		want state.PROutcome
+	}{
+		{"merged", PRView{State: "MERGED", MergedAt: &merged}, opened.Add(72 * time.Hour),
+			state.PROutcome{State: state.PRMerged, ResolvedAt: merged}},
+		{"closed", PRView{State: "CLOSED", ClosedAt: &merged}, opened.Add(72 * time.Hour),
+			state.PROutcome{State: state.PRClosed, ResolvedAt: merged}},
+		{"open", PRView{State: "OPEN"}, opened.Add(72 * time.Hour),
+			state.PROutcome{State: state.PROpen}},
+		{"stale", PRView{State: "OPEN"}, opened.Add(stale),
+			state.PROutcome{State: state.PRStale}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.view, opened, tt.now, stale); got != tt.want {
+				t.Errorf("Classify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	st, err := state.New(database)
+	if err != nil {
+		t.Fatalf("state: %v", err)
+	}
+
+	ended := time.Date(2026, 10, 1, 3, 0, 0, 0, time.Local)
+	for _, ref := range []string{"pr/1", "pr/2", "pr/3", ""} {
+		outputType := "PR"
+		if ref == "" {
+			outputType = ""
+		}
+		if _, err := st.AddTaskRun(state.TaskRunRecord{Project: "/p", TaskType: "lint-fix", Status: "completed",
+			OutputType: outputType, OutputRef: ref, EndedAt: ended}); err != nil {
+			t.Fatalf("AddTaskRun: %v", err)
+		}
+	}
+
+	mergedAt := ended.Add(time.Hour)
+	views := map[string]PRView{
+		"pr/1": {State: "MERGED", MergedAt: &mergedAt},
+		"pr/2": {State: "OPEN"},
+	}
+	var polled []string
+	syncer := &Syncer{
+		State: st,
+		View: func(_ context.Context, url string) (PRView, error) {
+			polled = append(polled, url)
+			v, ok := views[url]
+			if !ok {
+				return PRView{}, errors.New("not found")
+			}
+			return v, nil
+		},
+		StaleAfter: 24 * time.Hour,
+		Now:        func() time.Time { return ended.Add(48 * time.Hour) },
+	}
+
+	res, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res != (Result{Checked: 2, Resolved: 1, Errors: 1}) {
+		t.Errorf("Sync() = %+v", res)
+	}
+	if len(polled) != 3 {
+		t.Errorf("polled %v, want the 3 PRs", polled)
+	}
+
+	acceptance, err := st.PRAcceptance("/p", "lint-fix")
+	if err != nil {
+		t.Fatalf("PRAcceptance: %v", err)
+	}
+	if acceptance != (state.PRAcceptance{Merged: 1, Stale: 1}) {
+		t.Errorf("PRAcceptance() = %+v", acceptance)
+	}
+
+	// Merged PRs are not polled again
+	polled = nil
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(polled) != 2 {
+		t.Errorf("second sync polled %v, want the stale and failed PRs", polled)
+	}
+}
@@ -0,0 +1,143 @@
+package projects
+
+import (
+	"math"
+	"sort"
+)
+
+// Allocator splits a run's token allowance across projects by priority.
+// Projects are served highest priority first. When a project opens it gets
+// its weight's share of what is left in the pool, capped by MaxTokens and
+// MaxPercent; whatever it leaves unspent when closed stays in the pool and
+// rolls over to the lower-priority projects after it.
+type Allocator struct {
+	total    int64
+	pool     int64
+	order    []Project
+	weight   map[string]float64
+	index    map[string]int
+	alloc    map[string]int64
+	spent    map[string]int64
+	closed   map[string]bool
+	unopened float64 // summed weight of projects not yet closed
+}
+
+// NewAllocator creates an allocator for total tokens across projects.
+func NewAllocator(projects []Project, total int64) *Allocator {
+	if total < 0 {
+		total = 0
+	}
+	order := SortByPriority(projects)
+	a := &Allocator{
+		total:  total,
+		pool:   total,
+		order:  order,
+		weight: make(map[string]float64, len(order)),
+		index:  make(map[string]int, len(order)),
+		alloc:  make(map[string]int64, len(order)),
+		spent:  make(map[string]int64, len(order)),
+		closed: make(map[string]bool, len(order)),
+	}
+	for i, p := range a.order {
+		a.weight[p.Path] = priorityWeight(p.Priority)
+		a.index[p.Path] = i
+		a.unopened += a.weight[p.Path]
+	}
+	return a
+}
+
+// Projects returns the projects in the order allocations are made.
+func (a *Allocator) Projects() []Project {
+	return a.order
+}
+
+// Open fixes and returns path's allocation from the current pool. Calling it
+// again returns the same allocation. Unknown projects get nothing.
+func (a *Allocator) Open(path string) int64 {
+	if tokens, ok := a.alloc[path]; ok {
+		return tokens
+	}
+	i, ok := a.index[path]
+	if !ok || a.unopened <= 0 {
+		return 0
+	}
+	p := a.order[i]
+	w := a.weight[path]
+	tokens := int64(float64(a.pool) * w / a.unopened)
+	if a.unopened-w < 1e-9 {
+		tokens = a.pool // last open project takes the remainder
+	}
+	if c := a.projectCap(p); c >= 0 && tokens > c {
+		tokens = c
+	}
+	a.alloc[path] = tokens
+	return tokens
+}
+
+// Spend records tokens used by path.
+func (a *Allocator) Spend(path string, tokens int64) {
+	if tokens > 0 {
+		a.spent[path] += tokens
+	}
+}
+
+// Remaining returns what is left of path's allocation (0 if not opened).
+func (a *Allocator) Remaining(path string) int64 {
+	remaining := a.alloc[path] - a.spent[path]
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Spent returns the tokens recorded against path.
+func (a *Allocator) Spent(path string) int64 {
+	return a.spent[path]
+}
+
+// Close takes path's spending out of the pool and releases its unspent
+// allocation to the projects that have not opened yet.
+func (a *Allocator) Close(path string) {
+	if _, ok := a.index[path]; !ok || a.closed[path] {
+		return
+	}
+	a.Open(path)
+	a.closed[path] = true
+	a.unopened -= a.weight[path]
+	a.pool -= a.spent[path]
+	if a.pool < 0 {
+		a.pool = 0
+	}
+}
+
+// projectCap returns the tightest configured cap for p, or -1 for none.
+func (a *Allocator) projectCap(p Project) int64 {
+	c := int64(-1)
+	if p.MaxTokens > 0 {
+		c = p.MaxTokens
+	}
+	if p.MaxPercent > 0 {
+		pct := int64(math.Round(float64(a.total) * float64(p.MaxPercent) / 100))
+		if c < 0 || pct < c {
+			c = pct
+		}
+	}
+	return c
+}
+
+// priorityWeight maps a priority to its allocation weight (priority+1,
+// at least 1 so every project gets a share).
+func priorityWeight(priority int) float64 {
+	return math.Max(float64(priority+1), 1)
+}
+
+// sortAllocations restores the caller's project order.
+func sortAllocations(allocs []BudgetAllocation, projects []Project) {
+	pos := make(map[string]int, len(projects))
+	for i, p := range projects {
+		pos[p.Path] = i
+	}
+	sort.SliceStable(allocs, func(i, j int) bool {
+		return pos[allocs[i].Project.Path] < pos[allocs[j].Project.Path]
+	})
+}
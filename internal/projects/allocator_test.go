@@ -0,0 +1,74 @@
+package projects
+
+import "testing"
+
+func TestAllocatorRollsOverUnspent(t *testing.T) {
+	a := NewAllocator([]Project{
+		{Path: "/low", Priority: 0},  // weight 1
+		{Path: "/high", Priority: 2}, // weight 3
+		{Path: "/mid", Priority: 0},  // weight 1
+	}, 1000)
+
+	order := a.Projects()
+	if order[0].Path != "/high" {
+		t.Fatalf("first project = %s, want /high", order[0].Path)
+	}
+
+	// /high gets 3/5 of 1000 but only spends 200; the 400 left rolls over.
+	if got := a.Open("/high"); got != 600 {
+		t.Errorf("Open(/high) = %d, want 600", got)
+	}
+	a.Spend("/high", 200)
+	if got := a.Remaining("/high"); got != 400 {
+		t.Errorf("Remaining(/high) = %d, want 400", got)
+	}
+	a.Close("/high")
+
+	// Pool is 800, split evenly between the two weight-1 projects.
+	if got := a.Open("/low"); got != 400 {
+		t.Errorf("Open(/low) = %d, want 400", got)
+	}
+	a.Spend("/low", 400)
+	a.Close("/low")
+	if got := a.Open("/mid"); got != 400 {
+		t.Errorf("Open(/mid) = %d, want 400 (remainder)", got)
+	}
+}
+
+func TestAllocatorCaps(t *testing.T) {
+	a := NewAllocator([]Project{
+		{Path: "/capped", Priority: 9, MaxTokens: 100},
+		{Path: "/percent", Priority: 9, MaxPercent: 10},
+		{Path: "/open", Priority: 0},
+	}, 1000)
+
+	if got := a.Open("/capped"); got != 100 {
+		t.Errorf("Open(/capped) = %d, want 100", got)
+	}
+	a.Spend("/capped", 100)
+	a.Close("/capped")
+	if got := a.Open("/percent"); got != 100 {
+		t.Errorf("Open(/percent) = %d, want 100 (10%% of 1000)", got)
+	}
+	a.Spend("/percent", 100)
+	a.Close("/percent")
+	if got := a.Open("/open"); got != 800 {
+		t.Errorf("Open(/open) = %d, want 800", got)
+	}
+	if got := a.Open("/unknown"); got != 0 {
+		t.Errorf("Open(/unknown) = %d, want 0", got)
+	}
+}
+
+func TestAllocateBudgetHonorsCaps(t *testing.T) {
+	allocs := AllocateBudget([]Project{
+		{Path: "/a", Priority: 0},
+		{Path: "/b", Priority: 9, MaxTokens: 200},
+	}, 1000)
+	if len(allocs) != 2 || allocs[0].Project.Path != "/a" {
+		t.Fatalf("allocations = %+v, want input order", allocs)
+	}
+	if allocs[1].Tokens != 200 || allocs[0].Tokens != 800 {
+		t.Errorf("tokens = %d/%d, want 800/200", allocs[0].Tokens, allocs[1].Tokens)
+	}
+}
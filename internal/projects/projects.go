@@ -3,6 +3,7 @@
 package projects
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
@@ -21,6 +22,10 @@ type Project struct {
 	Priority int            // Priority for ordering (higher = more important)
 	Config   *config.Config // Merged configuration for this project
 	Weight   float64        // Normalized weight for budget allocation
+
+	// Optional per-run caps from ProjectConfig; 0 means uncapped.
+	MaxTokens  int64 // Max tokens this project may use per run
+	MaxPercent int   // Max % of the run's allowance this project may use
 }
 
 // Resolver handles project discovery and configuration merging.
@@ -46,7 +51,7 @@ func (r *Resolver) DiscoverProjects() ([]Project, error) {
 				return nil, err
 			}
 			for _, path := range matches {
-				proj, err := r.resolveProject(path, pc.Priority)
+				proj, err := r.resolveProject(path, pc)
 				if err != nil {
 					continue // Skip invalid projects
 				}
@@ -55,7 +60,7 @@ func (r *Resolver) DiscoverProjects() ([]Project, error) {
 		} else if pc.Path != "" {
 			// Explicit path
 			path := expandPath(pc.Path)
-			proj, err := r.resolveProject(path, pc.Priority)
+			proj, err := r.resolveProject(path, pc)
 			if err != nil {
 				continue // Skip invalid projects
 			}
@@ -67,7 +72,7 @@ func (r *Resolver) DiscoverProjects() ([]Project, error) {
 }
 
 // resolveProject creates a Project with merged configuration.
-func (r *Resolver) resolveProject(path string, priority int) (Project, error) {
+func (r *Resolver) resolveProject(path string, pc config.ProjectConfig) (Project, error) {
 	path, err := filepath.Abs(path)
 	if err != nil {
 		return Project{}, err
@@ -87,9 +92,11 @@ func (r *Resolver) resolveProject(path string, priority int) (Project, error) {
 	}
 
 	return Project{
-		Path:     path,
-		Priority: priority,
-		Config:   mergedCfg,
+		Path:       path,
+		Priority:   pc.Priority,
+		Config:     mergedCfg,
+		MaxTokens:  int64(pc.MaxTokens),
+		MaxPercent: pc.MaxPercent,
 	}, nil
 }
 
@@ -164,42 +171,33 @@ type BudgetAllocation struct {
 }
 
 // AllocateBudget distributes the total budget across projects by priority weight.
-// Projects with higher priority get proportionally more budget.
+// Projects with higher priority get proportionally more budget. Per-project
+// caps are honored and the excess goes to the remaining projects, assuming
+// each project spends its full allocation. Allocations follow input order.
 func AllocateBudget(projects []Project, totalBudget int64) []BudgetAllocation {
 	if len(projects) == 0 || totalBudget <= 0 {
 		return nil
 	}
 
-	// Calculate total priority weight (use priority+1 to avoid zero weight)
 	var totalWeight float64
-	for i := range projects {
-		projects[i].Weight = float64(projects[i].Priority + 1)
-		totalWeight += projects[i].Weight
-	}
-
-	// Normalize weights
-	for i := range projects {
-		projects[i].Weight /= totalWeight
+	for _, proj := range projects {
+		totalWeight += priorityWeight(proj.Priority)
 	}
 
-	// Allocate budget
-	allocations := make([]BudgetAllocation, len(projects))
-	var allocated int64
-
-	for i, proj := range projects {
-		tokens := int64(float64(totalBudget) * proj.Weight)
-		if i == len(projects)-1 {
-			// Give remainder to last project to avoid rounding loss
-			tokens = totalBudget - allocated
-		}
-		allocations[i] = BudgetAllocation{
+	a := NewAllocator(projects, totalBudget)
+	allocations := make([]BudgetAllocation, 0, len(projects))
+	for _, proj := range a.Projects() {
+		proj.Weight = priorityWeight(proj.Priority) / totalWeight
+		tokens := a.Open(proj.Path)
+		a.Spend(proj.Path, tokens)
+		a.Close(proj.Path)
+		allocations = append(allocations, BudgetAllocation{
 			Project:    proj,
 			Tokens:     tokens,
-			Percentage: proj.Weight * 100,
-		}
-		allocated += tokens
+			Percentage: float64(tokens) / float64(totalBudget) * 100,
+		})
 	}
-
+	sortAllocations(allocations, projects)
 	return allocations
 }
 
@@ -285,11 +283,15 @@ func MergeProjectConfig(globalCfg *config.Config, projectPath string) (*config.C
 		merged.Tasks.Priorities = intMap
 	}
 
-	if v.IsSet("providers.claude.enabled") {
-		merged.Providers.Claude.Enabled = v.GetBool("providers.claude.enabled")
-	}
-	if v.IsSet("providers.codex.enabled") {
-		merged.Providers.Codex.Enabled = v.GetBool("providers.codex.enabled")
+	// Copy before overriding so the global config is left alone
+	merged.Providers.Settings = maps.Clone(merged.Providers.Settings)
+	for _, name := range config.ProviderNames() {
+		key := "providers." + name + ".enabled"
+		if v.IsSet(key) {
+			settings := merged.ProviderSettings(name)
+			settings.Enabled = v.GetBool(key)
+			merged.SetProviderSettings(name, settings)
+		}
 	}
 
 	if v.IsSet("logging.level") {
@@ -4,15 +4,18 @@ package providers
 import (
 	"bufio"
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
 )
 
 // StatsCache represents the stats-cache.json structure from Claude Code.
@@ -101,18 +104,57 @@ func (c *Claude) Name() string {
 	return "claude"
 }
 
-// Execute runs a task via Claude Code CLI.
-func (c *Claude) Execute(ctx context.Context, task Task) (Result, error) {
-	// TODO: Implement - spawn claude CLI process
-	return Result{}, nil
+func init() {
+	Register(Registration{
+		Name:            "claude",
+		DefaultDataPath: config.DefaultClaudeDataPath,
+		DefaultEnabled:  true,
+		New:             func(dataPath string) Provider { return NewClaudeWithPath(dataPath) },
+	})
+}
+
+// Binary returns "claude".
+func (c *Claude) Binary() string {
+	return "claude"
 }
 
-// Cost returns Claude's token pricing (cents per 1K tokens).
-// Based on Claude Opus 4 pricing.
-func (c *Claude) Cost() (inputCents, outputCents int64) {
-	// Claude Opus 4: $15/M input, $75/M output
-	// Per 1K: 1.5 cents input, 7.5 cents output
-	return 150, 750 // in hundredths of a cent for precision
+// NewAgent creates a Claude Code agent from the claude provider settings.
+func (c *Claude) NewAgent(cfg *config.Config, runner agents.CommandRunner) agents.Agent {
+	var opts []agents.ClaudeOption
+	if cfg != nil {
+		opts = append(opts, agents.WithDangerouslySkipPermissions(cfg.ProviderSettings("claude").DangerouslySkipPermissions))
+	}
+	if runner != nil {
+		opts = append(opts, agents.WithRunner(runner))
+	}
+	return agents.NewClaudeAgent(opts...)
+}
+
+// GetResetTime returns when Claude's usage window resets. Claude's weekly
+// window is assumed to reset at midnight on Sunday; other windows are unknown.
+func (c *Claude) GetResetTime(mode string) (time.Time, error) {
+	switch mode {
+	case "weekly":
+		return nextWeekday(time.Now(), time.Sunday), nil
+	case "daily":
+		return time.Time{}, nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid mode: %s (must be 'daily' or 'weekly')", mode)
+	}
+}
+
+// Pricing returns Anthropic API list prices (USD per million tokens).
+// Cache writes are priced at the 5-minute TTL rate.
+func (c *Claude) Pricing() []ModelPrice {
+	return []ModelPrice{
+		{Model: "", Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+		{Model: "claude-opus-4", Input: 15, Output: 75, CacheRead: 1.50, CacheWrite: 18.75},
+		{Model: "claude-opus-4-5", Input: 5, Output: 25, CacheRead: 0.50, CacheWrite: 6.25},
+		{Model: "claude-sonnet-4", Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+		{Model: "claude-3-7-sonnet", Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+		{Model: "claude-haiku-4-5", Input: 1, Output: 5, CacheRead: 0.10, CacheWrite: 1.25},
+		{Model: "claude-3-5-haiku", Input: 0.80, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	}
 }
 
 // ParseStatsCache reads and parses the stats-cache.json file.
@@ -147,6 +189,21 @@ func (s *StatsCache) TokensByDate() map[string]int64 {
 	return m
 }
 
+// TokensByModelSince sums per-model tokens for every date on or after
+// cutoffDate ("YYYY-MM-DD").
+func (s *StatsCache) TokensByModelSince(cutoffDate string) map[string]int64 {
+	m := make(map[string]int64)
+	for _, entry := range s.DailyModelTokens {
+		if entry.Date < cutoffDate {
+			continue
+		}
+		for model, tokens := range entry.TokensByModel {
+			m[model] += tokens
+		}
+	}
+	return m
+}
+
 // GetDailyStat builds a combined DailyStat for a given date.
 func (s *StatsCache) GetDailyStat(date string) *DailyStat {
 	stat := &DailyStat{Date: date}
@@ -222,6 +279,11 @@ func (c *Claude) GetTodayUsage() (int64, error) {
 	return usage, err
 }
 
+// GetTodayTokens returns today's total token usage (alias of GetTodayUsage).
+func (c *Claude) GetTodayTokens() (int64, error) {
+	return c.GetTodayUsage()
+}
+
 func (c *Claude) getTodayUsageWithSource() (int64, string, error) {
 	stats, err := c.ParseStatsCache()
 	if err == nil {
@@ -251,6 +313,11 @@ func (c *Claude) GetWeeklyUsage() (int64, error) {
 	return usage, err
 }
 
+// GetWeeklyTokens returns the 7-day token usage (alias of GetWeeklyUsage).
+func (c *Claude) GetWeeklyTokens() (int64, error) {
+	return c.GetWeeklyUsage()
+}
+
 func (c *Claude) getWeeklyUsageWithSource() (int64, string, error) {
 	stats, err := c.ParseStatsCache()
 	if err == nil {
@@ -278,6 +345,48 @@ func (c *Claude) getWeeklyUsageWithSource() (int64, string, error) {
 	return 0, "stats-cache", nil
 }
 
+// GetTodayTokensByModel returns today's tokens keyed by model, from the same
+// source as GetTodayTokens.
+func (c *Claude) GetTodayTokensByModel() (map[string]int64, error) {
+	today := time.Now().Format("2006-01-02")
+	stats, err := c.ParseStatsCache()
+	if err == nil {
+		if byModel := stats.TokensByModelSince(today); len(byModel) > 0 {
+			return byModel, nil
+		}
+	}
+
+	byModel, scanErr := c.scanModelTokensSince(today, 0)
+	if scanErr == nil && len(byModel) > 0 {
+		return byModel, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int64{}, nil
+}
+
+// GetWeeklyTokensByModel returns the last 7 days' tokens keyed by model, from
+// the same source as GetWeeklyTokens.
+func (c *Claude) GetWeeklyTokensByModel() (map[string]int64, error) {
+	oldest := time.Now().AddDate(0, 0, -6).Format("2006-01-02")
+	stats, err := c.ParseStatsCache()
+	if err == nil {
+		if byModel := stats.TokensByModelSince(oldest); len(byModel) > 0 {
+			return byModel, nil
+		}
+	}
+
+	byModel, scanErr := c.scanModelTokensSince(oldest, 6)
+	if scanErr == nil && len(byModel) > 0 {
+		return byModel, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int64{}, nil
+}
+
 // GetUsedPercent calculates the used percentage based on mode and budget.
 // mode: "daily" or "weekly"
 // weeklyBudget: total weekly token budget
@@ -384,17 +493,36 @@ func (c *Claude) ScanWeeklyTokens() (int64, error) {
 // parses lines and sums input_tokens+output_tokens for assistant messages
 // whose timestamp falls on or after cutoffDate.
 func (c *Claude) scanTokensSince(cutoffDate string, extraMtimeDays int) (int64, error) {
-	projectsDir := filepath.Join(c.dataPath, "projects")
+	byModel, err := c.scanModelTokensSince(cutoffDate, extraMtimeDays)
+	return sumTokensByModel(byModel), err
+}
 
+// scanModelTokensSince is scanTokensSince split by message model.
+func (c *Claude) scanModelTokensSince(cutoffDate string, extraMtimeDays int) (map[string]int64, error) {
 	// mtime threshold: start of cutoff day (local) minus extra buffer
 	cutoff, err := time.ParseInLocation("2006-01-02", cutoffDate, time.Now().Location())
 	if err != nil {
-		return 0, fmt.Errorf("parsing cutoff date: %w", err)
+		return nil, fmt.Errorf("parsing cutoff date: %w", err)
 	}
 	mtimeCutoff := cutoff.AddDate(0, 0, -extraMtimeDays)
 
-	var total int64
+	total := make(map[string]int64)
+	err = c.walkSessionFiles(mtimeCutoff, func(path string) {
+		tokens, err := scanFileModelTokens(path, cutoffDate)
+		if err != nil {
+			return // skip corrupt files
+		}
+		for model, n := range tokens {
+			total[model] += n
+		}
+	})
+	return total, err
+}
 
+// walkSessionFiles calls fn for every JSONL file under projects/ modified at
+// or after mtimeCutoff. A missing projects directory is not an error.
+func (c *Claude) walkSessionFiles(mtimeCutoff time.Time, fn func(path string)) error {
+	projectsDir := filepath.Join(c.dataPath, "projects")
 	walkErr := filepath.WalkDir(projectsDir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			if os.IsPermission(err) {
@@ -415,30 +543,51 @@ func (c *Claude) scanTokensSince(cutoffDate string, extraMtimeDays int) (int64,
 			return nil
 		}
 
-		tokens, err := scanFileTokens(path, cutoffDate)
-		if err != nil {
-			return nil // skip corrupt files
-		}
-		total += tokens
+		fn(path)
 		return nil
 	})
 
 	if walkErr != nil && os.IsNotExist(walkErr) {
-		return 0, nil
+		return nil
 	}
-	return total, walkErr
+	return walkErr
 }
 
 // scanFileTokens reads a single JSONL file and sums input_tokens+output_tokens
 // for assistant messages whose timestamp (local) is on or after cutoffDate.
 func scanFileTokens(path string, cutoffDate string) (int64, error) {
+	byModel, err := scanFileModelTokens(path, cutoffDate)
+	return sumTokensByModel(byModel), err
+}
+
+// scanFileModelTokens is scanFileTokens keyed by message model.
+func scanFileModelTokens(path string, cutoffDate string) (map[string]int64, error) {
+	total := make(map[string]int64)
+	err := forEachAssistantMessage(path, func(msg SessionMessage) {
+		if msg.Timestamp.Local().Format("2006-01-02") < cutoffDate {
+			return
+		}
+		model := msg.Message.Model
+		if model == "" {
+			model = "unknown"
+		}
+		total[model] += msg.Message.Usage.InputTokens + msg.Message.Usage.OutputTokens
+	})
+	if err != nil {
+		return nil, err
+	}
+	return total, nil
+}
+
+// forEachAssistantMessage calls fn for every assistant message with usage in
+// a session JSONL file. Unparseable lines are skipped.
+func forEachAssistantMessage(path string, fn func(msg SessionMessage)) error {
 	file, err := os.Open(path)
 	if err != nil {
-		return 0, err
+		return err
 	}
 	defer func() { _ = file.Close() }()
 
-	var total int64
 	reader := bufio.NewReaderSize(file, 64*1024)
 	for {
 		line, err := reader.ReadBytes('\n')
@@ -450,21 +599,68 @@ func scanFileTokens(path string, cutoffDate string) (int64, error) {
 					msg.Type == "assistant" &&
 					msg.Message != nil &&
 					msg.Message.Usage != nil {
-					msgDate := msg.Timestamp.Local().Format("2006-01-02")
-					if msgDate >= cutoffDate {
-						total += msg.Message.Usage.InputTokens + msg.Message.Usage.OutputTokens
-					}
+					fn(msg)
 				}
 			}
 		}
 		if err != nil {
 			if err == io.EOF {
-				break
+				return nil
 			}
-			return 0, err
+			return err
 		}
 	}
-	return total, nil
+}
+
+// claudeSessionLength is the length of Claude's short usage window. A session
+// opens with the first message after the previous one expired.
+const claudeSessionLength = 5 * time.Hour
+
+// GetSessionWindow reconstructs the active 5-hour session from JSONL message
+// timestamps. Sessions start on the hour of their first message, which is how
+// Claude reports session resets. Only Tokens is set; Claude exposes no local
+// usage percentage for the session.
+func (c *Claude) GetSessionWindow() (SessionWindow, error) {
+	now := time.Now()
+	since := now.Add(-2 * claudeSessionLength)
+
+	type message struct {
+		at     time.Time
+		tokens int64
+	}
+	var messages []message
+	err := c.walkSessionFiles(since, func(path string) {
+		_ = forEachAssistantMessage(path, func(msg SessionMessage) {
+			if msg.Timestamp.Before(since) {
+				return
+			}
+			messages = append(messages, message{
+				at:     msg.Timestamp,
+				tokens: msg.Message.Usage.InputTokens + msg.Message.Usage.OutputTokens,
+			})
+		})
+	})
+	if err != nil {
+		return SessionWindow{}, err
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].at.Before(messages[j].at) })
+
+	window := SessionWindow{Length: claudeSessionLength}
+	var start time.Time
+	var tokens int64
+	for _, m := range messages {
+		if start.IsZero() || !m.at.Before(start.Add(claudeSessionLength)) {
+			start = m.at.Truncate(time.Hour)
+			tokens = 0
+		}
+		tokens += m.tokens
+	}
+	if start.IsZero() || !now.Before(start.Add(claudeSessionLength)) {
+		return window, nil
+	}
+	window.Tokens = tokens
+	window.ResetsAt = start.Add(claudeSessionLength)
+	return window, nil
 }
 
 // sumTokensByModel sums all token counts across models.
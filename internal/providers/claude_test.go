@@ -277,6 +277,64 @@ func TestClaudeProvider_GetTodayUsage(t *testing.T) {
 	}
 }
 
+func TestClaudeProvider_TokensByModel(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	threeDaysAgo := now.AddDate(0, 0, -3).Format("2006-01-02")
+	tenDaysAgo := now.AddDate(0, 0, -10).Format("2006-01-02")
+	content := `{
+		"version": 1,
+		"dailyModelTokens": [
+			{"date": "` + today + `", "tokensByModel": {"claude-opus-4": 75000, "claude-sonnet-4": 25000}},
+			{"date": "` + threeDaysAgo + `", "tokensByModel": {"claude-sonnet-4": 5000}},
+			{"date": "` + tenDaysAgo + `", "tokensByModel": {"claude-opus-4": 999999}}
+		]
+	}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "stats-cache.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewClaudeWithPath(tmpDir)
+	todayByModel, err := provider.GetTodayTokensByModel()
+	if err != nil {
+		t.Fatalf("GetTodayTokensByModel error: %v", err)
+	}
+	if todayByModel["claude-opus-4"] != 75000 || todayByModel["claude-sonnet-4"] != 25000 {
+		t.Errorf("today by model = %v", todayByModel)
+	}
+
+	weekly, err := provider.GetWeeklyTokensByModel()
+	if err != nil {
+		t.Fatalf("GetWeeklyTokensByModel error: %v", err)
+	}
+	if weekly["claude-opus-4"] != 75000 || weekly["claude-sonnet-4"] != 30000 {
+		t.Errorf("weekly by model = %v", weekly)
+	}
+}
+
+func TestClaudeProvider_TokensByModel_JSONLFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	projDir := filepath.Join(tmpDir, "projects", "myproj")
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Local()
+	writeJSONLFile(t, filepath.Join(projDir, "s1.jsonl"), []string{
+		makeAssistantLine(now, 100, 50),
+		`{"type":"assistant","message":{"model":"claude-opus-4","usage":{"input_tokens":10,"output_tokens":5}},"timestamp":"` + now.Format(time.RFC3339) + `"}`,
+	})
+
+	provider := NewClaudeWithPath(tmpDir)
+	byModel, err := provider.GetTodayTokensByModel()
+	if err != nil {
+		t.Fatalf("GetTodayTokensByModel error: %v", err)
+	}
+	if byModel["claude-sonnet-4"] != 150 || byModel["claude-opus-4"] != 15 {
+		t.Errorf("by model = %v", byModel)
+	}
+}
+
 func TestClaudeProvider_GetTodayUsage_NoData(t *testing.T) {
 	tmpDir := t.TempDir()
 	provider := NewClaudeWithPath(tmpDir)
@@ -552,14 +610,16 @@ func TestClaudeProvider_Name(t *testing.T) {
 	}
 }
 
-func TestClaudeProvider_Cost(t *testing.T) {
+func TestClaudeProvider_Pricing(t *testing.T) {
 	provider := NewClaude()
-	input, output := provider.Cost()
-	if input != 150 {
-		t.Errorf("input cost = %d, want 150", input)
+	prices := provider.Pricing()
+	if len(prices) == 0 || prices[0].Model != "" {
+		t.Fatalf("expected a fallback price first, got %+v", prices)
 	}
-	if output != 750 {
-		t.Errorf("output cost = %d, want 750", output)
+	for _, p := range prices {
+		if p.Input <= 0 || p.Output <= p.Input {
+			t.Errorf("implausible price %+v", p)
+		}
 	}
 }
 
@@ -744,3 +804,55 @@ func TestClaudeProvider_ScanWeeklyTokens(t *testing.T) {
 		t.Errorf("ScanWeeklyTokens = %d, want %d", tokens, expected)
 	}
 }
+
+func TestClaudeProvider_GetSessionWindow(t *testing.T) {
+	tmpDir := t.TempDir()
+	projDir := filepath.Join(tmpDir, "projects", "myproj")
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	writeJSONLFile(t, filepath.Join(projDir, "s1.jsonl"), []string{
+		makeAssistantLine(now.Add(-8*time.Hour), 1000, 1000), // previous window, already reset
+		makeAssistantLine(now.Add(-time.Hour), 100, 50),
+		makeAssistantLine(now.Add(-30*time.Minute), 200, 80),
+	})
+
+	window, err := NewClaudeWithPath(tmpDir).GetSessionWindow()
+	if err != nil {
+		t.Fatalf("GetSessionWindow error: %v", err)
+	}
+	if window.Length != 5*time.Hour {
+		t.Errorf("Length = %v, want 5h", window.Length)
+	}
+	if window.Tokens != 430 {
+		t.Errorf("Tokens = %d, want 430", window.Tokens)
+	}
+	wantReset := now.Add(-time.Hour).Truncate(time.Hour).Add(5 * time.Hour)
+	if !window.ResetsAt.Equal(wantReset) {
+		t.Errorf("ResetsAt = %v, want %v", window.ResetsAt, wantReset)
+	}
+	if window.UsedPercent != nil {
+		t.Errorf("UsedPercent = %v, want nil", *window.UsedPercent)
+	}
+}
+
+func TestClaudeProvider_GetSessionWindow_Idle(t *testing.T) {
+	tmpDir := t.TempDir()
+	projDir := filepath.Join(tmpDir, "projects", "myproj")
+	if err := os.MkdirAll(projDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeJSONLFile(t, filepath.Join(projDir, "s1.jsonl"), []string{
+		makeAssistantLine(time.Now().Add(-6*time.Hour), 100, 50),
+	})
+
+	window, err := NewClaudeWithPath(tmpDir).GetSessionWindow()
+	if err != nil {
+		t.Fatalf("GetSessionWindow error: %v", err)
+	}
+	if window.Tokens != 0 || !window.ResetsAt.IsZero() {
+		t.Errorf("expected no active window, got %+v", window)
+	}
+}
@@ -4,7 +4,6 @@ package providers
 import (
 	"bufio"
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,7 +11,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
 )
 
 // CodexRateLimits represents the rate_limits object in Codex session JSONL.
@@ -44,8 +47,10 @@ type CodexTokenCountInfo struct {
 }
 
 // CodexSessionPayload represents the payload object in a Codex JSONL entry.
+// Model is set on turn_context entries.
 type CodexSessionPayload struct {
 	Type       string               `json:"type"`
+	Model      string               `json:"model,omitempty"`
 	Info       *CodexTokenCountInfo `json:"info,omitempty"`
 	RateLimits *CodexRateLimits     `json:"rate_limits,omitempty"`
 }
@@ -59,8 +64,10 @@ type CodexSessionEntry struct {
 
 // Codex wraps the Codex CLI as a provider.
 type Codex struct {
-	dataPath   string           // Path to ~/.codex
-	rateLimits *CodexRateLimits // Cached rate limits
+	dataPath              string           // Path to ~/.codex
+	rateLimits            *CodexRateLimits // Cached rate limits
+	mu                    sync.RWMutex
+	lastUsedPercentSource string
 }
 
 // NewCodex creates a Codex provider.
@@ -83,18 +90,44 @@ func (c *Codex) Name() string {
 	return "codex"
 }
 
-// Execute runs a task via Codex CLI.
-func (c *Codex) Execute(ctx context.Context, task Task) (Result, error) {
-	// TODO: Implement - spawn codex CLI process
-	return Result{}, nil
+func init() {
+	Register(Registration{
+		Name:            "codex",
+		DefaultDataPath: config.DefaultCodexDataPath,
+		DefaultEnabled:  true,
+		New:             func(dataPath string) Provider { return NewCodexWithPath(dataPath) },
+	})
+}
+
+// Binary returns "codex".
+func (c *Codex) Binary() string {
+	return "codex"
+}
+
+// NewAgent creates a Codex CLI agent from the codex provider settings.
+func (c *Codex) NewAgent(cfg *config.Config, runner agents.CommandRunner) agents.Agent {
+	var opts []agents.CodexOption
+	if cfg != nil {
+		opts = append(opts, agents.WithDangerouslyBypassApprovalsAndSandbox(cfg.ProviderSettings("codex").DangerouslyBypassApprovalsAndSandbox))
+	}
+	if runner != nil {
+		opts = append(opts, agents.WithCodexRunner(runner))
+	}
+	return agents.NewCodexAgent(opts...)
 }
 
-// Cost returns Codex's token pricing (cents per 1K tokens).
-// Based on GPT-4 pricing estimates.
-func (c *Codex) Cost() (inputCents, outputCents int64) {
-	// GPT-4: ~$10/M input, ~$30/M output (estimates)
-	// Per 1K: 1 cent input, 3 cents output
-	return 100, 300 // in hundredths of a cent for precision
+// Pricing returns OpenAI API list prices (USD per million tokens).
+// OpenAI does not charge for cache writes.
+func (c *Codex) Pricing() []ModelPrice {
+	return []ModelPrice{
+		{Model: "", Input: 1.25, Output: 10, CacheRead: 0.125},
+		{Model: "gpt-5", Input: 1.25, Output: 10, CacheRead: 0.125},
+		{Model: "gpt-5-mini", Input: 0.25, Output: 2, CacheRead: 0.025},
+		{Model: "gpt-5-nano", Input: 0.05, Output: 0.40, CacheRead: 0.005},
+		{Model: "gpt-4.1", Input: 2, Output: 8, CacheRead: 0.50},
+		{Model: "o4-mini", Input: 1.10, Output: 4.40, CacheRead: 0.275},
+		{Model: "codex-mini", Input: 1.50, Output: 6, CacheRead: 0.375},
+	}
 }
 
 // DataPath returns the configured data path.
@@ -227,6 +260,7 @@ func (c *Codex) GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
 			if err == nil && usage != nil && usage.TotalTokens > 0 {
 				dailyBudget := weeklyBudget / 7
 				if dailyBudget > 0 {
+					c.setLastUsedPercentSource("local-tokens")
 					return float64(usage.TotalTokens) / float64(dailyBudget) * 100, nil
 				}
 			}
@@ -236,6 +270,7 @@ func (c *Codex) GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
 		if err != nil {
 			return 0, err
 		}
+		c.setLastUsedPercentSource("rate-limit")
 		if limits != nil && limits.Primary != nil {
 			return limits.Primary.UsedPercent, nil
 		}
@@ -246,9 +281,11 @@ func (c *Codex) GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
 			return 0, err
 		}
 		if limits != nil && limits.Secondary != nil {
+			c.setLastUsedPercentSource("rate-limit")
 			return limits.Secondary.UsedPercent, nil
 		}
 		// Fall back to token-based if no rate limit data
+		c.setLastUsedPercentSource("local-tokens")
 		if weeklyBudget > 0 {
 			usage, err := c.GetWeeklyTokenUsage()
 			if err == nil && usage != nil && usage.TotalTokens > 0 {
@@ -261,6 +298,20 @@ func (c *Codex) GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
 	}
 }
 
+// LastUsedPercentSource reports whether the last GetUsedPercent call read
+// Codex's rate limits ("rate-limit") or local token counts ("local-tokens").
+func (c *Codex) LastUsedPercentSource() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastUsedPercentSource
+}
+
+func (c *Codex) setLastUsedPercentSource(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastUsedPercentSource = source
+}
+
 // UsageBreakdown contains both rate-limit and local token data for display.
 type UsageBreakdown struct {
 	PrimaryPct   float64          // 5h window used_percent from rate limit
@@ -339,6 +390,33 @@ func (c *Codex) GetResetTime(mode string) (time.Time, error) {
 	}
 }
 
+// GetSessionWindow returns the primary (~5h) rate limit window. Rate limits
+// are re-read from disk because runs keep appending fresher ones; a window
+// whose reset has passed is reported as empty.
+func (c *Codex) GetSessionWindow() (SessionWindow, error) {
+	limits, err := c.RefreshRateLimits()
+	if err != nil {
+		return SessionWindow{}, err
+	}
+	if limits == nil || limits.Primary == nil {
+		return SessionWindow{}, nil
+	}
+
+	primary := limits.Primary
+	window := SessionWindow{Length: time.Duration(primary.WindowMinutes) * time.Minute}
+	used := primary.UsedPercent
+	if primary.ResetsAt > 0 {
+		resetsAt := time.Unix(primary.ResetsAt, 0)
+		if !resetsAt.After(time.Now()) {
+			used = 0
+		} else {
+			window.ResetsAt = resetsAt
+		}
+	}
+	window.UsedPercent = &used
+	return window, nil
+}
+
 // GetWindowMinutes returns the window duration for the specified mode.
 func (c *Codex) GetWindowMinutes(mode string) (int64, error) {
 	limits, err := c.GetRateLimits()
@@ -377,13 +455,21 @@ func (c *Codex) RefreshRateLimits() (*CodexRateLimits, error) {
 // excludes cached input tokens since those don't count against rate limits.
 // Returns nil if no token usage data is found (e.g. stub sessions).
 func (c *Codex) ParseSessionTokenUsage(path string) (*CodexTokenUsage, error) {
+	usage, _, err := c.parseSessionUsage(path)
+	return usage, err
+}
+
+// parseSessionUsage is ParseSessionTokenUsage that also reports the session's
+// model, taken from its last turn_context entry ("" when none is recorded).
+func (c *Codex) parseSessionUsage(path string) (*CodexTokenUsage, string, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("opening codex session: %w", err)
+		return nil, "", fmt.Errorf("opening codex session: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
 	var first, latest *CodexTokenUsage
+	var model string
 	eventCount := 0
 	reader := bufio.NewReaderSize(file, 64*1024)
 	for {
@@ -393,6 +479,9 @@ func (c *Codex) ParseSessionTokenUsage(path string) (*CodexTokenUsage, error) {
 			if len(line) > 0 {
 				var entry CodexSessionEntry
 				if jsonErr := json.Unmarshal(line, &entry); jsonErr == nil {
+					if entry.Type == "turn_context" && entry.Payload != nil && entry.Payload.Model != "" {
+						model = entry.Payload.Model
+					}
 					if entry.Payload != nil && entry.Payload.Type == "token_count" &&
 						entry.Payload.Info != nil && entry.Payload.Info.TotalTokenUsage != nil {
 						eventCount++
@@ -410,12 +499,12 @@ func (c *Codex) ParseSessionTokenUsage(path string) (*CodexTokenUsage, error) {
 			if err == io.EOF {
 				break
 			}
-			return nil, fmt.Errorf("reading codex session: %w", err)
+			return nil, "", fmt.Errorf("reading codex session: %w", err)
 		}
 	}
 
 	if latest == nil {
-		return nil, nil
+		return nil, model, nil
 	}
 
 	// For a single event, use its values directly as the session usage
@@ -450,7 +539,7 @@ func (c *Codex) ParseSessionTokenUsage(path string) (*CodexTokenUsage, error) {
 		OutputTokens:          output,
 		ReasoningOutputTokens: reasoning,
 		TotalTokens:           nonCachedInput + output + reasoning,
-	}, nil
+	}, model, nil
 }
 
 // FindMostRecentSessionWithData finds the most recent session file by
@@ -634,6 +723,43 @@ func (c *Codex) GetWeeklyTokenUsage() (*CodexTokenUsage, error) {
 	return &sum, nil
 }
 
+// GetTodayTokensByModel returns today's billable tokens keyed by model.
+func (c *Codex) GetTodayTokensByModel() (map[string]int64, error) {
+	return c.tokensByModel(1)
+}
+
+// GetWeeklyTokensByModel returns the last 7 days' billable tokens keyed by model.
+func (c *Codex) GetWeeklyTokensByModel() (map[string]int64, error) {
+	return c.tokensByModel(7)
+}
+
+// tokensByModel sums session usage over the last days (today included),
+// attributing each session to its model.
+func (c *Codex) tokensByModel(days int) (map[string]int64, error) {
+	now := time.Now()
+	total := make(map[string]int64)
+	for i := 0; i < days; i++ {
+		files, err := c.ListSessionFilesForDate(now.AddDate(0, 0, -i))
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			continue
+		}
+		for _, f := range files {
+			usage, model, err := c.parseSessionUsage(f)
+			if err != nil || usage == nil {
+				continue
+			}
+			if model == "" {
+				model = "unknown"
+			}
+			total[model] += usage.TotalTokens
+		}
+	}
+	return total, nil
+}
+
 // GetTodayTokenUsage sums token usage across ALL sessions for today's date.
 // Each session's cumulative total_token_usage is taken from the last
 // token_count event in that file (since total_token_usage is cumulative
@@ -855,14 +855,16 @@ func TestCodexProvider_Name(t *testing.T) {
 	}
 }
 
-func TestCodexProvider_Cost(t *testing.T) {
+func TestCodexProvider_Pricing(t *testing.T) {
 	provider := NewCodex()
-	input, output := provider.Cost()
-	if input != 100 {
-		t.Errorf("input cost = %d, want 100", input)
+	prices := provider.Pricing()
+	if len(prices) == 0 || prices[0].Model != "" {
+		t.Fatalf("expected a fallback price first, got %+v", prices)
 	}
-	if output != 300 {
-		t.Errorf("output cost = %d, want 300", output)
+	for _, p := range prices {
+		if p.Input <= 0 || p.Output <= p.Input {
+			t.Errorf("implausible price %+v", p)
+		}
 	}
 }
 
@@ -1114,6 +1116,48 @@ func TestCodexFindMostRecentSessionWithData_AllStubs(t *testing.T) {
 	}
 }
 
+func TestCodexTokensByModel(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	todayDir := filepath.Join(
+		tmpDir, "sessions",
+		fmt.Sprintf("%04d", now.Year()),
+		fmt.Sprintf("%02d", int(now.Month())),
+		fmt.Sprintf("%02d", now.Day()),
+	)
+	if err := os.MkdirAll(todayDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Billable: (500-400) + 100 + 25 = 225 each
+	s1 := `{"type":"turn_context","payload":{"model":"gpt-5-codex"}}
+` + codexTokenCountJSON(500, 400, 100, 25, 625) + "\n"
+	s2 := codexTokenCountJSON(500, 400, 100, 25, 625) + "\n"
+	if err := os.WriteFile(filepath.Join(todayDir, "s1.jsonl"), []byte(s1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(todayDir, "s2.jsonl"), []byte(s2), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := NewCodexWithPath(tmpDir)
+	byModel, err := provider.GetTodayTokensByModel()
+	if err != nil {
+		t.Fatalf("GetTodayTokensByModel error: %v", err)
+	}
+	if byModel["gpt-5-codex"] != 225 || byModel["unknown"] != 225 {
+		t.Errorf("by model = %v", byModel)
+	}
+
+	weekly, err := provider.GetWeeklyTokensByModel()
+	if err != nil {
+		t.Fatalf("GetWeeklyTokensByModel error: %v", err)
+	}
+	if weekly["gpt-5-codex"] != 225 {
+		t.Errorf("weekly by model = %v", weekly)
+	}
+}
+
 func TestCodexGetTodayTokenUsage(t *testing.T) {
 	tmpDir := t.TempDir()
 	now := time.Now()
@@ -1417,3 +1461,58 @@ func TestCodexParseSessionJSONL_LargeLines(t *testing.T) {
 		t.Errorf("Primary.UsedPercent = %.1f, want 34.0", limits.Primary.UsedPercent)
 	}
 }
+
+func TestCodexGetSessionWindow(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionsDir := filepath.Join(tmpDir, "sessions", "2026", "02", "03")
+	if err := os.MkdirAll(sessionsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	resetsAt := time.Now().Add(2 * time.Hour).Unix()
+	content := codexRateLimitsJSON(
+		fmt.Sprintf(`{"used_percent":62.0,"window_minutes":300,"resets_at":%d}`, resetsAt),
+		`{"used_percent":10.0,"window_minutes":10080,"resets_at":1770483159}`,
+	)
+	if err := os.WriteFile(filepath.Join(sessionsDir, "session.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	window, err := NewCodexWithPath(tmpDir).GetSessionWindow()
+	if err != nil {
+		t.Fatalf("GetSessionWindow error: %v", err)
+	}
+	if window.Length != 5*time.Hour {
+		t.Errorf("Length = %v, want 5h", window.Length)
+	}
+	if window.UsedPercent == nil || *window.UsedPercent != 62.0 {
+		t.Errorf("UsedPercent = %v, want 62", window.UsedPercent)
+	}
+	if window.ResetsAt.Unix() != resetsAt {
+		t.Errorf("ResetsAt = %v, want %v", window.ResetsAt.Unix(), resetsAt)
+	}
+}
+
+func TestCodexGetSessionWindow_Expired(t *testing.T) {
+	tmpDir := t.TempDir()
+	sessionsDir := filepath.Join(tmpDir, "sessions", "2026", "02", "03")
+	if err := os.MkdirAll(sessionsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := codexRateLimitsJSON(`{"used_percent":98.0,"window_minutes":300,"resets_at":1769896359}`, "")
+	if err := os.WriteFile(filepath.Join(sessionsDir, "session.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	window, err := NewCodexWithPath(tmpDir).GetSessionWindow()
+	if err != nil {
+		t.Fatalf("GetSessionWindow error: %v", err)
+	}
+	if window.UsedPercent == nil || *window.UsedPercent != 0 {
+		t.Errorf("UsedPercent = %v, want 0 after reset", window.UsedPercent)
+	}
+	if !window.ResetsAt.IsZero() {
+		t.Errorf("ResetsAt = %v, want zero", window.ResetsAt)
+	}
+}
@@ -2,16 +2,58 @@
 package providers
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
 )
 
+// GeminiChat is a chat recording written by Gemini CLI to
+// tmp/<project-hash>/chats/session-*.json.
+type GeminiChat struct {
+	SessionID   string              `json:"sessionId"`
+	ProjectHash string              `json:"projectHash"`
+	StartTime   time.Time           `json:"startTime"`
+	LastUpdated time.Time           `json:"lastUpdated"`
+	Messages    []GeminiChatMessage `json:"messages"`
+}
+
+// GeminiChatMessage is one message in a chat recording. Only model
+// ("gemini") messages carry token counts.
+type GeminiChatMessage struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Type      string        `json:"type"`
+	Model     string        `json:"model,omitempty"`
+	Tokens    *GeminiTokens `json:"tokens,omitempty"`
+}
+
+// GeminiTokens holds per-message token counts.
+type GeminiTokens struct {
+	Input    int64 `json:"input"`
+	Output   int64 `json:"output"`
+	Cached   int64 `json:"cached"`
+	Thoughts int64 `json:"thoughts,omitempty"`
+	Tool     int64 `json:"tool,omitempty"`
+	Total    int64 `json:"total"`
+}
+
+// TotalTokens returns the reported total, or the sum of the non-cached
+// fields when the total is missing. Cached tokens are already part of input.
+func (t GeminiTokens) TotalTokens() int64 {
+	if t.Total > 0 {
+		return t.Total
+	}
+	return t.Input + t.Output + t.Thoughts + t.Tool
+}
+
 // Gemini wraps the Gemini CLI as a provider.
 type Gemini struct {
 	dataPath string // Path to ~/.gemini
+	ledger   Ledger // nightshift's own runs; nil counts chat recordings only
 }
 
 // NewGemini creates a Gemini provider.
@@ -34,16 +76,63 @@ func (g *Gemini) Name() string {
 	return "gemini"
 }
 
-// Execute runs a task via Gemini CLI.
-func (g *Gemini) Execute(ctx context.Context, task Task) (Result, error) {
-	// TODO: Implement - spawn gemini CLI process
-	return Result{}, nil
+func init() {
+	Register(Registration{
+		Name:            "gemini",
+		DefaultDataPath: config.DefaultGeminiDataPath,
+		New:             func(dataPath string) Provider { return NewGeminiWithPath(dataPath) },
+	})
+}
+
+// Binary returns "gemini".
+func (g *Gemini) Binary() string {
+	return "gemini"
+}
+
+// NewAgent creates a Gemini CLI agent from the gemini provider settings.
+func (g *Gemini) NewAgent(cfg *config.Config, runner agents.CommandRunner) agents.Agent {
+	var opts []agents.GeminiOption
+	if cfg != nil {
+		opts = append(opts, agents.WithGeminiYolo(cfg.ProviderSettings("gemini").Yolo))
+	}
+	if runner != nil {
+		opts = append(opts, agents.WithGeminiRunner(runner))
+	}
+	return agents.NewGeminiAgent(opts...)
+}
+
+// CountsCacheTokens reports that Gemini token totals include cached input.
+func (g *Gemini) CountsCacheTokens() bool {
+	return true
 }
 
-// Cost returns Gemini's token pricing (hundredths of a cent per 1K tokens).
-// Based on Gemini 2.5 Pro pricing: ~$1.25/M input, ~$10/M output.
-func (g *Gemini) Cost() (inputCents, outputCents int64) {
-	return 13, 100
+// SetLedger makes usage include the runs nightshift recorded in l.
+func (g *Gemini) SetLedger(l Ledger) {
+	g.ledger = l
+}
+
+// GetResetTime returns when Gemini's usage window resets. The weekly window
+// is assumed to follow the calendar week (reset Sunday midnight).
+func (g *Gemini) GetResetTime(mode string) (time.Time, error) {
+	switch mode {
+	case "weekly":
+		return nextWeekday(time.Now(), time.Sunday), nil
+	case "daily":
+		return time.Time{}, nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid mode: %s (must be 'daily' or 'weekly')", mode)
+	}
+}
+
+// Pricing returns Gemini API list prices (USD per million tokens) for
+// prompts up to 200K tokens.
+func (g *Gemini) Pricing() []ModelPrice {
+	return []ModelPrice{
+		{Model: "", Input: 1.25, Output: 10, CacheRead: 0.31},
+		{Model: "gemini-2.5-pro", Input: 1.25, Output: 10, CacheRead: 0.31},
+		{Model: "gemini-2.5-flash", Input: 0.30, Output: 2.50, CacheRead: 0.075},
+		{Model: "gemini-2.5-flash-lite", Input: 0.10, Output: 0.40, CacheRead: 0.025},
+	}
 }
 
 // DataPath returns the configured data path.
@@ -52,7 +141,8 @@ func (g *Gemini) DataPath() string {
 }
 
 // GetUsedPercent returns the used percentage based on mode.
-// For Gemini, this is a token-based calculation against the weekly budget.
+// For Gemini, this is a token-based calculation against the weekly budget
+// using tokens parsed from chat recordings and nightshift's ledger.
 // Returns 0 if no parseable session data is found.
 func (g *Gemini) GetUsedPercent(mode string, weeklyBudget int64) (float64, error) {
 	switch mode {
@@ -81,40 +171,153 @@ func (g *Gemini) GetUsedPercent(mode string, weeklyBudget int64) (float64, error
 }
 
 // GetTodayTokens returns total tokens used today.
-// Scans Gemini session files from today's date.
 // Returns 0 if no parseable session data is found.
 func (g *Gemini) GetTodayTokens() (int64, error) {
-	now := time.Now()
-	return g.getTokensForDate(now)
+	byDate, err := g.DailyModelTokens(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return sumTokensByModel(byDate[time.Now().Format("2006-01-02")]), nil
 }
 
 // GetWeeklyTokens returns total tokens used in the last 7 days.
 // Returns 0 if no parseable session data is found.
 func (g *Gemini) GetWeeklyTokens() (int64, error) {
-	now := time.Now()
+	byDate, err := g.DailyModelTokens(time.Now().AddDate(0, 0, -6))
+	if err != nil {
+		return 0, err
+	}
 	var total int64
-	for i := 0; i < 7; i++ {
-		date := now.AddDate(0, 0, -i)
-		tokens, err := g.getTokensForDate(date)
+	for _, byModel := range byDate {
+		total += sumTokensByModel(byModel)
+	}
+	return total, nil
+}
+
+// GetTokensByModel returns tokens used on date, keyed by model.
+func (g *Gemini) GetTokensByModel(date time.Time) (map[string]int64, error) {
+	byDate, err := g.DailyModelTokens(date)
+	if err != nil {
+		return nil, err
+	}
+	day := date.Format("2006-01-02")
+	if byDate[day] == nil {
+		return map[string]int64{}, nil
+	}
+	return byDate[day], nil
+}
+
+// GetTodayTokensByModel returns today's tokens keyed by model.
+func (g *Gemini) GetTodayTokensByModel() (map[string]int64, error) {
+	return g.GetTokensByModel(time.Now())
+}
+
+// GetWeeklyTokensByModel returns the last 7 days' tokens keyed by model.
+func (g *Gemini) GetWeeklyTokensByModel() (map[string]int64, error) {
+	byDate, err := g.DailyModelTokens(time.Now().AddDate(0, 0, -6))
+	if err != nil {
+		return nil, err
+	}
+	total := make(map[string]int64)
+	for _, byModel := range byDate {
+		for model, tokens := range byModel {
+			total[model] += tokens
+		}
+	}
+	return total, nil
+}
+
+// DailyModelTokens returns tokens per local date and model for every day from
+// since onwards. Chat recordings under tmp/<hash>/chats/ are the primary
+// source; the ledger's record of nightshift's own runs (from their
+// --output-format json stats) fills in sessions that have no chat file.
+func (g *Gemini) DailyModelTokens(since time.Time) (map[string]map[string]int64, error) {
+	cutoffDate := since.Format("2006-01-02")
+	byDate := make(map[string]map[string]int64)
+	add := func(ts time.Time, model string, tokens int64) {
+		if tokens <= 0 {
+			return
+		}
+		day := ts.Local().Format("2006-01-02")
+		if day < cutoffDate {
+			return
+		}
+		if model == "" {
+			model = "unknown"
+		}
+		if byDate[day] == nil {
+			byDate[day] = make(map[string]int64)
+		}
+		byDate[day][model] += tokens
+	}
+
+	chats, err := g.ListChatFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip files not touched since the cutoff day (with a day of slack for
+	// timezone differences between file mtimes and message timestamps).
+	cutoff, _ := time.ParseInLocation("2006-01-02", cutoffDate, time.Local)
+	mtimeCutoff := cutoff.AddDate(0, 0, -1)
+
+	seen := make(map[string]bool)
+	for _, path := range chats {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(mtimeCutoff) {
+			continue
+		}
+		chat, err := ParseGeminiChat(path)
 		if err != nil {
+			continue // skip corrupt or in-progress files
+		}
+		if chat.SessionID != "" {
+			seen[chat.SessionID] = true
+		}
+		for _, msg := range chat.Messages {
+			if msg.Type != "gemini" || msg.Tokens == nil {
+				continue
+			}
+			add(msg.Timestamp, msg.Model, msg.Tokens.TotalTokens())
+		}
+	}
+
+	if g.ledger == nil {
+		return byDate, nil
+	}
+	runs, err := g.ledger.ProviderLedger(g.Name(), cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.SessionID != "" && seen[run.SessionID] {
 			continue
 		}
-		total += tokens
+		// Cached input is part of the totals chat recordings report
+		add(run.Time, run.Model, run.InputTokens+run.OutputTokens+run.CacheReadTokens+run.CacheWriteTokens)
 	}
-	return total, nil
+
+	return byDate, nil
 }
 
-// getTokensForDate returns tokens used on a specific date.
-// Gemini CLI stores session data in ~/.gemini/tmp/<hash>/chats/ but the
-// exact format may vary. For now, return 0 gracefully — calibration
-// snapshots will fill the gap once the user has Gemini sessions.
-func (g *Gemini) getTokensForDate(_ time.Time) (int64, error) {
-	// Check if the data directory exists at all
-	if _, err := os.Stat(g.dataPath); os.IsNotExist(err) {
-		return 0, nil
+// ListChatFiles finds recorded chat sessions under tmp/<hash>/chats/.
+func (g *Gemini) ListChatFiles() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(g.dataPath, "tmp", "*", "chats", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("listing gemini chats: %w", err)
+	}
+	return matches, nil
+}
+
+// ParseGeminiChat reads a Gemini CLI chat recording.
+func ParseGeminiChat(path string) (*GeminiChat, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading gemini chat: %w", err)
+	}
+	var chat GeminiChat
+	if err := json.Unmarshal(data, &chat); err != nil {
+		return nil, fmt.Errorf("parsing gemini chat: %w", err)
 	}
-	// TODO: Parse Gemini session files for token data once format is confirmed.
-	// The --output-format json response includes stats.models.<name>.tokens
-	// which can be parsed from session files.
-	return 0, nil
+	return &chat, nil
 }
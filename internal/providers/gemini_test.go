@@ -1,7 +1,13 @@
 package providers
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/state"
 )
 
 func TestGeminiProvider_Name(t *testing.T) {
@@ -11,14 +17,16 @@ func TestGeminiProvider_Name(t *testing.T) {
 	}
 }
 
-func TestGeminiProvider_Cost(t *testing.T) {
+func TestGeminiProvider_Pricing(t *testing.T) {
 	provider := NewGemini()
-	input, output := provider.Cost()
-	if input != 13 {
-		t.Errorf("input cost = %d, want 13", input)
+	prices := provider.Pricing()
+	if len(prices) == 0 || prices[0].Model != "" {
+		t.Fatalf("expected a fallback price first, got %+v", prices)
 	}
-	if output != 100 {
-		t.Errorf("output cost = %d, want 100", output)
+	for _, p := range prices {
+		if p.Input <= 0 || p.Output <= p.Input {
+			t.Errorf("implausible price %+v", p)
+		}
 	}
 }
 
@@ -92,3 +100,108 @@ func TestGeminiGetTodayTokens_MissingDataPath(t *testing.T) {
 		t.Errorf("expected 0 for missing path, got %d", tokens)
 	}
 }
+
+func writeGeminiChat(t *testing.T, dataPath, hash, name, body string) string {
+	t.Helper()
+	dir := filepath.Join(dataPath, "tmp", hash, "chats")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGeminiTokensFromChats(t *testing.T) {
+	dataPath := t.TempDir()
+	now := time.Now()
+	today := now.Format(time.RFC3339)
+	threeDaysAgo := now.AddDate(0, 0, -3).Format(time.RFC3339)
+	lastMonth := now.AddDate(0, 0, -30).Format(time.RFC3339)
+
+	writeGeminiChat(t, dataPath, "abc123", "session-1.json", fmt.Sprintf(`{
+  "sessionId": "s1",
+  "projectHash": "abc123",
+  "messages": [
+    {"timestamp": %q, "type": "user", "content": "hi"},
+    {"timestamp": %q, "type": "gemini", "model": "gemini-2.5-pro", "tokens": {"input": 100, "output": 50, "cached": 20, "total": 150}},
+    {"timestamp": %q, "type": "gemini", "model": "gemini-2.5-flash", "tokens": {"input": 10, "output": 5, "cached": 0, "total": 0}},
+    {"timestamp": %q, "type": "gemini", "model": "gemini-2.5-pro", "tokens": {"input": 1000, "output": 1000, "cached": 0, "total": 2000}}
+  ]
+}`, today, today, threeDaysAgo, lastMonth))
+	writeGeminiChat(t, dataPath, "def456", "session-2.json", "{not json")
+
+	provider := NewGeminiWithPath(dataPath)
+
+	todayTokens, err := provider.GetTodayTokens()
+	if err != nil {
+		t.Fatalf("GetTodayTokens: %v", err)
+	}
+	if todayTokens != 150 {
+		t.Errorf("today = %d, want 150", todayTokens)
+	}
+
+	weekly, err := provider.GetWeeklyTokens()
+	if err != nil {
+		t.Fatalf("GetWeeklyTokens: %v", err)
+	}
+	if weekly != 165 {
+		t.Errorf("weekly = %d, want 165", weekly)
+	}
+
+	byModel, err := provider.GetTokensByModel(now.AddDate(0, 0, -3))
+	if err != nil {
+		t.Fatalf("GetTokensByModel: %v", err)
+	}
+	if byModel["gemini-2.5-flash"] != 15 {
+		t.Errorf("flash tokens = %d, want 15", byModel["gemini-2.5-flash"])
+	}
+
+	pct, err := provider.GetUsedPercent("weekly", 1650)
+	if err != nil {
+		t.Fatalf("GetUsedPercent: %v", err)
+	}
+	if pct != 10 {
+		t.Errorf("weekly pct = %.1f, want 10", pct)
+	}
+}
+
+func TestGeminiLedgerDedupesRecordedSessions(t *testing.T) {
+	dataPath := t.TempDir()
+	now := time.Now()
+	writeGeminiChat(t, dataPath, "abc123", "session-1.json", fmt.Sprintf(`{
+  "sessionId": "recorded",
+  "messages": [{"timestamp": %q, "type": "gemini", "model": "gemini-2.5-pro", "tokens": {"total": 100}}]
+}`, now.Format(time.RFC3339)))
+
+	provider := NewGeminiWithPath(dataPath)
+	ledger := &fakeLedger{entries: []state.LedgerEntry{
+		{Time: now, Provider: "gemini", Model: "gemini-2.5-pro", SessionID: "recorded", InputTokens: 20, OutputTokens: 10, CacheReadTokens: 10, Measured: true},
+		{Time: now, Provider: "gemini", Model: "gemini-2.5-pro", SessionID: "headless", InputTokens: 20, OutputTokens: 10, CacheReadTokens: 10, Measured: true},
+	}}
+	UseLedger([]Provider{NewClaudeWithPath(t.TempDir()), provider}, ledger)
+
+	tokens, err := provider.GetTodayTokens()
+	if err != nil {
+		t.Fatalf("GetTodayTokens: %v", err)
+	}
+	// The "recorded" run is already counted from its chat file.
+	if tokens != 140 {
+		t.Errorf("today = %d, want 140", tokens)
+	}
+	if ledger.provider != "gemini" {
+		t.Errorf("ledger queried for %q, want gemini", ledger.provider)
+	}
+}
+
+type fakeLedger struct {
+	entries  []state.LedgerEntry
+	provider string
+}
+
+func (l *fakeLedger) ProviderLedger(provider string, _ time.Time) ([]state.LedgerEntry, error) {
+	l.provider = provider
+	return l.entries, nil
+}
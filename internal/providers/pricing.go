@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
+)
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	Model      string  // Model name prefix; empty matches any model
+	Input      float64 // Uncached input
+	Output     float64 // Output, including reasoning
+	CacheRead  float64 // Input served from cache
+	CacheWrite float64 // Input written to cache
+}
+
+// Cost returns the USD cost of u at this price.
+func (p ModelPrice) Cost(u agents.TokenUsage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheReadTokens)*p.CacheRead +
+		float64(u.CacheWriteTokens)*p.CacheWrite) / 1_000_000
+}
+
+// estimateInputShare is the fraction of an agent session's tokens assumed to
+// be input when only a total is known. Agent sessions re-send context on
+// every turn, so input dominates.
+const estimateInputShare = 0.8
+
+// PriceTable resolves model prices from config overrides and provider
+// defaults.
+type PriceTable struct {
+	overrides []config.ModelPricing
+	defaults  map[string][]ModelPrice
+}
+
+// NewPriceTable builds a price table from the providers' built-in prices and
+// the budget.pricing overrides in cfg.
+func NewPriceTable(cfg *config.Config, ps []Provider) *PriceTable {
+	t := &PriceTable{defaults: make(map[string][]ModelPrice, len(ps))}
+	if cfg != nil {
+		t.overrides = cfg.Budget.Pricing
+	}
+	for _, p := range ps {
+		if p != nil {
+			t.defaults[p.Name()] = p.Pricing()
+		}
+	}
+	return t
+}
+
+// Lookup returns the price for a provider's model. Overrides win over
+// built-in prices; within each, the longest matching model prefix wins.
+func (t *PriceTable) Lookup(provider, model string) (ModelPrice, bool) {
+	model = strings.ToLower(model)
+
+	best, found := ModelPrice{}, false
+	for _, o := range t.overrides {
+		if o.Provider != "" && !strings.EqualFold(o.Provider, provider) {
+			continue
+		}
+		prefix := strings.ToLower(o.Model)
+		if strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(best.Model)) {
+			best = ModelPrice{Model: prefix, Input: o.Input, Output: o.Output, CacheRead: o.CacheRead, CacheWrite: o.CacheWrite}
+			found = true
+		}
+	}
+	if found {
+		return best, true
+	}
+
+	for _, p := range t.defaults[provider] {
+		if strings.HasPrefix(model, p.Model) && (!found || len(p.Model) > len(best.Model)) {
+			best = p
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Cost returns the USD cost of the usage reported by a provider's agent.
+func (t *PriceTable) Cost(provider string, usage []agents.TokenUsage) float64 {
+	var total float64
+	for _, u := range usage {
+		if price, ok := t.Lookup(provider, u.Model); ok {
+			total += price.Cost(u)
+		}
+	}
+	return total
+}
+
+// Estimate returns the expected USD cost of tokens on a provider's default
+// model, assuming the input/output mix typical of agent sessions.
+func (t *PriceTable) Estimate(provider string, tokens int64) float64 {
+	price, ok := t.Lookup(provider, "")
+	if !ok {
+		return 0
+	}
+	input := int64(float64(tokens) * estimateInputShare)
+	return price.Cost(agents.TokenUsage{InputTokens: input, OutputTokens: tokens - input})
+}
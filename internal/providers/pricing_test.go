@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestModelPriceCost(t *testing.T) {
+	price := ModelPrice{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	usage := agents.TokenUsage{
+		InputTokens:      1_000_000,
+		OutputTokens:     100_000,
+		CacheReadTokens:  2_000_000,
+		CacheWriteTokens: 400_000,
+	}
+	// 3 + 1.5 + 0.6 + 1.5
+	if got := price.Cost(usage); !approxEqual(got, 6.6) {
+		t.Errorf("Cost = %f, want 6.6", got)
+	}
+}
+
+func TestPriceTableLookup(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Budget.Pricing = []config.ModelPricing{
+		{Model: "claude-sonnet-4", Input: 2, Output: 10},
+		{Provider: "codex", Model: "gpt-5", Input: 1, Output: 8},
+	}
+	table := NewPriceTable(cfg, []Provider{NewClaude(), NewCodex(), NewGemini()})
+
+	tests := []struct {
+		provider, model string
+		wantInput       float64
+	}{
+		{"claude", "claude-sonnet-4-5-20250929", 2}, // override
+		{"claude", "claude-opus-4-1-20250805", 15},  // built-in
+		{"claude", "claude-opus-4-5-20251101", 5},   // longest built-in prefix
+		{"claude", "some-future-model", 3},          // provider fallback
+		{"codex", "gpt-5-codex", 1},                 // provider-scoped override
+		{"gemini", "gemini-2.5-flash-lite", 0.10},   // longest prefix beats gemini-2.5-flash
+		{"gemini", "GEMINI-2.5-PRO", 1.25},          // case-insensitive
+	}
+	for _, tt := range tests {
+		price, ok := table.Lookup(tt.provider, tt.model)
+		if !ok {
+			t.Errorf("Lookup(%s, %s) not found", tt.provider, tt.model)
+			continue
+		}
+		if price.Input != tt.wantInput {
+			t.Errorf("Lookup(%s, %s).Input = %v, want %v", tt.provider, tt.model, price.Input, tt.wantInput)
+		}
+	}
+
+	if _, ok := table.Lookup("unknown", "x"); ok {
+		t.Error("expected no price for unknown provider")
+	}
+}
+
+func TestPriceTableCostAndEstimate(t *testing.T) {
+	table := NewPriceTable(nil, []Provider{NewGemini()})
+
+	cost := table.Cost("gemini", []agents.TokenUsage{
+		{Model: "gemini-2.5-pro", InputTokens: 1_000_000},
+		{Model: "gemini-2.5-flash", OutputTokens: 1_000_000},
+	})
+	if !approxEqual(cost, 1.25+2.50) {
+		t.Errorf("Cost = %f, want 3.75", cost)
+	}
+
+	// 800K input at $1.25 + 200K output at $10
+	if got := table.Estimate("gemini", 1_000_000); !approxEqual(got, 1.0+2.0) {
+		t.Errorf("Estimate = %f, want 3.0", got)
+	}
+}
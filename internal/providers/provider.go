@@ -2,26 +2,109 @@
 // Supports multiple backends: Claude Code, Codex CLI, etc.
 package providers
 
-import "context"
+import (
+	"time"
 
-// Provider is the interface all AI coding agents must implement.
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// Provider is the interface every AI CLI integration implements. A provider
+// bundles its agent factory, local usage source, pricing and reset-window
+// logic so budget, snapshots and the run loop can treat providers uniformly.
 type Provider interface {
 	// Name returns the provider identifier.
 	Name() string
 
-	// Execute runs a task and returns the result.
-	Execute(ctx context.Context, task Task) (Result, error)
+	// Binary returns the CLI executable looked up in PATH.
+	Binary() string
+
+	// NewAgent creates an agent for task execution using the provider's
+	// settings from cfg. A nil runner selects the default os/exec runner.
+	NewAgent(cfg *config.Config, runner agents.CommandRunner) agents.Agent
+
+	// GetUsedPercent returns the used percentage for the budget mode.
+	GetUsedPercent(mode string, weeklyBudget int64) (float64, error)
+
+	// GetTodayTokens returns tokens used today from local data.
+	GetTodayTokens() (int64, error)
+
+	// GetWeeklyTokens returns tokens used in the last 7 days from local data.
+	GetWeeklyTokens() (int64, error)
+
+	// Pricing returns the built-in per-model prices. An entry with an empty
+	// Model is the fallback for unrecognized models.
+	Pricing() []ModelPrice
+
+	// GetResetTime returns when the usage window for mode ("daily" or
+	// "weekly") resets. A zero time means the reset is unknown.
+	GetResetTime(mode string) (time.Time, error)
+}
+
+// ModelUsageReporter is implemented by providers whose local data splits
+// token usage by model. Totals match GetTodayTokens and GetWeeklyTokens.
+type ModelUsageReporter interface {
+	GetTodayTokensByModel() (map[string]int64, error)
+	GetWeeklyTokensByModel() (map[string]int64, error)
+}
+
+// CacheTokenCounter is implemented by providers whose local token counts
+// include cached input tokens, so nightshift's own usage is compared with
+// them on the same basis.
+type CacheTokenCounter interface {
+	CountsCacheTokens() bool
+}
+
+// SessionWindow describes a provider's short rolling usage window (e.g. the
+// ~5 hour limits Claude and Codex enforce alongside their weekly limits).
+type SessionWindow struct {
+	Length      time.Duration
+	UsedPercent *float64  // provider-reported usage; nil when only Tokens are known
+	Tokens      int64     // local tokens counted in the active window
+	ResetsAt    time.Time // zero when no window is active or the reset is unknown
+}
+
+// SessionWindowReporter is implemented by providers that know their short
+// usage window.
+type SessionWindowReporter interface {
+	GetSessionWindow() (SessionWindow, error)
+}
+
+// SessionFileLister is implemented by providers that keep local session
+// transcripts (JSONL files written by interactive and headless runs alike).
+type SessionFileLister interface {
+	ListSessionFiles() ([]string, error)
+}
+
+// Ledger reads the agent calls nightshift recorded in its database.
+// state.State implements it.
+type Ledger interface {
+	ProviderLedger(provider string, since time.Time) ([]state.LedgerEntry, error)
+}
 
-	// Cost returns estimated cost per 1K tokens (input, output).
-	Cost() (inputCents, outputCents int64)
+// LedgerReader is implemented by providers that count nightshift's own runs
+// from the ledger, for CLIs that may not record headless runs locally.
+type LedgerReader interface {
+	SetLedger(l Ledger)
 }
 
-// Task represents work to be done by a provider.
-type Task struct {
-	// TODO: Add task fields (prompt, files, etc.)
+// UseLedger gives the providers in ps that read the ledger access to l.
+func UseLedger(ps []Provider, l Ledger) {
+	for _, p := range ps {
+		if r, ok := p.(LedgerReader); ok {
+			r.SetLedger(l)
+		}
+	}
 }
 
-// Result holds the outcome of a provider execution.
-type Result struct {
-	// TODO: Add result fields (output, tokens used, etc.)
+// nextWeekday returns midnight of the next occurrence of day after now.
+// Used by providers whose weekly window follows the calendar.
+func nextWeekday(now time.Time, day time.Weekday) time.Time {
+	delta := (7 + int(day) - int(now.Weekday())) % 7
+	if delta == 0 {
+		delta = 7
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return midnight.AddDate(0, 0, delta)
 }
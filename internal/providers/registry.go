@@ -0,0 +1,122 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+// Registration describes how to construct a provider. Each provider file
+// registers itself from init, so adding a provider means adding one file.
+type Registration struct {
+	Name            string                         // Provider identifier (e.g. "claude")
+	DefaultDataPath string                         // Data directory when config has none (e.g. "~/.claude")
+	DefaultEnabled  bool                           // Enabled when config does not say
+	New             func(dataPath string) Provider // Constructor
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Registration
+)
+
+// Register adds a provider to the registry and its defaults to the config
+// (providers.<name>). Registering the same name twice replaces the earlier
+// registration.
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	config.RegisterProvider(r.Name, config.ProviderConfig{Enabled: r.DefaultEnabled, DataPath: r.DefaultDataPath})
+
+	for i, existing := range registry {
+		if existing.Name == r.Name {
+			registry[i] = r
+			return
+		}
+	}
+	registry = append(registry, r)
+}
+
+// Lookup returns the registration for name.
+func Lookup(name string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	name = strings.ToLower(name)
+	for _, r := range registry {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Registration{}, false
+}
+
+// Names returns registered provider names in registration order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for _, r := range registry {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// New constructs the named provider reading data from dataPath.
+// An empty dataPath uses the provider's default.
+func New(name, dataPath string) (Provider, error) {
+	r, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider: %s (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	if dataPath == "" {
+		dataPath = expandHome(r.DefaultDataPath)
+	}
+	return r.New(dataPath), nil
+}
+
+// FromConfig constructs every registered provider using the data paths in
+// cfg, in registration order. Disabled providers are included so usage can
+// still be reported; callers check ProviderConfig.Enabled before running.
+func FromConfig(cfg *config.Config) []Provider {
+	names := Names()
+	out := make([]Provider, 0, len(names))
+	for _, name := range names {
+		dataPath := ""
+		if cfg != nil {
+			dataPath = cfg.ExpandedProviderPath(name)
+		}
+		p, err := New(name, dataPath)
+		if err != nil {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Find returns the provider with the given name, or nil.
+func Find(ps []Provider, name string) Provider {
+	name = strings.ToLower(name)
+	for _, p := range ps {
+		if p != nil && p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+func TestRegistryBuiltins(t *testing.T) {
+	names := Names()
+	want := []string{"claude", "codex", "gemini"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("Names() = %v, want %v", names, want)
+	}
+
+	for _, name := range want {
+		p, err := New(name, t.TempDir())
+		if err != nil {
+			t.Fatalf("New(%q): %v", name, err)
+		}
+		if p.Name() != name {
+			t.Errorf("New(%q).Name() = %q", name, p.Name())
+		}
+		if p.Binary() == "" {
+			t.Errorf("%s: empty binary", name)
+		}
+		if agent := p.NewAgent(nil, nil); agent == nil || agent.Name() != name {
+			t.Errorf("%s: NewAgent returned %v", name, agent)
+		}
+	}
+}
+
+func TestRegistryConfigDefaults(t *testing.T) {
+	if !slices.Equal(config.ProviderNames(), Names()) {
+		t.Fatalf("config.ProviderNames() = %v, want %v", config.ProviderNames(), Names())
+	}
+
+	dir := t.TempDir()
+	cfg, err := config.LoadFromPaths(dir, filepath.Join(dir, "nonexistent.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFromPaths: %v", err)
+	}
+	if !slices.Equal(cfg.Providers.Preference, Names()) {
+		t.Errorf("Preference = %v, want %v", cfg.Providers.Preference, Names())
+	}
+	for name, enabled := range map[string]bool{"claude": true, "codex": true, "gemini": false} {
+		r, _ := Lookup(name)
+		got := cfg.ProviderSettings(name)
+		if got.Enabled != enabled || got.DataPath != r.DefaultDataPath {
+			t.Errorf("%s settings = %+v, want enabled=%v data_path=%s", name, got, enabled, r.DefaultDataPath)
+		}
+	}
+}
+
+func TestNewUnknownProvider(t *testing.T) {
+	if _, err := New("nope", ""); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func TestFromConfigUsesDataPaths(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.SetProviderSettings("codex", config.ProviderConfig{DataPath: dir})
+
+	ps := FromConfig(cfg)
+	if len(ps) != len(Names()) {
+		t.Fatalf("FromConfig returned %d providers, want %d", len(ps), len(Names()))
+	}
+	codex, ok := Find(ps, "codex").(*Codex)
+	if !ok {
+		t.Fatal("Find(codex) did not return *Codex")
+	}
+	if codex.DataPath() != dir {
+		t.Errorf("codex data path = %q, want %q", codex.DataPath(), dir)
+	}
+	if Find(ps, "missing") != nil {
+		t.Error("Find(missing) should be nil")
+	}
+}
@@ -46,6 +46,9 @@ func RenderRunReport(results *RunResults, logPath string) (string, error) {
 			formatTokens(results.RemainingBudget),
 		))
 	}
+	if results.CostUSD > 0 {
+		buf.WriteString(fmt.Sprintf("- Spend: %s\n", formatUSD(results.CostUSD)))
+	}
 	buf.WriteString(fmt.Sprintf("- Tasks: %d completed, %d failed, %d skipped\n",
 		len(completed), len(failed), len(skipped)))
 	if logPath != "" {
@@ -85,6 +88,9 @@ func writeTaskSection(buf *bytes.Buffer, title string, tasks []TaskResult, reaso
 		if task.TokensUsed > 0 {
 			line += fmt.Sprintf(" — %s tokens", formatTokens(task.TokensUsed))
 		}
+		if task.CostUSD > 0 {
+			line += fmt.Sprintf(" — %s", formatUSD(task.CostUSD))
+		}
 		if task.Duration > 0 {
 			line += fmt.Sprintf(" — %s", formatDuration(task.Duration))
 		}
@@ -20,15 +20,17 @@ import (
 
 // TaskResult represents a completed or skipped task in the run.
 type TaskResult struct {
-	Project    string        `json:"project"`
-	TaskType   string        `json:"task_type"`
-	Title      string        `json:"title"`
-	Status     string        `json:"status"`                // completed, failed, skipped
-	OutputType string        `json:"output_type,omitempty"` // PR, Report, Analysis, etc.
-	OutputRef  string        `json:"output_ref,omitempty"`  // PR number, report path, etc.
-	TokensUsed int           `json:"tokens_used"`
-	SkipReason string        `json:"skip_reason,omitempty"` // e.g., "insufficient budget"
-	Duration   time.Duration `json:"duration,omitempty"`
+	Project    string           `json:"project"`
+	TaskType   string           `json:"task_type"`
+	Title      string           `json:"title"`
+	Status     string           `json:"status"`                // completed, failed, skipped
+	OutputType string           `json:"output_type,omitempty"` // PR, Report, Analysis, etc.
+	OutputRef  string           `json:"output_ref,omitempty"`  // PR number, report path, etc.
+	TokensUsed int              `json:"tokens_used"`
+	CostUSD    float64          `json:"cost_usd,omitempty"`    // Dollar cost of the agent calls
+	Models     map[string]int64 `json:"models,omitempty"`      // Reported tokens by model (input + output)
+	SkipReason string           `json:"skip_reason,omitempty"` // e.g., "insufficient budget"
+	Duration   time.Duration    `json:"duration,omitempty"`
 }
 
 // RunResults holds all results from a nightshift run.
@@ -37,6 +39,7 @@ type RunResults struct {
 	StartBudget     int          `json:"start_budget"`
 	UsedBudget      int          `json:"used_budget"`
 	RemainingBudget int          `json:"remaining_budget"`
+	CostUSD         float64      `json:"cost_usd,omitempty"`
 	Tasks           []TaskResult `json:"tasks"`
 	StartTime       time.Time    `json:"start_time"`
 	EndTime         time.Time    `json:"end_time"`
@@ -54,6 +57,7 @@ type Summary struct {
 	BudgetStart     int
 	BudgetUsed      int
 	BudgetRemaining int
+	CostUSD         float64
 }
 
 // Generator creates morning summary reports.
@@ -81,6 +85,7 @@ func (g *Generator) Generate(results *RunResults) (*Summary, error) {
 		BudgetStart:     results.StartBudget,
 		BudgetUsed:      results.UsedBudget,
 		BudgetRemaining: results.RemainingBudget,
+		CostUSD:         results.CostUSD,
 		ProjectCounts:   make(map[string]int),
 		CompletedTasks:  make([]TaskResult, 0),
 		SkippedTasks:    make([]TaskResult, 0),
@@ -121,7 +126,11 @@ func (g *Generator) renderMarkdown(summary *Summary, results *RunResults) string
 	}
 	buf.WriteString(fmt.Sprintf("- Started with: %s tokens\n", formatTokens(summary.BudgetStart)))
 	buf.WriteString(fmt.Sprintf("- Used: %s tokens (%d%%)\n", formatTokens(summary.BudgetUsed), usedPercent))
-	buf.WriteString(fmt.Sprintf("- Remaining: %s tokens\n\n", formatTokens(summary.BudgetRemaining)))
+	buf.WriteString(fmt.Sprintf("- Remaining: %s tokens\n", formatTokens(summary.BudgetRemaining)))
+	if summary.CostUSD > 0 {
+		buf.WriteString(fmt.Sprintf("- Spend: %s\n", formatUSD(summary.CostUSD)))
+	}
+	buf.WriteString("\n")
 
 	// Projects processed section
 	if len(summary.ProjectCounts) > 0 {
@@ -398,8 +407,12 @@ func (g *Generator) formatSlackSummary(summary *Summary) string {
 	if summary.BudgetStart > 0 {
 		usedPercent = (summary.BudgetUsed * 100) / summary.BudgetStart
 	}
-	buf.WriteString(fmt.Sprintf("*Budget:* %s used (%d%%) of %s\n\n",
+	buf.WriteString(fmt.Sprintf("*Budget:* %s used (%d%%) of %s\n",
 		formatTokens(summary.BudgetUsed), usedPercent, formatTokens(summary.BudgetStart)))
+	if summary.CostUSD > 0 {
+		buf.WriteString(fmt.Sprintf("*Spend:* %s\n", formatUSD(summary.CostUSD)))
+	}
+	buf.WriteString("\n")
 
 	// Tasks completed
 	if len(summary.CompletedTasks) > 0 {
@@ -433,6 +446,14 @@ func formatTokens(tokens int) string {
 	return fmt.Sprintf("%d,%03d", tokens/1000, tokens%1000)
 }
 
+// formatUSD formats a dollar amount, e.g. "$1.25".
+func formatUSD(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
 // formatDuration formats a duration in a human-readable way.
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
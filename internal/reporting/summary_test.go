@@ -432,6 +432,56 @@ func TestFormatSlackSummary(t *testing.T) {
 	}
 }
 
+func TestSummaryIncludesSpend(t *testing.T) {
+	cfg := &config.Config{}
+	gen := NewGenerator(cfg)
+
+	results := &RunResults{
+		Date:        time.Now(),
+		StartBudget: 100000,
+		UsedBudget:  20000,
+		CostUSD:     1.234,
+		Tasks: []TaskResult{
+			{Project: "/p", TaskType: "lint", Title: "Lint", Status: "completed", CostUSD: 1.234},
+		},
+	}
+
+	summary, err := gen.Generate(results)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if summary.CostUSD != 1.234 {
+		t.Errorf("CostUSD = %v, want 1.234", summary.CostUSD)
+	}
+	if !strings.Contains(summary.Content, "- Spend: $1.23") {
+		t.Error("summary content missing spend line")
+	}
+	if !strings.Contains(gen.formatSlackSummary(summary), "*Spend:* $1.23") {
+		t.Error("Slack summary missing spend")
+	}
+
+	summary.CostUSD = 0
+	if strings.Contains(gen.formatSlackSummary(summary), "Spend") {
+		t.Error("Slack summary should omit spend when zero")
+	}
+}
+
+func TestFormatUSD(t *testing.T) {
+	tests := []struct {
+		usd  float64
+		want string
+	}{
+		{0, "$0.00"},
+		{0.004, "<$0.01"},
+		{1.5, "$1.50"},
+	}
+	for _, tt := range tests {
+		if got := formatUSD(tt.usd); got != tt.want {
+			t.Errorf("formatUSD(%v) = %q, want %q", tt.usd, got, tt.want)
+		}
+	}
+}
+
 func TestGenerateWithFailedTasks(t *testing.T) {
 	cfg := &config.Config{}
 	gen := NewGenerator(cfg)
@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// FireStatus is the outcome of a scheduled run time.
+type FireStatus string
+
+// Fire statuses.
+const (
+	FireRan      FireStatus = "ran"       // ran on time
+	FireMissed   FireStatus = "missed"    // machine asleep or daemon down, not caught up
+	FireCaughtUp FireStatus = "caught_up" // missed, then run late
+)
+
+// Fire records what happened to one scheduled run time.
+type Fire struct {
+	Schedule    string
+	ScheduledAt time.Time
+	FiredAt     time.Time // when it ran, or when the miss was noticed
+	Status      FireStatus
+}
+
+// FireLog persists fires so runs missed while the machine slept or the
+// daemon was down can be detected.
+type FireLog interface {
+	LastFire(schedule string) (Fire, bool, error)
+	RecordFire(f Fire) error
+}
+
+// CatchUp configures missed-run detection. With a Log, the scheduler
+// records every fire and, on start and after the wall clock jumps (e.g. on
+// wake from sleep), looks for runs that should have happened since the last
+// one. A missed run is caught up when Enabled and either the window is open
+// or no more than Grace has passed since the missed time; otherwise it is
+// recorded as missed.
+type CatchUp struct {
+	Name    string
+	Log     FireLog
+	Enabled bool
+	Grace   time.Duration
+}
+
+const (
+	// lateTolerance is how late a run may start and still count as on time.
+	lateTolerance = 2 * time.Minute
+
+	// clockCheckInterval is how often the wall clock is compared against
+	// the monotonic clock, which stops while the machine sleeps.
+	clockCheckInterval = time.Minute
+)
+
+// SetCatchUp enables missed-run detection. Call before Start.
+func (s *Scheduler) SetCatchUp(c CatchUp) {
+	s.mu.Lock()
+	s.catchUp = c
+	s.mu.Unlock()
+}
+
+func (s *Scheduler) catchUpConfig() CatchUp {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.catchUp
+}
+
+// fire handles the run scheduled at scheduled. Timers measure monotonic
+// time, so after a sleep they fire late; a late fire is treated as missed.
+func (s *Scheduler) fire(ctx context.Context, scheduled time.Time) {
+	c := s.catchUpConfig()
+	if c.Log == nil {
+		s.runJobs(ctx)
+		return
+	}
+	now := time.Now()
+	if last, ok, _ := c.Log.LastFire(c.Name); ok && !last.ScheduledAt.Before(scheduled) {
+		return // already handled when the clock jump was noticed
+	}
+	if now.Sub(scheduled) > lateTolerance {
+		s.handleMissed(ctx, scheduled, now)
+		return
+	}
+	if !s.IsInWindow(now) {
+		return
+	}
+	_ = c.Log.RecordFire(Fire{Schedule: c.Name, ScheduledAt: scheduled, FiredAt: now, Status: FireRan})
+	s.runJobs(ctx)
+}
+
+// checkMissed looks for the latest run time between the last recorded fire
+// and now, and catches it up or records it as missed. Nothing is checked
+// before the first recorded fire.
+func (s *Scheduler) checkMissed(ctx context.Context, now time.Time) {
+	c := s.catchUpConfig()
+	last, ok, err := c.Log.LastFire(c.Name)
+	if err != nil || !ok {
+		return
+	}
+	missed := s.lastRunBetween(last.ScheduledAt, now.Add(-lateTolerance))
+	if missed.IsZero() {
+		return
+	}
+	s.handleMissed(ctx, missed, now)
+}
+
+// handleMissed catches up the run missed at scheduled, or records the miss.
+func (s *Scheduler) handleMissed(ctx context.Context, scheduled, now time.Time) {
+	c := s.catchUpConfig()
+	fire := Fire{Schedule: c.Name, ScheduledAt: scheduled, FiredAt: now, Status: FireMissed}
+	if c.Enabled && (s.IsInWindow(now) || (c.Grace > 0 && now.Sub(scheduled) <= c.Grace)) {
+		fire.Status = FireCaughtUp
+	}
+	_ = c.Log.RecordFire(fire)
+	if fire.Status != FireCaughtUp || ctx.Err() != nil {
+		return
+	}
+
+	s.mu.RLock()
+	jobs := make([]Job, len(s.jobs))
+	copy(jobs, s.jobs)
+	s.mu.RUnlock()
+	s.runLocked(ctx, jobs)
+}
+
+// watchClock checks for missed runs whenever the wall clock moves further
+// than the monotonic clock, which happens when the machine wakes up.
+func (s *Scheduler) watchClock(ctx context.Context) {
+	ticker := time.NewTicker(clockCheckInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			// Round(0) drops the monotonic reading, comparing wall clocks
+			if now.Round(0).Sub(last.Round(0)) > clockCheckInterval+lateTolerance {
+				s.checkMissed(ctx, now)
+			}
+			last = now
+		}
+	}
+}
+
+// lastRunBetween returns the latest run time in (after, before] that falls
+// inside the window, or zero when there is none.
+func (s *Scheduler) lastRunBetween(after, before time.Time) time.Time {
+	s.mu.RLock()
+	cronExpr := s.cronExpr
+	interval := s.interval
+	window := s.window
+	location := s.location
+	s.mu.RUnlock()
+
+	inWindow := func(t time.Time) bool { return window == nil || window.Contains(t) }
+	var latest time.Time
+
+	if cronExpr != "" {
+		parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+		schedule, err := parser.Parse(cronExpr)
+		if err != nil {
+			return time.Time{}
+		}
+		// Bounded: an every-minute cron over a week is ~10k steps
+		for t, i := schedule.Next(after.In(location)), 0; !t.After(before) && i < 100000; t, i = schedule.Next(t), i+1 {
+			if inWindow(t) {
+				latest = t
+			}
+		}
+		return latest
+	}
+
+	if interval <= 0 || before.Sub(after) < interval {
+		return time.Time{}
+	}
+	steps := before.Sub(after) / interval
+	for t := after.Add(steps * interval); t.After(after); t = t.Add(-interval) {
+		if inWindow(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
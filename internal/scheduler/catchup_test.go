@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+type memFireLog struct {
+	mu    sync.Mutex
+	fires []Fire
+}
+
+func (l *memFireLog) LastFire(schedule string) (Fire, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := len(l.fires) - 1; i >= 0; i-- {
+		if l.fires[i].Schedule == schedule {
+			return l.fires[i], true, nil
+		}
+	}
+	return Fire{}, false, nil
+}
+
+func (l *memFireLog) RecordFire(f Fire) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fires = append(l.fires, f)
+	return nil
+}
+
+func newCatchUpScheduler(t *testing.T, cfg *config.ScheduleConfig, c CatchUp) (*Scheduler, *int) {
+	t.Helper()
+	s, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig: %v", err)
+	}
+	s.SetCatchUp(c)
+	runs := new(int)
+	s.AddJob(func(context.Context) error {
+		*runs++
+		return nil
+	})
+	return s, runs
+}
+
+func TestCheckMissed_CatchesUpInsideWindow(t *testing.T) {
+	now := time.Now()
+	log := &memFireLog{}
+	_ = log.RecordFire(Fire{Schedule: "nightly", ScheduledAt: now.Add(-25 * time.Hour), Status: FireRan})
+
+	s, runs := newCatchUpScheduler(t, &config.ScheduleConfig{Interval: "24h"}, CatchUp{Name: "nightly", Log: log, Enabled: true})
+	s.checkMissed(context.Background(), now)
+
+	if *runs != 1 {
+		t.Fatalf("expected a catch-up run, got %d runs", *runs)
+	}
+	last, _, _ := log.LastFire("nightly")
+	if last.Status != FireCaughtUp || !last.ScheduledAt.Equal(now.Add(-time.Hour)) {
+		t.Errorf("last fire = %+v, want caught_up for the run an hour ago", last)
+	}
+
+	// Already handled: a second check finds nothing
+	s.checkMissed(context.Background(), now)
+	if *runs != 1 {
+		t.Errorf("missed run caught up twice")
+	}
+}
+
+func TestHandleMissed_OutsideWindowUsesGrace(t *testing.T) {
+	// A window that is never open: only the grace period allows a catch-up
+	window := &config.WindowConfig{Start: "00:00", End: "00:00"}
+	now := time.Now()
+
+	log := &memFireLog{}
+	s, runs := newCatchUpScheduler(t, &config.ScheduleConfig{Interval: "24h", Window: window}, CatchUp{Name: "nightly", Log: log, Enabled: true, Grace: 2 * time.Hour})
+
+	s.handleMissed(context.Background(), now.Add(-6*time.Hour), now)
+	if *runs != 0 {
+		t.Fatalf("run missed 6h ago is past the 2h grace, got %d runs", *runs)
+	}
+	if last, _, _ := log.LastFire("nightly"); last.Status != FireMissed {
+		t.Errorf("expected missed, got %s", last.Status)
+	}
+
+	s.handleMissed(context.Background(), now.Add(-time.Hour), now)
+	if *runs != 1 {
+		t.Errorf("run missed 1h ago is within grace, got %d runs", *runs)
+	}
+	if last, _, _ := log.LastFire("nightly"); last.Status != FireCaughtUp {
+		t.Errorf("expected caught_up, got %s", last.Status)
+	}
+}
+
+func TestCheckMissed_DisabledOnlyRecords(t *testing.T) {
+	now := time.Now()
+	log := &memFireLog{}
+	_ = log.RecordFire(Fire{Schedule: "nightly", ScheduledAt: now.Add(-25 * time.Hour), Status: FireRan})
+
+	s, runs := newCatchUpScheduler(t, &config.ScheduleConfig{Interval: "24h"}, CatchUp{Name: "nightly", Log: log})
+	s.checkMissed(context.Background(), now)
+	if *runs != 0 {
+		t.Errorf("catch-up disabled, got %d runs", *runs)
+	}
+	if last, _, _ := log.LastFire("nightly"); last.Status != FireMissed {
+		t.Errorf("expected missed to be recorded, got %s", last.Status)
+	}
+}
+
+func TestFire_LateFireAfterCatchUpIsSkipped(t *testing.T) {
+	now := time.Now()
+	scheduled := now.Add(-3 * time.Hour)
+	log := &memFireLog{}
+	_ = log.RecordFire(Fire{Schedule: "nightly", ScheduledAt: scheduled, Status: FireCaughtUp})
+
+	s, runs := newCatchUpScheduler(t, &config.ScheduleConfig{Interval: "24h"}, CatchUp{Name: "nightly", Log: log, Enabled: true})
+	s.fire(context.Background(), scheduled)
+	if *runs != 0 {
+		t.Errorf("late timer for a caught-up run should not run again, got %d runs", *runs)
+	}
+}
+
+func TestLastRunBetween_Cron(t *testing.T) {
+	s, err := NewFromConfig(&config.ScheduleConfig{Cron: "0 2 * * *"})
+	if err != nil {
+		t.Fatalf("NewFromConfig: %v", err)
+	}
+	loc := s.location
+	after := time.Date(2026, 3, 1, 2, 0, 0, 0, loc)
+	before := time.Date(2026, 3, 3, 9, 0, 0, 0, loc)
+	if got, want := s.lastRunBetween(after, before), time.Date(2026, 3, 3, 2, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("lastRunBetween = %v, want %v", got, want)
+	}
+	if got := s.lastRunBetween(after, after.Add(time.Hour)); !got.IsZero() {
+		t.Errorf("expected no run, got %v", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -45,6 +46,14 @@ type Scheduler struct {
 	doneCh  chan struct{}
 	nextRun time.Time
 	entryID cron.EntryID
+	once    map[*time.Timer]time.Time // pending ScheduleOnce runs
+
+	// runMu keeps scheduled and one-off runs from overlapping. Schedulers
+	// can share one (SetRunLock).
+	runMu *sync.Mutex
+
+	catchUp CatchUp        // missed-run detection (SetCatchUp)
+	wg      sync.WaitGroup // background goroutines besides the run loop
 }
 
 // Window represents a time window constraint.
@@ -204,7 +213,8 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		// Cron-based scheduling
 		s.cron = cron.New(cron.WithLocation(s.location))
 		entryID, err := s.cron.AddFunc(s.cronExpr, func() {
-			s.runJobs(ctx)
+			s.fire(ctx, s.NextRun())
+			s.updateNextRun()
 		})
 		if err != nil {
 			s.running = false
@@ -234,6 +244,15 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return ErrNoSchedule
 	}
 
+	if s.catchUpConfig().Log != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.checkMissed(ctx, time.Now())
+			s.watchClock(ctx)
+		}()
+	}
+
 	return nil
 }
 
@@ -251,7 +270,7 @@ func (s *Scheduler) intervalLoop(ctx context.Context) {
 		case <-s.stopCh:
 			return
 		case <-timer.C:
-			s.runJobs(ctx)
+			s.fire(ctx, s.NextRun())
 			s.updateNextRun()
 			timer.Reset(time.Until(s.NextRun()))
 		}
@@ -272,6 +291,14 @@ func (s *Scheduler) runJobs(ctx context.Context) {
 	copy(jobs, s.jobs)
 	s.mu.RUnlock()
 
+	s.runLocked(ctx, jobs)
+}
+
+// runLocked executes jobs while holding the run lock.
+func (s *Scheduler) runLocked(ctx context.Context, jobs []Job) {
+	runMu := s.runLock()
+	runMu.Lock()
+	defer runMu.Unlock()
 	for _, job := range jobs {
 		select {
 		case <-ctx.Done():
@@ -291,10 +318,15 @@ func (s *Scheduler) Stop() error {
 	}
 	s.running = false
 	close(s.stopCh)
+	for timer := range s.once {
+		timer.Stop()
+	}
+	s.once = nil
 	s.mu.Unlock()
 
 	// Wait for scheduler to stop
 	<-s.doneCh
+	s.wg.Wait()
 	return nil
 }
 
@@ -415,6 +447,35 @@ func (w *Window) Contains(t time.Time) bool {
 	return currentMins >= startMins && currentMins < endMins
 }
 
+// NextWindowStart returns the first time at or after t inside the time
+// window (t itself when it is inside or no window is configured).
+func (s *Scheduler) NextWindowStart(t time.Time) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.window == nil || s.window.Contains(t) {
+		return t
+	}
+	return nextWindowStartForWindow(s.window, t)
+}
+
+// WindowEnd returns when the window containing t closes, or zero when no
+// window is configured or t is outside it.
+func (s *Scheduler) WindowEnd(t time.Time) time.Time {
+	s.mu.RLock()
+	window := s.window
+	s.mu.RUnlock()
+	if window == nil || !window.Contains(t) {
+		return time.Time{}
+	}
+
+	t = t.In(window.Location)
+	end := time.Date(t.Year(), t.Month(), t.Day(), window.End.Hour, window.End.Minute, 0, 0, window.Location)
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
 // nextWindowStartLocked returns the next time the window starts after t.
 // Must be called while holding the lock.
 func (s *Scheduler) nextWindowStartLocked(t time.Time) time.Time {
@@ -441,6 +502,98 @@ func nextWindowStartForWindow(window *Window, t time.Time) time.Time {
 	return today
 }
 
+// ScheduleOnce runs job once at the given time, in addition to the regular
+// schedule. The run is skipped if it fires outside the time window or after
+// ctx is done, including after waiting for a scheduled run to finish, and
+// never overlaps a scheduled run. skipped, if not nil, is called instead
+// of job when the run is skipped. Stop cancels pending runs.
+func (s *Scheduler) ScheduleOnce(ctx context.Context, at time.Time, job Job, skipped func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.once == nil {
+		s.once = make(map[*time.Timer]time.Time)
+	}
+	skip := func() {
+		if skipped != nil {
+			skipped()
+		}
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		s.mu.Lock()
+		_, pending := s.once[timer]
+		delete(s.once, timer)
+		s.mu.Unlock()
+		if !pending || ctx.Err() != nil || !s.IsInWindow(time.Now()) {
+			skip()
+			return
+		}
+		runMu := s.runLock()
+		runMu.Lock()
+		defer runMu.Unlock()
+		// The window may have closed while waiting for the lock
+		if ctx.Err() != nil || !s.IsInWindow(time.Now()) {
+			skip()
+			return
+		}
+		_ = job(ctx) // Errors handled by job itself
+	})
+	s.once[timer] = at
+}
+
+// SetRunLock makes s serialize its runs on mu. Schedulers sharing a lock
+// never run jobs at the same time.
+func (s *Scheduler) SetRunLock(mu *sync.Mutex) {
+	s.mu.Lock()
+	s.runMu = mu
+	s.mu.Unlock()
+}
+
+func (s *Scheduler) runLock() *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runMu == nil {
+		s.runMu = &sync.Mutex{}
+	}
+	return s.runMu
+}
+
+// PendingOnce returns the times of one-off runs that have not fired yet,
+// earliest first.
+func (s *Scheduler) PendingOnce() []time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	times := make([]time.Time, 0, len(s.once))
+	for _, at := range s.once {
+		times = append(times, at)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// SlotsBetween returns up to max times in [from, to), at least spacing
+// apart, that fall inside the time window.
+func (s *Scheduler) SlotsBetween(from, to time.Time, spacing time.Duration, max int) []time.Time {
+	s.mu.RLock()
+	window := s.window
+	s.mu.RUnlock()
+
+	var slots []time.Time
+	if spacing <= 0 || max <= 0 {
+		return slots
+	}
+	for t := from; t.Before(to) && len(slots) < max; t = t.Add(spacing) {
+		if window != nil && !window.Contains(t) {
+			t = nextWindowStartForWindow(window, t)
+			if !t.Before(to) {
+				break
+			}
+		}
+		slots = append(slots, t)
+	}
+	return slots
+}
+
 // IsRunning returns whether the scheduler is currently running.
 func (s *Scheduler) IsRunning() bool {
 	s.mu.RLock()
@@ -580,3 +580,101 @@ func TestScheduleInterval_Legacy(t *testing.T) {
 	}
 }
 
+
+func TestSlotsBetween_RespectsWindow(t *testing.T) {
+	s := New()
+	if err := s.SetWindow(&config.WindowConfig{Start: "22:00", End: "02:00", Timezone: "UTC"}); err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2026, 2, 13, 23, 0, 0, 0, time.UTC)
+	to := from.Add(30 * time.Hour)
+	slots := s.SlotsBetween(from, to, 90*time.Minute, 4)
+
+	want := []time.Time{
+		from,
+		from.Add(90 * time.Minute),
+		time.Date(2026, 2, 14, 22, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 14, 23, 30, 0, 0, time.UTC),
+	}
+	if len(slots) != len(want) {
+		t.Fatalf("slots = %v, want %v", slots, want)
+	}
+	for i := range want {
+		if !slots[i].Equal(want[i]) {
+			t.Errorf("slot %d = %v, want %v", i, slots[i], want[i])
+		}
+	}
+
+	if got := s.SlotsBetween(from, from.Add(time.Hour), 90*time.Minute, 4); len(got) != 1 {
+		t.Errorf("expected 1 slot before the deadline, got %v", got)
+	}
+}
+
+func TestScheduleOnce(t *testing.T) {
+	s := New()
+	var runs int32
+	done := make(chan struct{})
+	s.ScheduleOnce(context.Background(), time.Now().Add(10*time.Millisecond), func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		close(done)
+		return nil
+	}, nil)
+	s.ScheduleOnce(context.Background(), time.Now().Add(time.Hour), func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}, nil)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("one-off job did not run")
+	}
+	if pending := s.PendingOnce(); len(pending) != 1 {
+		t.Errorf("pending = %v, want 1 run left", pending)
+	}
+	if got := atomic.LoadInt32(&runs); got != 1 {
+		t.Errorf("runs = %d, want 1", got)
+	}
+}
+
+func TestScheduleOnce_SkippedOutsideWindow(t *testing.T) {
+	s := New()
+	// A window that never opens
+	if err := s.SetWindow(&config.WindowConfig{Start: "00:00", End: "00:00"}); err != nil {
+		t.Fatalf("SetWindow: %v", err)
+	}
+	skipped := make(chan struct{})
+	s.ScheduleOnce(context.Background(), time.Now().Add(10*time.Millisecond), func(ctx context.Context) error {
+		t.Error("job ran outside the window")
+		return nil
+	}, func() { close(skipped) })
+
+	select {
+	case <-skipped:
+	case <-time.After(time.Second):
+		t.Fatal("skip callback not called")
+	}
+}
+
+func TestScheduler_WindowEnd(t *testing.T) {
+	s := New()
+	if !s.WindowEnd(time.Now()).IsZero() {
+		t.Error("WindowEnd() with no window should be zero")
+	}
+
+	_ = s.SetWindow(&config.WindowConfig{Start: "22:00", End: "06:00", Timezone: "UTC"})
+	tests := []struct {
+		at   time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 6, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 2, 5, 10, 0, 0, time.UTC), time.Date(2024, 1, 2, 6, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := s.WindowEnd(tt.at); !got.Equal(tt.want) {
+			t.Errorf("WindowEnd(%s) = %v, want %v", tt.at.Format("15:04"), got, tt.want)
+		}
+	}
+}
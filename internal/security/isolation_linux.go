@@ -0,0 +1,38 @@
+//go:build linux
+
+package security
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolateNetwork runs cmd in fresh user and network namespaces. The new
+// network namespace only has a loopback interface, so outbound traffic is
+// impossible. The current uid/gid are mapped 1:1 so file ownership inside
+// the worktree is unchanged.
+func isolateNetwork(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+}
+
+// CheckNetworkIsolation verifies that network isolation can actually be
+// applied on this host by starting a trivial process in new namespaces.
+func CheckNetworkIsolation() error {
+	bin, err := exec.LookPath("true")
+	if err != nil {
+		return fmt.Errorf("probe binary not found: %w", err)
+	}
+	cmd := exec.Command(bin)
+	isolateNetwork(cmd)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("user namespaces unavailable: %w", err)
+	}
+	return nil
+}
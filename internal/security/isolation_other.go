@@ -0,0 +1,16 @@
+//go:build !linux
+
+package security
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// isolateNetwork is a no-op on platforms without user namespaces.
+func isolateNetwork(cmd *exec.Cmd) {}
+
+// CheckNetworkIsolation reports that network isolation is unsupported.
+func CheckNetworkIsolation() error {
+	return errors.New("network isolation requires Linux user namespaces")
+}
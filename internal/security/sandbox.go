@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
@@ -32,6 +33,18 @@ type SandboxConfig struct {
 	MaxMemoryMB int
 	// Environment variables to pass through.
 	Environment map[string]string
+	// EnvAllowlist names additional host environment variables passed through
+	// unchanged. Everything not allow-listed is scrubbed.
+	EnvAllowlist []string
+	// HomeOverlay runs the process with HOME pointing at a fresh directory
+	// inside the sandbox instead of the user's home directory.
+	HomeOverlay bool
+	// HomePassthrough lists entries relative to the real home directory that
+	// are symlinked into the overlay (e.g. ".claude" for CLI credentials).
+	HomePassthrough []string
+	// IsolateNetwork runs the process in a private network namespace when the
+	// platform supports it (Linux user namespaces). Ignored if AllowNetwork.
+	IsolateNetwork bool
 	// Cleanup removes temp files after execution (default true).
 	Cleanup bool
 }
@@ -67,10 +80,18 @@ func NewSandbox(cfg SandboxConfig) (*Sandbox, error) {
 		}
 	}
 
-	return &Sandbox{
+	s := &Sandbox{
 		config:  cfg,
 		tempDir: tempDir,
-	}, nil
+	}
+
+	if cfg.HomeOverlay {
+		if err := s.prepareHomeOverlay(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
 }
 
 // TempDir returns the sandbox temporary directory.
@@ -78,6 +99,48 @@ func (s *Sandbox) TempDir() string {
 	return s.tempDir
 }
 
+// HomeDir returns the HOME directory seen by sandboxed processes.
+func (s *Sandbox) HomeDir() string {
+	if s.config.HomeOverlay {
+		return filepath.Join(s.tempDir, "home")
+	}
+	return os.Getenv("HOME")
+}
+
+// prepareHomeOverlay creates the temporary HOME and links the configured
+// passthrough entries from the real home directory into it.
+func (s *Sandbox) prepareHomeOverlay() error {
+	overlay := filepath.Join(s.tempDir, "home")
+	if err := os.MkdirAll(overlay, 0700); err != nil {
+		return fmt.Errorf("creating home overlay: %w", err)
+	}
+
+	realHome, err := os.UserHomeDir()
+	if err != nil || len(s.config.HomePassthrough) == 0 {
+		return nil
+	}
+
+	for _, entry := range s.config.HomePassthrough {
+		entry = filepath.Clean(entry)
+		if entry == "." || filepath.IsAbs(entry) || strings.HasPrefix(entry, "..") {
+			continue
+		}
+		src := filepath.Join(realHome, entry)
+		if _, err := os.Lstat(src); err != nil {
+			continue
+		}
+		dst := filepath.Join(overlay, entry)
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return fmt.Errorf("creating home overlay: %w", err)
+		}
+		if err := os.Symlink(src, dst); err != nil {
+			return fmt.Errorf("linking %s into home overlay: %w", entry, err)
+		}
+	}
+
+	return nil
+}
+
 // Execute runs a command within the sandbox.
 func (s *Sandbox) Execute(ctx context.Context, name string, args ...string) (*ExecResult, error) {
 	s.mu.Lock()
@@ -102,6 +165,11 @@ func (s *Sandbox) Execute(ctx context.Context, name string, args ...string) (*Ex
 		return nil, err
 	}
 
+	// Validate working directory
+	if err := s.validateWorkDir(); err != nil {
+		return nil, err
+	}
+
 	// Build command
 	cmd := exec.CommandContext(ctx, name, args...)
 
@@ -114,6 +182,8 @@ func (s *Sandbox) Execute(ctx context.Context, name string, args ...string) (*Ex
 
 	// Configure environment
 	cmd.Env = s.buildEnvironment()
+	s.applyIsolation(cmd)
+	killGroupOnCancel(cmd)
 
 	// Capture output
 	var stdout, stderr strings.Builder
@@ -166,6 +236,9 @@ func (s *Sandbox) ExecuteWithIO(ctx context.Context, stdin io.Reader, stdout, st
 	if err := s.validateCommand(name); err != nil {
 		return err
 	}
+	if err := s.validateWorkDir(); err != nil {
+		return err
+	}
 
 	cmd := exec.CommandContext(ctx, name, args...)
 
@@ -176,6 +249,8 @@ func (s *Sandbox) ExecuteWithIO(ctx context.Context, stdin io.Reader, stdout, st
 	}
 
 	cmd.Env = s.buildEnvironment()
+	s.applyIsolation(cmd)
+	killGroupOnCancel(cmd)
 	cmd.Stdin = stdin
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
@@ -207,7 +282,7 @@ func (s *Sandbox) validateCommand(name string) error {
 
 	// Check against denied paths
 	for _, denied := range s.config.DeniedPaths {
-		if strings.HasPrefix(path, denied) {
+		if pathWithin(path, denied) {
 			return fmt.Errorf("command path denied: %s", path)
 		}
 	}
@@ -215,24 +290,59 @@ func (s *Sandbox) validateCommand(name string) error {
 	return nil
 }
 
+// validateWorkDir checks the configured working directory against the
+// allowed and denied path lists.
+func (s *Sandbox) validateWorkDir() error {
+	if s.config.WorkDir == "" {
+		return nil
+	}
+	return s.ValidatePath(s.config.WorkDir)
+}
+
+// applyIsolation configures OS-level isolation for the command.
+func (s *Sandbox) applyIsolation(cmd *exec.Cmd) {
+	if s.config.IsolateNetwork && !s.config.AllowNetwork {
+		isolateNetwork(cmd)
+	}
+}
+
+// killGroupOnCancel runs cmd in its own process group and kills the whole
+// group when its context ends, so a timeout also stops whatever the command
+// started, not just the command itself.
+func killGroupOnCancel(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+}
+
 // buildEnvironment constructs the environment for sandboxed execution.
 func (s *Sandbox) buildEnvironment() []string {
 	env := make([]string, 0)
 
-	// Minimal safe environment
-	safeVars := []string{"PATH", "HOME", "USER", "SHELL", "TERM", "LANG", "LC_ALL"}
-	for _, v := range safeVars {
+	// Minimal safe environment, API keys (needed for agent execution),
+	// then anything explicitly allow-listed. HOME and TMPDIR are always
+	// set by the sandbox itself.
+	passVars := []string{"PATH", "USER", "SHELL", "TERM", "LANG", "LC_ALL", EnvAnthropicKey, EnvOpenAIKey}
+	passVars = append(passVars, s.config.EnvAllowlist...)
+	seen := map[string]bool{"HOME": true, "TMPDIR": true}
+	for _, v := range passVars {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
 		if val := os.Getenv(v); val != "" {
 			env = append(env, v+"="+val)
 		}
 	}
 
-	// API keys (needed for agent execution)
-	apiKeyVars := []string{EnvAnthropicKey, EnvOpenAIKey}
-	for _, v := range apiKeyVars {
-		if val := os.Getenv(v); val != "" {
-			env = append(env, v+"="+val)
-		}
+	// HOME is either the real home or the sandbox overlay
+	if home := s.HomeDir(); home != "" {
+		env = append(env, "HOME="+home)
 	}
 
 	// Add configured environment variables
@@ -263,7 +373,7 @@ func (s *Sandbox) ValidatePath(path string) error {
 
 	// Check denied paths first
 	for _, denied := range s.config.DeniedPaths {
-		if strings.HasPrefix(absPath, denied) {
+		if pathWithin(absPath, denied) {
 			return fmt.Errorf("path access denied: %s", path)
 		}
 	}
@@ -272,7 +382,7 @@ func (s *Sandbox) ValidatePath(path string) error {
 	if len(s.config.AllowedPaths) > 0 {
 		allowed := false
 		for _, allowedPath := range s.config.AllowedPaths {
-			if strings.HasPrefix(absPath, allowedPath) {
+			if pathWithin(absPath, allowedPath) {
 				allowed = true
 				break
 			}
@@ -285,6 +395,16 @@ func (s *Sandbox) ValidatePath(path string) error {
 	return nil
 }
 
+// pathWithin reports whether path is dir or inside it. A bare prefix is not
+// enough: /work/abc is not within /work/a.
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // CreateTempFile creates a temporary file within the sandbox.
 func (s *Sandbox) CreateTempFile(pattern string) (*os.File, error) {
 	return os.CreateTemp(s.tempDir, pattern)
@@ -350,3 +470,54 @@ func (a *SandboxedAgent) Sandbox() *Sandbox {
 func (a *SandboxedAgent) Close() error {
 	return a.sandbox.Cleanup()
 }
+
+// SandboxRunner executes each command in a fresh Sandbox. It satisfies the
+// agents.CommandRunner interface so agent CLIs can be routed through the
+// sandbox without the agents package depending on security.
+type SandboxRunner struct {
+	config SandboxConfig
+}
+
+// NewSandboxRunner creates a runner that applies cfg to every invocation.
+// WorkDir is taken from each call rather than from cfg.
+func NewSandboxRunner(cfg SandboxConfig) *SandboxRunner {
+	return &SandboxRunner{config: cfg}
+}
+
+// Config returns the sandbox configuration applied to each invocation.
+func (r *SandboxRunner) Config() SandboxConfig {
+	return r.config
+}
+
+// Run executes a command inside a per-invocation sandbox and returns output.
+// The sandbox temp directory (including any HOME overlay) is removed afterwards.
+func (r *SandboxRunner) Run(ctx context.Context, name string, args []string, dir string, stdin string) (string, string, int, error) {
+	cfg := r.config
+	cfg.WorkDir = dir
+	cfg.TempDir = ""
+	cfg.Cleanup = true
+
+	sandbox, err := NewSandbox(cfg)
+	if err != nil {
+		return "", "", -1, err
+	}
+	defer func() { _ = sandbox.Cleanup() }()
+
+	var stdinReader io.Reader
+	if stdin != "" {
+		stdinReader = strings.NewReader(stdin)
+	}
+
+	var stdout, stderr strings.Builder
+	err = sandbox.ExecuteWithIO(ctx, stdinReader, &stdout, &stderr, name, args...)
+
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+
+	return stdout.String(), stderr.String(), exitCode, err
+}
@@ -93,6 +93,31 @@ func TestSandbox_ExecuteWithTimeout(t *testing.T) {
 	}
 }
 
+func TestSandbox_ExecuteTimeoutKillsProcessGroup(t *testing.T) {
+	cfg := DefaultSandboxConfig()
+	cfg.MaxDuration = 100 * time.Millisecond
+
+	sandbox, err := NewSandbox(cfg)
+	if err != nil {
+		t.Fatalf("NewSandbox failed: %v", err)
+	}
+	defer func() { _ = sandbox.Cleanup() }()
+
+	// The background sleep keeps stdout open; killing only sh would leave
+	// Execute waiting for it
+	start := time.Now()
+	result, err := sandbox.Execute(context.Background(), "sh", "-c", "sleep 10 & wait")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Success() {
+		t.Error("expected timed out command to fail")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("Execute returned after %s; child of timed out command kept running", d)
+	}
+}
+
 func TestSandbox_ExecuteCommandNotFound(t *testing.T) {
 	cfg := DefaultSandboxConfig()
 
@@ -112,8 +137,8 @@ func TestSandbox_ExecuteCommandNotFound(t *testing.T) {
 
 func TestSandbox_ValidatePath(t *testing.T) {
 	cfg := DefaultSandboxConfig()
-	cfg.AllowedPaths = []string{"/tmp", "/var/tmp"}
-	cfg.DeniedPaths = []string{"/etc", "/root"}
+	cfg.AllowedPaths = []string{"/tmp", "/var/tmp", "/work/a"}
+	cfg.DeniedPaths = []string{"/etc", "/root", "/tmp/secret"}
 
 	sandbox, err := NewSandbox(cfg)
 	if err != nil {
@@ -130,6 +155,11 @@ func TestSandbox_ValidatePath(t *testing.T) {
 		{"/etc/passwd", true},
 		{"/root/.ssh", true},
 		{"/home/user/file", true}, // Not in allowed list
+		{"/work/a", false},
+		{"/work/a/src", false},
+		{"/work/abc", true},           // Shares a prefix with /work/a only
+		{"/tmp/secret/key", true},     // Denied inside an allowed path
+		{"/tmp/secretive.txt", false}, // Shares a prefix with a denied path only
 	}
 
 	for _, tt := range tests {
@@ -343,3 +373,133 @@ func TestNewSandboxedAgent(t *testing.T) {
 		t.Error("expected sandbox to be initialized")
 	}
 }
+
+func TestSandbox_BuildEnvironmentScrubsUnlisted(t *testing.T) {
+	t.Setenv("NIGHTSHIFT_TEST_SECRET", "leak")
+	t.Setenv("NIGHTSHIFT_TEST_ALLOWED", "ok")
+
+	cfg := DefaultSandboxConfig()
+	cfg.EnvAllowlist = []string{"NIGHTSHIFT_TEST_ALLOWED", "PATH"}
+
+	sandbox, err := NewSandbox(cfg)
+	if err != nil {
+		t.Fatalf("NewSandbox failed: %v", err)
+	}
+	defer func() { _ = sandbox.Cleanup() }()
+
+	env := strings.Join(sandbox.buildEnvironment(), "\n")
+	if strings.Contains(env, "NIGHTSHIFT_TEST_SECRET") {
+		t.Error("expected unlisted variable to be scrubbed")
+	}
+	if !strings.Contains(env, "NIGHTSHIFT_TEST_ALLOWED=ok") {
+		t.Error("expected allow-listed variable to pass through")
+	}
+	if strings.Count(env, "PATH=") != 1 {
+		t.Error("expected PATH exactly once")
+	}
+}
+
+func TestSandbox_HomeOverlay(t *testing.T) {
+	realHome := t.TempDir()
+	t.Setenv("HOME", realHome)
+	if err := os.MkdirAll(filepath.Join(realHome, ".claude"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultSandboxConfig()
+	cfg.HomeOverlay = true
+	cfg.HomePassthrough = []string{".claude", ".missing", "../escape"}
+
+	sandbox, err := NewSandbox(cfg)
+	if err != nil {
+		t.Fatalf("NewSandbox failed: %v", err)
+	}
+	defer func() { _ = sandbox.Cleanup() }()
+
+	home := sandbox.HomeDir()
+	if home == realHome || !strings.HasPrefix(home, sandbox.TempDir()) {
+		t.Fatalf("expected overlay home inside sandbox, got %s", home)
+	}
+
+	target, err := os.Readlink(filepath.Join(home, ".claude"))
+	if err != nil {
+		t.Fatalf("expected .claude passthrough link: %v", err)
+	}
+	if target != filepath.Join(realHome, ".claude") {
+		t.Errorf("link target = %s, want %s", target, filepath.Join(realHome, ".claude"))
+	}
+	if _, err := os.Lstat(filepath.Join(home, ".missing")); !os.IsNotExist(err) {
+		t.Error("expected missing entries to be skipped")
+	}
+
+	found := false
+	for _, e := range sandbox.buildEnvironment() {
+		if e == "HOME="+home {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected HOME to point at overlay")
+	}
+}
+
+func TestSandbox_ExecuteRejectsDisallowedWorkDir(t *testing.T) {
+	allowed := t.TempDir()
+	other := t.TempDir()
+
+	cfg := DefaultSandboxConfig()
+	cfg.WorkDir = other
+	cfg.AllowedPaths = []string{allowed}
+
+	sandbox, err := NewSandbox(cfg)
+	if err != nil {
+		t.Fatalf("NewSandbox failed: %v", err)
+	}
+	defer func() { _ = sandbox.Cleanup() }()
+
+	if _, err := sandbox.Execute(context.Background(), "echo", "hi"); err == nil {
+		t.Error("expected execution outside allowed paths to fail")
+	}
+}
+
+func TestSandboxRunner_Run(t *testing.T) {
+	workDir := t.TempDir()
+
+	cfg := DefaultSandboxConfig()
+	cfg.AllowedPaths = []string{workDir}
+	cfg.HomeOverlay = true
+	runner := NewSandboxRunner(cfg)
+
+	stdout, _, exitCode, err := runner.Run(context.Background(), "sh", []string{"-c", "pwd; echo $HOME; echo $NIGHTSHIFT_SANDBOX"}, workDir, "")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if exitCode != 0 {
+		t.Errorf("exit code = %d, want 0", exitCode)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output: %q", stdout)
+	}
+	if resolved, _ := filepath.EvalSymlinks(workDir); lines[0] != workDir && lines[0] != resolved {
+		t.Errorf("pwd = %s, want %s", lines[0], workDir)
+	}
+	if !strings.HasSuffix(lines[1], "/home") {
+		t.Errorf("HOME = %s, want overlay", lines[1])
+	}
+	if lines[2] != "1" {
+		t.Errorf("NIGHTSHIFT_SANDBOX = %q, want 1", lines[2])
+	}
+	if _, err := os.Stat(filepath.Dir(lines[1])); !os.IsNotExist(err) {
+		t.Error("expected per-invocation sandbox to be cleaned up")
+	}
+
+	_, _, exitCode, err = runner.Run(context.Background(), "sh", []string{"-c", "exit 3"}, workDir, "")
+	if err == nil || exitCode != 3 {
+		t.Errorf("expected exit code 3 with error, got %d (%v)", exitCode, err)
+	}
+
+	if _, _, _, err := runner.Run(context.Background(), "echo", nil, t.TempDir(), ""); err == nil {
+		t.Error("expected run outside allowed paths to fail")
+	}
+}
@@ -2,9 +2,7 @@
 package setup
 
 import (
-	"os"
-	"path/filepath"
-	"strings"
+	"slices"
 
 	"github.com/marcus/nightshift/internal/tasks"
 )
@@ -18,43 +16,28 @@ const (
 	PresetAggressive Preset = "aggressive"
 )
 
-// RepoSignals holds detected project characteristics that influence task selection.
-type RepoSignals struct {
-	HasRelease bool
-	HasADR     bool
-}
-
-// DetectRepoSignals inspects project roots for release and ADR signals.
-func DetectRepoSignals(projects []string) RepoSignals {
-	signals := RepoSignals{}
+// DetectProfiles inspects each project root for the requirements of tasks
+// (TaskDefinition.Requires). A project that cannot be inspected gets a nil
+// profile, which meets every requirement.
+func DetectProfiles(projects []string) []*tasks.RepoProfile {
+	profiles := make([]*tasks.RepoProfile, 0, len(projects))
 	for _, project := range projects {
 		if project == "" {
 			continue
 		}
-		if hasAny(project, []string{
-			"CHANGELOG.md",
-			filepath.Join(".github", "workflows", "release.yml"),
-			filepath.Join(".github", "workflows", "release.yaml"),
-		}) {
-			signals.HasRelease = true
-		}
-		if hasAny(project, []string{
-			filepath.Join("docs", "adr"),
-			filepath.Join("docs", "ADR"),
-			"adr",
-			"ADR",
-		}) {
-			signals.HasADR = true
-		}
+		p, _ := tasks.DetectRepoProfile(project)
+		profiles = append(profiles, p)
 	}
-	return signals
+	return profiles
 }
 
-// PresetTasks returns the set of enabled task types for the given preset and repo signals.
-func PresetTasks(preset Preset, defs []tasks.TaskDefinition, signals RepoSignals) map[tasks.TaskType]bool {
+// PresetTasks returns the set of enabled task types for the given preset.
+// Tasks with requirements are only included when one of the projects'
+// profiles meets them.
+func PresetTasks(preset Preset, defs []tasks.TaskDefinition, profiles []*tasks.RepoProfile) map[tasks.TaskType]bool {
 	selected := make(map[tasks.TaskType]bool)
 	for _, def := range defs {
-		if !presetAllowsTask(preset, def, signals) {
+		if !presetAllowsTask(preset, def) || !appliesToAny(def, profiles) {
 			continue
 		}
 		selected[def.Type] = true
@@ -62,10 +45,26 @@ func PresetTasks(preset Preset, defs []tasks.TaskDefinition, signals RepoSignals
 	return selected
 }
 
-func presetAllowsTask(preset Preset, def tasks.TaskDefinition, signals RepoSignals) bool {
+// appliesToAny reports whether def has no requirements or one of profiles
+// meets them.
+func appliesToAny(def tasks.TaskDefinition, profiles []*tasks.RepoProfile) bool {
+	if def.Requires.IsZero() {
+		return true
+	}
+	return slices.ContainsFunc(profiles, func(p *tasks.RepoProfile) bool {
+		ok, _ := def.Requires.Check(p)
+		return ok
+	})
+}
+
+func presetAllowsTask(preset Preset, def tasks.TaskDefinition) bool {
 	if def.Category != tasks.CategoryPR && def.Category != tasks.CategoryAnalysis {
 		return false
 	}
+	// Opt-in tasks are left for the user to pick
+	if def.DisabledByDefault {
+		return false
+	}
 
 	switch preset {
 	case PresetSafe:
@@ -92,25 +91,9 @@ func presetAllowsTask(preset Preset, def tasks.TaskDefinition, signals RepoSigna
 		}
 	}
 
-	if isReleaseTask(def.Type) && !signals.HasRelease {
-		return false
-	}
-	if def.Type == tasks.TaskADRDraft && !signals.HasADR {
-		return false
-	}
-
 	return true
 }
 
-func isReleaseTask(t tasks.TaskType) bool {
-	switch t {
-	case tasks.TaskChangelogSynth, tasks.TaskReleaseNotes:
-		return true
-	default:
-		return false
-	}
-}
-
 func isHeavyPR(t tasks.TaskType) bool {
 	switch t {
 	case tasks.TaskBugFinder, tasks.TaskAutoDRY:
@@ -119,15 +102,3 @@ func isHeavyPR(t tasks.TaskType) bool {
 		return false
 	}
 }
-
-func hasAny(root string, relPaths []string) bool {
-	for _, rel := range relPaths {
-		path := filepath.Join(root, rel)
-		if info, err := os.Stat(path); err == nil {
-			if info.IsDir() || strings.HasSuffix(rel, ".md") || strings.HasSuffix(rel, ".yml") || strings.HasSuffix(rel, ".yaml") {
-				return true
-			}
-		}
-	}
-	return false
-}
@@ -4,6 +4,7 @@ package snapshots
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -20,22 +21,23 @@ type UsageScraper interface {
 	ScrapeCodexUsage(ctx context.Context) (tmux.UsageResult, error)
 }
 
-// ClaudeUsage defines local usage access for Claude.
-type ClaudeUsage interface {
-	GetWeeklyUsage() (int64, error)
-	GetTodayUsage() (int64, error)
-}
-
-// CodexUsage defines local usage access for Codex.
-type CodexUsage interface {
+// UsageSource defines local usage access for a provider.
+// Every providers.Provider satisfies it.
+type UsageSource interface {
 	GetTodayTokens() (int64, error)
 	GetWeeklyTokens() (int64, error)
 }
 
-// GeminiUsage defines local usage access for Gemini.
-type GeminiUsage interface {
-	GetTodayTokens() (int64, error)
-	GetWeeklyTokens() (int64, error)
+// ModelUsageSource is implemented by usage sources that can split the weekly
+// total by model (see providers.ModelUsageReporter).
+type ModelUsageSource interface {
+	GetWeeklyTokensByModel() (map[string]int64, error)
+}
+
+// CacheTokenSource is implemented by usage sources whose local token counts
+// include cached input tokens (see providers.CacheTokenCounter).
+type CacheTokenSource interface {
+	CountsCacheTokens() bool
 }
 
 // Snapshot represents a stored usage snapshot.
@@ -52,9 +54,10 @@ type Snapshot struct {
 	HourOfDay        int
 	WeekNumber       int
 	Year             int
-	SessionResetTime string // scraped reset time for current session/5h window
-	WeeklyResetTime  string // scraped reset time for weekly window
-	ScrapeErr        error  `json:"-"` // not persisted; for CLI diagnostics
+	SessionResetTime string           // scraped reset time for current session/5h window
+	WeeklyResetTime  string           // scraped reset time for weekly window
+	ModelTokens      map[string]int64 // weekly local tokens by model, when the source splits them
+	ScrapeErr        error            `json:"-"` // not persisted; for CLI diagnostics
 }
 
 // HourlyAverage represents average daily tokens by hour.
@@ -66,23 +69,20 @@ type HourlyAverage struct {
 // Collector gathers and stores usage snapshots.
 type Collector struct {
 	db           *db.DB
-	claude       ClaudeUsage
-	codex        CodexUsage
-	gemini       GeminiUsage
+	sources      map[string]UsageSource
 	scraper      UsageScraper
 	weekStartDay time.Weekday
 }
 
-// NewCollector creates a snapshot collector.
-func NewCollector(database *db.DB, claude ClaudeUsage, codex CodexUsage, gemini GeminiUsage, scraper UsageScraper, weekStartDay time.Weekday) *Collector {
+// NewCollector creates a snapshot collector. sources maps provider names to
+// their local usage data; a nil map is fine for read-only collectors.
+func NewCollector(database *db.DB, sources map[string]UsageSource, scraper UsageScraper, weekStartDay time.Weekday) *Collector {
 	if weekStartDay < time.Sunday || weekStartDay > time.Saturday {
 		weekStartDay = time.Monday
 	}
 	return &Collector{
 		db:           database,
-		claude:       claude,
-		codex:        codex,
-		gemini:       gemini,
+		sources:      sources,
 		scraper:      scraper,
 		weekStartDay: weekStartDay,
 	}
@@ -103,63 +103,28 @@ func (c *Collector) TakeSnapshot(ctx context.Context, provider string) (Snapshot
 	var scrapeErr error
 	var sessionResetTime, weeklyResetTime string
 
-	switch provider {
-	case "claude":
-		if c.claude == nil {
-			return Snapshot{}, errors.New("claude provider is nil")
-		}
-		localWeekly, err = c.claude.GetWeeklyUsage()
-		if err != nil {
-			return Snapshot{}, err
-		}
-		localDaily, err = c.claude.GetTodayUsage()
-		if err != nil {
-			return Snapshot{}, err
-		}
-		if c.scraper != nil {
-			result, sErr := c.scraper.ScrapeClaudeUsage(ctx)
-			if sErr != nil {
-				scrapeErr = sErr
-			} else {
-				if result.WeeklyPct >= 0 && result.WeeklyPct <= 100 {
-					pct := result.WeeklyPct
-					scrapedPct = &pct
-				}
-				sessionResetTime = result.SessionResetTime
-				weeklyResetTime = result.WeeklyResetTime
-			}
-		}
-	case "codex":
-		if c.codex == nil {
-			return Snapshot{}, errors.New("codex provider is nil")
-		}
-		localWeekly, localDaily, err = codexTokenTotals(c.codex)
-		if err != nil {
-			return Snapshot{}, err
-		}
-		if c.scraper != nil {
-			result, sErr := c.scraper.ScrapeCodexUsage(ctx)
-			if sErr != nil {
-				scrapeErr = sErr
-			} else {
-				if result.WeeklyPct >= 0 && result.WeeklyPct <= 100 {
-					pct := result.WeeklyPct
-					scrapedPct = &pct
-				}
-				sessionResetTime = result.SessionResetTime
-				weeklyResetTime = result.WeeklyResetTime
+	source, ok := c.sources[provider]
+	if !ok || source == nil {
+		return Snapshot{}, fmt.Errorf("%s provider is nil", provider)
+	}
+	localWeekly, localDaily, err = tokenTotals(source)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	modelTokens := weeklyModelTokens(source)
+
+	if c.scraper != nil {
+		result, scraped, sErr := c.scrape(ctx, provider)
+		if sErr != nil {
+			scrapeErr = sErr
+		} else if scraped {
+			if result.WeeklyPct >= 0 && result.WeeklyPct <= 100 {
+				pct := result.WeeklyPct
+				scrapedPct = &pct
 			}
+			sessionResetTime = result.SessionResetTime
+			weeklyResetTime = result.WeeklyResetTime
 		}
-	case "gemini":
-		if c.gemini == nil {
-			return Snapshot{}, errors.New("gemini provider is nil")
-		}
-		localWeekly, localDaily, err = geminiTokenTotals(c.gemini)
-		if err != nil {
-			return Snapshot{}, err
-		}
-	default:
-		return Snapshot{}, fmt.Errorf("unknown provider: %s", provider)
 	}
 
 	weekStart := startOfWeek(now, c.weekStartDay)
@@ -174,8 +139,8 @@ func (c *Collector) TakeSnapshot(ctx context.Context, provider string) (Snapshot
 	}
 
 	result, err := c.db.SQL().Exec(
-		`INSERT INTO snapshots (provider, timestamp, week_start, local_tokens, local_daily, scraped_pct, inferred_budget, day_of_week, hour_of_day, week_number, year, session_reset_time, weekly_reset_time)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO snapshots (provider, timestamp, week_start, local_tokens, local_daily, scraped_pct, inferred_budget, day_of_week, hour_of_day, week_number, year, session_reset_time, weekly_reset_time, model_tokens)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		provider,
 		now,
 		weekStart,
@@ -189,6 +154,7 @@ func (c *Collector) TakeSnapshot(ctx context.Context, provider string) (Snapshot
 		year,
 		nullString(sessionResetTime),
 		nullString(weeklyResetTime),
+		nullModelTokens(modelTokens),
 	)
 	if err != nil {
 		return Snapshot{}, fmt.Errorf("insert snapshot: %w", err)
@@ -211,6 +177,7 @@ func (c *Collector) TakeSnapshot(ctx context.Context, provider string) (Snapshot
 		Year:             year,
 		SessionResetTime: sessionResetTime,
 		WeeklyResetTime:  weeklyResetTime,
+		ModelTokens:      modelTokens,
 		ScrapeErr:        scrapeErr,
 	}, nil
 }
@@ -221,7 +188,7 @@ func (c *Collector) GetLatest(provider string, n int) ([]Snapshot, error) {
 		return []Snapshot{}, nil
 	}
 	rows, err := c.db.SQL().Query(
-		`SELECT id, provider, timestamp, week_start, local_tokens, local_daily, scraped_pct, inferred_budget, day_of_week, hour_of_day, week_number, year, session_reset_time, weekly_reset_time
+		`SELECT id, provider, timestamp, week_start, local_tokens, local_daily, scraped_pct, inferred_budget, day_of_week, hour_of_day, week_number, year, session_reset_time, weekly_reset_time, model_tokens
 		 FROM snapshots
 		 WHERE provider = ?
 		 ORDER BY timestamp DESC
@@ -248,11 +215,21 @@ func (c *Collector) GetLatest(provider string, n int) ([]Snapshot, error) {
 	return snapshots, nil
 }
 
+// SessionResetTime returns the session window reset scraped in the latest
+// snapshot, if it can be parsed. Satisfies budget.SessionResetSource.
+func (c *Collector) SessionResetTime(provider string) (time.Time, bool) {
+	latest, err := c.GetLatest(provider, 1)
+	if err != nil || len(latest) == 0 || latest[0].SessionResetTime == "" {
+		return time.Time{}, false
+	}
+	return tmux.ParseResetTime(latest[0].SessionResetTime, latest[0].Timestamp)
+}
+
 // GetSinceWeekStart returns snapshots from the current week.
 func (c *Collector) GetSinceWeekStart(provider string) ([]Snapshot, error) {
 	weekStart := startOfWeek(time.Now(), c.weekStartDay)
 	rows, err := c.db.SQL().Query(
-		`SELECT id, provider, timestamp, week_start, local_tokens, local_daily, scraped_pct, inferred_budget, day_of_week, hour_of_day, week_number, year, session_reset_time, weekly_reset_time
+		`SELECT id, provider, timestamp, week_start, local_tokens, local_daily, scraped_pct, inferred_budget, day_of_week, hour_of_day, week_number, year, session_reset_time, weekly_reset_time, model_tokens
 		 FROM snapshots
 		 WHERE provider = ? AND week_start = ?
 		 ORDER BY timestamp ASC`,
@@ -333,7 +310,7 @@ func scanSnapshot(rows *sql.Rows) (Snapshot, error) {
 	var snapshot Snapshot
 	var scraped sql.NullFloat64
 	var inferred sql.NullInt64
-	var sessionReset, weeklyReset sql.NullString
+	var sessionReset, weeklyReset, modelTokens sql.NullString
 	if err := rows.Scan(
 		&snapshot.ID,
 		&snapshot.Provider,
@@ -349,6 +326,7 @@ func scanSnapshot(rows *sql.Rows) (Snapshot, error) {
 		&snapshot.Year,
 		&sessionReset,
 		&weeklyReset,
+		&modelTokens,
 	); err != nil {
 		return Snapshot{}, fmt.Errorf("scan snapshot: %w", err)
 	}
@@ -365,6 +343,11 @@ func scanSnapshot(rows *sql.Rows) (Snapshot, error) {
 	if weeklyReset.Valid {
 		snapshot.WeeklyResetTime = weeklyReset.String
 	}
+	if modelTokens.Valid && modelTokens.String != "" {
+		if err := json.Unmarshal([]byte(modelTokens.String), &snapshot.ModelTokens); err != nil {
+			return Snapshot{}, fmt.Errorf("decode snapshot model tokens: %w", err)
+		}
+	}
 	return snapshot, nil
 }
 
@@ -399,26 +382,53 @@ func nullString(value string) any {
 	return sql.NullString{String: value, Valid: true}
 }
 
-// geminiTokenTotals returns weekly and daily token totals from Gemini session files.
-func geminiTokenTotals(gemini GeminiUsage) (int64, int64, error) {
-	weekly, err := gemini.GetWeeklyTokens()
-	if err != nil {
-		return 0, 0, fmt.Errorf("get weekly tokens: %w", err)
+func nullModelTokens(value map[string]int64) any {
+	if len(value) == 0 {
+		return sql.NullString{}
 	}
-	daily, err := gemini.GetTodayTokens()
+	data, err := json.Marshal(value)
 	if err != nil {
-		return 0, 0, fmt.Errorf("get today tokens: %w", err)
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// weeklyModelTokens returns the source's weekly per-model split, or nil when
+// the source doesn't provide one. A failed split doesn't fail the snapshot.
+func weeklyModelTokens(source UsageSource) map[string]int64 {
+	split, ok := source.(ModelUsageSource)
+	if !ok {
+		return nil
+	}
+	byModel, err := split.GetWeeklyTokensByModel()
+	if err != nil || len(byModel) == 0 {
+		return nil
+	}
+	return byModel
+}
+
+// scrape runs the tmux scraper for providers that support it. The boolean
+// result reports whether the provider has a scraper at all.
+func (c *Collector) scrape(ctx context.Context, provider string) (tmux.UsageResult, bool, error) {
+	switch provider {
+	case "claude":
+		result, err := c.scraper.ScrapeClaudeUsage(ctx)
+		return result, true, err
+	case "codex":
+		result, err := c.scraper.ScrapeCodexUsage(ctx)
+		return result, true, err
+	default:
+		return tmux.UsageResult{}, false, nil
 	}
-	return weekly, daily, nil
 }
 
-// codexTokenTotals returns weekly and daily token totals from Codex session files.
-func codexTokenTotals(codex CodexUsage) (int64, int64, error) {
-	weekly, err := codex.GetWeeklyTokens()
+// tokenTotals returns weekly and daily token totals from a provider's local data.
+func tokenTotals(source UsageSource) (int64, int64, error) {
+	weekly, err := source.GetWeeklyTokens()
 	if err != nil {
 		return 0, 0, fmt.Errorf("get weekly tokens: %w", err)
 	}
-	daily, err := codex.GetTodayTokens()
+	daily, err := source.GetTodayTokens()
 	if err != nil {
 		return 0, 0, fmt.Errorf("get today tokens: %w", err)
 	}
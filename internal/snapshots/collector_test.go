@@ -16,8 +16,8 @@ type fakeClaude struct {
 	err    error
 }
 
-func (f fakeClaude) GetWeeklyUsage() (int64, error) { return f.weekly, f.err }
-func (f fakeClaude) GetTodayUsage() (int64, error)  { return f.daily, f.err }
+func (f fakeClaude) GetWeeklyTokens() (int64, error) { return f.weekly, f.err }
+func (f fakeClaude) GetTodayTokens() (int64, error)  { return f.daily, f.err }
 
 type fakeScraper struct {
 	claudePct        float64
@@ -77,7 +77,7 @@ func TestTakeSnapshotInsertsClaude(t *testing.T) {
 	}
 	defer func() { _ = database.Close() }()
 
-	collector := NewCollector(database, fakeClaude{weekly: 700, daily: 120}, nil, nil, fakeScraper{claudePct: 50}, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"claude": fakeClaude{weekly: 700, daily: 120}}, fakeScraper{claudePct: 50}, time.Monday)
 
 	_, err = collector.TakeSnapshot(context.Background(), "claude")
 	if err != nil {
@@ -124,7 +124,7 @@ func TestTakeSnapshotCodexWithTokenData(t *testing.T) {
 	defer func() { _ = database.Close() }()
 
 	codex := fakeCodex{weeklyTokens: 35000, dailyTokens: 5000}
-	collector := NewCollector(database, nil, codex, nil, fakeScraper{codexPct: 42}, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"codex": codex}, fakeScraper{codexPct: 42}, time.Monday)
 
 	snap, err := collector.TakeSnapshot(context.Background(), "codex")
 	if err != nil {
@@ -160,7 +160,7 @@ func TestTakeSnapshotCodexNoTokenData(t *testing.T) {
 	}
 	defer func() { _ = database.Close() }()
 
-	collector := NewCollector(database, nil, fakeCodex{}, nil, fakeScraper{codexPct: 42}, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"codex": fakeCodex{}}, fakeScraper{codexPct: 42}, time.Monday)
 
 	snap, err := collector.TakeSnapshot(context.Background(), "codex")
 	if err != nil {
@@ -191,7 +191,7 @@ func TestTakeSnapshotGeminiWithTokenData(t *testing.T) {
 	defer func() { _ = database.Close() }()
 
 	gemini := fakeGemini{weeklyTokens: 20000, dailyTokens: 3000}
-	collector := NewCollector(database, nil, nil, gemini, nil, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"gemini": gemini}, nil, time.Monday)
 
 	snap, err := collector.TakeSnapshot(context.Background(), "gemini")
 	if err != nil {
@@ -213,6 +213,55 @@ func TestTakeSnapshotGeminiWithTokenData(t *testing.T) {
 	}
 }
 
+type fakeModelSource struct {
+	fakeClaude
+	byModel map[string]int64
+}
+
+func (f fakeModelSource) GetWeeklyTokensByModel() (map[string]int64, error) { return f.byModel, nil }
+
+func TestTakeSnapshotStoresModelTokens(t *testing.T) {
+	home := t.TempDir()
+	database, err := db.Open(filepath.Join(home, "nightshift.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	source := fakeModelSource{
+		fakeClaude: fakeClaude{weekly: 1500, daily: 500},
+		byModel:    map[string]int64{"claude-opus-4-5": 1000, "claude-haiku-4-5": 500},
+	}
+	collector := NewCollector(database, map[string]UsageSource{"claude": source}, nil, time.Monday)
+
+	snap, err := collector.TakeSnapshot(context.Background(), "claude")
+	if err != nil {
+		t.Fatalf("take snapshot: %v", err)
+	}
+	if snap.ModelTokens["claude-opus-4-5"] != 1000 {
+		t.Fatalf("model tokens = %v", snap.ModelTokens)
+	}
+
+	latest, err := collector.GetLatest("claude", 1)
+	if err != nil || len(latest) != 1 {
+		t.Fatalf("get latest: %v (%d)", err, len(latest))
+	}
+	got := latest[0].ModelTokens
+	if len(got) != 2 || got["claude-haiku-4-5"] != 500 {
+		t.Fatalf("stored model tokens = %v", got)
+	}
+
+	// Sources without a split store nothing.
+	collector = NewCollector(database, map[string]UsageSource{"claude": fakeClaude{weekly: 10}}, nil, time.Monday)
+	snap, err = collector.TakeSnapshot(context.Background(), "claude")
+	if err != nil {
+		t.Fatalf("take snapshot: %v", err)
+	}
+	if snap.ModelTokens != nil {
+		t.Fatalf("model tokens = %v, want nil", snap.ModelTokens)
+	}
+}
+
 func TestTakeSnapshotGeminiNilProvider(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
@@ -224,7 +273,7 @@ func TestTakeSnapshotGeminiNilProvider(t *testing.T) {
 	}
 	defer func() { _ = database.Close() }()
 
-	collector := NewCollector(database, nil, nil, nil, nil, time.Monday)
+	collector := NewCollector(database, nil, nil, time.Monday)
 
 	_, err = collector.TakeSnapshot(context.Background(), "gemini")
 	if err == nil {
@@ -233,7 +282,7 @@ func TestTakeSnapshotGeminiNilProvider(t *testing.T) {
 }
 
 func TestGeminiTokenTotals(t *testing.T) {
-	weekly, daily, err := geminiTokenTotals(fakeGemini{
+	weekly, daily, err := tokenTotals(fakeGemini{
 		weeklyTokens: 25000,
 		dailyTokens:  4000,
 	})
@@ -249,14 +298,14 @@ func TestGeminiTokenTotals(t *testing.T) {
 }
 
 func TestGeminiTokenTotalsPropagatesErrors(t *testing.T) {
-	_, _, err := geminiTokenTotals(fakeGemini{err: context.DeadlineExceeded})
+	_, _, err := tokenTotals(fakeGemini{err: context.DeadlineExceeded})
 	if err == nil {
 		t.Fatal("expected error from geminiTokenTotals")
 	}
 }
 
 func TestCodexTokenTotalsReturnsTokenData(t *testing.T) {
-	weekly, daily, err := codexTokenTotals(fakeCodex{
+	weekly, daily, err := tokenTotals(fakeCodex{
 		files:        []string{"/some/path.jsonl"},
 		weeklyTokens: 50000,
 		dailyTokens:  8000,
@@ -273,7 +322,7 @@ func TestCodexTokenTotalsReturnsTokenData(t *testing.T) {
 }
 
 func TestCodexTokenTotalsNoData(t *testing.T) {
-	weekly, daily, err := codexTokenTotals(fakeCodex{})
+	weekly, daily, err := tokenTotals(fakeCodex{})
 	if err != nil {
 		t.Fatalf("codexTokenTotals: %v", err)
 	}
@@ -286,7 +335,7 @@ func TestCodexTokenTotalsNoData(t *testing.T) {
 }
 
 func TestCodexTokenTotalsPropagatesErrors(t *testing.T) {
-	_, _, err := codexTokenTotals(fakeCodex{err: context.DeadlineExceeded})
+	_, _, err := tokenTotals(fakeCodex{err: context.DeadlineExceeded})
 	if err == nil {
 		t.Fatal("expected error from codexTokenTotals")
 	}
@@ -308,7 +357,7 @@ func TestTakeSnapshotStoresResetTimes(t *testing.T) {
 		sessionResetTime: "9pm (America/Los_Angeles)",
 		weeklyResetTime:  "Feb 8 at 10am (America/Los_Angeles)",
 	}
-	collector := NewCollector(database, fakeClaude{weekly: 700, daily: 120}, nil, nil, scraper, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"claude": fakeClaude{weekly: 700, daily: 120}}, scraper, time.Monday)
 
 	_, err = collector.TakeSnapshot(context.Background(), "claude")
 	if err != nil {
@@ -348,7 +397,7 @@ func TestTakeSnapshotCodexResetTimes(t *testing.T) {
 		sessionResetTime: "20:15",
 		weeklyResetTime:  "20:08 on 9 Feb",
 	}
-	collector := NewCollector(database, nil, fakeCodex{}, nil, scraper, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"codex": fakeCodex{}}, scraper, time.Monday)
 
 	snap, err := collector.TakeSnapshot(context.Background(), "codex")
 	if err != nil {
@@ -375,7 +424,7 @@ func TestTakeSnapshotEmptyResetTimes(t *testing.T) {
 	defer func() { _ = database.Close() }()
 
 	// scraper with no reset times
-	collector := NewCollector(database, fakeClaude{weekly: 100, daily: 10}, nil, nil, fakeScraper{claudePct: 25}, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"claude": fakeClaude{weekly: 100, daily: 10}}, fakeScraper{claudePct: 25}, time.Monday)
 
 	_, err = collector.TakeSnapshot(context.Background(), "claude")
 	if err != nil {
@@ -407,7 +456,7 @@ func TestPruneSnapshots(t *testing.T) {
 	}
 	defer func() { _ = database.Close() }()
 
-	collector := NewCollector(database, fakeClaude{}, nil, nil, nil, time.Monday)
+	collector := NewCollector(database, map[string]UsageSource{"claude": fakeClaude{}}, nil, time.Monday)
 
 	oldTime := time.Now().AddDate(0, 0, -3)
 	weekStart := startOfWeek(oldTime, time.Monday)
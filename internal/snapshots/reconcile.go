@@ -0,0 +1,155 @@
+package snapshots
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// untrackedTolerance is the share of the ledger total that snapshot deltas
+	// may exceed it by before the gap is flagged (rolling windows and clock
+	// skew make the two disagree slightly).
+	untrackedTolerance = 0.10
+	// minUntrackedTokens ignores small gaps regardless of ratio.
+	minUntrackedTokens = 10_000
+)
+
+// LedgerSource reports tokens nightshift's own agent invocations used,
+// with cache tokens when withCache is set. Satisfied by state.State.
+type LedgerSource interface {
+	LedgerTokens(provider string, since, until time.Time, withCache bool) (tokens int64, unmeasured int, err error)
+}
+
+// Reconciliation compares the ledger with provider-wide snapshot deltas.
+type Reconciliation struct {
+	Provider string
+	Since    time.Time
+	Until    time.Time
+
+	// HasSnapshots is false when no snapshots bracket the window; the
+	// delta and untracked figures are then meaningless.
+	HasSnapshots  bool
+	SnapshotDelta int64 // local tokens consumed between the bracketing snapshots
+	LedgerTokens  int64 // tokens nightshift recorded in the window
+	Unmeasured    int   // invocations in the window that reported no usage
+	Untracked     int64 // SnapshotDelta - LedgerTokens; usage the ledger doesn't explain
+
+	WeeklyTokens int64 // provider-wide local tokens over the last 7 days
+	WeeklyLedger int64 // nightshift's tokens over the same 7 days
+}
+
+// Share returns nightshift's fraction of the provider's weekly usage (0-1),
+// or 0 when weekly usage is unknown.
+func (r Reconciliation) Share() float64 {
+	if r.WeeklyTokens <= 0 {
+		return 0
+	}
+	share := float64(r.WeeklyLedger) / float64(r.WeeklyTokens)
+	if share > 1 {
+		share = 1
+	}
+	return share
+}
+
+// Flagged reports whether the window saw noticeably more usage than the
+// ledger accounts for, i.e. consumption nightshift didn't track.
+func (r Reconciliation) Flagged() bool {
+	if !r.HasSnapshots || r.Untracked < minUntrackedTokens {
+		return false
+	}
+	return float64(r.Untracked) > float64(r.LedgerTokens)*untrackedTolerance
+}
+
+// String summarizes the reconciliation on one line.
+func (r Reconciliation) String() string {
+	parts := []string{fmt.Sprintf("%s: ledger %d tokens", r.Provider, r.LedgerTokens)}
+	if r.HasSnapshots {
+		parts = append(parts, fmt.Sprintf("snapshots %d", r.SnapshotDelta))
+		if r.Flagged() {
+			parts = append(parts, fmt.Sprintf("%d untracked", r.Untracked))
+		}
+	} else {
+		parts = append(parts, "no bracketing snapshots")
+	}
+	if r.Unmeasured > 0 {
+		parts = append(parts, fmt.Sprintf("%d unmeasured calls", r.Unmeasured))
+	}
+	if r.WeeklyTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%% of weekly usage", r.Share()*100))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Reconcile compares ledger totals for [since, until) with the change in the
+// provider's local token count between the snapshots bracketing that window,
+// and computes nightshift's share of the last 7 days of usage. Ledger
+// tokens are counted the way the provider's usage source counts them.
+func (c *Collector) Reconcile(ledger LedgerSource, provider string, since, until time.Time) (Reconciliation, error) {
+	provider = strings.ToLower(provider)
+	r := Reconciliation{Provider: provider, Since: since, Until: until}
+
+	withCache := false
+	if cs, ok := c.sources[provider].(CacheTokenSource); ok {
+		withCache = cs.CountsCacheTokens()
+	}
+	tokens, unmeasured, err := ledger.LedgerTokens(provider, since, until, withCache)
+	if err != nil {
+		return r, err
+	}
+	r.LedgerTokens = tokens
+	r.Unmeasured = unmeasured
+
+	start, startOK, err := c.snapshotNear(provider, since, true)
+	if err != nil {
+		return r, err
+	}
+	end, endOK, err := c.snapshotNear(provider, until, false)
+	if err != nil {
+		return r, err
+	}
+	if startOK && endOK && end.Timestamp.After(start.Timestamp) {
+		r.HasSnapshots = true
+		r.SnapshotDelta = end.LocalTokens - start.LocalTokens
+		if r.SnapshotDelta < 0 {
+			// Local weekly totals roll over; treat a drop as unknown growth.
+			r.SnapshotDelta = 0
+		}
+		r.Untracked = r.SnapshotDelta - r.LedgerTokens
+	}
+
+	latest, err := c.GetLatest(provider, 1)
+	if err != nil {
+		return r, err
+	}
+	if len(latest) > 0 {
+		r.WeeklyTokens = latest[0].LocalTokens
+		weekly, _, err := ledger.LedgerTokens(provider, latest[0].Timestamp.AddDate(0, 0, -7), latest[0].Timestamp.Add(time.Second), withCache)
+		if err != nil {
+			return r, err
+		}
+		r.WeeklyLedger = weekly
+	}
+	return r, nil
+}
+
+// snapshotNear returns the latest snapshot at or before t (before=true) or
+// the earliest at or after t.
+func (c *Collector) snapshotNear(provider string, t time.Time, before bool) (Snapshot, bool, error) {
+	query := `SELECT timestamp, local_tokens FROM snapshots WHERE provider = ? AND timestamp >= ? ORDER BY timestamp ASC LIMIT 1`
+	if before {
+		query = `SELECT timestamp, local_tokens FROM snapshots WHERE provider = ? AND timestamp <= ? ORDER BY timestamp DESC LIMIT 1`
+	}
+	var s Snapshot
+	err := c.db.SQL().QueryRow(query, provider, t).Scan(&s.Timestamp, &s.LocalTokens)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("query snapshot near %s: %w", t.Format(time.RFC3339), err)
+	}
+	s.Provider = provider
+	return s, true, nil
+}
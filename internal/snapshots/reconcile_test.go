@@ -0,0 +1,147 @@
+package snapshots
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/db"
+)
+
+type fakeLedger struct {
+	run    int64
+	weekly int64
+	cache  int64 // cache tokens in the run, counted only withCache
+}
+
+func (f fakeLedger) LedgerTokens(provider string, since, until time.Time, withCache bool) (int64, int, error) {
+	if until.Sub(since) > 24*time.Hour {
+		return f.weekly, 0, nil
+	}
+	if withCache {
+		return f.run + f.cache, 1, nil
+	}
+	return f.run, 1, nil
+}
+
+// cacheCountingSource is a usage source whose totals include cache tokens.
+type cacheCountingSource struct{}
+
+func (cacheCountingSource) GetTodayTokens() (int64, error)  { return 0, nil }
+func (cacheCountingSource) GetWeeklyTokens() (int64, error) { return 0, nil }
+func (cacheCountingSource) CountsCacheTokens() bool         { return true }
+
+func insertSnapshot(t *testing.T, database *db.DB, provider string, at time.Time, localTokens int64) {
+	t.Helper()
+	weekStart := startOfWeek(at, time.Monday)
+	weekNumber, year := weekStart.ISOWeek()
+	if _, err := database.SQL().Exec(
+		`INSERT INTO snapshots (provider, timestamp, week_start, local_tokens, local_daily, day_of_week, hour_of_day, week_number, year)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		provider, at, weekStart, localTokens, 0, int(at.Weekday()), at.Hour(), weekNumber, year,
+	); err != nil {
+		t.Fatalf("insert snapshot: %v", err)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	home := t.TempDir()
+	database, err := db.Open(filepath.Join(home, "nightshift.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	collector := NewCollector(database, nil, nil, time.Monday)
+
+	runStart := time.Now().Add(-3 * time.Hour)
+	runEnd := time.Now().Add(-time.Hour)
+	insertSnapshot(t, database, "claude", runStart.Add(-time.Minute), 1_000_000)
+	insertSnapshot(t, database, "claude", runEnd.Add(time.Minute), 1_200_000)
+
+	tests := []struct {
+		name          string
+		ledger        fakeLedger
+		wantUntracked int64
+		wantFlagged   bool
+	}{
+		{"ledger explains usage", fakeLedger{run: 195_000, weekly: 600_000}, 5_000, false},
+		{"untracked consumption", fakeLedger{run: 120_000, weekly: 600_000}, 80_000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := collector.Reconcile(tt.ledger, "claude", runStart, runEnd)
+			if err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+			if !r.HasSnapshots || r.SnapshotDelta != 200_000 {
+				t.Fatalf("delta = %d (has snapshots %v), want 200000", r.SnapshotDelta, r.HasSnapshots)
+			}
+			if r.Untracked != tt.wantUntracked {
+				t.Errorf("Untracked = %d, want %d", r.Untracked, tt.wantUntracked)
+			}
+			if r.Flagged() != tt.wantFlagged {
+				t.Errorf("Flagged = %v, want %v", r.Flagged(), tt.wantFlagged)
+			}
+			if r.Share() != 0.5 {
+				t.Errorf("Share = %v, want 0.5", r.Share())
+			}
+			if r.Unmeasured != 1 {
+				t.Errorf("Unmeasured = %d, want 1", r.Unmeasured)
+			}
+		})
+	}
+}
+
+func TestReconcile_CountsCacheTokens(t *testing.T) {
+	home := t.TempDir()
+	database, err := db.Open(filepath.Join(home, "nightshift.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	runStart := time.Now().Add(-3 * time.Hour)
+	runEnd := time.Now().Add(-time.Hour)
+	for _, provider := range []string{"gemini", "claude"} {
+		insertSnapshot(t, database, provider, runStart.Add(-time.Minute), 1_000_000)
+		insertSnapshot(t, database, provider, runEnd.Add(time.Minute), 1_200_000)
+	}
+	collector := NewCollector(database, map[string]UsageSource{"gemini": cacheCountingSource{}}, nil, time.Monday)
+
+	// A cache-heavy run: most of the snapshot delta is cached input
+	ledger := fakeLedger{run: 40_000, cache: 158_000}
+	r, err := collector.Reconcile(ledger, "gemini", runStart, runEnd)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if r.LedgerTokens != 198_000 || r.Untracked != 2_000 || r.Flagged() {
+		t.Errorf("gemini: ledger %d, untracked %d, flagged %v; want 198000, 2000, false", r.LedgerTokens, r.Untracked, r.Flagged())
+	}
+
+	// Sources that count input+output only leave cache tokens out
+	r, err = collector.Reconcile(ledger, "claude", runStart, runEnd)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if r.LedgerTokens != 40_000 {
+		t.Errorf("claude: ledger %d, want 40000", r.LedgerTokens)
+	}
+}
+
+func TestReconcileWithoutSnapshots(t *testing.T) {
+	home := t.TempDir()
+	database, err := db.Open(filepath.Join(home, "nightshift.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() { _ = database.Close() }()
+	collector := NewCollector(database, nil, nil, time.Monday)
+
+	r, err := collector.Reconcile(fakeLedger{run: 50_000}, "codex", time.Now().Add(-time.Hour), time.Now())
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if r.HasSnapshots || r.Flagged() || r.Share() != 0 {
+		t.Errorf("expected empty reconciliation, got %+v", r)
+	}
+}
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// Coordinator shares task claims and run history with other machines
+// running nightshift against the same projects. Methods take the local
+// project path; implementations key projects by coord.ProjectKey, the
+// normalized origin URL, so clones at different paths are shared.
+type Coordinator interface {
+	// Claim takes an expiring lease on a task for this machine. It returns
+	// the machine holding a live lease when another machine claimed the
+	// task first, or "" when the claim succeeded.
+	Claim(project, taskType string, ttl time.Duration) (string, error)
+	// Release drops this machine's lease on a task.
+	Release(project, taskType string) error
+	// Holder returns the other machine holding a live lease on a task, or "".
+	Holder(project, taskType string) (string, error)
+	// RecordRun records that a task ran at the given time.
+	RecordRun(project, taskType string, at time.Time) error
+	// LastRun returns when a task last ran on any machine, or the zero time.
+	LastRun(project, taskType string) (time.Time, error)
+}
+
+// SetCoordinator shares claims and history through c, with claims lasting
+// lease unless released. Nil restores local-only state.
+func (s *State) SetCoordinator(c Coordinator, lease time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coord = c
+	s.lease = lease
+}
+
+func (s *State) coordinator() (Coordinator, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.coord, s.lease
+}
+
+// ClaimTask marks a task assigned and, with a coordinator set, claims it
+// for this machine. When another machine holds the task, ClaimTask returns
+// that machine's name and does not mark the task. A coordination error is
+// returned with the task marked, so callers may run it anyway.
+func (s *State) ClaimTask(taskID, project, taskType string) (string, error) {
+	if c, lease := s.coordinator(); c != nil {
+		holder, err := c.Claim(normalizePath(project), taskType, lease)
+		if err == nil && holder != "" {
+			return holder, nil
+		}
+		s.MarkAssigned(taskID, project, taskType)
+		return "", err
+	}
+	s.MarkAssigned(taskID, project, taskType)
+	return "", nil
+}
+
+// remoteHolder returns the other machine holding taskID, or "".
+func (s *State) remoteHolder(taskID string) string {
+	c, _ := s.coordinator()
+	if c == nil {
+		return ""
+	}
+	// Task IDs are "<task type>:<project path>"
+	taskType, project, ok := strings.Cut(taskID, ":")
+	if !ok {
+		return ""
+	}
+	holder, err := c.Holder(normalizePath(project), taskType)
+	if err != nil {
+		log.Printf("state: coordination holder: %v", err)
+		return ""
+	}
+	return holder
+}
+
+// recordRemoteRun shares a task run with other machines.
+func (s *State) recordRemoteRun(project, taskType string, at time.Time) {
+	c, _ := s.coordinator()
+	if c == nil {
+		return
+	}
+	if err := c.RecordRun(project, taskType, at); err != nil {
+		log.Printf("state: coordination record run: %v", err)
+	}
+}
+
+// remoteLastRun returns when a task last ran on any machine, or the zero
+// time without a coordinator.
+func (s *State) remoteLastRun(project, taskType string) time.Time {
+	c, _ := s.coordinator()
+	if c == nil {
+		return time.Time{}
+	}
+	at, err := c.LastRun(project, taskType)
+	if err != nil {
+		log.Printf("state: coordination last run: %v", err)
+		return time.Time{}
+	}
+	return at
+}
+
+// releaseRemote drops this machine's lease on an assigned task.
+func (s *State) releaseRemote(task AssignedTask) {
+	c, _ := s.coordinator()
+	if c == nil {
+		return
+	}
+	if err := c.Release(task.Project, task.TaskType); err != nil {
+		log.Printf("state: coordination release: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/marcus/nightshift/internal/scheduler"
+)
+
+var _ scheduler.FireLog = (*State)(nil)
+
+// LastFire returns the most recent fire recorded for schedule. Implements
+// scheduler.FireLog.
+func (s *State) LastFire(schedule string) (scheduler.Fire, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f := scheduler.Fire{Schedule: schedule}
+	var status string
+	err := s.db.SQL().QueryRow(
+		`SELECT scheduled_at, fired_at, status FROM schedule_fires
+		 WHERE schedule = ? ORDER BY scheduled_at DESC, id DESC LIMIT 1`,
+		schedule,
+	).Scan(&f.ScheduledAt, &f.FiredAt, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return scheduler.Fire{}, false, nil
+	}
+	if err != nil {
+		return scheduler.Fire{}, false, fmt.Errorf("query schedule fires: %w", err)
+	}
+	f.Status = scheduler.FireStatus(status)
+	return f, true, nil
+}
+
+// RecordFire persists a fire. Implements scheduler.FireLog.
+func (s *State) RecordFire(f scheduler.Fire) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.SQL().Exec(
+		`INSERT INTO schedule_fires (schedule, scheduled_at, fired_at, status) VALUES (?, ?, ?, ?)`,
+		f.Schedule, f.ScheduledAt, f.FiredAt, string(f.Status),
+	)
+	if err != nil {
+		return fmt.Errorf("insert schedule fire: %w", err)
+	}
+	return nil
+}
+
+// RecentFires returns up to limit fires of schedule with one of the given
+// statuses (all when none are given), newest first.
+func (s *State) RecentFires(schedule string, limit int, statuses ...scheduler.FireStatus) ([]scheduler.Fire, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `SELECT scheduled_at, fired_at, status FROM schedule_fires WHERE schedule = ?`
+	args := []any{schedule}
+	if len(statuses) > 0 {
+		query += " AND status IN (?" + strings.Repeat(", ?", len(statuses)-1) + ")"
+		for _, st := range statuses {
+			args = append(args, string(st))
+		}
+	}
+	query += " ORDER BY scheduled_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.SQL().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query schedule fires: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var fires []scheduler.Fire
+	for rows.Next() {
+		f := scheduler.Fire{Schedule: schedule}
+		var status string
+		if err := rows.Scan(&f.ScheduledAt, &f.FiredAt, &status); err != nil {
+			return nil, fmt.Errorf("scan schedule fire: %w", err)
+		}
+		f.Status = scheduler.FireStatus(status)
+		fires = append(fires, f)
+	}
+	return fires, rows.Err()
+}
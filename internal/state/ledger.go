@@ -0,0 +1,164 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// LedgerEntry is one model's usage in an agent invocation and its dollar
+// cost. Ledger entries are stored in the spend table.
+type LedgerEntry struct {
+	Time             time.Time     `json:"time"`
+	Provider         string        `json:"provider"`
+	Model            string        `json:"model,omitempty"`
+	Phase            string        `json:"phase,omitempty"` // planning, executing or reviewing
+	Iteration        int           `json:"iteration,omitempty"`
+	TaskID           string        `json:"task_id,omitempty"`
+	TaskType         string        `json:"task_type,omitempty"`
+	Project          string        `json:"project,omitempty"`
+	SessionID        string        `json:"session_id,omitempty"` // CLI session, when the agent reports one
+	InputTokens      int64         `json:"input_tokens"`
+	OutputTokens     int64         `json:"output_tokens"`
+	CacheReadTokens  int64         `json:"cache_read_tokens"`
+	CacheWriteTokens int64         `json:"cache_write_tokens"`
+	Duration         time.Duration `json:"duration"`
+	Measured         bool          `json:"measured"` // false when the agent reported no usage
+	CostUSD          float64       `json:"cost_usd"`
+	Estimated        bool          `json:"estimated,omitempty"` // Cost priced from the task estimate
+}
+
+// Tokens returns input+output tokens, the measure budgets use.
+func (e LedgerEntry) Tokens() int64 {
+	return e.InputTokens + e.OutputTokens
+}
+
+// TokensWithCache returns Tokens plus cache reads and writes, for providers
+// whose local usage counts cached input.
+func (e LedgerEntry) TokensWithCache() int64 {
+	return e.Tokens() + e.CacheReadTokens + e.CacheWriteTokens
+}
+
+// RecordLedger persists ledger entries.
+func (s *State) RecordLedger(entries ...LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.SQL().Begin()
+	if err != nil {
+		return fmt.Errorf("begin ledger insert: %w", err)
+	}
+	for _, e := range entries {
+		if e.Time.IsZero() {
+			e.Time = time.Now()
+		}
+		if e.Project != "" {
+			e.Project = normalizePath(e.Project)
+		}
+		_, err := tx.Exec(
+			`INSERT INTO spend (`+ledgerColumns+`)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			e.Time, e.Provider, e.Model, e.Phase, e.Iteration, e.TaskID, e.TaskType, e.Project, e.SessionID,
+			e.InputTokens, e.OutputTokens, e.CacheReadTokens, e.CacheWriteTokens, e.Duration.Milliseconds(), e.Measured,
+			e.CostUSD, e.Estimated,
+		)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("insert ledger: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit ledger: %w", err)
+	}
+	return nil
+}
+
+// LedgerTokens sums input+output tokens recorded for provider in [since, until),
+// plus cache reads and writes when withCache is set, and counts the
+// invocations that reported no usage.
+func (s *State) LedgerTokens(provider string, since, until time.Time, withCache bool) (int64, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sum := "input_tokens + output_tokens"
+	if withCache {
+		sum += " + cache_read_tokens + cache_write_tokens"
+	}
+	var tokens int64
+	var unmeasured int
+	row := s.db.SQL().QueryRow(
+		`SELECT COALESCE(SUM(`+sum+`), 0),
+		        COALESCE(SUM(CASE WHEN measured = 0 THEN 1 ELSE 0 END), 0)
+		 FROM spend
+		 WHERE provider = ? AND timestamp >= ? AND timestamp < ?`,
+		provider, since, until,
+	)
+	if err := row.Scan(&tokens, &unmeasured); err != nil {
+		return 0, 0, fmt.Errorf("query ledger: %w", err)
+	}
+	return tokens, unmeasured, nil
+}
+
+// LedgerSince returns ledger entries recorded since the given time, newest
+// first, for provider or for all providers when it is "". A limit <= 0
+// returns all of them.
+func (s *State) LedgerSince(provider string, since time.Time, limit int) ([]LedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `SELECT ` + ledgerColumns + ` FROM spend WHERE timestamp >= ?`
+	args := []any{since}
+	if provider != "" {
+		query += " AND provider = ?"
+		args = append(args, provider)
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return s.queryLedger(query, args...)
+}
+
+// ProviderLedger returns the measured ledger entries for provider recorded
+// since the given time, oldest first.
+func (s *State) ProviderLedger(provider string, since time.Time) ([]LedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.queryLedger(`SELECT `+ledgerColumns+` FROM spend
+		WHERE provider = ? AND timestamp >= ? AND measured = 1 ORDER BY timestamp, id`, provider, since)
+}
+
+const ledgerColumns = `timestamp, provider, model, phase, iteration, task_id, task_type, project, session_id,
+	input_tokens, output_tokens, cache_read_tokens, cache_write_tokens, duration_ms, measured, cost_usd, estimated`
+
+// queryLedger runs a ledger query selecting ledgerColumns. The caller
+// holds s.mu.
+func (s *State) queryLedger(query string, args ...any) ([]LedgerEntry, error) {
+	rows, err := s.db.SQL().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query ledger: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []LedgerEntry
+	for rows.Next() {
+		var e LedgerEntry
+		var durationMS int64
+		if err := rows.Scan(&e.Time, &e.Provider, &e.Model, &e.Phase, &e.Iteration, &e.TaskID, &e.TaskType, &e.Project, &e.SessionID,
+			&e.InputTokens, &e.OutputTokens, &e.CacheReadTokens, &e.CacheWriteTokens, &durationMS, &e.Measured,
+			&e.CostUSD, &e.Estimated); err != nil {
+			return nil, fmt.Errorf("scan ledger: %w", err)
+		}
+		e.Duration = time.Duration(durationMS) * time.Millisecond
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ledger: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,72 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLedgerTokens(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+
+	err := s.RecordLedger(
+		LedgerEntry{Time: now.Add(-48 * time.Hour), Provider: "claude", Model: "claude-sonnet-4-5", Phase: "planning", InputTokens: 5000, Measured: true},
+		LedgerEntry{Time: now.Add(-time.Hour), Provider: "claude", Model: "claude-sonnet-4-5", Phase: "executing", Iteration: 1, InputTokens: 300, OutputTokens: 100, CacheReadTokens: 9000, Measured: true},
+		LedgerEntry{Time: now.Add(-30 * time.Minute), Provider: "claude", Phase: "reviewing", Iteration: 1},
+		LedgerEntry{Time: now.Add(-time.Minute), Provider: "codex", InputTokens: 50, Measured: true},
+		LedgerEntry{Time: now.Add(-40 * time.Minute), Provider: "gemini", SessionID: "s1", InputTokens: 70, Measured: true},
+	)
+	if err != nil {
+		t.Fatalf("RecordLedger: %v", err)
+	}
+
+	tokens, unmeasured, err := s.LedgerTokens("claude", now.Add(-24*time.Hour), now, false)
+	if err != nil {
+		t.Fatalf("LedgerTokens: %v", err)
+	}
+	if tokens != 400 {
+		t.Errorf("tokens = %d, want 400 (cache reads excluded)", tokens)
+	}
+	if unmeasured != 1 {
+		t.Errorf("unmeasured = %d, want 1", unmeasured)
+	}
+	tokens, _, err = s.LedgerTokens("claude", now.Add(-24*time.Hour), now, true)
+	if err != nil {
+		t.Fatalf("LedgerTokens: %v", err)
+	}
+	if tokens != 9400 {
+		t.Errorf("tokens with cache = %d, want 9400", tokens)
+	}
+
+	entries, err := s.LedgerSince("", now.Add(-24*time.Hour), 2)
+	if err != nil {
+		t.Fatalf("LedgerSince: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Provider != "codex" || entries[1].Phase != "reviewing" {
+		t.Errorf("LedgerSince = %+v", entries)
+	}
+
+	// The provider filter applies before the limit
+	entries, err = s.LedgerSince("claude", now.Add(-24*time.Hour), 2)
+	if err != nil {
+		t.Fatalf("LedgerSince: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Phase != "reviewing" || entries[1].Phase != "executing" {
+		t.Errorf("LedgerSince(claude) = %+v", entries)
+	}
+
+	claude, err := s.ProviderLedger("claude", now.Add(-72*time.Hour))
+	if err != nil {
+		t.Fatalf("ProviderLedger: %v", err)
+	}
+	if len(claude) != 2 || claude[0].Phase != "planning" || claude[1].CacheReadTokens != 9000 {
+		t.Errorf("ProviderLedger(claude) = %+v, want the two measured calls oldest first", claude)
+	}
+	gemini, err := s.ProviderLedger("gemini", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ProviderLedger: %v", err)
+	}
+	if len(gemini) != 1 || gemini[0].SessionID != "s1" {
+		t.Errorf("ProviderLedger(gemini) = %+v", gemini)
+	}
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// PR outcome states stored against task runs that opened a PR.
+const (
+	PROpen   = "open"
+	PRMerged = "merged"
+	PRClosed = "closed" // closed without merging
+	PRStale  = "stale"  // still open long after it was opened
+)
+
+// PROutcome is the observed state of a task run's PR.
+type PROutcome struct {
+	State      string
+	Reviews    int
+	ResolvedAt time.Time // when it was merged or closed
+}
+
+// PRAcceptance counts a (project, task type)'s PRs by outcome.
+type PRAcceptance struct {
+	Merged int `json:"merged"`
+	Closed int `json:"closed"`
+	Stale  int `json:"stale"`
+	Open   int `json:"open"`
+}
+
+// Decided returns how many PRs have a verdict: merged, closed or stale.
+func (a PRAcceptance) Decided() int {
+	return a.Merged + a.Closed + a.Stale
+}
+
+// Rate returns the share of decided PRs that were merged, or false when
+// none are decided.
+func (a PRAcceptance) Rate() (float64, bool) {
+	if a.Decided() == 0 {
+		return 0, false
+	}
+	return float64(a.Merged) / float64(a.Decided()), true
+}
+
+// UnresolvedPRRuns returns task runs whose PR has not been merged or
+// closed yet, oldest first.
+func (s *State) UnresolvedPRRuns() ([]TaskRunRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.SQL().Query(
+		`SELECT `+taskRunColumns+` FROM task_runs
+		 WHERE output_type = 'PR' AND output_ref != '' AND pr_state NOT IN (?, ?)
+		 ORDER BY started_at, id`,
+		PRMerged, PRClosed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query unresolved PRs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	return scanTaskRuns(rows)
+}
+
+// SetPROutcome stores the observed state of task run id's PR.
+func (s *State) SetPROutcome(id int64, o PROutcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var resolved any
+	if !o.ResolvedAt.IsZero() {
+		resolved = o.ResolvedAt
+	}
+	_, err := s.db.SQL().Exec(
+		`UPDATE task_runs SET pr_state = ?, pr_reviews = ?, pr_resolved_at = ?, pr_synced_at = ? WHERE id = ?`,
+		o.State, o.Reviews, resolved, time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("update PR outcome: %w", err)
+	}
+	return nil
+}
+
+// PRAcceptance counts the outcomes of PRs opened by taskType in project.
+func (s *State) PRAcceptance(project, taskType string) (PRAcceptance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.SQL().Query(
+		`SELECT pr_state, COUNT(*) FROM task_runs
+		 WHERE project = ? AND task_type = ? AND pr_state != ''
+		 GROUP BY pr_state`,
+		normalizePath(project), taskType,
+	)
+	if err != nil {
+		return PRAcceptance{}, fmt.Errorf("query PR acceptance: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var a PRAcceptance
+	for rows.Next() {
+		var prState string
+		var n int
+		if err := rows.Scan(&prState, &n); err != nil {
+			return PRAcceptance{}, fmt.Errorf("scan PR acceptance: %w", err)
+		}
+		switch prState {
+		case PRMerged:
+			a.Merged = n
+		case PRClosed:
+			a.Closed = n
+		case PRStale:
+			a.Stale = n
+		case PROpen:
+			a.Open = n
+		}
+	}
+	return a, rows.Err()
+}
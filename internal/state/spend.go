@@ -0,0 +1,19 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// SpendSince returns the total USD spent across providers since the given time.
+func (s *State) SpendSince(since time.Time) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total float64
+	row := s.db.SQL().QueryRow(`SELECT COALESCE(SUM(cost_usd), 0) FROM spend WHERE timestamp >= ?`, since)
+	if err := row.Scan(&total); err != nil {
+		return 0, fmt.Errorf("query spend: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,48 @@
+package state
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpendSince(t *testing.T) {
+	s := newTestState(t)
+	now := time.Now()
+
+	err := s.RecordLedger(
+		LedgerEntry{Time: now.Add(-48 * time.Hour), Provider: "claude", Model: "claude-sonnet-4-5", Measured: true, CostUSD: 5},
+		LedgerEntry{Time: now.Add(-time.Hour), Provider: "claude", Model: "claude-sonnet-4-5", Project: "/p", Measured: true, CostUSD: 1.25},
+		LedgerEntry{Time: now.Add(-time.Minute), Provider: "codex", Estimated: true, CostUSD: 0.5},
+	)
+	if err != nil {
+		t.Fatalf("RecordLedger: %v", err)
+	}
+
+	total, err := s.SpendSince(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("SpendSince: %v", err)
+	}
+	if math.Abs(total-1.75) > 1e-9 {
+		t.Errorf("SpendSince(24h) = %f, want 1.75", total)
+	}
+
+	total, err = s.SpendSince(now.Add(-7 * 24 * time.Hour))
+	if err != nil {
+		t.Fatalf("SpendSince: %v", err)
+	}
+	if math.Abs(total-6.75) > 1e-9 {
+		t.Errorf("SpendSince(7d) = %f, want 6.75", total)
+	}
+}
+
+func TestSpendSinceEmpty(t *testing.T) {
+	s := newTestState(t)
+	total, err := s.SpendSince(time.Time{})
+	if err != nil {
+		t.Fatalf("SpendSince: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("SpendSince = %f, want 0", total)
+	}
+}
@@ -20,8 +20,10 @@ import (
 
 // State manages persistent nightshift state.
 type State struct {
-	mu sync.RWMutex
-	db *db.DB
+	mu    sync.RWMutex
+	db    *db.DB
+	coord Coordinator   // shares claims and history with other machines; nil when local-only
+	lease time.Duration // how long coordinated claims last
 }
 
 // RunRecord represents a single nightshift run for history tracking.
@@ -81,11 +83,13 @@ func (s *State) RecordProjectRun(projectPath string) {
 
 // RecordTaskRun marks a specific task type as having run for a project.
 func (s *State) RecordTaskRun(projectPath, taskType string) {
+	projectPath = normalizePath(projectPath)
+	now := time.Now()
+	defer s.recordRemoteRun(projectPath, taskType, now)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	projectPath = normalizePath(projectPath)
-	now := time.Now()
 	_, err := s.db.SQL().Exec(
 		`INSERT INTO task_history (project_path, task_type, last_run) VALUES (?, ?, ?)
 		 ON CONFLICT(project_path, task_type) DO UPDATE SET last_run = excluded.last_run`,
@@ -138,12 +142,21 @@ func (s *State) LastProjectRun(projectPath string) time.Time {
 	return lastRun.Time
 }
 
-// LastTaskRun returns when a task type was last run for a project.
+// LastTaskRun returns when a task type was last run for a project, on this
+// machine or, with a coordinator set, on any machine.
 func (s *State) LastTaskRun(projectPath, taskType string) time.Time {
+	projectPath = normalizePath(projectPath)
+	last := s.localLastTaskRun(projectPath, taskType)
+	if remote := s.remoteLastRun(projectPath, taskType); remote.After(last) {
+		return remote
+	}
+	return last
+}
+
+func (s *State) localLastTaskRun(projectPath, taskType string) time.Time {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	projectPath = normalizePath(projectPath)
 	row := s.db.SQL().QueryRow(`SELECT last_run FROM task_history WHERE project_path = ? AND task_type = ?`, projectPath, taskType)
 	var lastRun time.Time
 	if err := row.Scan(&lastRun); err != nil {
@@ -200,8 +213,13 @@ func (s *State) MarkAssigned(taskID, project, taskType string) {
 	}
 }
 
-// IsAssigned checks if a task is currently assigned.
+// IsAssigned checks if a task is currently assigned, here or, with a
+// coordinator set, on another machine.
 func (s *State) IsAssigned(taskID string) bool {
+	return s.isAssignedLocally(taskID) || s.remoteHolder(taskID) != ""
+}
+
+func (s *State) isAssignedLocally(taskID string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -232,8 +250,13 @@ func (s *State) GetAssigned(taskID string) (AssignedTask, bool) {
 	return task, true
 }
 
-// ClearAssigned removes a task from the assigned list.
+// ClearAssigned removes a task from the assigned list and releases its
+// coordinated claim.
 func (s *State) ClearAssigned(taskID string) {
+	if task, ok := s.GetAssigned(taskID); ok {
+		defer s.releaseRemote(task)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -549,6 +572,51 @@ func (s *State) GetTodayRuns() []RunRecord {
 	return result
 }
 
+// RunsSince returns runs that ended at or after since (or haven't ended),
+// newest first.
+func (s *State) RunsSince(since time.Time) []RunRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.SQL().Query(
+		`SELECT id, start_time, end_time, provider, project, tasks, tokens_used, status, error
+		 FROM run_history
+		 WHERE end_time IS NULL OR end_time >= ?
+		 ORDER BY start_time DESC`,
+		since,
+	)
+	if err != nil {
+		log.Printf("state: get runs since: %v", err)
+		return nil
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := make([]RunRecord, 0)
+	for rows.Next() {
+		var record RunRecord
+		var tasksJSON string
+		var endTime sql.NullTime
+		if err := rows.Scan(&record.ID, &record.StartTime, &endTime, &record.Provider, &record.Project, &tasksJSON, &record.TokensUsed, &record.Status, &record.Error); err != nil {
+			log.Printf("state: scan runs since: %v", err)
+			return result
+		}
+		if endTime.Valid {
+			record.EndTime = endTime.Time
+		}
+		if tasksJSON != "" {
+			if err := json.Unmarshal([]byte(tasksJSON), &record.Tasks); err != nil {
+				log.Printf("state: unmarshal tasks: %v", err)
+				return result
+			}
+		}
+		result = append(result, record)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("state: runs since rows: %v", err)
+	}
+	return result
+}
+
 // TodaySummary returns a summary of today's activity.
 type TodaySummary struct {
 	TotalRuns      int
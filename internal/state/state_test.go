@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/marcus/nightshift/internal/db"
+	"github.com/marcus/nightshift/internal/scheduler"
 )
 
 func TestNewNilDB(t *testing.T) {
@@ -320,6 +321,19 @@ func TestRunHistoryProviderPersisted(t *testing.T) {
 	}
 }
 
+func TestRunsSince(t *testing.T) {
+	s := newTestState(t)
+
+	now := time.Now()
+	s.AddRunRecord(RunRecord{ID: "old", StartTime: now.Add(-5 * time.Hour), EndTime: now.Add(-4 * time.Hour), Project: "/tmp/a", Status: "success"})
+	s.AddRunRecord(RunRecord{ID: "recent", StartTime: now.Add(-50 * time.Minute), EndTime: now.Add(-20 * time.Minute), Project: "/tmp/b", Status: "success"})
+
+	runs := s.RunsSince(now.Add(-time.Hour))
+	if len(runs) != 1 || runs[0].ID != "recent" {
+		t.Fatalf("RunsSince() = %+v, want only the recent run", runs)
+	}
+}
+
 func newTestState(t *testing.T) *State {
 	t.Helper()
 
@@ -341,3 +355,178 @@ func newTestState(t *testing.T) *State {
 	}
 	return s
 }
+
+func TestScheduleFires(t *testing.T) {
+	s := newTestState(t)
+
+	if _, ok, err := s.LastFire("nightly"); ok || err != nil {
+		t.Fatalf("LastFire() on empty log = %v, %v", ok, err)
+	}
+
+	base := time.Date(2026, 3, 1, 2, 0, 0, 0, time.Local)
+	fires := []scheduler.Fire{
+		{Schedule: "nightly", ScheduledAt: base, FiredAt: base, Status: scheduler.FireRan},
+		{Schedule: "nightly", ScheduledAt: base.AddDate(0, 0, 1), FiredAt: base.AddDate(0, 0, 1).Add(7 * time.Hour), Status: scheduler.FireMissed},
+		{Schedule: "nightly", ScheduledAt: base.AddDate(0, 0, 2), FiredAt: base.AddDate(0, 0, 2).Add(time.Hour), Status: scheduler.FireCaughtUp},
+		{Schedule: "weekend", ScheduledAt: base.AddDate(0, 0, 3), FiredAt: base.AddDate(0, 0, 3), Status: scheduler.FireRan},
+	}
+	for _, f := range fires {
+		if err := s.RecordFire(f); err != nil {
+			t.Fatalf("RecordFire: %v", err)
+		}
+	}
+
+	last, ok, err := s.LastFire("nightly")
+	if err != nil || !ok {
+		t.Fatalf("LastFire() = %v, %v", ok, err)
+	}
+	if last.Status != scheduler.FireCaughtUp || !last.ScheduledAt.Equal(base.AddDate(0, 0, 2)) {
+		t.Errorf("LastFire() = %+v", last)
+	}
+
+	recent, err := s.RecentFires("nightly", 5, scheduler.FireMissed, scheduler.FireCaughtUp)
+	if err != nil {
+		t.Fatalf("RecentFires: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Status != scheduler.FireCaughtUp {
+		t.Errorf("RecentFires() = %+v", recent)
+	}
+}
+
+func TestTaskRuns(t *testing.T) {
+	s := newTestState(t)
+
+	base := time.Date(2026, 3, 1, 2, 0, 0, 0, time.Local)
+	runs := []TaskRunRecord{
+		{Project: "/p", TaskType: "bug-finder", Provider: "claude", Status: "completed", Duration: 10 * time.Minute, StartedAt: base},
+		{Project: "/p", TaskType: "bug-finder", Provider: "claude", Status: "failed", Error: "timeout", Duration: 30 * time.Minute, StartedAt: base.Add(time.Hour)},
+		{Project: "/p", TaskType: "bug-finder", Provider: "codex", Status: "completed", Duration: 20 * time.Minute, StartedAt: base.Add(2 * time.Hour),
+			OutputType: "PR", OutputRef: "https://github.com/o/r/pull/1", Iterations: 2, Tokens: 12000},
+		{Project: "/q", TaskType: "lint-fix", Provider: "claude", Status: "completed", Duration: time.Minute, StartedAt: base.Add(3 * time.Hour)},
+	}
+	for _, r := range runs {
+		if _, err := s.AddTaskRun(r); err != nil {
+			t.Fatalf("AddTaskRun: %v", err)
+		}
+	}
+
+	got, err := s.TaskRuns(TaskRunFilter{Project: "/p", TaskType: "bug-finder"})
+	if err != nil {
+		t.Fatalf("TaskRuns: %v", err)
+	}
+	if len(got) != 3 || got[0].OutputRef != "https://github.com/o/r/pull/1" || got[0].Tokens != 12000 {
+		t.Fatalf("TaskRuns() = %+v", got)
+	}
+	if !got[0].EndedAt.Equal(base.Add(2*time.Hour + 20*time.Minute)) {
+		t.Errorf("EndedAt = %v, want start + duration", got[0].EndedAt)
+	}
+
+	got, _ = s.TaskRuns(TaskRunFilter{Status: "failed"})
+	if len(got) != 1 || got[0].Error != "timeout" {
+		t.Errorf("TaskRuns(failed) = %+v", got)
+	}
+	got, _ = s.TaskRuns(TaskRunFilter{Since: base.Add(90 * time.Minute), Limit: 1})
+	if len(got) != 1 || got[0].TaskType != "lint-fix" {
+		t.Errorf("TaskRuns(since, limit) = %+v", got)
+	}
+
+	durations, err := s.RecentTaskDurations(2)
+	if err != nil {
+		t.Fatalf("RecentTaskDurations: %v", err)
+	}
+	bugFinder := durations["bug-finder"]
+	if len(bugFinder) != 2 || bugFinder[0] != 20*time.Minute || bugFinder[1] != 30*time.Minute {
+		t.Errorf("bug-finder durations = %v, want newest two", bugFinder)
+	}
+	if len(durations["lint-fix"]) != 1 {
+		t.Errorf("lint-fix durations = %v", durations["lint-fix"])
+	}
+}
+
+// fakeCoordinator is a Coordinator shared by States standing in for
+// machines.
+type fakeCoordinator struct {
+	leases  map[string]string // project/task type -> holder
+	history map[string]time.Time
+}
+
+func newFakeCoordinator() *fakeCoordinator {
+	return &fakeCoordinator{leases: map[string]string{}, history: map[string]time.Time{}}
+}
+
+// machine returns the fake as seen from the named machine.
+func (f *fakeCoordinator) machine(name string) Coordinator {
+	return fakeMachine{f, name}
+}
+
+type fakeMachine struct {
+	*fakeCoordinator
+	name string
+}
+
+func (m fakeMachine) Claim(project, taskType string, _ time.Duration) (string, error) {
+	key := project + "/" + taskType
+	if h := m.leases[key]; h != "" && h != m.name {
+		return h, nil
+	}
+	m.leases[key] = m.name
+	return "", nil
+}
+
+func (m fakeMachine) Release(project, taskType string) error {
+	if key := project + "/" + taskType; m.leases[key] == m.name {
+		delete(m.leases, key)
+	}
+	return nil
+}
+
+func (m fakeMachine) Holder(project, taskType string) (string, error) {
+	if h := m.leases[project+"/"+taskType]; h != m.name {
+		return h, nil
+	}
+	return "", nil
+}
+
+func (m fakeMachine) RecordRun(project, taskType string, at time.Time) error {
+	m.history[project+"/"+taskType] = at
+	return nil
+}
+
+func (m fakeMachine) LastRun(project, taskType string) (time.Time, error) {
+	return m.history[project+"/"+taskType], nil
+}
+
+func TestCoordinatedClaimsAndHistory(t *testing.T) {
+	shared := newFakeCoordinator()
+	a, b := newTestState(t), newTestState(t)
+	a.SetCoordinator(shared.machine("a"), time.Hour)
+	b.SetCoordinator(shared.machine("b"), time.Hour)
+
+	project := "/path/to/project"
+	taskID := "lint-fix:" + project
+
+	if holder, err := a.ClaimTask(taskID, project, "lint-fix"); err != nil || holder != "" {
+		t.Fatalf("a.ClaimTask() = %q, %v; want claimed", holder, err)
+	}
+	if !b.IsAssigned(taskID) {
+		t.Error("b.IsAssigned() = false while a holds the task")
+	}
+	if holder, _ := b.ClaimTask(taskID, project, "lint-fix"); holder != "a" {
+		t.Errorf("b.ClaimTask() holder = %q, want a", holder)
+	}
+	if b.isAssignedLocally(taskID) {
+		t.Error("b marked a task held by a")
+	}
+
+	a.RecordTaskRun(project, "lint-fix")
+	a.ClearAssigned(taskID)
+	if b.IsAssigned(taskID) {
+		t.Error("b.IsAssigned() = true after a released the task")
+	}
+	if b.DaysSinceLastRun(project, "lint-fix") != 0 {
+		t.Errorf("b.DaysSinceLastRun() = %d, want 0 after a ran the task", b.DaysSinceLastRun(project, "lint-fix"))
+	}
+	if holder, _ := b.ClaimTask(taskID, project, "lint-fix"); holder != "" {
+		t.Errorf("b.ClaimTask() holder = %q after release, want claimed", holder)
+	}
+}
@@ -0,0 +1,175 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaskRunRecord is one task execution and its outcome.
+type TaskRunRecord struct {
+	ID         int64         `json:"id"`
+	Project    string        `json:"project"`
+	TaskType   string        `json:"task_type"`
+	Provider   string        `json:"provider,omitempty"`
+	Status     string        `json:"status"` // completed, failed or abandoned
+	Iterations int           `json:"iterations"`
+	Tokens     int64         `json:"tokens"` // input+output reported by the agent; 0 when unmeasured
+	Duration   time.Duration `json:"duration"`
+	OutputType string        `json:"output_type,omitempty"` // e.g. "PR"
+	OutputRef  string        `json:"output_ref,omitempty"`  // e.g. PR URL
+	Output     string        `json:"output,omitempty"`      // Agent's summary, handed to downstream tasks
+	Error      string        `json:"error,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	EndedAt    time.Time     `json:"ended_at"`
+
+	// PR outcome, filled in by PR syncing for runs that opened a PR
+	PRState      string    `json:"pr_state,omitempty"` // open, merged, closed or stale
+	PRReviews    int       `json:"pr_reviews,omitempty"`
+	PRResolvedAt time.Time `json:"pr_resolved_at,omitempty"`
+}
+
+// TaskRunFilter narrows TaskRuns. Zero fields match everything.
+type TaskRunFilter struct {
+	Project  string
+	TaskType string
+	Provider string
+	Status   string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// AddTaskRun persists a task execution and returns its ID.
+func (s *State) AddTaskRun(r TaskRunRecord) (int64, error) {
+	switch {
+	case r.StartedAt.IsZero() && r.EndedAt.IsZero():
+		r.EndedAt = time.Now()
+		r.StartedAt = r.EndedAt.Add(-r.Duration)
+	case r.StartedAt.IsZero():
+		r.StartedAt = r.EndedAt.Add(-r.Duration)
+	case r.EndedAt.IsZero():
+		r.EndedAt = r.StartedAt.Add(r.Duration)
+	}
+	if r.Project != "" {
+		r.Project = normalizePath(r.Project)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.SQL().Exec(
+		`INSERT INTO task_runs (project, task_type, provider, status, iterations, tokens, duration_ms,
+		 output_type, output_ref, output, error, started_at, ended_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Project, r.TaskType, r.Provider, r.Status, r.Iterations, r.Tokens, r.Duration.Milliseconds(),
+		r.OutputType, r.OutputRef, r.Output, r.Error, r.StartedAt, r.EndedAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert task run: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// TaskRuns returns task executions matching f, newest first.
+func (s *State) TaskRuns(f TaskRunFilter) ([]TaskRunRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var where []string
+	var args []any
+	if f.Project != "" {
+		where = append(where, "project = ?")
+		args = append(args, normalizePath(f.Project))
+	}
+	if f.TaskType != "" {
+		where = append(where, "task_type = ?")
+		args = append(args, f.TaskType)
+	}
+	if f.Provider != "" {
+		where = append(where, "provider = ?")
+		args = append(args, f.Provider)
+	}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, f.Until)
+	}
+
+	query := `SELECT ` + taskRunColumns + ` FROM task_runs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.SQL().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query task runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanTaskRuns(rows)
+}
+
+// taskRunColumns are the task_runs columns scanTaskRuns reads.
+const taskRunColumns = `id, project, task_type, provider, status, iterations, tokens, duration_ms,
+	 output_type, output_ref, output, error, started_at, ended_at, pr_state, pr_reviews, pr_resolved_at`
+
+func scanTaskRuns(rows *sql.Rows) ([]TaskRunRecord, error) {
+	var runs []TaskRunRecord
+	for rows.Next() {
+		var r TaskRunRecord
+		var ms int64
+		var resolved sql.NullTime
+		if err := rows.Scan(&r.ID, &r.Project, &r.TaskType, &r.Provider, &r.Status, &r.Iterations, &r.Tokens, &ms,
+			&r.OutputType, &r.OutputRef, &r.Output, &r.Error, &r.StartedAt, &r.EndedAt, &r.PRState, &r.PRReviews, &resolved); err != nil {
+			return nil, fmt.Errorf("scan task run: %w", err)
+		}
+		r.Duration = time.Duration(ms) * time.Millisecond
+		if resolved.Valid {
+			r.PRResolvedAt = resolved.Time
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// RecentTaskDurations returns, per task type, the durations of its last
+// perType executions, newest first.
+func (s *State) RecentTaskDurations(perType int) (map[string][]time.Duration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.SQL().Query(
+		`SELECT task_type, duration_ms FROM task_runs WHERE duration_ms > 0 ORDER BY started_at DESC, id DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query task durations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	durations := make(map[string][]time.Duration)
+	for rows.Next() {
+		var taskType string
+		var ms int64
+		if err := rows.Scan(&taskType, &ms); err != nil {
+			return nil, fmt.Errorf("scan task duration: %w", err)
+		}
+		if len(durations[taskType]) < perType {
+			durations[taskType] = append(durations[taskType], time.Duration(ms)*time.Millisecond)
+		}
+	}
+	return durations, rows.Err()
+}
@@ -76,6 +76,10 @@ type StatsResult struct {
 	TotalTokensUsed int `json:"total_tokens_used"`
 	AvgTokensPerRun int `json:"avg_tokens_per_run"`
 
+	// Dollar spend
+	TotalCostUSD  float64 `json:"total_cost_usd,omitempty"`
+	AvgCostPerRun float64 `json:"avg_cost_per_run,omitempty"`
+
 	// Budget
 	BudgetProjection  *BudgetProjection  `json:"budget_projection,omitempty"` // Deprecated: use BudgetProjections.
 	BudgetProjections []BudgetProjection `json:"budget_projections,omitempty"`
@@ -86,6 +90,9 @@ type StatsResult struct {
 
 	// Task types
 	TaskTypeBreakdown map[string]int `json:"task_type_breakdown,omitempty"`
+
+	// Models: input+output tokens agents reported per model
+	ModelBreakdown map[string]int64 `json:"model_breakdown,omitempty"`
 }
 
 // BudgetProjection estimates remaining budget days from snapshot data.
@@ -163,6 +170,7 @@ func (s *Stats) Compute() (*StatsResult, error) {
 		if result.TotalTokensUsed > 0 {
 			result.AvgTokensPerRun = result.TotalTokensUsed / result.TotalRuns
 		}
+		result.AvgCostPerRun = result.TotalCostUSD / float64(result.TotalRuns)
 	}
 
 	// Success rate
@@ -247,6 +255,7 @@ func (s *Stats) computeFromReports(result *StatsResult, reports []*reporting.Run
 		if r.UsedBudget > 0 {
 			result.TotalTokensUsed += r.UsedBudget
 		}
+		result.TotalCostUSD += r.CostUSD
 
 		for _, task := range r.Tasks {
 			switch task.Status {
@@ -280,6 +289,8 @@ func (s *Stats) computeFromReports(result *StatsResult, reports []*reporting.Run
 			if r.UsedBudget == 0 && task.TokensUsed > 0 {
 				result.TotalTokensUsed += task.TokensUsed
 			}
+
+			result.AddModelTokens(task.Models)
 		}
 	}
 
@@ -356,6 +367,57 @@ func (s *Stats) computeFromRunHistory(result *StatsResult) {
 			result.TotalTokensUsed = totalTokens
 		}
 	}
+
+	// Sum spend from the spend table if reports carried no cost
+	if result.TotalCostUSD == 0 {
+		row = sqlDB.QueryRow(`SELECT COALESCE(SUM(cost_usd), 0) FROM spend`)
+		var totalCost float64
+		if err := row.Scan(&totalCost); err != nil {
+			log.Printf("stats: sum spend: %v", err)
+		} else {
+			result.TotalCostUSD = totalCost
+		}
+	}
+
+	// Per-model tokens from the spend table if reports carried none
+	if len(result.ModelBreakdown) == 0 {
+		s.computeModelsFromSpend(sqlDB, result)
+	}
+}
+
+// computeModelsFromSpend fills ModelBreakdown from recorded spend rows.
+func (s *Stats) computeModelsFromSpend(sqlDB *sql.DB, result *StatsResult) {
+	rows, err := sqlDB.Query(`SELECT model, SUM(input_tokens + output_tokens) FROM spend WHERE model != '' GROUP BY model`)
+	if err != nil {
+		log.Printf("stats: spend by model: %v", err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	byModel := make(map[string]int64)
+	for rows.Next() {
+		var model string
+		var tokens int64
+		if err := rows.Scan(&model, &tokens); err != nil {
+			log.Printf("stats: scan spend by model: %v", err)
+			return
+		}
+		byModel[model] = tokens
+	}
+	result.AddModelTokens(byModel)
+}
+
+// AddModelTokens accumulates per-model token counts into the result.
+func (r *StatsResult) AddModelTokens(byModel map[string]int64) {
+	for model, tokens := range byModel {
+		if tokens <= 0 {
+			continue
+		}
+		if r.ModelBreakdown == nil {
+			r.ModelBreakdown = make(map[string]int64)
+		}
+		r.ModelBreakdown[model] += tokens
+	}
 }
 
 // computeFromProjects queries the projects table for project count and run counts.
@@ -253,6 +253,37 @@ func TestCompute_FromReports(t *testing.T) {
 	}
 }
 
+func TestCompute_CostAndModels(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 1, 15, 2, 0, 0, 0, time.UTC)
+
+	writeReport(t, dir, 0, &reporting.RunResults{
+		StartTime: now,
+		EndTime:   now.Add(time.Hour),
+		CostUSD:   1.5,
+		Tasks: []reporting.TaskResult{
+			{TaskType: "lint", Status: "completed", CostUSD: 1, Models: map[string]int64{"claude-opus-4-5": 300, "claude-haiku-4-5": 100}},
+			{TaskType: "docs", Status: "completed", CostUSD: 0.5, Models: map[string]int64{"claude-opus-4-5": 200}},
+		},
+	})
+	writeReport(t, dir, 1, &reporting.RunResults{
+		StartTime: now.Add(24 * time.Hour),
+		EndTime:   now.Add(25 * time.Hour),
+		CostUSD:   0.5,
+	})
+
+	result, err := New(nil, dir).Compute()
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	if result.TotalCostUSD != 2 || result.AvgCostPerRun != 1 {
+		t.Errorf("cost total/avg = %v/%v, want 2/1", result.TotalCostUSD, result.AvgCostPerRun)
+	}
+	if result.ModelBreakdown["claude-opus-4-5"] != 500 || result.ModelBreakdown["claude-haiku-4-5"] != 100 {
+		t.Errorf("model breakdown = %v", result.ModelBreakdown)
+	}
+}
+
 func TestCompute_ReportTokensFallback(t *testing.T) {
 	// When UsedBudget is 0, tokens should be summed from individual tasks
 	dir := t.TempDir()
@@ -0,0 +1,20 @@
+package tasks
+
+// minOutcomeSamples is how many decided PRs (merged, closed or stale) a
+// (project, task type) needs before its acceptance rate affects scoring.
+const minOutcomeSamples = 3
+
+// acceptanceBonus scores how well taskType's PRs are received in project:
+// up to +weight when all are merged, down to -weight when none are.
+func (s *Selector) acceptanceBonus(taskType TaskType, project string) float64 {
+	pr := s.cfg.Integrations.PROutcomes
+	if !pr.Enabled || pr.Weight == 0 || project == "" {
+		return 0
+	}
+	acceptance, err := s.state.PRAcceptance(project, string(taskType))
+	if err != nil || acceptance.Decided() < minOutcomeSamples {
+		return 0
+	}
+	rate, _ := acceptance.Rate()
+	return pr.Weight * (2*rate - 1)
+}
@@ -0,0 +1,371 @@
+package tasks
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxProfileFiles bounds the files DetectRepoProfile inspects. Larger repos
+// get a truncated profile, against which file and directory requirements
+// are assumed to be met.
+const maxProfileFiles = 50000
+
+// Requirements describe the repositories a task is useful in. Every
+// non-empty field must be met by at least one of its entries. Tasks without
+// requirements apply everywhere.
+type Requirements struct {
+	Languages    []string // Languages with source files in the repo, e.g. "go", "typescript"
+	Files        []string // Globs over repo-relative file paths; ** spans directories
+	Dirs         []string // Globs over repo-relative directory paths
+	Dependencies []string // Substrings of dependency names in go.mod, package.json, requirements.txt or Cargo.toml
+}
+
+// Requirements shared by built-in tasks.
+var (
+	uiRequirements = Requirements{
+		Files: []string{"**/*.tsx", "**/*.jsx", "**/*.vue", "**/*.svelte", "**/*.html", "**/*.erb", "**/*.cshtml"},
+	}
+	schemaRequirements = Requirements{
+		Files: []string{"**/*.sql", "**/schema.prisma", "**/schema.rb", "**/migrations/**"},
+	}
+	migrationRequirements = Requirements{
+		Dirs: []string{"**/migrations", "**/migrate", "**/alembic", "**/flyway"},
+	}
+	featureFlagRequirements = Requirements{
+		Dependencies: []string{"launchdarkly", "unleash", "flagsmith", "growthbook", "openfeature",
+			"open-feature", "configcat", "flipt", "splitio", "statsig"},
+	}
+	analyticsRequirements = Requirements{
+		Dependencies: []string{"segment/analytics", "segmentio/analytics", "analytics-node", "amplitude",
+			"mixpanel", "posthog", "rudderstack", "snowplow"},
+	}
+	apiSpecRequirements = Requirements{
+		Files: []string{"**/openapi*.yaml", "**/openapi*.yml", "**/openapi*.json", "**/swagger*.yaml",
+			"**/swagger*.yml", "**/swagger*.json", "**/*.proto", "**/*.graphql", "**/*.graphqls"},
+	}
+	changelogRequirements = Requirements{
+		Files: []string{"**/CHANGELOG*", "**/CHANGES.md", "**/HISTORY.md"},
+	}
+	releaseRequirements = Requirements{
+		Files: []string{".github/workflows/*release*", ".gitlab/ci/*release*", ".goreleaser.y*ml", ".releaserc*",
+			"release.config.*", "release-please-config.json", ".changeset/config.json"},
+	}
+	adrRequirements = Requirements{
+		Dirs: []string{"**/adr", "**/ADR", "**/adrs", "**/decisions"},
+	}
+	ciRequirements = Requirements{
+		Files: []string{".github/workflows/*", ".gitlab-ci.yml", ".circleci/*", ".buildkite/*", "Jenkinsfile",
+			"azure-pipelines.yml", ".travis.yml", "bitbucket-pipelines.yml"},
+	}
+)
+
+// IsZero reports whether r applies to every repository.
+func (r Requirements) IsZero() bool {
+	return len(r.Languages) == 0 && len(r.Files) == 0 && len(r.Dirs) == 0 && len(r.Dependencies) == 0
+}
+
+// Validate checks that the file and directory globs are well-formed.
+func (r Requirements) Validate() error {
+	for _, g := range slices.Concat(r.Files, r.Dirs) {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("invalid glob %q", g)
+		}
+	}
+	return nil
+}
+
+// Check reports whether the repository described by p meets r and, when it
+// does not, why. A nil profile, for a project that could not be inspected,
+// meets every requirement.
+func (r Requirements) Check(p *RepoProfile) (bool, string) {
+	if p == nil {
+		return true, ""
+	}
+	if len(r.Languages) > 0 && !slices.ContainsFunc(r.Languages, func(l string) bool { return p.Languages[strings.ToLower(l)] }) {
+		return false, "no " + strings.Join(r.Languages, ", ") + " code"
+	}
+	if len(r.Files) > 0 && !p.Truncated && !anyGlobMatches(r.Files, p.Files) {
+		return false, "no files matching " + strings.Join(r.Files, ", ")
+	}
+	if len(r.Dirs) > 0 && !p.Truncated && !anyGlobMatches(r.Dirs, p.Dirs) {
+		return false, "no directories matching " + strings.Join(r.Dirs, ", ")
+	}
+	if len(r.Dependencies) > 0 && !slices.ContainsFunc(r.Dependencies, p.hasDependency) {
+		return false, "no dependency on " + strings.Join(r.Dependencies, ", ")
+	}
+	return true, ""
+}
+
+// RepoProfile is what a repository contains, as far as task requirements go.
+type RepoProfile struct {
+	Languages    map[string]bool
+	Files        []string // Repo-relative, slash-separated
+	Dirs         []string // Repo-relative, slash-separated
+	Dependencies []string // Lowercased dependency names from manifests
+	Truncated    bool     // The walk stopped at maxProfileFiles
+}
+
+func (p *RepoProfile) hasDependency(substr string) bool {
+	substr = strings.ToLower(substr)
+	return slices.ContainsFunc(p.Dependencies, func(d string) bool { return strings.Contains(d, substr) })
+}
+
+// languageExtensions maps source file extensions to language names.
+var languageExtensions = map[string]string{
+	".go": "go", ".py": "python", ".rb": "ruby", ".rs": "rust", ".java": "java",
+	".kt": "kotlin", ".swift": "swift", ".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp",
+	".hpp": "cpp", ".cs": "csharp", ".php": "php", ".scala": "scala", ".ex": "elixir",
+	".exs": "elixir", ".js": "javascript", ".jsx": "javascript", ".mjs": "javascript",
+	".cjs": "javascript", ".ts": "typescript", ".tsx": "typescript", ".dart": "dart",
+}
+
+// skippedDirs are dependency, build and VCS directories, whose contents say
+// nothing about the repository's own code.
+var skippedDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, "node_modules": true, "vendor": true,
+	".venv": true, "venv": true, "__pycache__": true, "target": true, "dist": true,
+	"build": true, ".next": true, ".cache": true, ".terraform": true,
+}
+
+// DetectRepoProfile inspects the repository at root: the languages of its
+// source files, its file and directory paths, and the dependencies declared
+// in its manifests.
+func DetectRepoProfile(root string) (*RepoProfile, error) {
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	p := &RepoProfile{Languages: make(map[string]bool)}
+	errStop := errors.New("stop")
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped
+		}
+		rel, relErr := filepath.Rel(root, name)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			p.Dirs = append(p.Dirs, rel)
+			return nil
+		}
+		if len(p.Files) >= maxProfileFiles {
+			p.Truncated = true
+			return errStop
+		}
+		p.Files = append(p.Files, rel)
+		if lang, ok := languageExtensions[strings.ToLower(filepath.Ext(rel))]; ok {
+			p.Languages[lang] = true
+		}
+		p.Dependencies = append(p.Dependencies, manifestDependencies(name, d.Name())...)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+	return p, nil
+}
+
+// manifestDependencies returns the lowercased dependency names declared in
+// the manifest at file, or nil when file is not a known manifest.
+func manifestDependencies(file, base string) []string {
+	switch {
+	case base == "go.mod":
+		return goModDependencies(file)
+	case base == "package.json":
+		return packageJSONDependencies(file)
+	case base == "Cargo.toml":
+		return cargoDependencies(file)
+	case strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt"):
+		return requirementsDependencies(file)
+	}
+	return nil
+}
+
+func goModDependencies(file string) []string {
+	var deps []string
+	inRequire := false
+	scanLines(file, func(line string) {
+		switch {
+		case line == "require (":
+			inRequire = true
+		case inRequire && line == ")":
+			inRequire = false
+		case inRequire:
+			deps = appendFirstField(deps, line)
+		case strings.HasPrefix(line, "require "):
+			deps = appendFirstField(deps, strings.TrimPrefix(line, "require "))
+		}
+	})
+	return deps
+}
+
+func packageJSONDependencies(file string) []string {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	var deps []string
+	for _, m := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+		for name := range m {
+			deps = append(deps, strings.ToLower(name))
+		}
+	}
+	return deps
+}
+
+func cargoDependencies(file string) []string {
+	var deps []string
+	inDeps := false
+	scanLines(file, func(line string) {
+		if strings.HasPrefix(line, "[") {
+			inDeps = strings.Contains(line, "dependencies")
+			return
+		}
+		if name, _, ok := strings.Cut(line, "="); inDeps && ok {
+			deps = append(deps, strings.ToLower(strings.TrimSpace(name)))
+		}
+	})
+	return deps
+}
+
+func requirementsDependencies(file string) []string {
+	var deps []string
+	scanLines(file, func(line string) {
+		if strings.HasPrefix(line, "-") {
+			return // pip options such as -r other.txt
+		}
+		if i := strings.IndexAny(line, "=<>!~;[@ "); i >= 0 {
+			line = line[:i]
+		}
+		if line != "" {
+			deps = append(deps, strings.ToLower(line))
+		}
+	})
+	return deps
+}
+
+// scanLines calls fn with each trimmed, non-empty, non-comment line of file.
+func scanLines(file string, fn func(line string)) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		fn(line)
+	}
+}
+
+func appendFirstField(deps []string, line string) []string {
+	if fields := strings.Fields(line); len(fields) > 0 {
+		deps = append(deps, strings.ToLower(fields[0]))
+	}
+	return deps
+}
+
+func anyGlobMatches(patterns, names []string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if matchGlob(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against pattern. Segments match
+// as in path.Match; a ** segment matches any number of segments.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// profileSet caches detected repo profiles by project path. A nil entry
+// marks a project that could not be inspected.
+type profileSet map[string]*RepoProfile
+
+// repoProfile returns the profile of project, detecting it on first use.
+func (s *Selector) repoProfile(project string) *RepoProfile {
+	if p, ok := s.profiles[project]; ok {
+		return p
+	}
+	if s.profiles == nil {
+		s.profiles = make(profileSet)
+	}
+	p, err := DetectRepoProfile(project)
+	if err != nil {
+		p = nil
+	}
+	s.profiles[project] = p
+	return p
+}
+
+// Applicability reports whether def's requirements are met by project and,
+// when they are not, why.
+func (s *Selector) Applicability(def TaskDefinition, project string) (bool, string) {
+	if def.Requires.IsZero() || project == "" {
+		return true, ""
+	}
+	return def.Requires.Check(s.repoProfile(project))
+}
+
+// FilterApplicable returns tasks whose requirements project meets.
+func (s *Selector) FilterApplicable(tasks []TaskDefinition, project string) []TaskDefinition {
+	filtered := make([]TaskDefinition, 0, len(tasks))
+	for _, t := range tasks {
+		if ok, _ := s.Applicability(t, project); ok {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
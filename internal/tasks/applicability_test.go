@@ -0,0 +1,128 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.sql", "schema.sql", true},
+		{"**/*.sql", "db/sql/001_init.sql", true},
+		{"**/*.sql", "db/sql/readme.md", false},
+		{".github/workflows/*", ".github/workflows/ci.yml", true},
+		{".github/workflows/*", "docs/.github/workflows/ci.yml", false},
+		{"**/migrations/**", "app/migrations/0001.py", true},
+		{"**/migrations", "migrations", true},
+		{"Jenkinsfile", "ci/Jenkinsfile", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectRepoProfile(t *testing.T) {
+	root := t.TempDir()
+	writeTaskFile(t, root, "go.mod", "module example.com/app\n\nrequire (\n\tgithub.com/open-feature/go-sdk v1.0.0\n)\n")
+	writeTaskFile(t, filepath.Join(root, "web"), "package.json", `{"dependencies": {"react": "^18"}, "devDependencies": {"@Segment/analytics-next": "1"}}`)
+	writeTaskFile(t, filepath.Join(root, "web", "src"), "App.tsx", "")
+	writeTaskFile(t, filepath.Join(root, "db", "migrations"), "001_init.sql", "")
+	writeTaskFile(t, filepath.Join(root, "web", "node_modules", "launchdarkly"), "index.js", "")
+
+	p, err := DetectRepoProfile(root)
+	if err != nil {
+		t.Fatalf("DetectRepoProfile: %v", err)
+	}
+	if !p.Languages["typescript"] || p.Languages["javascript"] {
+		t.Errorf("Languages = %v, want typescript only (node_modules skipped)", p.Languages)
+	}
+	for _, dep := range []string{"github.com/open-feature/go-sdk", "react", "@segment/analytics-next"} {
+		if !p.hasDependency(dep) {
+			t.Errorf("dependency %q not detected in %v", dep, p.Dependencies)
+		}
+	}
+
+	tests := []struct {
+		req    Requirements
+		ok     bool
+		reason string
+	}{
+		{uiRequirements, true, ""},
+		{schemaRequirements, true, ""},
+		{migrationRequirements, true, ""},
+		{featureFlagRequirements, true, ""},
+		{analyticsRequirements, true, ""},
+		{apiSpecRequirements, false, "no files matching **/openapi*.yaml"},
+		{ciRequirements, false, "no files matching .github/workflows/*"},
+		{Requirements{Languages: []string{"Python", "Ruby"}}, false, "no Python, Ruby code"},
+		{Requirements{Dirs: []string{"db"}, Dependencies: []string{"django"}}, false, "no dependency on django"},
+	}
+	for _, tt := range tests {
+		ok, reason := tt.req.Check(p)
+		if ok != tt.ok || !strings.HasPrefix(reason, tt.reason) {
+			t.Errorf("Check(%+v) = %v, %q; want %v, %q", tt.req, ok, reason, tt.ok, tt.reason)
+		}
+	}
+}
+
+func TestFilterApplicable(t *testing.T) {
+	root := t.TempDir()
+	writeTaskFile(t, root, "main.go", "package main")
+
+	s := NewSelector(nil, nil)
+	defs := []TaskDefinition{
+		{Type: TaskLintFix},
+		{Type: TaskA11yLint, Requires: uiRequirements},
+		{Type: TaskMigrationRehearsal, Requires: migrationRequirements},
+	}
+	got := s.FilterApplicable(defs, root)
+	if len(got) != 1 || got[0].Type != TaskLintFix {
+		t.Errorf("FilterApplicable() = %v, want only lint-fix", got)
+	}
+	if ok, reason := s.Applicability(defs[1], root); ok || !strings.Contains(reason, "**/*.tsx") {
+		t.Errorf("Applicability(a11y-lint) = %v, %q", ok, reason)
+	}
+
+	// Projects that cannot be inspected keep every task
+	if got := s.FilterApplicable(defs, filepath.Join(root, "missing")); len(got) != len(defs) {
+		t.Errorf("missing project: %d tasks, want %d", len(got), len(defs))
+	}
+}
+
+func TestReleaseAndADRRequirements(t *testing.T) {
+	root := t.TempDir()
+	writeTaskFile(t, root, "main.go", "package main")
+	s := NewSelector(nil, nil)
+	for _, taskType := range []TaskType{TaskChangelogSynth, TaskReleaseNotes, TaskADRDraft} {
+		def, err := GetDefinition(taskType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := s.Applicability(def, root); ok {
+			t.Errorf("%s applies to a repo without a changelog, release workflow or ADRs", taskType)
+		}
+	}
+
+	root = t.TempDir()
+	for _, dir := range []string{".github/workflows", "docs/adr"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTaskFile(t, root, "CHANGELOG.md", "# Changelog")
+	writeTaskFile(t, root, ".github/workflows/release.yml", "on: push")
+	writeTaskFile(t, root, "docs/adr/0001-use-go.md", "# Use Go")
+	for _, taskType := range []TaskType{TaskChangelogSynth, TaskReleaseNotes, TaskADRDraft} {
+		def, _ := GetDefinition(taskType)
+		if ok, reason := s.Applicability(def, root); !ok {
+			t.Errorf("%s does not apply: %s", taskType, reason)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/marcus/nightshift/internal/state"
+)
+
+// maxUpstreamOutput bounds how much of an upstream task's output is handed
+// to a downstream prompt.
+const maxUpstreamOutput = 8000
+
+// Task chains: a task's After tasks must succeed in a project before it
+// runs there again, and its Consumes tasks must too, with their output
+// added to its prompt. "Succeed again" means a completed run since the
+// downstream task last ran, so each upstream result feeds it once.
+// Disabled upstream tasks are ignored.
+
+// Upstream returns the tasks def runs after: its After and Consumes tasks.
+func (d TaskDefinition) Upstream() []TaskType {
+	up := slices.Clone(d.After)
+	for _, t := range d.Consumes {
+		if !slices.Contains(up, t) {
+			up = append(up, t)
+		}
+	}
+	return up
+}
+
+// ValidateChains checks that every task's upstream tasks exist and that no
+// task depends on itself through its upstream tasks, for the global tasks
+// and for each project with its own task files.
+func ValidateChains() error {
+	if err := validateChains(AllDefinitions()); err != nil {
+		return err
+	}
+	var errs []error
+	for _, project := range slices.Sorted(maps.Keys(projectTaskFiles)) {
+		if err := validateChains(ProjectDefinitions(project)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", project, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateChains checks the chains among defs.
+func validateChains(defs []TaskDefinition) error {
+	byType := make(map[TaskType]TaskDefinition, len(defs))
+	for _, d := range defs {
+		byType[d.Type] = d
+	}
+	for _, d := range defs {
+		for _, up := range d.Upstream() {
+			if _, ok := byType[up]; !ok {
+				return fmt.Errorf("task %q runs after unknown task %q", d.Type, up)
+			}
+		}
+	}
+
+	// Depth-first search; a task reached again while on the path closes a cycle
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := make(map[TaskType]int, len(defs))
+	var path []TaskType
+	var visit func(t TaskType) error
+	visit = func(t TaskType) error {
+		switch marks[t] {
+		case visiting:
+			i := slices.Index(path, t)
+			cycle := make([]string, 0, len(path)-i+1)
+			for _, p := range append(path[i:], t) {
+				cycle = append(cycle, string(p))
+			}
+			return fmt.Errorf("task chain cycle: %s", strings.Join(cycle, " -> "))
+		case done:
+			return nil
+		}
+		marks[t] = visiting
+		path = append(path, t)
+		for _, up := range byType[t].Upstream() {
+			if err := visit(up); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[t] = done
+		return nil
+	}
+	for _, d := range defs {
+		if err := visit(d.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upstreamEnabled reports whether up would be selected in project at all,
+// so a chain does not wait on a task that never runs.
+func (s *Selector) upstreamEnabled(up TaskType, project string) bool {
+	def, err := ProjectDefinition(project, up)
+	if err != nil {
+		return false
+	}
+	if def.DisabledByDefault && !s.cfg.IsTaskExplicitlyEnabled(string(up)) {
+		return false
+	}
+	return s.cfg.IsTaskEnabled(string(up))
+}
+
+// freshUpstreamRun returns up's latest completed run in project if it
+// finished after def last ran there.
+func (s *Selector) freshUpstreamRun(def TaskDefinition, up TaskType, project string) (state.TaskRunRecord, bool) {
+	runs, err := s.state.TaskRuns(state.TaskRunFilter{
+		Project:  project,
+		TaskType: string(up),
+		Status:   "completed",
+		Limit:    1,
+	})
+	if err != nil || len(runs) == 0 {
+		return state.TaskRunRecord{}, false
+	}
+	if !runs[0].EndedAt.After(s.state.LastTaskRun(project, string(def.Type))) {
+		return state.TaskRunRecord{}, false
+	}
+	return runs[0], true
+}
+
+// pendingUpstream returns def's enabled upstream tasks without a fresh
+// successful run in project.
+func (s *Selector) pendingUpstream(def TaskDefinition, project string) []TaskType {
+	var pending []TaskType
+	for _, up := range def.Upstream() {
+		if !s.upstreamEnabled(up, project) {
+			continue
+		}
+		if _, ok := s.freshUpstreamRun(def, up, project); !ok {
+			pending = append(pending, up)
+		}
+	}
+	return pending
+}
+
+// ChainSkipReason returns why def cannot run yet in project, or "" when
+// its upstream tasks have all succeeded since it last ran.
+func (s *Selector) ChainSkipReason(def TaskDefinition, project string) string {
+	pending := s.pendingUpstream(def, project)
+	if len(pending) == 0 {
+		return ""
+	}
+	names := make([]string, len(pending))
+	for i, t := range pending {
+		names[i] = string(t)
+	}
+	return "waiting for " + strings.Join(names, ", ") + " to succeed"
+}
+
+// FilterChains returns tasks whose upstream tasks have all succeeded since
+// they last ran.
+func (s *Selector) FilterChains(tasks []TaskDefinition, project string) []TaskDefinition {
+	filtered := make([]TaskDefinition, 0, len(tasks))
+	for _, t := range tasks {
+		if len(t.Upstream()) == 0 || s.ChainSkipReason(t, project) == "" {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// waitsOnlyOn reports whether def is held back only by upstream tasks
+// already in picked, so it can follow them in the same run.
+func (s *Selector) waitsOnlyOn(def TaskDefinition, picked []ScoredTask, project string) bool {
+	isPicked := func(t TaskType) bool {
+		return slices.ContainsFunc(picked, func(st ScoredTask) bool { return st.Definition.Type == t })
+	}
+	if isPicked(def.Type) || len(def.Upstream()) == 0 {
+		return false
+	}
+	pending := s.pendingUpstream(def, project)
+	return len(pending) > 0 && !slices.ContainsFunc(pending, func(t TaskType) bool { return !isPicked(t) })
+}
+
+// UpstreamOutputs returns the latest successful runs of the tasks def
+// consumes in project, skipping those without output.
+func (s *Selector) UpstreamOutputs(def TaskDefinition, project string) []state.TaskRunRecord {
+	var runs []state.TaskRunRecord
+	for _, up := range def.Consumes {
+		if r, ok := s.freshUpstreamRun(def, up, project); ok && (r.Output != "" || r.OutputRef != "") {
+			runs = append(runs, r)
+		}
+	}
+	return runs
+}
+
+// ChainPrompt appends the output of upstream runs to a downstream task's
+// prompt.
+func ChainPrompt(description string, upstream []state.TaskRunRecord) string {
+	if len(upstream) == 0 {
+		return description
+	}
+	var b strings.Builder
+	b.WriteString(description)
+	for _, r := range upstream {
+		fmt.Fprintf(&b, "\n\n## Output of %s (%s)\n\n", r.TaskType, r.EndedAt.Format("2006-01-02 15:04"))
+		if r.OutputRef != "" {
+			fmt.Fprintf(&b, "%s: %s\n\n", r.OutputType, r.OutputRef)
+		}
+		out := r.Output
+		if len(out) > maxUpstreamOutput {
+			out = out[:maxUpstreamOutput] + "\n[truncated]"
+		}
+		b.WriteString(out)
+	}
+	b.WriteString("\n\nBase your work on the output above.")
+	return b.String()
+}
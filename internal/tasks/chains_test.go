@@ -0,0 +1,125 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/state"
+)
+
+func TestChainSkipReason(t *testing.T) {
+	sel, st := setupTestSelector(t)
+	project := "/test/project"
+	notes, _ := GetDefinition(TaskReleaseNotes)
+
+	if reason := sel.ChainSkipReason(notes, project); !strings.Contains(reason, string(TaskChangelogSynth)) {
+		t.Errorf("before upstream ran: reason = %q, want waiting for changelog-synth", reason)
+	}
+
+	// A failed upstream run does not unblock it
+	addRun(t, st, project, TaskChangelogSynth, "failed", "", time.Now().Add(-2*time.Hour))
+	if sel.ChainSkipReason(notes, project) == "" {
+		t.Error("failed upstream run unblocked release-notes")
+	}
+
+	addRun(t, st, project, TaskChangelogSynth, "completed", "## v1.2\n- Faster runs", time.Now().Add(-time.Hour))
+	if reason := sel.ChainSkipReason(notes, project); reason != "" {
+		t.Errorf("after upstream succeeded: reason = %q, want none", reason)
+	}
+	outputs := sel.UpstreamOutputs(notes, project)
+	if len(outputs) != 1 || !strings.Contains(ChainPrompt(notes.Description, outputs), "- Faster runs") {
+		t.Errorf("upstream output not handed to the prompt: %v", outputs)
+	}
+
+	// Once release-notes ran, it waits for the next changelog
+	st.RecordTaskRun(project, string(TaskReleaseNotes))
+	if sel.ChainSkipReason(notes, project) == "" {
+		t.Error("release-notes not waiting after consuming the upstream output")
+	}
+
+	// Disabled upstream tasks are not waited on
+	sel.cfg.Tasks.Disabled = []string{string(TaskChangelogSynth)}
+	if reason := sel.ChainSkipReason(notes, project); reason != "" {
+		t.Errorf("with changelog-synth disabled: reason = %q, want none", reason)
+	}
+}
+
+func TestSelectTopN_SchedulesChain(t *testing.T) {
+	sel, _ := setupTestSelector(t)
+	sel.cfg.Tasks.Enabled = []string{string(TaskChangelogSynth), string(TaskReleaseNotes), string(TaskLintFix)}
+	sel.cfg.Tasks.Priorities = map[string]int{string(TaskChangelogSynth): 10}
+	project := "/test/project"
+
+	got := sel.SelectTopN(1000000, project, 3)
+	if len(got) != 3 || got[0].Definition.Type != TaskChangelogSynth || got[1].Definition.Type != TaskReleaseNotes {
+		t.Fatalf("SelectTopN() = %v, want release-notes right after changelog-synth", taskTypesOf(got))
+	}
+
+	// Without room for the follower, it waits for a later run
+	if got := sel.SelectTopN(1000000, project, 1); len(got) != 1 || got[0].Definition.Type != TaskChangelogSynth {
+		t.Errorf("SelectTopN(1) = %v, want changelog-synth only", taskTypesOf(got))
+	}
+	_, max := mustDefinition(t, TaskChangelogSynth).EstimatedTokens()
+	if got := sel.SelectTopN(int64(max), project, 3); len(got) != 2 || got[1].Definition.Type == TaskReleaseNotes {
+		t.Errorf("SelectTopN(budget for one) = %v, want release-notes left out", taskTypesOf(got))
+	}
+}
+
+func TestValidateChains(t *testing.T) {
+	t.Cleanup(func() { ClearCustom() })
+	if err := ValidateChains(); err != nil {
+		t.Fatalf("built-in tasks: %v", err)
+	}
+
+	if err := RegisterCustom(TaskDefinition{Type: "a", Name: "A", After: []TaskType{"missing"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateChains(); err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("unknown upstream: err = %v", err)
+	}
+
+	ClearCustom()
+	for _, def := range []TaskDefinition{
+		{Type: "a", Name: "A", After: []TaskType{"b"}},
+		{Type: "b", Name: "B", Consumes: []TaskType{"a"}},
+	} {
+		if err := RegisterCustom(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ValidateChains(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("cycle: err = %v", err)
+	}
+}
+
+func addRun(t *testing.T, st *state.State, project string, taskType TaskType, status, output string, ended time.Time) {
+	t.Helper()
+	if _, err := st.AddTaskRun(state.TaskRunRecord{
+		Project:  project,
+		TaskType: string(taskType),
+		Status:   status,
+		Output:   output,
+		Duration: time.Minute,
+		EndedAt:  ended,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustDefinition(t *testing.T, taskType TaskType) TaskDefinition {
+	t.Helper()
+	def, err := GetDefinition(taskType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return def
+}
+
+func taskTypesOf(scored []ScoredTask) []TaskType {
+	types := make([]TaskType, len(scored))
+	for i, st := range scored {
+		types[i] = st.Definition.Type
+	}
+	return types
+}
@@ -0,0 +1,163 @@
+package tasks
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// minDurationSamples is how many recorded executions a task type needs
+// before its duration is predicted. Tasks with less history always run.
+const minDurationSamples = 3
+
+// P90 returns the 90th percentile (nearest rank) of samples, or 0 when
+// there are none.
+func P90(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	rank := (len(sorted)*9 + 9) / 10 // ceil(0.9 * n)
+	return sorted[rank-1]
+}
+
+// PredictDurations returns each task type's p90 duration from its recent
+// executions, skipping types with fewer than minDurationSamples.
+func PredictDurations(history map[string][]time.Duration) map[TaskType]time.Duration {
+	predicted := make(map[TaskType]time.Duration, len(history))
+	for taskType, samples := range history {
+		if len(samples) >= minDurationSamples {
+			predicted[TaskType(taskType)] = P90(samples)
+		}
+	}
+	return predicted
+}
+
+// deadlinePlan is the deadline set by SetDeadline.
+type deadlinePlan struct {
+	at        time.Time
+	durations map[TaskType]time.Duration
+	now       func() time.Time
+}
+
+// SetDeadline makes selection skip tasks whose predicted (p90) duration
+// exceeds the time left before deadline, e.g. the end of the schedule
+// window. A zero deadline disables the check.
+func (s *Selector) SetDeadline(deadline time.Time, durations map[TaskType]time.Duration) {
+	s.deadline.at = deadline
+	s.deadline.durations = durations
+}
+
+// SetNowFunc overrides the clock used to compute the time left before the
+// deadline (preview evaluates future runs).
+func (s *Selector) SetNowFunc(fn func() time.Time) {
+	s.deadline.now = fn
+}
+
+// PredictedDuration returns the p90 duration of taskType, if it has enough
+// history.
+func (s *Selector) PredictedDuration(taskType TaskType) (time.Duration, bool) {
+	d, ok := s.deadline.durations[taskType]
+	return d, ok
+}
+
+// LatestStart returns the latest time a task of taskType can start and
+// still finish before the deadline: the deadline less its predicted
+// duration, if it has one. Zero when no deadline is set.
+func (s *Selector) LatestStart(taskType TaskType) time.Time {
+	if s.deadline.at.IsZero() {
+		return time.Time{}
+	}
+	predicted, _ := s.PredictedDuration(taskType)
+	return s.deadline.at.Add(-predicted)
+}
+
+// timeLeft returns the time left before the deadline, or false when no
+// deadline is set.
+func (s *Selector) timeLeft() (time.Duration, bool) {
+	if s.deadline.at.IsZero() {
+		return 0, false
+	}
+	now := time.Now()
+	if s.deadline.now != nil {
+		now = s.deadline.now()
+	}
+	return s.deadline.at.Sub(now), true
+}
+
+// DeadlineSkipReason returns why a task of taskType would overrun the
+// deadline, or "" when it may start.
+func (s *Selector) DeadlineSkipReason(taskType TaskType) string {
+	left, ok := s.timeLeft()
+	if !ok {
+		return ""
+	}
+	predicted, ok := s.PredictedDuration(taskType)
+	if !ok || predicted <= left {
+		return ""
+	}
+	return fmt.Sprintf("p90 duration %s exceeds %s left in window",
+		predicted.Round(time.Minute), max(left, 0).Round(time.Minute))
+}
+
+// FilterByDeadline returns tasks predicted to finish before the deadline.
+func (s *Selector) FilterByDeadline(tasks []TaskDefinition) []TaskDefinition {
+	if _, ok := s.timeLeft(); !ok {
+		return tasks
+	}
+	filtered := make([]TaskDefinition, 0, len(tasks))
+	for _, t := range tasks {
+		if s.DeadlineSkipReason(t.Type) == "" {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// fitDeadline returns up to n of scored (best first) whose predicted
+// durations fit before the deadline when run one after another. A task that
+// no longer fits is passed over for later, shorter ones, so shorter tasks
+// are preferred as the deadline nears. Tasks in pool waiting on a picked
+// task follow it directly, so a chain completes within one run, when they
+// fit in n, the deadline and budget alongside the tasks before them.
+func (s *Selector) fitDeadline(scored []ScoredTask, pool []TaskDefinition, n int, budget int64, project string) []ScoredTask {
+	left, hasDeadline := s.timeLeft()
+	fits := func(def TaskDefinition) bool {
+		predicted, known := s.PredictedDuration(def.Type)
+		return !hasDeadline || !known || predicted <= left
+	}
+
+	picked := make([]ScoredTask, 0, n)
+	var spent int64
+	pick := func(st ScoredTask) {
+		predicted, _ := s.PredictedDuration(st.Definition.Type)
+		left -= predicted
+		_, max := st.Definition.EstimatedTokens()
+		spent += int64(max)
+		picked = append(picked, st)
+	}
+	for _, st := range scored {
+		if len(picked) >= n {
+			break
+		}
+		if !fits(st.Definition) {
+			continue
+		}
+		pick(st)
+		for added := true; added; {
+			added = false
+			for _, def := range pool {
+				if len(picked) >= n || !s.waitsOnlyOn(def, picked, project) {
+					continue
+				}
+				if _, max := def.EstimatedTokens(); spent+int64(max) > budget || !fits(def) {
+					continue
+				}
+				pick(ScoredTask{Definition: def, Score: s.ScoreTask(def.Type, project), Project: project})
+				added = true
+			}
+		}
+	}
+	return picked
+}
@@ -0,0 +1,88 @@
+package tasks
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Filter restricts task selection by category, type and cost tier.
+// Empty fields match every task.
+type Filter struct {
+	Categories []TaskCategory
+	Types      []TaskType
+	CostTiers  []CostTier
+
+	ExcludeCategories []TaskCategory // Applied after the fields above
+}
+
+// IsZero reports whether the filter matches every task.
+func (f Filter) IsZero() bool {
+	return len(f.Categories) == 0 && len(f.Types) == 0 && len(f.CostTiers) == 0 && len(f.ExcludeCategories) == 0
+}
+
+// Matches reports whether def passes the filter.
+func (f Filter) Matches(def TaskDefinition) bool {
+	if len(f.Categories) > 0 && !slices.Contains(f.Categories, def.Category) {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, def.Type) {
+		return false
+	}
+	if len(f.CostTiers) > 0 && !slices.Contains(f.CostTiers, def.CostTier) {
+		return false
+	}
+	if slices.Contains(f.ExcludeCategories, def.Category) {
+		return false
+	}
+	return true
+}
+
+// AnalysisOnly returns f narrowed to tasks that touch no code and open no
+// PRs: analysis, options and map.
+func (f Filter) AnalysisOnly() Filter {
+	f.ExcludeCategories = append(slices.Clone(f.ExcludeCategories), CategoryPR, CategorySafe, CategoryEmergency)
+	return f
+}
+
+// SetFilter restricts every selection made by s to tasks matching f.
+func (s *Selector) SetFilter(f Filter) {
+	s.filter = f
+}
+
+// ParseCategory parses a short category name (pr, analysis, options, safe,
+// map, emergency).
+func ParseCategory(name string) (TaskCategory, error) {
+	switch strings.ToLower(name) {
+	case "pr":
+		return CategoryPR, nil
+	case "analysis":
+		return CategoryAnalysis, nil
+	case "options":
+		return CategoryOptions, nil
+	case "safe":
+		return CategorySafe, nil
+	case "map":
+		return CategoryMap, nil
+	case "emergency":
+		return CategoryEmergency, nil
+	default:
+		return 0, fmt.Errorf("unknown category: %s (valid: pr, analysis, options, safe, map, emergency)", name)
+	}
+}
+
+// ParseCostTier parses a cost tier name (low, medium, high, veryhigh).
+func ParseCostTier(name string) (CostTier, error) {
+	switch strings.ToLower(name) {
+	case "low":
+		return CostLow, nil
+	case "medium":
+		return CostMedium, nil
+	case "high":
+		return CostHigh, nil
+	case "veryhigh":
+		return CostVeryHigh, nil
+	default:
+		return 0, fmt.Errorf("unknown cost tier: %s (valid: low, medium, high, veryhigh)", name)
+	}
+}
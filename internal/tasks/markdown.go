@@ -0,0 +1,477 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ProjectTasksDir is where a project keeps its own task files, relative to
+// the project root.
+const ProjectTasksDir = ".nightshift/tasks"
+
+var (
+	taskTypeRe  = regexp.MustCompile(`^[a-z0-9-]+$`)
+	yamlErrorRe = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+)
+
+// taskFiles holds the registered tasks that came from global markdown files.
+var taskFiles = map[TaskType]TaskFile{}
+
+// projectTaskFiles holds each project's own task files by project root,
+// then type. They stay out of the registry so that two projects can define
+// the same type; each project sees only its own.
+var projectTaskFiles = map[string]map[TaskType]TaskFile{}
+
+// TaskFile is a task defined in a markdown file: YAML frontmatter with the
+// task's metadata, then the prompt as the markdown body.
+type TaskFile struct {
+	Definition TaskDefinition
+	Path       string // Source file
+	Project    string // Project root for files in its ProjectTasksDir; "" for global tasks
+	typeLine   int    // Line of the type key, for duplicate errors
+}
+
+// TaskFileError is an invalid task file, pointing at the offending line.
+type TaskFileError struct {
+	Path string
+	Line int // 1-based; 0 when the error concerns the whole file
+	Err  error
+}
+
+func (e *TaskFileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *TaskFileError) Unwrap() error {
+	return e.Err
+}
+
+// LoadTaskFiles loads the task files in globalDir and in each project's
+// ProjectTasksDir. Missing directories are skipped. Every invalid file is
+// reported, joined into one error.
+func LoadTaskFiles(globalDir string, projects []string) ([]TaskFile, error) {
+	files, err := LoadTaskDir(globalDir, "")
+	errs := []error{err}
+	for _, project := range projects {
+		projectFiles, err := LoadTaskDir(filepath.Join(project, ProjectTasksDir), project)
+		files = append(files, projectFiles...)
+		errs = append(errs, err)
+	}
+	return files, errors.Join(errs...)
+}
+
+// LoadTaskDir parses the *.md files in dir, in name order. project is the
+// project root the tasks are scoped to, or "" for global tasks.
+func LoadTaskDir(dir, project string) ([]TaskFile, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read task dir: %w", err)
+	}
+
+	var files []TaskFile
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".md" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, &TaskFileError{Path: path, Err: err})
+			continue
+		}
+		f, err := ParseTaskFile(path, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f.Project = project
+		files = append(files, f)
+	}
+	return files, errors.Join(errs...)
+}
+
+// ParseTaskFile parses a markdown task file. The frontmatter is optional;
+// the type defaults to the file name without .md, the name to the type,
+// and the remaining fields as for custom tasks in config.
+func ParseTaskFile(path string, data []byte) (TaskFile, error) {
+	fail := func(line int, format string, args ...any) (TaskFile, error) {
+		return TaskFile{}, &TaskFileError{Path: path, Line: line, Err: fmt.Errorf(format, args...)}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var frontmatter []string
+	bodyStart := 0 // index of the first body line
+	if strings.TrimSpace(lines[0]) == "---" {
+		end := slices.IndexFunc(lines[1:], func(l string) bool { return strings.TrimSpace(l) == "---" })
+		if end < 0 {
+			return fail(1, "frontmatter is not closed with ---")
+		}
+		frontmatter = lines[1 : end+1]
+		bodyStart = end + 2
+	}
+
+	f := TaskFile{Path: path}
+	def := TaskDefinition{
+		Type:      TaskType(strings.TrimSuffix(filepath.Base(path), ".md")),
+		Category:  CategoryAnalysis,
+		CostTier:  CostMedium,
+		RiskLevel: RiskLow,
+	}
+	var interval time.Duration
+
+	fields, err := parseFrontmatter(frontmatter)
+	if err != nil {
+		var ferr *TaskFileError
+		if errors.As(err, &ferr) {
+			ferr.Path = path
+		}
+		return TaskFile{}, err
+	}
+	for _, fld := range fields {
+		switch fld.key {
+		case "requires":
+			if def.Requires, err = parseRequires(fld.node); err != nil {
+				return fail(fld.line, "%v", err)
+			}
+			continue
+		case "pre_commands":
+			if def.PreCommands, err = parsePreCommands(fld.node); err != nil {
+				return fail(fld.line, "%v", err)
+			}
+			continue
+		case "after", "consumes":
+			types, err := parseTaskTypeList(fld.node)
+			if err != nil {
+				return fail(fld.line, "%s: %v", fld.key, err)
+			}
+			if fld.key == "after" {
+				def.After = types
+			} else {
+				def.Consumes = types
+			}
+			continue
+		}
+		if fld.node.Kind != yaml.ScalarNode {
+			return fail(fld.line, "%s must be a single value", fld.key)
+		}
+		switch fld.key {
+		case "type":
+			def.Type = TaskType(fld.value)
+			f.typeLine = fld.line
+		case "name":
+			def.Name = fld.value
+		case "category":
+			if def.Category, err = ParseCategory(fld.value); err != nil {
+				return fail(fld.line, "%v", err)
+			}
+		case "cost_tier":
+			// Config spells the top tier very-high
+			if def.CostTier, err = ParseCostTier(strings.ReplaceAll(fld.value, "-", "")); err != nil {
+				return fail(fld.line, "%v", err)
+			}
+		case "risk_level":
+			if def.RiskLevel, err = parseRiskLevel(fld.value); err != nil {
+				return fail(fld.line, "%v", err)
+			}
+		case "interval":
+			if interval, err = time.ParseDuration(fld.value); err != nil || interval <= 0 {
+				return fail(fld.line, "invalid interval %q", fld.value)
+			}
+		default:
+			return fail(fld.line, "unknown field %q (valid: type, name, category, cost_tier, risk_level, interval, requires, after, consumes, pre_commands)", fld.key)
+		}
+	}
+
+	if !taskTypeRe.MatchString(string(def.Type)) {
+		return fail(f.typeLine, "type %q must match [a-z0-9-]+", def.Type)
+	}
+	if def.Name == "" {
+		def.Name = string(def.Type)
+	}
+	if bodyStart < len(lines) {
+		def.Description = strings.TrimSpace(strings.Join(lines[bodyStart:], "\n"))
+	}
+	if def.Description == "" {
+		return fail(bodyStart+1, "prompt is empty: write it below the frontmatter")
+	}
+	def.DefaultInterval = DefaultIntervalForCategory(def.Category)
+	if interval > 0 {
+		def.DefaultInterval = interval
+	}
+	f.Definition = def
+	return f, nil
+}
+
+// frontmatterField is a key: value pair of the frontmatter. line is the
+// file line of the value.
+type frontmatterField struct {
+	key, value string
+	node       *yaml.Node
+	line       int
+}
+
+// parseFrontmatter parses frontmatter lines, which start on line 2 of the
+// file, into fields.
+func parseFrontmatter(lines []string) ([]frontmatterField, error) {
+	const offset = 1 // frontmatter line 1 is file line 2
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &doc); err != nil {
+		if m := yamlErrorRe.FindStringSubmatch(err.Error()); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return nil, &TaskFileError{Line: n + offset, Err: errors.New(m[2])}
+		}
+		return nil, &TaskFileError{Line: 1 + offset, Err: err}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, &TaskFileError{Line: mapping.Line + offset, Err: errors.New("frontmatter must be key: value pairs")}
+	}
+
+	fields := make([]frontmatterField, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		fields = append(fields, frontmatterField{key: key.Value, value: strings.TrimSpace(value.Value), node: value, line: value.Line + offset})
+	}
+	return fields, nil
+}
+
+// parseRequires decodes the requires mapping of lists, e.g.
+// requires: {files: ["**/*.sql"]}.
+func parseRequires(node *yaml.Node) (Requirements, error) {
+	var req struct {
+		Languages    []string `yaml:"languages"`
+		Files        []string `yaml:"files"`
+		Dirs         []string `yaml:"dirs"`
+		Dependencies []string `yaml:"dependencies"`
+	}
+	if node.Kind != yaml.MappingNode {
+		return Requirements{}, errors.New("requires must map languages, files, dirs or dependencies to lists")
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "languages", "files", "dirs", "dependencies":
+		default:
+			return Requirements{}, fmt.Errorf("unknown requires field %q (valid: languages, files, dirs, dependencies)", key)
+		}
+	}
+	if err := node.Decode(&req); err != nil {
+		return Requirements{}, fmt.Errorf("invalid requires: %v", err)
+	}
+	r := Requirements{Languages: req.Languages, Files: req.Files, Dirs: req.Dirs, Dependencies: req.Dependencies}
+	return r, r.Validate()
+}
+
+// parsePreCommands decodes the pre_commands list of mappings, e.g.
+// pre_commands: [{command: "npm run lint", skip_if: clean}].
+func parsePreCommands(node *yaml.Node) ([]PreCommand, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, errors.New("pre_commands must be a list of commands")
+	}
+	var pre []PreCommand
+	for _, item := range node.Content {
+		var c struct {
+			Name      string    `yaml:"name"`
+			Command   string    `yaml:"command"`
+			Timeout   string    `yaml:"timeout"`
+			MaxOutput int       `yaml:"max_output"`
+			SkipIf    string    `yaml:"skip_if"`
+			Requires  yaml.Node `yaml:"requires"`
+		}
+		if item.Kind != yaml.MappingNode {
+			return nil, errors.New("pre_commands entries must map name, command, timeout, max_output, skip_if or requires")
+		}
+		for i := 0; i < len(item.Content); i += 2 {
+			switch key := item.Content[i].Value; key {
+			case "name", "command", "timeout", "max_output", "skip_if", "requires":
+			default:
+				return nil, fmt.Errorf("unknown pre_commands field %q (valid: name, command, timeout, max_output, skip_if, requires)", key)
+			}
+		}
+		if err := item.Decode(&c); err != nil {
+			return nil, fmt.Errorf("invalid pre_commands entry: %v", err)
+		}
+		p := PreCommand{Name: c.Name, Command: c.Command, MaxOutput: c.MaxOutput, SkipIf: c.SkipIf}
+		if c.Timeout != "" {
+			d, err := time.ParseDuration(c.Timeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid pre-command timeout %q", c.Timeout)
+			}
+			p.Timeout = d
+		}
+		if c.Requires.Kind != 0 {
+			req, err := parseRequires(&c.Requires)
+			if err != nil {
+				return nil, err
+			}
+			p.Requires = req
+		}
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		pre = append(pre, p)
+	}
+	return pre, nil
+}
+
+// parseTaskTypeList decodes a task type or a list of them.
+func parseTaskTypeList(node *yaml.Node) ([]TaskType, error) {
+	var names []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		names = []string{node.Value}
+	case yaml.SequenceNode:
+		if err := node.Decode(&names); err != nil {
+			return nil, errors.New("must be a task type or a list of task types")
+		}
+	default:
+		return nil, errors.New("must be a task type or a list of task types")
+	}
+	types := make([]TaskType, 0, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if !taskTypeRe.MatchString(n) {
+			return nil, fmt.Errorf("invalid task type %q", n)
+		}
+		types = append(types, TaskType(n))
+	}
+	return types, nil
+}
+
+// parseRiskLevel parses a risk level name (low, medium, high).
+func parseRiskLevel(name string) (RiskLevel, error) {
+	switch strings.ToLower(name) {
+	case "low":
+		return RiskLow, nil
+	case "medium":
+		return RiskMedium, nil
+	case "high":
+		return RiskHigh, nil
+	default:
+		return 0, fmt.Errorf("unknown risk level: %s (valid: low, medium, high)", name)
+	}
+}
+
+// RegisterTaskFiles registers the tasks defined in files: global files as
+// custom tasks, project files for their project only. If any registration
+// fails, the tasks registered by this call are rolled back.
+func RegisterTaskFiles(files []TaskFile) error {
+	var registered []TaskFile
+	for _, f := range files {
+		if err := registerTaskFile(f); err != nil {
+			for _, r := range registered {
+				unregisterTaskFile(r)
+			}
+			return err
+		}
+		registered = append(registered, f)
+	}
+	return nil
+}
+
+// registerTaskFile registers one task file. A project file may not reuse a
+// registered type or a type its project already defines.
+func registerTaskFile(f TaskFile) error {
+	t := f.Definition.Type
+	dup := func(other TaskFile) error {
+		err := fmt.Errorf("task type %q already defined", t)
+		if other.Path != "" {
+			err = fmt.Errorf("task type %q already defined in %s", t, other.Path)
+		}
+		return &TaskFileError{Path: f.Path, Line: f.typeLine, Err: err}
+	}
+
+	if f.Project == "" {
+		if err := RegisterCustom(f.Definition); err != nil {
+			return dup(taskFiles[t])
+		}
+		taskFiles[t] = f
+		return nil
+	}
+
+	project := filepath.Clean(f.Project)
+	if other, ok := projectTaskFiles[project][t]; ok {
+		return dup(other)
+	}
+	if _, ok := registry[t]; ok {
+		return dup(taskFiles[t])
+	}
+	if projectTaskFiles[project] == nil {
+		projectTaskFiles[project] = map[TaskType]TaskFile{}
+	}
+	projectTaskFiles[project][t] = f
+	return nil
+}
+
+// unregisterTaskFile removes a task registered by registerTaskFile.
+func unregisterTaskFile(f TaskFile) {
+	if f.Project == "" {
+		UnregisterCustom(f.Definition.Type)
+		return
+	}
+	delete(projectTaskFiles[filepath.Clean(f.Project)], f.Definition.Type)
+}
+
+// ProjectDefinition returns the definition of taskType in project: the
+// project's own task file if it has one, else the registered task.
+func ProjectDefinition(project string, taskType TaskType) (TaskDefinition, error) {
+	if f, ok := projectTaskFiles[filepath.Clean(project)][taskType]; ok {
+		return f.Definition, nil
+	}
+	return GetDefinition(taskType)
+}
+
+// ProjectDefinitions returns the registered task definitions plus
+// project's own task files.
+func ProjectDefinitions(project string) []TaskDefinition {
+	defs := AllDefinitions()
+	for _, f := range projectTaskFiles[filepath.Clean(project)] {
+		defs = append(defs, f.Definition)
+	}
+	return defs
+}
+
+// ProjectDefinitionsSorted returns ProjectDefinitions in the order of
+// AllDefinitionsSorted.
+func ProjectDefinitionsSorted(project string) []TaskDefinition {
+	defs := ProjectDefinitions(project)
+	sortDefinitions(defs)
+	return defs
+}
+
+// isProjectTask reports whether taskType comes from one of project's own
+// task files rather than built-ins, config or the user's task directory.
+func isProjectTask(project string, taskType TaskType) bool {
+	_, ok := projectTaskFiles[filepath.Clean(project)][taskType]
+	return ok
+}
+
+// SourceFile returns the markdown file a task was loaded from, or "".
+// project selects which project's task files are considered.
+func SourceFile(project string, taskType TaskType) string {
+	if f, ok := projectTaskFiles[filepath.Clean(project)][taskType]; ok {
+		return f.Path
+	}
+	return taskFiles[taskType].Path
+}
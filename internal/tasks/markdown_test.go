@@ -0,0 +1,241 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTaskFile(t *testing.T) {
+	data := `---
+type: api-review
+name: API Review
+category: pr
+cost_tier: very-high
+risk_level: medium
+interval: 48h
+requires:
+  languages: [go]
+  files:
+    - api/**/*.proto
+consumes: [semantic-diff]
+after: doc-drift
+pre_commands:
+  - name: buf breaking
+    command: buf breaking --against .git#branch=main
+    timeout: 5m
+    skip_if: clean
+---
+
+# Review the public API
+
+Look for breaking changes.
+`
+	f, err := ParseTaskFile("/tasks/review.md", []byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def := f.Definition
+	if def.Type != "api-review" || def.Name != "API Review" {
+		t.Errorf("type/name = %q/%q, want api-review/API Review", def.Type, def.Name)
+	}
+	if def.Category != CategoryPR || def.CostTier != CostVeryHigh || def.RiskLevel != RiskMedium {
+		t.Errorf("category/cost/risk = %v/%v/%v", def.Category, def.CostTier, def.RiskLevel)
+	}
+	if def.DefaultInterval != 48*time.Hour {
+		t.Errorf("DefaultInterval = %v, want 48h", def.DefaultInterval)
+	}
+	if len(def.Consumes) != 1 || def.Consumes[0] != TaskSemanticDiff || len(def.After) != 1 || def.After[0] != TaskDocDrift {
+		t.Errorf("Consumes/After = %v/%v", def.Consumes, def.After)
+	}
+	if len(def.Requires.Files) != 1 || def.Requires.Files[0] != "api/**/*.proto" || def.Requires.Languages[0] != "go" {
+		t.Errorf("Requires = %+v", def.Requires)
+	}
+	if len(def.PreCommands) != 1 || def.PreCommands[0].Label() != "buf breaking" || def.PreCommands[0].Timeout != 5*time.Minute || def.PreCommands[0].SkipIf != PreSkipClean {
+		t.Errorf("PreCommands = %+v", def.PreCommands)
+	}
+	if want := "# Review the public API\n\nLook for breaking changes."; def.Description != want {
+		t.Errorf("Description = %q, want %q", def.Description, want)
+	}
+}
+
+func TestParseTaskFile_Defaults(t *testing.T) {
+	f, err := ParseTaskFile("/tasks/dead-flags.md", []byte("Remove feature flags that are always on.\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def := f.Definition
+	if def.Type != "dead-flags" || def.Name != "dead-flags" {
+		t.Errorf("type/name = %q/%q, want the file name", def.Type, def.Name)
+	}
+	if def.Category != CategoryAnalysis || def.DefaultInterval != DefaultIntervalForCategory(CategoryAnalysis) {
+		t.Errorf("category/interval = %v/%v, want analysis defaults", def.Category, def.DefaultInterval)
+	}
+}
+
+func TestParseTaskFile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+		want string
+	}{
+		{"unclosed", "---\ntype: x\n", 1, "not closed"},
+		{"bad category", "---\ntype: x\ncategory: docs\n---\nprompt", 3, "unknown category"},
+		{"bad interval", "---\n\ninterval: 2d\n---\nprompt", 3, "invalid interval"},
+		{"unknown field", "---\ncost: low\n---\nprompt", 2, `unknown field "cost"`},
+		{"bad type", "---\ntype: My Task\n---\nprompt", 2, "must match"},
+		{"yaml syntax", "---\nname: a\n  b: c\n---\nprompt", 3, "mapping values"},
+		{"list value", "---\nname:\n  - a\n---\nprompt", 3, "single value"},
+		{"empty prompt", "---\nname: a\n---\n\n", 4, "prompt is empty"},
+		{"bad upstream", "---\nafter: [Doc Drift]\n---\nprompt", 2, "invalid task type"},
+		{"requires list", "---\nrequires:\n  - go\n---\nprompt", 3, "requires must map"},
+		{"requires field", "---\nrequires:\n  langs: [go]\n---\nprompt", 3, `unknown requires field "langs"`},
+		{"requires glob", "---\nrequires:\n  files: [\"[\"]\n---\nprompt", 3, "invalid glob"},
+		{"pre_commands map", "---\npre_commands:\n  command: make lint\n---\nprompt", 3, "must be a list"},
+		{"pre_commands skip", "---\npre_commands:\n  - command: make lint\n    skip_if: never\n---\nprompt", 3, `unknown skip_if "never"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTaskFile("task.md", []byte(tt.data))
+			var ferr *TaskFileError
+			if !errors.As(err, &ferr) {
+				t.Fatalf("expected TaskFileError, got %v", err)
+			}
+			if ferr.Path != "task.md" || ferr.Line != tt.line {
+				t.Errorf("error at %s:%d, want task.md:%d (%v)", ferr.Path, ferr.Line, tt.line, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAndRegisterTaskFiles(t *testing.T) {
+	t.Cleanup(func() { ClearCustom() })
+
+	global := t.TempDir()
+	project := t.TempDir()
+	other := t.TempDir()
+	writeTaskFile(t, global, "shared-audit.md", "Audit everything.")
+	writeTaskFile(t, global, "notes.txt", "not a task")
+	writeTaskFile(t, filepath.Join(project, ProjectTasksDir), "local-check.md", "---\ncategory: safe\n---\nCheck locally.")
+
+	files, err := LoadTaskFiles(global, []string{project, other})
+	if err != nil {
+		t.Fatalf("LoadTaskFiles: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("loaded %d files, want 2", len(files))
+	}
+	if err := RegisterTaskFiles(files); err != nil {
+		t.Fatalf("RegisterTaskFiles: %v", err)
+	}
+	if SourceFile(project, "local-check") != filepath.Join(project, ProjectTasksDir, "local-check.md") {
+		t.Errorf("local-check not registered from its file (source %q)", SourceFile(project, "local-check"))
+	}
+	if _, err := ProjectDefinition(project, "local-check"); err != nil {
+		t.Errorf("ProjectDefinition(own project): %v", err)
+	}
+	if _, err := ProjectDefinition(other, "local-check"); err == nil {
+		t.Error("local-check visible from another project")
+	}
+	if got := len(ProjectDefinitions(project)) - len(ProjectDefinitions(other)); got != 1 {
+		t.Errorf("own project has %d more tasks than another, want 1", got)
+	}
+
+	// A second definition of a registered type points at both files
+	dup := TaskFile{Definition: TaskDefinition{Type: "shared-audit"}, Path: "/other/shared-audit.md", typeLine: 2}
+	err = RegisterTaskFiles([]TaskFile{dup})
+	if err == nil || !strings.Contains(err.Error(), "/other/shared-audit.md:2:") || !strings.Contains(err.Error(), "shared-audit.md") {
+		t.Errorf("duplicate error = %v", err)
+	}
+
+	ClearCustom()
+	if SourceFile(project, "local-check") != "" {
+		t.Error("ClearCustom kept the task file")
+	}
+}
+
+func TestRegisterTaskFiles_SameTypeInTwoProjects(t *testing.T) {
+	t.Cleanup(func() { ClearCustom() })
+
+	a := t.TempDir()
+	b := t.TempDir()
+	writeTaskFile(t, filepath.Join(a, ProjectTasksDir), "triage.md", "---\nname: Triage A\n---\nTriage A.")
+	writeTaskFile(t, filepath.Join(b, ProjectTasksDir), "triage.md", "---\nname: Triage B\nafter: [lint-fix]\n---\nTriage B.")
+
+	files, err := LoadTaskFiles("", []string{a, b})
+	if err != nil {
+		t.Fatalf("LoadTaskFiles: %v", err)
+	}
+	if err := RegisterTaskFiles(files); err != nil {
+		t.Fatalf("RegisterTaskFiles: %v", err)
+	}
+	if err := ValidateChains(); err != nil {
+		t.Errorf("ValidateChains: %v", err)
+	}
+
+	for project, want := range map[string]string{a: "Triage A", b: "Triage B"} {
+		def, err := ProjectDefinition(project, "triage")
+		if err != nil || def.Name != want {
+			t.Errorf("ProjectDefinition(%s) = %q, %v; want %q", project, def.Name, err, want)
+		}
+		var names []string
+		for _, d := range ProjectDefinitions(project) {
+			if d.Type == "triage" {
+				names = append(names, d.Name)
+			}
+		}
+		if len(names) != 1 || names[0] != want {
+			t.Errorf("ProjectDefinitions(%s) triage = %v, want [%s]", project, names, want)
+		}
+	}
+	if _, err := GetDefinition("triage"); err == nil {
+		t.Error("project task registered globally")
+	}
+
+	// Within one project a type is still defined once
+	dup := TaskFile{Definition: TaskDefinition{Type: "triage"}, Path: "/dup/triage.md", Project: a}
+	if err := RegisterTaskFiles([]TaskFile{dup}); err == nil || !strings.Contains(err.Error(), filepath.Join(a, ProjectTasksDir, "triage.md")) {
+		t.Errorf("duplicate in one project: %v", err)
+	}
+	builtin := TaskFile{Definition: TaskDefinition{Type: TaskLintFix}, Path: "/dup/lint-fix.md", Project: b}
+	if err := RegisterTaskFiles([]TaskFile{builtin}); err == nil {
+		t.Error("project file redefined a built-in task")
+	}
+}
+
+func TestLoadTaskFiles_ReportsEveryInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFile(t, dir, "a.md", "---\ncategory: nope\n---\nprompt")
+	writeTaskFile(t, dir, "b.md", "---\nrisk_level: extreme\n---\nprompt")
+	writeTaskFile(t, dir, "c.md", "fine")
+
+	files, err := LoadTaskFiles(dir, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"a.md:2:", "b.md:2:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not point at %s", err, want)
+		}
+	}
+	if len(files) != 1 {
+		t.Errorf("loaded %d valid files, want 1", len(files))
+	}
+}
+
+func writeTaskFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,41 @@
+package tasks
+
+import "github.com/marcus/nightshift/internal/config"
+
+// openPRSet maps projects to the task types with an open nightshift PR.
+type openPRSet map[string]map[TaskType]bool
+
+// SetOpenPRs records the task types with an open nightshift PR in project,
+// replacing any earlier call for that project. Selection skips those whose
+// open PR policy is skip.
+func (s *Selector) SetOpenPRs(project string, types []TaskType) {
+	if s.openPRs == nil {
+		s.openPRs = make(openPRSet)
+	}
+	open := make(map[TaskType]bool, len(types))
+	for _, t := range types {
+		open[t] = true
+	}
+	s.openPRs[project] = open
+}
+
+// HasOpenPR reports whether taskType has an open nightshift PR in project.
+func (s *Selector) HasOpenPR(taskType TaskType, project string) bool {
+	return s.openPRs[project][taskType]
+}
+
+// FilterOpenPRs returns tasks that may run in project: those without an
+// open PR, or whose open PR policy is update or allow.
+func (s *Selector) FilterOpenPRs(tasks []TaskDefinition, project string) []TaskDefinition {
+	if len(s.openPRs[project]) == 0 {
+		return tasks
+	}
+	filtered := make([]TaskDefinition, 0, len(tasks))
+	for _, t := range tasks {
+		if s.HasOpenPR(t.Type, project) && s.cfg.OpenPRPolicy(string(t.Type)) == config.OpenPRSkip {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
@@ -0,0 +1,277 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/marcus/nightshift/internal/agents"
+)
+
+// Pre-command defaults.
+const (
+	DefaultPreCommandTimeout = 2 * time.Minute
+	DefaultPreCommandOutput  = 16 * 1024 // bytes
+)
+
+// ErrUntrustedPreCommand is the result error of a pre-command from a
+// project's own task file that was not run because it would have run on the
+// host without tasks.allow_project_pre_commands.
+var ErrUntrustedPreCommand = errors.New("pre-commands from project task files need sandbox.enabled or tasks.allow_project_pre_commands")
+
+// PreCommand skip conditions: when a pre-command reports that its task has
+// nothing to do.
+const (
+	PreSkipClean = "clean" // The command exits 0, e.g. a linter with no findings
+	PreSkipEmpty = "empty" // The command prints nothing
+)
+
+// PreCommand is a deterministic tool run in the project before a task is
+// planned. Its output goes into the planning prompt as evidence, so the
+// agent does not spend tokens rediscovering it.
+type PreCommand struct {
+	Name      string        // Shown in the prompt; defaults to Command
+	Command   string        // Run with sh -c in the project directory, sandboxed like agents
+	Timeout   time.Duration // Defaults to DefaultPreCommandTimeout
+	MaxOutput int           // Bytes of output kept; defaults to DefaultPreCommandOutput
+	SkipIf    string        // PreSkipClean, PreSkipEmpty or "" (never skip)
+	Requires  Requirements  // Only run in matching repos
+}
+
+// Label returns the command's display name.
+func (c PreCommand) Label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Command
+}
+
+// Validate checks the skip condition and requirements.
+func (c PreCommand) Validate() error {
+	if strings.TrimSpace(c.Command) == "" {
+		return errors.New("pre-command has no command")
+	}
+	switch c.SkipIf {
+	case "", PreSkipClean, PreSkipEmpty:
+	default:
+		return fmt.Errorf("pre-command %q: unknown skip_if %q (valid: clean, empty)", c.Label(), c.SkipIf)
+	}
+	if c.Timeout < 0 || c.MaxOutput < 0 {
+		return fmt.Errorf("pre-command %q: timeout and max_output must not be negative", c.Label())
+	}
+	return c.Requires.Validate()
+}
+
+// PreCommandResult is the outcome of one pre-command.
+type PreCommandResult struct {
+	Command   PreCommand
+	Output    string
+	ExitCode  int
+	Duration  time.Duration
+	Truncated bool  // Output was cut at MaxOutput
+	Err       error // Timeout or failure to run; a non-zero exit is not an error
+}
+
+// Unavailable reports whether the command could not be found, as when an
+// optional tool is not installed. Such results are ignored.
+func (r PreCommandResult) Unavailable() bool {
+	return r.ExitCode == 127 || errors.Is(r.Err, exec.ErrNotFound)
+}
+
+// Untrusted reports whether the command was refused under
+// ErrUntrustedPreCommand.
+func (r PreCommandResult) Untrusted() bool {
+	return errors.Is(r.Err, ErrUntrustedPreCommand)
+}
+
+// NothingToDo reports whether the result meets the command's skip condition.
+func (r PreCommandResult) NothingToDo() bool {
+	if r.Err != nil || r.Unavailable() {
+		return false
+	}
+	switch r.Command.SkipIf {
+	case PreSkipClean:
+		return r.ExitCode == 0
+	case PreSkipEmpty:
+		return strings.TrimSpace(r.Output) == ""
+	}
+	return false
+}
+
+// PreAnalysis holds the results of a task's pre-commands.
+type PreAnalysis struct {
+	Results []PreCommandResult
+}
+
+// NothingToDo reports whether the task can be skipped: at least one
+// pre-command ran, and every one that ran reported nothing to do.
+func (a PreAnalysis) NothingToDo() bool {
+	ran := 0
+	for _, r := range a.Results {
+		if r.Unavailable() {
+			continue
+		}
+		if !r.NothingToDo() {
+			return false
+		}
+		ran++
+	}
+	return ran > 0
+}
+
+// Evidence formats the pre-command output for the planning prompt, or ""
+// when no command produced any.
+func (a PreAnalysis) Evidence() string {
+	var b strings.Builder
+	for _, r := range a.Results {
+		if r.Unavailable() || r.Untrusted() {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s\n$ %s", r.Command.Label(), r.Command.Command)
+		switch {
+		case r.Err != nil:
+			fmt.Fprintf(&b, "  (%v)", r.Err)
+		case r.ExitCode != 0:
+			fmt.Fprintf(&b, "  (exit status %d)", r.ExitCode)
+		}
+		b.WriteString("\n```\n")
+		b.WriteString(strings.TrimRight(r.Output, "\n"))
+		if r.Truncated {
+			b.WriteString("\n[output truncated]")
+		}
+		b.WriteString("\n```\n\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// SetPreCommandRunner makes s run pre-commands through r, such as the
+// sandbox runner agents use when sandbox.enabled is set. Pre-commands may
+// come from a project's own task files, so they get the same isolation as
+// the agents. Nil runs them on the host, where those from project task files
+// only run with tasks.allow_project_pre_commands.
+func (s *Selector) SetPreCommandRunner(r agents.CommandRunner) {
+	s.preRunner = r
+}
+
+// RunPreCommands runs def's pre-commands whose requirements project meets,
+// one after another, in the project directory. Commands from the project's
+// own task file that would run on the host without opt-in are not run; their
+// results carry ErrUntrustedPreCommand.
+func (s *Selector) RunPreCommands(ctx context.Context, def TaskDefinition, project string) PreAnalysis {
+	var a PreAnalysis
+	optIn := s.cfg != nil && s.cfg.Tasks.AllowProjectPreCommands
+	untrusted := s.preRunner == nil && !optIn && isProjectTask(project, def.Type)
+	for _, c := range def.PreCommands {
+		if ok, _ := c.Requires.Check(s.repoProfile(project)); !ok {
+			continue
+		}
+		if untrusted {
+			a.Results = append(a.Results, PreCommandResult{Command: c, ExitCode: -1, Err: ErrUntrustedPreCommand})
+			continue
+		}
+		a.Results = append(a.Results, RunPreCommand(ctx, s.preRunner, c, project))
+	}
+	return a
+}
+
+// RunPreCommand runs c in dir, bounded by its timeout and output cap,
+// through runner, or on the host when runner is nil.
+func RunPreCommand(ctx context.Context, runner agents.CommandRunner, c PreCommand, dir string) PreCommandResult {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPreCommandTimeout
+	}
+	limit := c.MaxOutput
+	if limit <= 0 {
+		limit = DefaultPreCommandOutput
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out := &cappedBuffer{limit: limit}
+	start := time.Now()
+	var err error
+	if runner != nil {
+		var stdout, stderr string
+		stdout, stderr, _, err = runner.Run(ctx, "sh", []string{"-c", c.Command}, dir, "")
+		_, _ = out.Write([]byte(stdout))
+		_, _ = out.Write([]byte(stderr))
+	} else {
+		err = runOnHost(ctx, c.Command, dir, out)
+	}
+	r := PreCommandResult{
+		Command:   c,
+		Output:    out.buf.String(),
+		Duration:  time.Since(start),
+		Truncated: out.truncated,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		r.ExitCode = -1
+		r.Err = fmt.Errorf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		r.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		r.ExitCode = -1
+		r.Err = err
+	}
+	return r
+}
+
+// runOnHost runs command with sh -c in dir, writing its output to out.
+func runOnHost(ctx context.Context, command, dir string, out *cappedBuffer) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Run in its own process group so a timeout stops the tools the shell
+	// started, not just the shell
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+	return cmd.Run()
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a chatty command cannot exhaust memory.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// Pre-commands of built-in tasks. Each runs only where its tool applies;
+// one that is not installed is ignored.
+var (
+	lintPreCommands = []PreCommand{
+		{Name: "golangci-lint", Command: "golangci-lint run ./...", SkipIf: PreSkipClean, Requires: Requirements{Files: []string{"go.mod"}}},
+		{Name: "ruff", Command: "ruff check .", SkipIf: PreSkipClean, Requires: Requirements{Languages: []string{"python"}}},
+	}
+	coveragePreCommands = []PreCommand{
+		{Name: "go test -cover", Command: "go test -cover ./...", Timeout: 10 * time.Minute, Requires: Requirements{Files: []string{"go.mod"}}},
+	}
+	dependencyPreCommands = []PreCommand{
+		{Name: "go mod graph", Command: "go mod graph", Requires: Requirements{Files: []string{"go.mod"}}},
+		{Name: "npm ls", Command: "npm ls --all", Requires: Requirements{Files: []string{"package-lock.json"}}},
+		{Name: "cargo tree", Command: "cargo tree", Requires: Requirements{Files: []string{"Cargo.toml"}}},
+	}
+)
@@ -0,0 +1,161 @@
+package tasks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcus/nightshift/internal/agents"
+	"github.com/marcus/nightshift/internal/config"
+)
+
+func TestRunPreCommand(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFile(t, dir, "marker.txt", "")
+	ctx := context.Background()
+
+	r := RunPreCommand(ctx, nil, PreCommand{Command: "ls; exit 3"}, dir)
+	if r.Err != nil || r.ExitCode != 3 || !strings.Contains(r.Output, "marker.txt") {
+		t.Errorf("ls in project dir: exit %d, err %v, output %q", r.ExitCode, r.Err, r.Output)
+	}
+
+	r = RunPreCommand(ctx, nil, PreCommand{Command: "yes | head -c 5000", MaxOutput: 100}, dir)
+	if len(r.Output) != 100 || !r.Truncated {
+		t.Errorf("capped output: %d bytes, truncated %v; want 100, true", len(r.Output), r.Truncated)
+	}
+
+	r = RunPreCommand(ctx, nil, PreCommand{Command: "sleep 5", Timeout: 50 * time.Millisecond}, dir)
+	if r.Err == nil || !strings.Contains(r.Err.Error(), "timed out") || r.Duration > 4*time.Second {
+		t.Errorf("timeout: err %v after %s", r.Err, r.Duration)
+	}
+
+	if r := RunPreCommand(ctx, nil, PreCommand{Command: "no-such-tool-xyz --check"}, dir); !r.Unavailable() {
+		t.Errorf("missing tool: exit %d not reported unavailable", r.ExitCode)
+	}
+}
+
+// recordingRunner runs commands on the host and records what it ran.
+type recordingRunner struct {
+	agents.ExecRunner
+	calls []string
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args []string, dir string, stdin string) (string, string, int, error) {
+	r.calls = append(r.calls, name+" "+strings.Join(args, " ")+" in "+dir)
+	return r.ExecRunner.Run(ctx, name, args, dir, stdin)
+}
+
+func TestRunPreCommands_Runner(t *testing.T) {
+	dir := t.TempDir()
+	runner := &recordingRunner{}
+	s := NewSelector(nil, nil)
+	s.SetPreCommandRunner(runner)
+
+	a := s.RunPreCommands(context.Background(), TaskDefinition{PreCommands: []PreCommand{{Command: "echo found; exit 2"}}}, dir)
+	if len(runner.calls) != 1 || runner.calls[0] != "sh -c echo found; exit 2 in "+dir {
+		t.Fatalf("runner calls = %q", runner.calls)
+	}
+	if r := a.Results[0]; r.ExitCode != 2 || r.Err != nil || strings.TrimSpace(r.Output) != "found" {
+		t.Errorf("result through runner: exit %d, err %v, output %q", r.ExitCode, r.Err, r.Output)
+	}
+}
+
+func TestRunPreCommands_ProjectTaskFile(t *testing.T) {
+	t.Cleanup(func() { ClearCustom() })
+
+	dir := t.TempDir()
+	writeTaskFile(t, filepath.Join(dir, ProjectTasksDir), "triage.md", "---\npre_commands:\n  - command: touch ran\n---\nTriage.")
+	files, err := LoadTaskFiles("", []string{dir})
+	if err != nil {
+		t.Fatalf("LoadTaskFiles: %v", err)
+	}
+	if err := RegisterTaskFiles(files); err != nil {
+		t.Fatalf("RegisterTaskFiles: %v", err)
+	}
+	def, err := ProjectDefinition(dir, "triage")
+	if err != nil {
+		t.Fatalf("ProjectDefinition: %v", err)
+	}
+	marker := filepath.Join(dir, "ran")
+	ctx := context.Background()
+
+	// On the host without opt-in, a cloned repo's commands do not run
+	a := NewSelector(&config.Config{}, nil).RunPreCommands(ctx, def, dir)
+	if len(a.Results) != 1 || !a.Results[0].Untrusted() {
+		t.Fatalf("results without opt-in = %+v, want one untrusted", a.Results)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("project pre-command ran on the host without opt-in")
+	}
+	if a.NothingToDo() || a.Evidence() != "" {
+		t.Errorf("refused pre-command: NothingToDo %v, Evidence %q", a.NothingToDo(), a.Evidence())
+	}
+
+	// Through the sandbox runner
+	runner := &recordingRunner{}
+	s := NewSelector(&config.Config{}, nil)
+	s.SetPreCommandRunner(runner)
+	if a := s.RunPreCommands(ctx, def, dir); len(runner.calls) != 1 || a.Results[0].Err != nil {
+		t.Errorf("through runner: calls %q, results %+v", runner.calls, a.Results)
+	}
+	_ = os.Remove(marker)
+
+	// On the host with opt-in
+	cfg := &config.Config{Tasks: config.TasksConfig{AllowProjectPreCommands: true}}
+	if a := NewSelector(cfg, nil).RunPreCommands(ctx, def, dir); a.Results[0].Err != nil {
+		t.Errorf("with opt-in: %v", a.Results[0].Err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("project pre-command did not run with opt-in: %v", err)
+	}
+}
+
+func TestRunPreCommands_NothingToDo(t *testing.T) {
+	dir := t.TempDir()
+	writeTaskFile(t, dir, "go.mod", "module example.com/app\n")
+	s := NewSelector(nil, nil)
+	ctx := context.Background()
+
+	def := TaskDefinition{Type: TaskLintFix, PreCommands: []PreCommand{
+		{Name: "lint", Command: "true", SkipIf: PreSkipClean},
+		{Name: "todos", Command: "printf ''", SkipIf: PreSkipEmpty},
+		{Name: "optional", Command: "no-such-tool-xyz"},
+		{Name: "python", Command: "echo never", Requires: Requirements{Languages: []string{"python"}}},
+	}}
+	a := s.RunPreCommands(ctx, def, dir)
+	if len(a.Results) != 3 {
+		t.Errorf("ran %d pre-commands, want 3 (python skipped by requirements)", len(a.Results))
+	}
+	if !a.NothingToDo() {
+		t.Error("clean pre-commands did not report nothing to do")
+	}
+
+	// A finding means the task has work, and its output is evidence
+	def.PreCommands[0].Command = "echo 'main.go:3: unused'; exit 1"
+	a = s.RunPreCommands(ctx, def, dir)
+	if a.NothingToDo() {
+		t.Error("failing linter reported nothing to do")
+	}
+	ev := a.Evidence()
+	if !strings.Contains(ev, "main.go:3: unused") || !strings.Contains(ev, "exit status 1") || strings.Contains(ev, "optional") {
+		t.Errorf("Evidence() = %q", ev)
+	}
+
+	// Without a skip condition, a task is never skipped
+	if (PreAnalysis{Results: []PreCommandResult{{Command: PreCommand{Command: "true"}}}}).NothingToDo() {
+		t.Error("pre-command without skip_if reported nothing to do")
+	}
+}
+
+func TestBuiltinPreCommandsValid(t *testing.T) {
+	for _, def := range AllDefinitions() {
+		for _, c := range def.PreCommands {
+			if err := c.Validate(); err != nil {
+				t.Errorf("%s: %v", def.Type, err)
+			}
+		}
+	}
+}
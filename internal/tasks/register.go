@@ -30,6 +30,13 @@ func RegisterCustomTasksFromConfig(customs []config.CustomTaskConfig) error {
 			}
 			interval = d
 		}
+		pre, err := preCommandsFromConfig(c.PreCommands)
+		if err != nil {
+			for _, t := range registered {
+				UnregisterCustom(t)
+			}
+			return fmt.Errorf("custom task %q: %w", c.Type, err)
+		}
 
 		def := TaskDefinition{
 			Type:            TaskType(c.Type),
@@ -39,6 +46,15 @@ func RegisterCustomTasksFromConfig(customs []config.CustomTaskConfig) error {
 			CostTier:        cost,
 			RiskLevel:       risk,
 			DefaultInterval: interval,
+			Requires: Requirements{
+				Languages:    c.Requires.Languages,
+				Files:        c.Requires.Files,
+				Dirs:         c.Requires.Dirs,
+				Dependencies: c.Requires.Dependencies,
+			},
+			After:       taskTypes(c.After),
+			Consumes:    taskTypes(c.Consumes),
+			PreCommands: pre,
 		}
 
 		if err := RegisterCustom(def); err != nil {
@@ -52,6 +68,49 @@ func RegisterCustomTasksFromConfig(customs []config.CustomTaskConfig) error {
 	return nil
 }
 
+// taskTypes converts config task type names to TaskTypes.
+func taskTypes(names []string) []TaskType {
+	if len(names) == 0 {
+		return nil
+	}
+	types := make([]TaskType, len(names))
+	for i, n := range names {
+		types[i] = TaskType(strings.TrimSpace(n))
+	}
+	return types
+}
+
+// preCommandsFromConfig converts pre-command configs into PreCommands.
+func preCommandsFromConfig(configs []config.PreCommandConfig) ([]PreCommand, error) {
+	var pre []PreCommand
+	for _, c := range configs {
+		p := PreCommand{
+			Name:      c.Name,
+			Command:   c.Command,
+			MaxOutput: c.MaxOutput,
+			SkipIf:    strings.ToLower(strings.TrimSpace(c.SkipIf)),
+			Requires: Requirements{
+				Languages:    c.Requires.Languages,
+				Files:        c.Requires.Files,
+				Dirs:         c.Requires.Dirs,
+				Dependencies: c.Requires.Dependencies,
+			},
+		}
+		if c.Timeout != "" {
+			d, err := time.ParseDuration(c.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pre-command timeout %q: %w", c.Timeout, err)
+			}
+			p.Timeout = d
+		}
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		pre = append(pre, p)
+	}
+	return pre, nil
+}
+
 // parseCategoryString maps a config category string to TaskCategory.
 // Defaults to CategoryAnalysis if empty or unrecognized.
 func parseCategoryString(s string) TaskCategory {
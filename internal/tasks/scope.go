@@ -0,0 +1,53 @@
+package tasks
+
+import (
+	"strings"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+// ScopeAllows reports whether every scope allows changing name, a
+// slash-separated path relative to the project: it must match one of each
+// scope's include globs, when there are any, and none of its exclude
+// globs. A glob matching a directory covers everything below it.
+func ScopeAllows(scopes []config.PathScope, name string) bool {
+	for _, s := range scopes {
+		if len(s.Include) > 0 && !matchAnyScopeGlob(s.Include, name) {
+			return false
+		}
+		if matchAnyScopeGlob(s.Exclude, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopeViolations returns the paths in changed that scopes do not allow.
+func ScopeViolations(scopes []config.PathScope, changed []string) []string {
+	var outside []string
+	for _, name := range changed {
+		if !ScopeAllows(scopes, name) {
+			outside = append(outside, name)
+		}
+	}
+	return outside
+}
+
+// matchAnyScopeGlob reports whether name or one of its parent directories
+// matches one of patterns.
+func matchAnyScopeGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		for p := name; ; {
+			if matchGlob(pattern, p) {
+				return true
+			}
+			i := strings.LastIndex(p, "/")
+			if i < 0 {
+				break
+			}
+			p = p[:i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/marcus/nightshift/internal/config"
+)
+
+func TestScopeAllows(t *testing.T) {
+	project := config.PathScope{Exclude: []string{"vendor", "**/*.pb.go"}}
+	task := config.PathScope{Include: []string{"src/**", "go.mod"}, Exclude: []string{"src/billing/"}}
+	scopes := []config.PathScope{project, task}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"src/app/main.go", true},
+		{"go.mod", true},
+		{"README.md", false},                // not included by the task
+		{"vendor/github.com/x/y.go", false}, // directory excluded by the project
+		{"src/api/v1/api.pb.go", false},
+		{"src/billing/invoice.go", false},
+		{"src/billingx/invoice.go", true},
+	}
+	for _, tt := range tests {
+		if got := ScopeAllows(scopes, tt.name); got != tt.want {
+			t.Errorf("ScopeAllows(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !ScopeAllows(nil, "anything/at/all") {
+		t.Error("no scopes should allow every path")
+	}
+	changed := []string{"src/a.go", "README.md", "vendor/m.go"}
+	if got := ScopeViolations(scopes, changed); !slices.Equal(got, []string{"README.md", "vendor/m.go"}) {
+		t.Errorf("ScopeViolations() = %v", got)
+	}
+}
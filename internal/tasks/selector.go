@@ -6,6 +6,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/marcus/nightshift/internal/agents"
 	"github.com/marcus/nightshift/internal/config"
 	"github.com/marcus/nightshift/internal/state"
 )
@@ -17,6 +18,12 @@ type Selector struct {
 	contextMentions    map[string]bool    // Tasks mentioned in claude.md/agents.md
 	taskSources        map[string]bool    // Tasks from td/github issues
 	simulatedCooldowns map[string]bool    // task:project keys simulated as on cooldown (for preview)
+	filter             Filter             // Restricts selection, e.g. to a named schedule's task set
+	deadline           deadlinePlan       // Skips tasks that would overrun the window (SetDeadline)
+	openPRs            openPRSet          // Task types with an open PR, per project (SetOpenPRs)
+	profiles           profileSet         // Detected repo contents, per project (Applicability)
+
+	preRunner agents.CommandRunner // Runs pre-commands; nil for the host (SetPreCommandRunner)
 }
 
 // NewSelector creates a new task selector.
@@ -55,7 +62,7 @@ func (s *Selector) SetTaskSources(sources []string) {
 }
 
 // ScoreTask calculates the priority score for a task.
-// Formula: base_priority + staleness_bonus + context_bonus + task_source_bonus
+// Formula: base_priority + staleness_bonus + context_bonus + task_source_bonus + acceptance_bonus
 func (s *Selector) ScoreTask(taskType TaskType, project string) float64 {
 	var score float64
 
@@ -75,6 +82,9 @@ func (s *Selector) ScoreTask(taskType TaskType, project string) float64 {
 		score += 3.0
 	}
 
+	// Acceptance bonus: favor task types whose PRs get merged here
+	score += s.acceptanceBonus(taskType, project)
+
 	return score
 }
 
@@ -86,6 +96,9 @@ func (s *Selector) FilterEnabled(tasks []TaskDefinition) []TaskDefinition {
 		if t.DisabledByDefault && !s.cfg.IsTaskExplicitlyEnabled(string(t.Type)) {
 			continue
 		}
+		if !s.filter.Matches(t) {
+			continue
+		}
 		if s.cfg.IsTaskEnabled(string(t.Type)) {
 			filtered = append(filtered, t)
 		}
@@ -185,7 +198,7 @@ func (s *Selector) HasSimulatedCooldown(taskType string, project string) bool {
 // IsOnCooldown returns whether a task is on cooldown for a project.
 // Returns (onCooldown, remainingTime, totalInterval).
 func (s *Selector) IsOnCooldown(taskType TaskType, project string) (bool, time.Duration, time.Duration) {
-	def, err := GetDefinition(taskType)
+	def, err := ProjectDefinition(project, taskType)
 	if err != nil {
 		return false, 0, 0
 	}
@@ -204,11 +217,13 @@ func (s *Selector) IsOnCooldown(taskType TaskType, project string) (bool, time.D
 	return true, interval - elapsed, interval
 }
 
-// SelectNext returns the best task for the given budget and project.
-// Returns nil if no suitable task is found.
-func (s *Selector) SelectNext(budget int64, project string) *ScoredTask {
-	// Start with all task definitions
-	tasks := AllDefinitions()
+// candidates runs the filter pipeline shared by the Select functions and
+// scores the tasks that pass, unsorted. pool holds the tasks that passed
+// every filter but the chain check: those may still follow a selected
+// upstream task. Cooldowns apply unless ignoreCooldown is set.
+func (s *Selector) candidates(budget int64, project string, ignoreCooldown bool) (scored []ScoredTask, pool []TaskDefinition) {
+	// Start with the global task definitions and this project's own
+	tasks := ProjectDefinitions(project)
 
 	// Filter: enabled tasks only
 	tasks = s.FilterEnabled(tasks)
@@ -219,15 +234,26 @@ func (s *Selector) SelectNext(budget int64, project string) *ScoredTask {
 	// Filter: unassigned tasks
 	tasks = s.FilterUnassigned(tasks, project)
 
-	// Filter: tasks not on cooldown
-	tasks = s.FilterByCooldown(tasks, project)
+	// Filter: tasks whose previous PR is not still open
+	tasks = s.FilterOpenPRs(tasks, project)
 
-	if len(tasks) == 0 {
-		return nil
+	// Filter: tasks whose requirements the repo meets
+	tasks = s.FilterApplicable(tasks, project)
+
+	// Filter: tasks not on cooldown
+	if !ignoreCooldown {
+		tasks = s.FilterByCooldown(tasks, project)
 	}
 
+	// Filter: tasks that finish before the deadline
+	tasks = s.FilterByDeadline(tasks)
+
+	// Filter: tasks whose upstream tasks have succeeded since they last ran
+	pool = tasks
+	tasks = s.FilterChains(tasks, project)
+
 	// Score each task
-	scored := make([]ScoredTask, len(tasks))
+	scored = make([]ScoredTask, len(tasks))
 	for i, t := range tasks {
 		scored[i] = ScoredTask{
 			Definition: t,
@@ -235,11 +261,21 @@ func (s *Selector) SelectNext(budget int64, project string) *ScoredTask {
 			Project:    project,
 		}
 	}
+	return scored, pool
+}
 
-	// Sort by score descending
-	sort.Slice(scored, func(i, j int) bool {
+// sortByScore sorts scored by score, highest first.
+func sortByScore(scored []ScoredTask) {
+	sort.SliceStable(scored, func(i, j int) bool {
 		return scored[i].Score > scored[j].Score
 	})
+}
+
+// SelectNext returns the best task for the given budget and project.
+// Returns nil if no suitable task is found.
+func (s *Selector) SelectNext(budget int64, project string) *ScoredTask {
+	scored, _ := s.candidates(budget, project, false)
+	sortByScore(scored)
 
 	// Select top task that fits remaining budget
 	for _, st := range scored {
@@ -274,45 +310,15 @@ func makeTaskID(taskType, project string) string {
 
 // SelectTopN returns the top N tasks by score that fit within budget.
 func (s *Selector) SelectTopN(budget int64, project string, n int) []ScoredTask {
-	// Start with all task definitions
-	tasks := AllDefinitions()
-
-	// Filter: enabled tasks only
-	tasks = s.FilterEnabled(tasks)
-
-	// Filter: tasks within budget estimate
-	tasks = s.FilterByBudget(tasks, budget)
-
-	// Filter: unassigned tasks
-	tasks = s.FilterUnassigned(tasks, project)
-
-	// Filter: tasks not on cooldown
-	tasks = s.FilterByCooldown(tasks, project)
-
-	if len(tasks) == 0 {
+	scored, pool := s.candidates(budget, project, false)
+	if len(scored) == 0 {
 		return nil
 	}
+	sortByScore(scored)
 
-	// Score each task
-	scored := make([]ScoredTask, len(tasks))
-	for i, t := range tasks {
-		scored[i] = ScoredTask{
-			Definition: t,
-			Score:      s.ScoreTask(t.Type, project),
-			Project:    project,
-		}
-	}
-
-	// Sort by score descending
-	sort.Slice(scored, func(i, j int) bool {
-		return scored[i].Score > scored[j].Score
-	})
-
-	// Return top N
-	if n > len(scored) {
-		n = len(scored)
-	}
-	return scored[:n]
+	// Return top N that finish together before the deadline, each followed
+	// by the downstream tasks it unblocks
+	return s.fitDeadline(scored, pool, n, budget, project)
 }
 
 // SelectRandom returns a random task from the eligible pool.
@@ -320,36 +326,51 @@ func (s *Selector) SelectTopN(budget int64, project string, n int) []ScoredTask
 // instead of by highest score. The returned ScoredTask still has an
 // accurate Score for display purposes. Returns nil if no task is eligible.
 func (s *Selector) SelectRandom(budget int64, project string) *ScoredTask {
-	// Start with all task definitions
-	tasks := AllDefinitions()
-
-	// Filter: enabled tasks only
-	tasks = s.FilterEnabled(tasks)
-
-	// Filter: tasks within budget estimate
-	tasks = s.FilterByBudget(tasks, budget)
-
-	// Filter: unassigned tasks
-	tasks = s.FilterUnassigned(tasks, project)
+	scored, _ := s.candidates(budget, project, false)
+	if len(scored) == 0 {
+		return nil
+	}
 
-	// Filter: tasks not on cooldown
-	tasks = s.FilterByCooldown(tasks, project)
+	// Pick a random task from the eligible pool
+	pick := scored[rand.IntN(len(scored))]
+	return &pick
+}
 
-	if len(tasks) == 0 {
+// SelectBurst returns up to n tasks for an end-of-week burst run with the
+// given budget. Tasks too expensive for a regular run (max estimate above
+// regularBudget) come first, then higher cost tiers, then score, so spare
+// budget goes to the high-value work regular runs filter out.
+func (s *Selector) SelectBurst(budget, regularBudget int64, project string, n int) []ScoredTask {
+	scored, pool := s.candidates(budget, project, false)
+	if len(scored) == 0 {
 		return nil
 	}
 
-	// Score each task
-	scored := make([]ScoredTask, len(tasks))
-	for i, t := range tasks {
-		scored[i] = ScoredTask{
-			Definition: t,
-			Score:      s.ScoreTask(t.Type, project),
-			Project:    project,
-		}
+	skippedByRegular := func(st ScoredTask) bool {
+		_, max := st.Definition.EstimatedTokens()
+		return int64(max) > regularBudget
 	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		a, b := scored[i], scored[j]
+		if skippedByRegular(a) != skippedByRegular(b) {
+			return skippedByRegular(a)
+		}
+		if a.Definition.CostTier != b.Definition.CostTier {
+			return a.Definition.CostTier > b.Definition.CostTier
+		}
+		return a.Score > b.Score
+	})
+	return s.fitDeadline(scored, pool, n, budget, project)
+}
 
-	// Pick a random task from the eligible pool
-	pick := scored[rand.IntN(len(scored))]
-	return &pick
+// SelectChanged returns up to n tasks for a run triggered by new commits.
+// Like SelectTopN, but cooldowns are ignored: the new commits are what the
+// tasks need to look at, however recently they last ran.
+func (s *Selector) SelectChanged(budget int64, project string, n int) []ScoredTask {
+	scored, pool := s.candidates(budget, project, true)
+	if len(scored) == 0 {
+		return nil
+	}
+	sortByScore(scored)
+	return s.fitDeadline(scored, pool, n, budget, project)
 }
@@ -104,6 +104,78 @@ func TestScoreTaskWithConfigPriority(t *testing.T) {
 	}
 }
 
+func TestScoreTaskWithPRAcceptance(t *testing.T) {
+	st := newTestState(t)
+	cfg := &config.Config{}
+	cfg.Integrations.PROutcomes = config.PROutcomesConfig{Enabled: true, Weight: 3}
+	sel := NewSelector(cfg, st)
+
+	project := "/test/project"
+	st.RecordTaskRun(project, string(TaskLintFix))
+	st.RecordTaskRun(project, string(TaskBugFinder))
+	addOutcomes := func(taskType TaskType, states ...string) {
+		for _, prState := range states {
+			id, err := st.AddTaskRun(state.TaskRunRecord{Project: project, TaskType: string(taskType), Status: "completed",
+				OutputType: "PR", OutputRef: "https://github.com/o/r/pull/1"})
+			if err != nil {
+				t.Fatalf("AddTaskRun: %v", err)
+			}
+			if err := st.SetPROutcome(id, state.PROutcome{State: prState}); err != nil {
+				t.Fatalf("SetPROutcome: %v", err)
+			}
+		}
+	}
+
+	// Too few decided PRs: no effect
+	addOutcomes(TaskLintFix, state.PRMerged, state.PRMerged, state.PROpen)
+	if score := sel.ScoreTask(TaskLintFix, project); score > 0.1 {
+		t.Errorf("expected no acceptance bonus below %d samples, got %f", minOutcomeSamples, score)
+	}
+
+	addOutcomes(TaskLintFix, state.PRMerged, state.PRMerged)
+	if score := sel.ScoreTask(TaskLintFix, project); score < 2.9 || score > 3.1 {
+		t.Errorf("expected ~+3 for always-merged PRs, got %f", score)
+	}
+
+	addOutcomes(TaskBugFinder, state.PRClosed, state.PRStale, state.PRClosed)
+	if score := sel.ScoreTask(TaskBugFinder, project); score < -3.1 || score > -2.9 {
+		t.Errorf("expected ~-3 for never-merged PRs, got %f", score)
+	}
+
+	// Other projects are unaffected
+	if score := sel.ScoreTask(TaskBugFinder, "/other/project"); score < 2.9 {
+		t.Errorf("expected only the staleness bonus in another project, got %f", score)
+	}
+}
+
+func TestSelectTopN_OpenPRs(t *testing.T) {
+	sel, _ := setupTestSelector(t)
+	sel.cfg.Tasks.OpenPRs = map[string]string{string(TaskDocsBackfill): config.OpenPRUpdate}
+	project := "/test/project"
+	other := "/test/other"
+
+	sel.SetOpenPRs(project, []TaskType{TaskLintFix, TaskDocsBackfill})
+
+	has := func(selected []ScoredTask, taskType TaskType) bool {
+		for _, st := range selected {
+			if st.Definition.Type == taskType {
+				return true
+			}
+		}
+		return false
+	}
+	selected := sel.SelectTopN(1_000_000_000, project, 100)
+	if has(selected, TaskLintFix) {
+		t.Error("lint-fix has an open PR and the skip policy, but was selected")
+	}
+	if !has(selected, TaskDocsBackfill) {
+		t.Error("docs-backfill has the update policy and should still be selected")
+	}
+	if !has(sel.SelectTopN(1_000_000_000, other, 100), TaskLintFix) {
+		t.Error("open PRs in one project should not affect another")
+	}
+}
+
 func TestFilterEnabled(t *testing.T) {
 	st := newTestState(t)
 
@@ -734,3 +806,157 @@ func TestSelectNextRespectssCooldown(t *testing.T) {
 		t.Errorf("SelectNext() = %s, want %s (lint-fix on cooldown)", task.Definition.Type, TaskDocsBackfill)
 	}
 }
+
+func TestSelectBurst_PrefersTasksRegularRunsSkip(t *testing.T) {
+	st := newTestState(t)
+
+	cfg := &config.Config{
+		Tasks: config.TasksConfig{
+			Enabled: []string{
+				string(TaskLintFix),
+				string(TaskDocsBackfill),
+				string(TaskDeadCode),
+			},
+			Priorities: map[string]int{
+				string(TaskLintFix):      10,
+				string(TaskDocsBackfill): 5,
+				string(TaskDeadCode):     1,
+			},
+		},
+	}
+	sel := NewSelector(cfg, st)
+	project := "/test/project"
+
+	// dead-code (medium) doesn't fit a 60k regular run but leads a burst
+	tasks := sel.SelectBurst(1_000_000, 60_000, project, 3)
+	if len(tasks) != 3 {
+		t.Fatalf("SelectBurst len = %d, want 3", len(tasks))
+	}
+	want := []TaskType{TaskDeadCode, TaskLintFix, TaskDocsBackfill}
+	for i, w := range want {
+		if tasks[i].Definition.Type != w {
+			t.Errorf("tasks[%d] = %s, want %s", i, tasks[i].Definition.Type, w)
+		}
+	}
+
+	if got := sel.SelectBurst(60_000, 60_000, project, 3); len(got) != 2 {
+		t.Errorf("burst budget should still filter, got %d tasks", len(got))
+	}
+}
+
+func TestSelectorFilter(t *testing.T) {
+	selector, _ := setupTestSelector(t)
+	selector.SetFilter(Filter{Categories: []TaskCategory{CategoryPR}, CostTiers: []CostTier{CostLow, CostMedium}})
+
+	filtered := selector.FilterEnabled(AllDefinitions())
+	if len(filtered) == 0 {
+		t.Fatal("expected low/medium PR tasks to pass the filter")
+	}
+	for _, def := range filtered {
+		if def.Category != CategoryPR || def.CostTier > CostMedium {
+			t.Errorf("task %s (category %v, cost %v) should be filtered out", def.Type, def.Category, def.CostTier)
+		}
+	}
+
+	selector.SetFilter(Filter{Types: []TaskType{TaskLintFix}})
+	filtered = selector.FilterEnabled(AllDefinitions())
+	if len(filtered) != 1 || filtered[0].Type != TaskLintFix {
+		t.Errorf("expected only %s, got %d tasks", TaskLintFix, len(filtered))
+	}
+
+	selector.SetFilter(Filter{}.AnalysisOnly())
+	filtered = selector.FilterEnabled(AllDefinitions())
+	if len(filtered) == 0 {
+		t.Fatal("expected analysis tasks to pass the analysis-only filter")
+	}
+	for _, def := range filtered {
+		if def.Category == CategoryPR || def.Category == CategorySafe || def.Category == CategoryEmergency {
+			t.Errorf("task %s (category %v) should be filtered out", def.Type, def.Category)
+		}
+	}
+}
+
+func TestSelectChanged_IgnoresCooldown(t *testing.T) {
+	selector, st := setupTestSelector(t)
+	project := "/test/project"
+	selector.SetFilter(Filter{Types: []TaskType{TaskSemanticDiff, TaskCommitNormalize}})
+
+	st.RecordTaskRun(project, string(TaskSemanticDiff))
+	if got := selector.SelectTopN(1_000_000, project, 5); len(got) != 1 {
+		t.Fatalf("SelectTopN should skip the task on cooldown, got %d tasks", len(got))
+	}
+	if got := selector.SelectChanged(1_000_000, project, 5); len(got) != 2 {
+		t.Errorf("SelectChanged len = %d, want 2", len(got))
+	}
+}
+
+func TestP90(t *testing.T) {
+	var samples []time.Duration
+	for i := 10; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Minute)
+	}
+	if got := P90(samples); got != 9*time.Minute {
+		t.Errorf("P90() = %v, want 9m", got)
+	}
+	if got := P90(samples[:1]); got != 10*time.Minute {
+		t.Errorf("P90() of one sample = %v, want 10m", got)
+	}
+
+	predicted := PredictDurations(map[string][]time.Duration{
+		"bug-finder": {40 * time.Minute, 30 * time.Minute, 35 * time.Minute},
+		"lint-fix":   {5 * time.Minute},
+	})
+	if predicted[TaskBugFinder] != 40*time.Minute {
+		t.Errorf("bug-finder p90 = %v, want 40m", predicted[TaskBugFinder])
+	}
+	if _, ok := predicted[TaskLintFix]; ok {
+		t.Error("lint-fix has too little history to be predicted")
+	}
+}
+
+func TestSelectTopN_Deadline(t *testing.T) {
+	selector, _ := setupTestSelector(t)
+	project := "/test/project"
+	selector.cfg.Tasks.Priorities = map[string]int{"bug-finder": 10, "docs-backfill": 5, "lint-fix": 1}
+	selector.SetFilter(Filter{Types: []TaskType{TaskBugFinder, TaskDocsBackfill, TaskLintFix}})
+
+	now := time.Date(2026, 3, 2, 5, 30, 0, 0, time.UTC)
+	selector.SetNowFunc(func() time.Time { return now })
+	selector.SetDeadline(now.Add(25*time.Minute), map[TaskType]time.Duration{
+		TaskBugFinder:    40 * time.Minute,
+		TaskDocsBackfill: 20 * time.Minute,
+		TaskLintFix:      10 * time.Minute,
+	})
+
+	// bug-finder overruns the window; docs-backfill fits but leaves no room
+	// for lint-fix
+	got := selector.SelectTopN(1_000_000, project, 5)
+	if len(got) != 1 || got[0].Definition.Type != TaskDocsBackfill {
+		t.Errorf("expected only docs-backfill, got %v", got)
+	}
+	if reason := selector.DeadlineSkipReason(TaskBugFinder); reason != "p90 duration 40m0s exceeds 25m0s left in window" {
+		t.Errorf("DeadlineSkipReason() = %q", reason)
+	}
+
+	// Near the end, the shorter task is picked over the higher-scored one
+	now = now.Add(10 * time.Minute)
+	got = selector.SelectTopN(1_000_000, project, 5)
+	if len(got) != 1 || got[0].Definition.Type != TaskLintFix {
+		t.Errorf("expected only lint-fix, got %v", got)
+	}
+
+	if got := selector.LatestStart(TaskDocsBackfill); !got.Equal(now.Add(-5 * time.Minute)) {
+		t.Errorf("LatestStart(docs-backfill) = %v, want 20m before the deadline", got)
+	}
+	if got := selector.LatestStart(TaskMetricsCoverage); !got.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("LatestStart() without history = %v, want the deadline", got)
+	}
+
+	selector.SetDeadline(time.Time{}, nil)
+	if !selector.LatestStart(TaskLintFix).IsZero() {
+		t.Error("LatestStart() without a deadline is not zero")
+	}
+	if got := selector.SelectTopN(1_000_000, project, 5); len(got) != 3 {
+		t.Errorf("expected all tasks without a deadline, got %d", len(got))
+	}
+}
@@ -140,6 +140,8 @@ const (
 	TaskChangelogSynth       TaskType = "changelog-synth"
 	TaskReleaseNotes         TaskType = "release-notes"
 	TaskADRDraft             TaskType = "adr-draft"
+	TaskTestBackfill         TaskType = "test-backfill"
+	TaskDeadCodeRemoval      TaskType = "dead-code-removal"
 	TaskTDReview             TaskType = "td-review"
 )
 
@@ -218,6 +220,10 @@ type TaskDefinition struct {
 	CostTier          CostTier
 	RiskLevel         RiskLevel
 	DefaultInterval   time.Duration
+	Requires          Requirements
+	After             []TaskType
+	Consumes          []TaskType
+	PreCommands       []PreCommand
 	DisabledByDefault bool // Requires explicit opt-in via tasks.enabled
 }
 
@@ -260,6 +266,7 @@ var registry = map[TaskType]TaskDefinition{
 		CostTier:        CostLow,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 24 * time.Hour,
+		PreCommands:     lintPreCommands,
 	},
 	TaskBugFinder: {
 		Type:            TaskBugFinder,
@@ -300,6 +307,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 168 * time.Hour,
+		Requires:        apiSpecRequirements,
 	},
 	TaskBackwardCompat: {
 		Type:            TaskBackwardCompat,
@@ -345,6 +353,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostLow,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 168 * time.Hour,
+		Requires:        changelogRequirements,
 	},
 	TaskReleaseNotes: {
 		Type:            TaskReleaseNotes,
@@ -354,6 +363,8 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostLow,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 168 * time.Hour,
+		Consumes:        []TaskType{TaskChangelogSynth},
+		Requires:        releaseRequirements,
 	},
 	TaskADRDraft: {
 		Type:            TaskADRDraft,
@@ -363,6 +374,29 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 168 * time.Hour,
+		Requires:        adrRequirements,
+	},
+	TaskTestBackfill: {
+		Type:              TaskTestBackfill,
+		Category:          CategoryPR,
+		Name:              "Test Backfiller",
+		Description:       "Write tests for the coverage gaps found by the test gap finder",
+		CostTier:          CostHigh,
+		RiskLevel:         RiskLow,
+		DefaultInterval:   72 * time.Hour,
+		Consumes:          []TaskType{TaskTestGap},
+		DisabledByDefault: true,
+	},
+	TaskDeadCodeRemoval: {
+		Type:              TaskDeadCodeRemoval,
+		Category:          CategoryPR,
+		Name:              "Dead Code Remover",
+		Description:       "Remove the unused code found by the dead code detector",
+		CostTier:          CostMedium,
+		RiskLevel:         RiskMedium,
+		DefaultInterval:   72 * time.Hour,
+		Consumes:          []TaskType{TaskDeadCode},
+		DisabledByDefault: true,
 	},
 	TaskTDReview: {
 		Type:     TaskTDReview,
@@ -417,6 +451,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 72 * time.Hour,
+		PreCommands:     dependencyPreCommands,
 	},
 	TaskTestGap: {
 		Type:            TaskTestGap,
@@ -426,6 +461,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 72 * time.Hour,
+		PreCommands:     coveragePreCommands,
 	},
 	TaskTestFlakiness: {
 		Type:            TaskTestFlakiness,
@@ -507,6 +543,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 72 * time.Hour,
+		Requires:        schemaRequirements,
 	},
 	TaskEventTaxonomy: {
 		Type:            TaskEventTaxonomy,
@@ -516,6 +553,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 72 * time.Hour,
+		Requires:        analyticsRequirements,
 	},
 	TaskRoadmapEntropy: {
 		Type:            TaskRoadmapEntropy,
@@ -635,6 +673,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 168 * time.Hour,
+		Requires:        uiRequirements,
 	},
 	TaskServiceAdvisor: {
 		Type:            TaskServiceAdvisor,
@@ -673,6 +712,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostVeryHigh,
 		RiskLevel:       RiskHigh,
 		DefaultInterval: 336 * time.Hour,
+		Requires:        migrationRequirements,
 	},
 	TaskContractFuzzer: {
 		Type:            TaskContractFuzzer,
@@ -682,6 +722,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostVeryHigh,
 		RiskLevel:       RiskHigh,
 		DefaultInterval: 336 * time.Hour,
+		Requires:        apiSpecRequirements,
 	},
 	TaskGoldenPath: {
 		Type:            TaskGoldenPath,
@@ -756,6 +797,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 168 * time.Hour,
+		Requires:        featureFlagRequirements,
 	},
 	TaskCISignalNoise: {
 		Type:            TaskCISignalNoise,
@@ -765,6 +807,7 @@ Apply safe updates directly, and leave concise follow-ups for anything uncertain
 		CostTier:        CostMedium,
 		RiskLevel:       RiskLow,
 		DefaultInterval: 168 * time.Hour,
+		Requires:        ciRequirements,
 	},
 	TaskHistoricalContext: {
 		Type:            TaskHistoricalContext,
@@ -893,13 +936,18 @@ func DefaultDisabledTaskTypes() []TaskType {
 // deterministic ordering for CLI output.
 func AllDefinitionsSorted() []TaskDefinition {
 	defs := AllDefinitions()
+	sortDefinitions(defs)
+	return defs
+}
+
+// sortDefinitions sorts defs by Category, then by Name.
+func sortDefinitions(defs []TaskDefinition) {
 	slices.SortFunc(defs, func(a, b TaskDefinition) int {
 		if c := cmp.Compare(a.Category, b.Category); c != 0 {
 			return c
 		}
 		return cmp.Compare(a.Name, b.Name)
 	})
-	return defs
 }
 
 // RegisterCustom registers a custom task definition. Returns an error if the
@@ -918,6 +966,7 @@ func UnregisterCustom(taskType TaskType) {
 	if customTypes[taskType] {
 		delete(registry, taskType)
 		delete(customTypes, taskType)
+		delete(taskFiles, taskType)
 	}
 }
 
@@ -932,6 +981,8 @@ func ClearCustom() {
 		delete(registry, t)
 	}
 	customTypes = map[TaskType]bool{}
+	taskFiles = map[TaskType]TaskFile{}
+	projectTaskFiles = map[string]map[TaskType]TaskFile{}
 }
 
 // Task represents a unit of work for an AI agent.
@@ -941,6 +992,7 @@ type Task struct {
 	Description string
 	Priority    int
 	Type        TaskType // Optional: links to a TaskDefinition
+	Evidence    string   // Pre-command output for the planning prompt
 	// TODO: Add more fields (labels, assignee, source, etc.)
 }
 
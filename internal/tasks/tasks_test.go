@@ -316,6 +316,11 @@ func TestDisabledByDefault(t *testing.T) {
 	if !def.DisabledByDefault {
 		t.Error("TaskTDReview should be DisabledByDefault")
 	}
+	for _, tt := range []TaskType{TaskTestBackfill, TaskDeadCodeRemoval} {
+		if def, _ := GetDefinition(tt); !def.DisabledByDefault {
+			t.Errorf("%s should be DisabledByDefault", tt)
+		}
+	}
 
 	// Non-disabled-by-default tasks should have false
 	def, _ = GetDefinition(TaskLintFix)
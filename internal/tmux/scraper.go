@@ -361,3 +361,47 @@ func ctxSleep(ctx context.Context, d time.Duration) error {
 		return nil
 	}
 }
+
+// resetZoneRe matches a trailing "(Area/City)" timezone on Claude reset times.
+var resetZoneRe = regexp.MustCompile(`\s*\(([^)]+)\)\s*$`)
+
+// ParseResetTime converts a scraped reset string into the first matching
+// instant after ref. Handles Claude's "9pm (America/Los_Angeles)" and
+// "Feb 8 at 10am (America/Los_Angeles)" and Codex's "20:15" and
+// "02:50 on 8 Feb". Strings without a timezone use ref's location.
+func ParseResetTime(text string, ref time.Time) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	loc := ref.Location()
+	if m := resetZoneRe.FindStringSubmatch(text); len(m) == 2 {
+		if l, err := time.LoadLocation(strings.TrimSpace(m[1])); err == nil {
+			loc = l
+		}
+		text = strings.TrimSpace(text[:len(text)-len(m[0])])
+	}
+	local := ref.In(loc)
+
+	for _, layout := range []string{"3pm", "3:04pm", "15:04"} {
+		clock, err := time.Parse(layout, text)
+		if err != nil {
+			continue
+		}
+		t := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+		if !t.After(local) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, true
+	}
+
+	for _, layout := range []string{"Jan 2 at 3pm", "Jan 2 at 3:04pm", "15:04 on 2 Jan"} {
+		parsed, err := time.Parse(layout, text)
+		if err != nil {
+			continue
+		}
+		t := time.Date(local.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), 0, 0, loc)
+		if t.Before(local.AddDate(0, -6, 0)) {
+			t = t.AddDate(1, 0, 0) // reset falls in the next year
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
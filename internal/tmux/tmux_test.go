@@ -443,3 +443,34 @@ Weekly limit: 23.5% used
 		})
 	}
 }
+
+func TestParseResetTime(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("tzdata unavailable")
+	}
+	ref := time.Date(2026, 2, 7, 22, 30, 0, 0, la)
+	tests := []struct {
+		input  string
+		want   time.Time
+		wantOK bool
+	}{
+		{"9pm (America/Los_Angeles)", time.Date(2026, 2, 8, 21, 0, 0, 0, la), true},
+		{"11:59pm (America/Los_Angeles)", time.Date(2026, 2, 7, 23, 59, 0, 0, la), true},
+		{"Feb 8 at 10am (America/Los_Angeles)", time.Date(2026, 2, 8, 10, 0, 0, 0, la), true},
+		{"23:15", time.Date(2026, 2, 7, 23, 15, 0, 0, la), true},
+		{"02:50 on 8 Feb", time.Date(2026, 2, 8, 2, 50, 0, 0, la), true},
+		{"soon", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := ParseResetTime(tt.input, ref)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
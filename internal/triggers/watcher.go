@@ -0,0 +1,205 @@
+// Package triggers watches project repositories and reports new commits on
+// their main branch, so change-sensitive tasks can run soon after a merge.
+package triggers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Change is a range of commits that landed on a project's main branch.
+type Change struct {
+	Project string
+	Branch  string
+	From    string // previous tip; empty when the branch had no commits
+	To      string // new tip
+}
+
+// Range returns the change as a git revision range, e.g. "abc123..def456".
+func (c Change) Range() string {
+	if c.From == "" {
+		return c.To
+	}
+	return c.From + ".." + c.To
+}
+
+// Merge extends c with a later change to the same branch.
+func (c Change) Merge(later Change) Change {
+	c.To = later.To
+	return c
+}
+
+// repo is a watched project.
+type repo struct {
+	project string
+	gitDir  string
+	branch  string
+	head    string
+	timer   *time.Timer
+}
+
+// Watcher reports new commits on the main branch of each added project.
+// Ref updates are debounced so a push of many commits, or a rebase, is
+// reported once.
+type Watcher struct {
+	fs       *fsnotify.Watcher
+	debounce time.Duration
+	branch   string
+	handler  func(Change)
+
+	mu    sync.Mutex
+	repos map[string]*repo // git dir -> repo
+}
+
+// New creates a watcher. branch overrides main branch detection when set.
+// handler is called from the watcher's goroutine for every change.
+func New(debounce time.Duration, branch string, handler func(Change)) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+	return &Watcher{
+		fs:       fs,
+		debounce: debounce,
+		branch:   branch,
+		handler:  handler,
+		repos:    make(map[string]*repo),
+	}, nil
+}
+
+// Add starts watching project's HEAD and branch refs. Commits already on
+// the branch are not reported.
+func (w *Watcher) Add(project string) error {
+	gitDir := filepath.Join(project, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s: not a git repository", project)
+	}
+	branch := w.branch
+	if branch == "" {
+		branch = MainBranch(project)
+	}
+	if branch == "" {
+		return fmt.Errorf("%s: cannot determine main branch", project)
+	}
+
+	for _, dir := range []string{gitDir, filepath.Join(gitDir, "refs", "heads")} {
+		if err := w.fs.Add(dir); err != nil {
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+
+	w.mu.Lock()
+	w.repos[gitDir] = &repo{project: project, gitDir: gitDir, branch: branch, head: revParse(project, branch)}
+	w.mu.Unlock()
+	return nil
+}
+
+// Run handles file events until ctx is done, then closes the watcher.
+func (w *Watcher) Run(ctx context.Context) {
+	defer w.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Close stops watching and cancels pending debounce timers.
+func (w *Watcher) Close() {
+	w.mu.Lock()
+	for _, r := range w.repos {
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+	}
+	w.mu.Unlock()
+	_ = w.fs.Close()
+}
+
+// handle (re)starts the debounce timer of the repo an event belongs to.
+func (w *Watcher) handle(event fsnotify.Event) {
+	name := filepath.Base(event.Name)
+	gitDir := filepath.Dir(event.Name)
+	if filepath.Base(gitDir) == "heads" {
+		gitDir = filepath.Dir(filepath.Dir(gitDir))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	r, ok := w.repos[gitDir]
+	if !ok || !relevant(name, r.branch) {
+		return
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timer = time.AfterFunc(w.debounce, func() { w.check(r) })
+}
+
+// check compares the branch tip with the last one seen.
+func (w *Watcher) check(r *repo) {
+	head := revParse(r.project, r.branch)
+
+	w.mu.Lock()
+	prev := r.head
+	if head != "" {
+		r.head = head
+	}
+	w.mu.Unlock()
+
+	if head == "" || head == prev {
+		return
+	}
+	w.handler(Change{Project: r.project, Branch: r.branch, From: prev, To: head})
+}
+
+// relevant reports whether a changed file in .git or .git/refs/heads can
+// move the branch tip.
+func relevant(name, branch string) bool {
+	name = strings.TrimSuffix(name, ".lock")
+	return name == "HEAD" || name == "packed-refs" || name == filepath.Base(branch)
+}
+
+// MainBranch returns the branch origin's HEAD points to, else "main" or
+// "master" when present locally, else "".
+func MainBranch(project string) string {
+	out, err := exec.Command("git", "-C", project, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output()
+	if err == nil {
+		ref := strings.TrimSpace(string(out))
+		if _, branch, ok := strings.Cut(ref, "/"); ok && branch != "" {
+			return branch
+		}
+	}
+	for _, branch := range []string{"main", "master"} {
+		if revParse(project, branch) != "" {
+			return branch
+		}
+	}
+	return ""
+}
+
+func revParse(project, branch string) string {
+	out, err := exec.Command("git", "-C", project, "rev-parse", "--verify", "-q", "refs/heads/"+branch).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,113 @@
+package triggers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func commit(t *testing.T, dir, msg string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(msg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-q", "-m", msg)
+}
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	commit(t, dir, "initial")
+	return dir
+}
+
+func TestWatcher_ReportsNewCommits(t *testing.T) {
+	dir := newRepo(t)
+	before := revParse(dir, "main")
+
+	changes := make(chan Change, 4)
+	w, err := New(100*time.Millisecond, "", func(c Change) { changes <- c })
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := w.Add(dir); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	// Two quick commits are debounced into one change
+	commit(t, dir, "second")
+	commit(t, dir, "third")
+	after := revParse(dir, "main")
+
+	select {
+	case c := <-changes:
+		if c.From != before || c.To != after || c.Branch != "main" {
+			t.Errorf("change = %+v, want %s..%s on main", c, before, after)
+		}
+		if c.Range() != before+".."+after {
+			t.Errorf("Range() = %q", c.Range())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	select {
+	case c := <-changes:
+		t.Errorf("unexpected second change %+v", c)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestWatcher_IgnoresOtherBranches(t *testing.T) {
+	dir := newRepo(t)
+
+	changes := make(chan Change, 4)
+	w, err := New(50*time.Millisecond, "main", func(c Change) { changes <- c })
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := w.Add(dir); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	gitRun(t, dir, "checkout", "-q", "-b", "feature")
+	commit(t, dir, "feature work")
+
+	select {
+	case c := <-changes:
+		t.Errorf("unexpected change for feature branch: %+v", c)
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
+func TestMainBranch(t *testing.T) {
+	dir := newRepo(t)
+	if got := MainBranch(dir); got != "main" {
+		t.Errorf("MainBranch() = %q, want main", got)
+	}
+	if err := (&Watcher{}).Add(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without .git")
+	}
+}